@@ -19,6 +19,10 @@ func main() {
 	// Initialize Monarch Money client
 	client, err := monarch.NewClient(&monarch.ClientOptions{
 		Token: token,
+		// Tools look up categories, tags and accounts on most calls
+		Cache: monarch.NewCache(nil),
+		// Parallel tool calls often issue the same query at once
+		CoalesceQueries: true,
 	})
 	if err != nil {
 		log.Fatalf("failed to initialize Monarch Money client: %v", err)
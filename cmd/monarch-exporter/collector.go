@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// collector gathers metrics from the API in the background once per
+// interval and serves the last result to every scrape, so that a slow
+// collection never holds up or is cancelled by a scrape
+type collector struct {
+	client   *monarch.Client
+	interval time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+	logger   *log.Logger
+
+	mu          sync.Mutex
+	families    []*family
+	collected   bool // whether a collection has finished
+	succeeded   time.Time
+	lastErr     error
+	lastElapsed time.Duration
+}
+
+// newCollector creates a collector that refreshes every interval once run
+func newCollector(client *monarch.Client, interval time.Duration, logger *log.Logger) *collector {
+	return &collector{
+		client:   client,
+		interval: interval,
+		timeout:  time.Minute,
+		now:      time.Now,
+		logger:   logger,
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	families := c.snapshot()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writeFamilies(w, families); err != nil {
+		c.logger.Printf("write metrics: %v", err)
+	}
+}
+
+// run collects metrics right away and then every interval until ctx is done
+func (c *collector) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// snapshot returns the metrics of the last successful collection. After a
+// failed collection they are still served and monarch_exporter_up reports 0.
+func (c *collector) snapshot() []*family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	families := append([]*family{}, c.families...)
+	return append(families, c.status()...)
+}
+
+// refresh collects new metrics, bounded by the collector's timeout. Scrapes
+// are served the previous metrics while it runs.
+func (c *collector) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := c.now()
+	families, err := collect(ctx, c.client, start)
+	elapsed := c.now().Sub(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.collected = true
+	c.lastElapsed = elapsed
+	c.lastErr = err
+	if err != nil {
+		c.logger.Printf("collect metrics: %v", err)
+		return
+	}
+	c.families = families
+	c.succeeded = start
+}
+
+// status describes the exporter's own health; the caller holds mu
+func (c *collector) status() []*family {
+	up := newFamily("monarch_exporter_up", "Whether the last collection from Monarch succeeded.")
+	if c.collected && c.lastErr == nil {
+		up.add(1)
+	} else {
+		up.add(0)
+	}
+
+	elapsed := newFamily("monarch_exporter_collect_duration_seconds", "Duration of the last collection from Monarch.")
+	elapsed.add(c.lastElapsed.Seconds())
+
+	last := newFamily("monarch_exporter_last_success_timestamp_seconds", "Unix time of the last successful collection.")
+	if !c.succeeded.IsZero() {
+		last.add(float64(c.succeeded.Unix()))
+	}
+	return []*family{up, elapsed, last}
+}
+
+// collect fetches balances, net worth, budgets and holdings
+func collect(ctx context.Context, client *monarch.Client, now time.Time) ([]*family, error) {
+	accounts, err := client.Accounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := newFamily("monarch_account_balance", "Current balance of each account, negative for liabilities.")
+	netWorth := newFamily("monarch_net_worth", "Assets minus liabilities over accounts included in net worth.")
+	assets := newFamily("monarch_assets", "Total balance of asset accounts included in net worth.")
+	liabilities := newFamily("monarch_liabilities", "Total owed on liability accounts included in net worth.")
+	holdingValue := newFamily("monarch_holding_value", "Market value of each investment holding.")
+	holdingQuantity := newFamily("monarch_holding_quantity", "Quantity held of each investment holding.")
+
+	var assetTotal, liabilityTotal float64
+	for _, acc := range accounts {
+		if acc.DeactivatedAt != nil {
+			continue
+		}
+		balance.add(acc.CurrentBalance,
+			"account_id", acc.ID,
+			"account", acc.DisplayName,
+			"type", accountType(acc),
+			"subtype", accountSubtype(acc),
+			"institution", institutionName(acc),
+		)
+
+		// CurrentBalance is negative for liabilities, with DisplayBalance
+		// holding what is owed, as in the monarchtest and monarchfake
+		// datasets. monarch_liabilities reports what is owed.
+		if acc.IncludeInNetWorth {
+			if acc.IsAsset {
+				assetTotal += acc.CurrentBalance
+			} else {
+				liabilityTotal -= acc.CurrentBalance
+			}
+		}
+
+		if !hasHoldings(acc) {
+			continue
+		}
+		holdings, err := client.Accounts.GetHoldings(ctx, acc.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range holdings {
+			// An account can hold a symbol more than once, such as in tax lots
+			labels := []string{"account_id", acc.ID, "account", acc.DisplayName, "holding_id", h.ID, "symbol", h.Symbol, "name", h.Name}
+			holdingValue.add(h.Value, labels...)
+			holdingQuantity.add(h.Quantity, labels...)
+		}
+	}
+	assets.add(assetTotal)
+	liabilities.add(liabilityTotal)
+	netWorth.add(assetTotal - liabilityTotal)
+
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	budgets, err := client.Budgets.List(ctx, monthStart, monthStart.AddDate(0, 1, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	budgeted := newFamily("monarch_budget_amount", "Budgeted amount for each category this month.")
+	spent := newFamily("monarch_budget_spent", "Amount spent in each category this month.")
+	for _, b := range budgets {
+		labels := []string{"category_id", b.CategoryID, "category", categoryName(b), "group", groupName(b)}
+		budgeted.add(b.Amount, labels...)
+		spent.add(b.Spent, labels...)
+	}
+
+	return []*family{balance, netWorth, assets, liabilities, budgeted, spent, holdingValue, holdingQuantity}, nil
+}
+
+// hasHoldings reports whether an account may hold investments
+func hasHoldings(acc *monarch.Account) bool {
+	return acc.HoldingsCount > 0 || accountType(acc) == "brokerage"
+}
+
+func accountType(acc *monarch.Account) string {
+	if acc.Type == nil {
+		return ""
+	}
+	return acc.Type.Name
+}
+
+func accountSubtype(acc *monarch.Account) string {
+	if acc.Subtype == nil {
+		return ""
+	}
+	return acc.Subtype.Name
+}
+
+func institutionName(acc *monarch.Account) string {
+	switch {
+	case acc.Institution != nil:
+		return acc.Institution.Name
+	case acc.Credential != nil && acc.Credential.Institution != nil:
+		return acc.Credential.Institution.Name
+	}
+	if acc.IsManual {
+		return "manual"
+	}
+	return ""
+}
+
+func categoryName(b *monarch.Budget) string {
+	if b.Category == nil {
+		return ""
+	}
+	return strings.TrimSpace(b.Category.Name)
+}
+
+func groupName(b *monarch.Budget) string {
+	if b.Category == nil || b.Category.Group == nil {
+		return ""
+	}
+	return b.Category.Group.Name
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchfake"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+func testDataset() *monarchtest.Dataset {
+	chase := &monarch.Institution{ID: "inst-chase", Name: "Chase"}
+	food := &monarch.CategoryGroup{ID: "grp-food", Name: "Food", Type: "expense"}
+	return &monarchtest.Dataset{
+		Accounts: []*monarch.Account{
+			{
+				ID: "acc-checking", DisplayName: "Checking", IsAsset: true, IncludeInNetWorth: true,
+				CurrentBalance: 1000, DisplayBalance: 1000, Institution: chase,
+				Type:    &monarch.AccountTypeInfo{Name: "depository"},
+				Subtype: &monarch.AccountSubtypeInfo{Name: "checking"},
+			},
+			{
+				ID: "acc-card", DisplayName: `Sapphire "Reserve"`, IncludeInNetWorth: true,
+				CurrentBalance: -300, DisplayBalance: 300, Institution: chase,
+				Type:    &monarch.AccountTypeInfo{Name: "credit"},
+				Subtype: &monarch.AccountSubtypeInfo{Name: "credit_card"},
+			},
+			{
+				ID: "acc-brokerage", DisplayName: "Brokerage", IsAsset: true, IncludeInNetWorth: true, IsManual: true,
+				CurrentBalance: 1500, DisplayBalance: 1500,
+				Type:    &monarch.AccountTypeInfo{Name: "brokerage"},
+				Subtype: &monarch.AccountSubtypeInfo{Name: "brokerage"},
+			},
+		},
+		CategoryGroups: []*monarch.CategoryGroup{food},
+		Categories: []*monarch.TransactionCategory{
+			{ID: "cat-groceries", Name: "Groceries", Group: food, GroupID: food.ID},
+		},
+		Transactions: []*monarch.Transaction{
+			{
+				ID: "txn-1", Amount: -120, Date: monarch.Date{Time: testNow.AddDate(0, 0, -3)},
+				Account:  &monarch.Account{ID: "acc-checking"},
+				Category: &monarch.TransactionCategory{ID: "cat-groceries"},
+			},
+		},
+		Budgets: []*monarch.Budget{
+			{ID: "bud-1", CategoryID: "cat-groceries", Amount: 500, StartDate: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		Securities: []*monarch.Security{{ID: "sec-aapl", Ticker: "AAPL", Name: "Apple Inc.", CurrentPrice: 150}},
+		Holdings:   []*monarch.Holding{{ID: "hold-1", AccountID: "acc-brokerage", Symbol: "AAPL", Quantity: 10}},
+	}
+}
+
+// newTestCollector returns a collector over the test dataset that has not
+// collected yet
+func newTestCollector(t *testing.T) (*collector, *monarchfake.Fake) {
+	t.Helper()
+	fake := monarchfake.New(testDataset())
+	fake.SetNow(func() time.Time { return testNow })
+
+	c := newCollector(fake.Client(), time.Minute, log.New(io.Discard, "", 0))
+	c.now = func() time.Time { return testNow }
+	return c, fake
+}
+
+func scrape(t *testing.T, c *collector) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	return rec.Body.String()
+}
+
+func TestCollector_Metrics(t *testing.T) {
+	c, _ := newTestCollector(t)
+	c.refresh(context.Background())
+	out := scrape(t, c)
+
+	for _, line := range []string{
+		"# HELP monarch_account_balance Current balance of each account, negative for liabilities.",
+		"# TYPE monarch_account_balance gauge",
+		`monarch_account_balance{account_id="acc-checking",account="Checking",type="depository",subtype="checking",institution="Chase"} 1000`,
+		`monarch_account_balance{account_id="acc-card",account="Sapphire \"Reserve\"",type="credit",subtype="credit_card",institution="Chase"} -300`,
+		`monarch_account_balance{account_id="acc-brokerage",account="Brokerage",type="brokerage",subtype="brokerage",institution="manual"} 1500`,
+		"monarch_assets 2500",
+		"monarch_liabilities 300",
+		"monarch_net_worth 2200",
+		`monarch_budget_amount{category_id="cat-groceries",category="Groceries",group="Food"} 500`,
+		`monarch_budget_spent{category_id="cat-groceries",category="Groceries",group="Food"} 120`,
+		`monarch_holding_value{account_id="acc-brokerage",account="Brokerage",holding_id="hold-1",symbol="AAPL",name="Apple Inc."} 1500`,
+		`monarch_holding_quantity{account_id="acc-brokerage",account="Brokerage",holding_id="hold-1",symbol="AAPL",name="Apple Inc."} 10`,
+		"monarch_exporter_up 1",
+		"monarch_exporter_last_success_timestamp_seconds " + formatValue(float64(testNow.Unix())),
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
+
+func TestCollector_NetWorthOfDefaultDataset(t *testing.T) {
+	c := newCollector(monarchfake.New(monarchtest.DefaultDataset()).Client(), time.Minute, log.New(io.Discard, "", 0))
+	c.refresh(context.Background())
+	out := scrape(t, c)
+
+	// Checking 5000 and brokerage 8949.60, less 850 owed on the card
+	assert.Contains(t, out, `monarch_account_balance{account_id="acc-credit",account="Credit Card",type="credit",subtype="credit_card",institution="American Express"} -850`+"\n")
+	assert.Contains(t, out, "monarch_assets 13949.6\n")
+	assert.Contains(t, out, "monarch_liabilities 850\n")
+	assert.Contains(t, out, "monarch_net_worth 13099.6\n")
+}
+
+func TestCollector_ScrapesServeLastCollection(t *testing.T) {
+	c, fake := newTestCollector(t)
+
+	out := scrape(t, c)
+	assert.Contains(t, out, "monarch_exporter_up 0\n", "nothing has been collected yet")
+	assert.NotContains(t, out, "monarch_net_worth")
+
+	c.refresh(context.Background())
+	scrape(t, c)
+	scrape(t, c)
+	assert.Equal(t, 1, countCalls(fake, "Accounts.List"), "scrapes never collect")
+}
+
+func TestCollector_RunCollectsEveryInterval(t *testing.T) {
+	c, fake := newTestCollector(t)
+	c.interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.run(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return countCalls(fake, "Accounts.List") >= 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Contains(t, scrape(t, c), "monarch_exporter_up 1\n")
+}
+
+func TestCollector_ServesStaleMetricsOnError(t *testing.T) {
+	c, fake := newTestCollector(t)
+	c.refresh(context.Background())
+
+	fake.Fail("Accounts.List", errors.New("boom"))
+	c.refresh(context.Background())
+	out := scrape(t, c)
+
+	assert.Contains(t, out, "monarch_exporter_up 0\n")
+	assert.Contains(t, out, "monarch_net_worth 2200\n", "the last good metrics are kept")
+	assert.Contains(t, out, "monarch_exporter_last_success_timestamp_seconds "+formatValue(float64(testNow.Unix()))+"\n")
+}
+
+func TestCollector_HoldingsOfOneSymbol(t *testing.T) {
+	data := testDataset()
+	data.Holdings = append(data.Holdings, &monarch.Holding{ID: "hold-2", AccountID: "acc-brokerage", Symbol: "AAPL", Quantity: 5})
+	c := newCollector(monarchfake.New(data).Client(), time.Minute, log.New(io.Discard, "", 0))
+	c.refresh(context.Background())
+	out := scrape(t, c)
+
+	assert.Contains(t, out, `monarch_holding_quantity{account_id="acc-brokerage",account="Brokerage",holding_id="hold-1",symbol="AAPL",name="Apple Inc."} 10`+"\n")
+	assert.Contains(t, out, `monarch_holding_quantity{account_id="acc-brokerage",account="Brokerage",holding_id="hold-2",symbol="AAPL",name="Apple Inc."} 5`+"\n")
+}
+
+func TestWriteFamilies_Escaping(t *testing.T) {
+	f := newFamily("test_metric", "Help with \\ and\nnewline.")
+	f.add(1.5, "b", "line\nbreak")
+	f.add(2, "b", `back\slash`)
+
+	var out strings.Builder
+	require.NoError(t, writeFamilies(&out, []*family{f}))
+	assert.Equal(t, "# HELP test_metric Help with \\\\ and\\nnewline.\n"+
+		"# TYPE test_metric gauge\n"+
+		"test_metric{b=\"back\\\\slash\"} 2\n"+
+		"test_metric{b=\"line\\nbreak\"} 1.5\n", out.String())
+}
+
+func TestNewClient_RequiresAuth(t *testing.T) {
+	_, err := newClient(func(string) string { return "" }, "")
+	assert.Error(t, err)
+
+	env := map[string]string{"MONARCH_EMAIL": "user@example.com", "MONARCH_PASSWORD": "secret"}
+	client, err := newClient(func(k string) string { return env[k] }, "")
+	require.NoError(t, err)
+	assert.NotNil(t, client)
+}
+
+func countCalls(fake *monarchfake.Fake, method string) int {
+	n := 0
+	for _, call := range fake.Calls() {
+		if call == method {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,109 @@
+// Command monarch-exporter serves Monarch Money balances, net worth, budgets
+// and investment holdings as Prometheus metrics.
+//
+// Usage:
+//
+//	monarch-exporter [-listen :9847] [-interval 5m] [-session-file path]
+//
+// Authentication is read from the MONARCH_TOKEN environment variable or from
+// a session file (-session-file or MONARCH_SESSION_FILE). When MONARCH_EMAIL
+// and MONARCH_PASSWORD are set, the exporter logs in again whenever the
+// session expires, using MONARCH_TOTP_SECRET for MFA if given.
+//
+// Metrics are collected from the API in the background once per interval;
+// scrapes are served the last collection.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+func main() {
+	logger := log.New(os.Stderr, "monarch-exporter: ", log.LstdFlags)
+	if err := run(os.Args[1:], os.Getenv, logger); err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// run parses flags, connects to Monarch and serves metrics until interrupted
+func run(args []string, getenv func(string) string, logger *log.Logger) error {
+	fs := flag.NewFlagSet("monarch-exporter", flag.ContinueOnError)
+	listen := fs.String("listen", ":9847", "address to serve metrics on")
+	interval := fs.Duration("interval", 5*time.Minute, "time between collections from the API")
+	sessionFile := fs.String("session-file", getenv("MONARCH_SESSION_FILE"), "path to a saved session file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("-interval must be positive")
+	}
+
+	client, err := newClient(getenv, *sessionFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collector := newCollector(client, *interval, logger)
+	go collector.run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", collector)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><body><h1>Monarch Money exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+	})
+
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+
+	logger.Printf("serving metrics on %s/metrics every %s", *listen, *interval)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newClient creates a client authenticated from the environment
+func newClient(getenv func(string) string, sessionFile string) (*monarch.Client, error) {
+	opts := &monarch.ClientOptions{
+		BaseURL:     getenv("MONARCH_BASE_URL"),
+		Token:       getenv("MONARCH_TOKEN"),
+		SessionFile: sessionFile,
+		RetryPolicy: monarch.DefaultRetryPolicy(),
+	}
+	if email, password := getenv("MONARCH_EMAIL"), getenv("MONARCH_PASSWORD"); email != "" && password != "" {
+		opts.CredentialProvider = monarch.StaticCredentials(monarch.Credentials{
+			Email:      email,
+			Password:   password,
+			TOTPSecret: getenv("MONARCH_TOTP_SECRET"),
+		})
+	}
+
+	if opts.Token == "" && opts.SessionFile == "" && opts.CredentialProvider == nil {
+		return nil, errors.New("set MONARCH_TOKEN, MONARCH_SESSION_FILE or MONARCH_EMAIL and MONARCH_PASSWORD")
+	}
+	return monarch.NewClient(opts)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// family is a Prometheus metric family of gauges
+type family struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+// sample is one labeled value of a family
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// label is a name/value pair; labels are written in the order given
+type label struct {
+	name, value string
+}
+
+// newFamily creates an empty gauge family
+func newFamily(name, help string) *family {
+	return &family{name: name, help: help}
+}
+
+// add appends a sample with labels given as name, value pairs
+func (f *family) add(value float64, labels ...string) {
+	s := sample{value: value}
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.labels = append(s.labels, label{name: labels[i], value: labels[i+1]})
+	}
+	f.samples = append(f.samples, s)
+}
+
+// writeFamilies writes families in the Prometheus text exposition format.
+// Samples are sorted by their labels so output is stable between scrapes.
+func writeFamilies(w io.Writer, families []*family) error {
+	for _, f := range families {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", f.name, escapeHelp(f.help), f.name); err != nil {
+			return err
+		}
+
+		lines := make([]string, 0, len(f.samples))
+		for _, s := range f.samples {
+			lines = append(lines, f.name+formatLabels(s.labels)+" "+formatValue(s.value)+"\n")
+		}
+		sort.Strings(lines)
+		for _, line := range lines {
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formatLabels renders {name="value",...}, or "" without labels
+func formatLabels(labels []label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(l.value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatValue renders a sample value
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
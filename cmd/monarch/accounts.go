@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// accountsCommand builds the "accounts" command group
+func accountsCommand() *command {
+	return &command{
+		name:    "accounts",
+		summary: "List and manage accounts",
+		subcommands: []*command{
+			{name: "list", summary: "List all accounts", run: runAccountsList},
+			{name: "get", summary: "Show a single account", run: runAccountsGet},
+			{name: "create", summary: "Create a manual account", run: runAccountsCreate},
+			{name: "update", summary: "Update an account", run: runAccountsUpdate},
+			{name: "delete", summary: "Delete an account", run: runAccountsDelete},
+			{name: "types", summary: "List account types and subtypes", run: runAccountsTypes},
+		},
+	}
+}
+
+func runAccountsList(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("accounts list", "[--type TYPE] [--hidden]")
+	accountType := fs.String("type", "", "only show accounts of this type (e.g. depository, credit)")
+	showHidden := fs.Bool("hidden", false, "include hidden accounts")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	accounts, err := client.Accounts.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	filtered := make([]*monarch.Account, 0, len(accounts))
+	for _, acc := range accounts {
+		if acc.IsHidden && !*showHidden {
+			continue
+		}
+		if *accountType != "" && (acc.Type == nil || !strings.EqualFold(acc.Type.Name, *accountType)) {
+			continue
+		}
+		filtered = append(filtered, acc)
+	}
+
+	t := &table{headers: []string{"ID", "NAME", "TYPE", "SUBTYPE", "INSTITUTION", "BALANCE", "UPDATED"}}
+	for _, acc := range filtered {
+		t.addRow(
+			acc.ID,
+			acc.DisplayName,
+			accountTypeName(acc),
+			accountSubtypeName(acc),
+			institutionName(acc),
+			formatAmount(acc.CurrentBalance),
+			formatTime(acc.DisplayLastUpdatedAt),
+		)
+	}
+
+	return a.render(filtered, t)
+}
+
+func runAccountsGet(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("accounts get", "<account-id>")
+	args, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	account, err := client.Accounts.Get(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("account %s: %w", args[0], err)
+	}
+
+	return a.render(account, accountDetailTable(account))
+}
+
+func runAccountsCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("accounts create", "--name NAME --type TYPE --subtype SUBTYPE [--balance N]")
+	name := fs.String("name", "", "account name (required)")
+	accountType := fs.String("type", "", "account type, see 'monarch accounts types' (required)")
+	subtype := fs.String("subtype", "", "account subtype, see 'monarch accounts types' (required)")
+	balance := fs.Float64("balance", 0, "current balance")
+	netWorth := fs.Bool("net-worth", true, "include the account in net worth")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if *name == "" || *accountType == "" || *subtype == "" {
+		fs.Usage()
+		return usagef("accounts create: --name, --type and --subtype are required")
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	account, err := client.Accounts.Create(ctx, &monarch.CreateAccountParams{
+		AccountName:       *name,
+		AccountType:       *accountType,
+		AccountSubtype:    *subtype,
+		CurrentBalance:    *balance,
+		IncludeInNetWorth: *netWorth,
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.render(account, accountDetailTable(account))
+}
+
+func runAccountsUpdate(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("accounts update", "<account-id> [--name NAME] [--balance N] [--net-worth=BOOL] [--hide=BOOL] [--hide-transactions=BOOL]")
+	name := fs.String("name", "", "new display name")
+	balance := fs.Float64("balance", 0, "new current balance")
+	netWorth := fs.Bool("net-worth", false, "include the account in net worth")
+	hide := fs.Bool("hide", false, "hide the account from the account list")
+	hideTransactions := fs.Bool("hide-transactions", false, "hide the account's transactions from reports")
+	args, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	// Only send fields that were explicitly given
+	params := &monarch.UpdateAccountParams{}
+	if flagWasSet(fs, "name") {
+		params.DisplayName = name
+	}
+	if flagWasSet(fs, "balance") {
+		params.CurrentBalance = balance
+	}
+	if flagWasSet(fs, "net-worth") {
+		params.IncludeInNetWorth = netWorth
+	}
+	if flagWasSet(fs, "hide") {
+		params.HideFromList = hide
+	}
+	if flagWasSet(fs, "hide-transactions") {
+		params.HideTransactionsFromReports = hideTransactions
+	}
+
+	if *params == (monarch.UpdateAccountParams{}) {
+		fs.Usage()
+		return usagef("accounts update: nothing to update")
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	account, err := client.Accounts.Update(ctx, args[0], params)
+	if err != nil {
+		return err
+	}
+
+	return a.render(account, accountDetailTable(account))
+}
+
+func runAccountsDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("accounts delete", "<account-id> [--yes]")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	args, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	accountID := args[0]
+	if !*yes {
+		account, err := client.Accounts.Get(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("account %s: %w", accountID, err)
+		}
+		if !a.confirm(fmt.Sprintf("Delete account %q (%s)?", account.DisplayName, account.ID)) {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	if err := client.Accounts.Delete(ctx, accountID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Deleted account %s\n", accountID)
+	return nil
+}
+
+func runAccountsTypes(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("accounts types", "")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	types, err := client.Accounts.GetTypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := &table{headers: []string{"TYPE", "SUBTYPE", "DISPLAY"}}
+	for _, at := range types {
+		if at.Type == nil {
+			continue
+		}
+		for _, sub := range at.PossibleSubtypes {
+			t.addRow(at.Type.Name, sub.Name, at.Type.Display+" / "+sub.Display)
+		}
+	}
+
+	return a.render(types, t)
+}
+
+// accountDetailTable renders a single account as field/value rows
+func accountDetailTable(acc *monarch.Account) *table {
+	t := &table{headers: []string{"FIELD", "VALUE"}}
+	if acc == nil {
+		return t
+	}
+	t.addRow("ID", acc.ID)
+	t.addRow("Name", acc.DisplayName)
+	t.addRow("Type", accountTypeName(acc))
+	t.addRow("Subtype", accountSubtypeName(acc))
+	t.addRow("Institution", institutionName(acc))
+	t.addRow("Current balance", formatAmount(acc.CurrentBalance))
+	t.addRow("Display balance", formatAmount(acc.DisplayBalance))
+	t.addRow("Asset", formatBool(acc.IsAsset))
+	t.addRow("Manual", formatBool(acc.IsManual))
+	t.addRow("Hidden", formatBool(acc.IsHidden))
+	t.addRow("In net worth", formatBool(acc.IncludeInNetWorth))
+	t.addRow("Mask", acc.Mask)
+	t.addRow("Transactions", fmt.Sprint(acc.TransactionsCount))
+	t.addRow("Holdings", fmt.Sprint(acc.HoldingsCount))
+	t.addRow("Last updated", formatTime(acc.DisplayLastUpdatedAt))
+	return t
+}
+
+// accountTypeName returns the account's type name or an empty string
+func accountTypeName(acc *monarch.Account) string {
+	if acc.Type == nil {
+		return ""
+	}
+	return acc.Type.Name
+}
+
+// accountSubtypeName returns the account's subtype name or an empty string
+func accountSubtypeName(acc *monarch.Account) string {
+	if acc.Subtype == nil {
+		return ""
+	}
+	return acc.Subtype.Name
+}
+
+// institutionName returns the account's institution name, falling back to
+// the credential's institution for synced accounts
+func institutionName(acc *monarch.Account) string {
+	if acc.Institution != nil && acc.Institution.Name != "" {
+		return acc.Institution.Name
+	}
+	if acc.Credential != nil && acc.Credential.Institution != nil {
+		return acc.Credential.Institution.Name
+	}
+	return ""
+}
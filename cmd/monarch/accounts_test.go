@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const accountsResponse = `{
+	"accounts": [
+		{
+			"id": "acc-1",
+			"displayName": "Checking",
+			"currentBalance": 1500.5,
+			"type": {"name": "depository", "display": "Cash"},
+			"subtype": {"name": "checking", "display": "Checking"},
+			"institution": {"name": "Chase"}
+		},
+		{
+			"id": "acc-2",
+			"displayName": "Visa",
+			"currentBalance": -200,
+			"type": {"name": "credit", "display": "Credit Cards"},
+			"credential": {"institution": {"name": "Citi"}}
+		},
+		{
+			"id": "acc-3",
+			"displayName": "Old Savings",
+			"isHidden": true,
+			"type": {"name": "depository", "display": "Cash"}
+		}
+	],
+	"householdPreferences": null
+}`
+
+func TestAccountsList_Table(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetAccounts": accountsResponse})
+
+	code, stdout, _ := runCLI(t, srv, "", "accounts", "list")
+
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "Checking")
+	assert.Contains(t, stdout, "Chase")
+	assert.Contains(t, stdout, "Citi")
+	assert.Contains(t, stdout, "1500.50")
+	assert.NotContains(t, stdout, "Old Savings")
+}
+
+func TestAccountsList_FilterAndJSON(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetAccounts": accountsResponse})
+
+	code, stdout, _ := runCLI(t, srv, "", "accounts", "list", "--type", "depository", "--hidden", "-o", "json")
+
+	require.Equal(t, exitOK, code)
+	var accounts []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &accounts))
+	require.Len(t, accounts, 2)
+	assert.Equal(t, "acc-1", accounts[0]["id"])
+	assert.Equal(t, "acc-3", accounts[1]["id"])
+}
+
+func TestAccountsList_CSVGlobalFlagFirst(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetAccounts": accountsResponse})
+
+	code, stdout, _ := runCLI(t, srv, "", "-o", "csv", "accounts", "list")
+
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "ID,NAME,TYPE,SUBTYPE,INSTITUTION,BALANCE,UPDATED\n")
+	assert.Contains(t, stdout, "acc-2,Visa,credit,,Citi,-200.00,\n")
+}
+
+func TestAccountsGet_NotFound(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetAccounts": accountsResponse})
+
+	code, _, stderr := runCLI(t, srv, "", "accounts", "get", "acc-missing")
+
+	assert.Equal(t, exitNotFound, code)
+	assert.Contains(t, stderr, "acc-missing")
+}
+
+func TestAccountsGet_MissingArg(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, _ := runCLI(t, srv, "", "accounts", "get")
+
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestAccountsCreate(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"Web_CreateManualAccount": `{"createManualAccount": {"account": {"id": "acc-1"}, "errors": []}}`,
+		"GetAccounts":             accountsResponse,
+	})
+
+	code, stdout, _ := runCLI(t, srv, "", "accounts", "create",
+		"--name", "Cash Jar", "--type", "depository", "--subtype", "checking", "--balance", "42.5")
+
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "acc-1")
+
+	calls := stub.callsTo("Web_CreateManualAccount")
+	require.Len(t, calls, 1)
+	input := calls[0]["input"].(map[string]interface{})
+	assert.Equal(t, "Cash Jar", input["name"])
+	assert.Equal(t, "depository", input["type"])
+	assert.Equal(t, 42.5, input["displayBalance"])
+	assert.Equal(t, true, input["includeInNetWorth"])
+}
+
+func TestAccountsCreate_RequiresFlags(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, _ := runCLI(t, srv, "", "accounts", "create", "--name", "x")
+
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestAccountsUpdate_OnlySendsGivenFields(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"Common_UpdateAccount": `{"updateAccount": {"account": {"id": "acc-1", "displayName": "Renamed"}, "errors": []}}`,
+	})
+
+	code, stdout, _ := runCLI(t, srv, "", "accounts", "update", "acc-1", "--name", "Renamed", "--hide=false")
+
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "Renamed")
+
+	input := stub.callsTo("Common_UpdateAccount")[0]["input"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"id":                  "acc-1",
+		"name":                "Renamed",
+		"hideFromSummaryList": false,
+	}, input)
+}
+
+func TestAccountsUpdate_NothingToUpdate(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, _ := runCLI(t, srv, "", "accounts", "update", "acc-1")
+
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestAccountsDelete_Confirmation(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":          accountsResponse,
+		"Common_DeleteAccount": `{"deleteAccount": {"deleted": true, "errors": []}}`,
+	})
+
+	code, _, _ := runCLI(t, srv, "n\n", "accounts", "delete", "acc-1")
+	assert.Equal(t, exitError, code)
+	assert.Empty(t, stub.callsTo("Common_DeleteAccount"))
+
+	code, _, stderr := runCLI(t, srv, "y\n", "accounts", "delete", "acc-1")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "Deleted account acc-1")
+	assert.Len(t, stub.callsTo("Common_DeleteAccount"), 1)
+}
+
+func TestAccountsTypes(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{
+		"GetAccountTypeOptions": `{"accountTypeOptions": [{
+			"type": {"name": "depository", "display": "Cash"},
+			"possibleSubtypes": [{"name": "checking", "display": "Checking"}, {"name": "savings", "display": "Savings"}]
+		}]}`,
+	})
+
+	code, stdout, _ := runCLI(t, srv, "", "accounts", "types", "-o", "csv")
+
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "TYPE,SUBTYPE,DISPLAY\ndepository,checking,Cash / Checking\ndepository,savings,Cash / Savings\n", stdout)
+}
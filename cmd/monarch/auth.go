@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"golang.org/x/term"
+)
+
+// defaultProfile is used when neither --profile nor MONARCH_PROFILE is set
+const defaultProfile = "default"
+
+// loginCommand builds the "login" command
+func loginCommand() *command {
+	return &command{name: "login", summary: "Log in and save the session for a profile", run: runLogin}
+}
+
+// logoutCommand builds the "logout" command
+func logoutCommand() *command {
+	return &command{name: "logout", summary: "Remove the saved session for a profile", run: runLogout}
+}
+
+// whoamiCommand builds the "whoami" command
+func whoamiCommand() *command {
+	return &command{name: "whoami", summary: "Show the logged in user and session expiry", run: runWhoami}
+}
+
+func runLogin(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("login", "[--email EMAIL] [--totp-secret SECRET | --mfa-code CODE | --email-otp CODE]")
+	email := fs.String("email", "", "account email (default $MONARCH_EMAIL, prompted if empty)")
+	totpSecret := fs.String("totp-secret", "", "TOTP secret used to generate the MFA code (default $MONARCH_TOTP_SECRET)")
+	mfaCode := fs.String("mfa-code", "", "MFA code from your authenticator app")
+	emailOTP := fs.String("email-otp", "", "one-time code sent by email after a previous login attempt")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if *totpSecret == "" {
+		*totpSecret = a.getenv("MONARCH_TOTP_SECRET")
+	}
+	if *email == "" {
+		*email = a.getenv("MONARCH_EMAIL")
+	}
+	if *email == "" {
+		fmt.Fprint(a.stderr, "Email: ")
+		line, err := a.readLine()
+		if err != nil {
+			return fmt.Errorf("read email: %w", err)
+		}
+		*email = line
+	}
+	if *email == "" {
+		return usagef("login: an email is required")
+	}
+
+	password, err := a.readPassword()
+	if err != nil {
+		return err
+	}
+
+	path, err := a.sessionPath()
+	if err != nil {
+		return err
+	}
+
+	client, err := a.newClient(&monarch.ClientOptions{BaseURL: a.getenv("MONARCH_BASE_URL")})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	switch {
+	case *totpSecret != "":
+		err = client.Auth.LoginWithTOTP(ctx, *email, password, *totpSecret)
+	case *mfaCode != "":
+		err = client.Auth.LoginWithMFA(ctx, *email, password, *mfaCode)
+	case *emailOTP != "":
+		err = client.Auth.LoginWithEmailOTP(ctx, *email, password, *emailOTP)
+	default:
+		err = client.Auth.LoginWithChallenge(ctx, *email, password, monarch.ChallengeHandlerFunc(a.promptChallenge))
+	}
+	if err != nil {
+		if monarch.IsAuthError(err) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", monarch.ErrLoginFailed, err)
+	}
+
+	if err := client.Auth.SaveSession(path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Logged in as %s (profile %q), session saved to %s\n", *email, a.profileName(), path)
+	return nil
+}
+
+func runLogout(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("logout", "")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	path, err := a.sessionPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			fmt.Fprintf(a.stderr, "No saved session for profile %q\n", a.profileName())
+			return nil
+		}
+		return fmt.Errorf("remove session: %w", err)
+	}
+
+	fmt.Fprintf(a.stderr, "Logged out of profile %q\n", a.profileName())
+	return nil
+}
+
+// whoami is the output of the whoami command
+type whoami struct {
+	Profile     string    `json:"profile"`
+	Source      string    `json:"source"`
+	SessionFile string    `json:"sessionFile,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	UserID      string    `json:"userId,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt,omitempty"`
+}
+
+func runWhoami(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("whoami", "")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	info := whoami{Profile: a.profileName(), Source: "MONARCH_TOKEN"}
+	if a.getenv("MONARCH_TOKEN") == "" {
+		info.Source = "session"
+		info.SessionFile, _ = a.sessionPath()
+		session, err := client.Auth.GetSession()
+		if err != nil {
+			return err
+		}
+		info.Email = session.Email
+		info.UserID = session.UserID
+		info.ExpiresAt = session.ExpiresAt
+	}
+
+	t := &table{headers: []string{"FIELD", "VALUE"}}
+	t.addRow("Profile", info.Profile)
+	t.addRow("Source", info.Source)
+	t.addRow("Session file", info.SessionFile)
+	t.addRow("Email", info.Email)
+	t.addRow("User ID", info.UserID)
+	t.addRow("Expires", formatTime(info.ExpiresAt))
+
+	return a.render(info, t)
+}
+
+// profileName returns the selected profile
+func (a *app) profileName() string {
+	if a.global.profile != "" {
+		return a.global.profile
+	}
+	if p := a.getenv("MONARCH_PROFILE"); p != "" {
+		return p
+	}
+	return defaultProfile
+}
+
+// configDir returns the directory holding CLI state, $MONARCH_CONFIG_DIR or
+// "monarch" under the user config dir
+func (a *app) configDir() (string, error) {
+	if dir := a.getenv("MONARCH_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "monarch"), nil
+}
+
+// sessionPath returns the session file to use: --session-file,
+// $MONARCH_SESSION_FILE, or the selected profile's saved session
+func (a *app) sessionPath() (string, error) {
+	if a.global.sessionFile != "" {
+		return a.global.sessionFile, nil
+	}
+	if path := a.getenv("MONARCH_SESSION_FILE"); path != "" {
+		return path, nil
+	}
+
+	profile := a.profileName()
+	if strings.ContainsAny(profile, `/\`) || profile == "." || profile == ".." {
+		return "", usagef("invalid profile name %q", profile)
+	}
+	dir, err := a.configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "profiles", profile, "session.json"), nil
+}
+
+// loadSession loads the saved session into client
+func (a *app) loadSession(client *monarch.Client) error {
+	path, err := a.sessionPath()
+	if err != nil {
+		return err
+	}
+
+	err = client.Auth.LoadSession(path)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, monarch.ErrSessionExpired):
+		return fmt.Errorf("%w: session for profile %q has expired, run 'monarch login'", err, a.profileName())
+	case errors.Is(err, monarch.ErrNotAuthenticated):
+		return fmt.Errorf("%w: no session for profile %q, run 'monarch login' or set MONARCH_TOKEN", err, a.profileName())
+	default:
+		return fmt.Errorf("load session from %s: %w", path, err)
+	}
+}
+
+// promptChallenge asks for the code of a login challenge on stderr and reads
+// it from stdin
+func (a *app) promptChallenge(ctx context.Context, challenge monarch.Challenge) (string, error) {
+	switch challenge.Kind {
+	case monarch.ChallengeEmailOTP:
+		fmt.Fprint(a.stderr, "A one-time code has been sent to your email address.\nEmail code: ")
+	default:
+		fmt.Fprint(a.stderr, "Authenticator code: ")
+	}
+	code, err := a.readLine()
+	if err != nil {
+		return "", fmt.Errorf("read code: %w", err)
+	}
+	if code == "" {
+		return "", usagef("login: a code is required")
+	}
+	return code, nil
+}
+
+// readPassword reads the password from $MONARCH_PASSWORD, without echo from a
+// terminal, or as a line from stdin
+func (a *app) readPassword() (string, error) {
+	if password := a.getenv("MONARCH_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	if f, ok := a.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(a.stderr, "Password: ")
+		b, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(a.stderr)
+		if err != nil {
+			return "", fmt.Errorf("read password: %w", err)
+		}
+		return string(b), nil
+	}
+
+	password, err := a.readLine()
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	return password, nil
+}
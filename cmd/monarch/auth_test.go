@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSession saves a session file for profile under configDir
+func writeSession(t *testing.T, configDir, profile string, expiresAt time.Time) string {
+	t.Helper()
+	path := filepath.Join(configDir, "profiles", profile, "session.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	data, err := json.Marshal(map[string]interface{}{
+		"token":     "saved-token",
+		"userId":    "user-1",
+		"email":     profile + "@example.com",
+		"expiresAt": expiresAt,
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestLogin_SavesProfileSession(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"/auth/login/": `{"token": "new-token", "userId": "user-1"}`,
+	})
+	configDir := t.TempDir()
+	env := map[string]string{"MONARCH_CONFIG_DIR": configDir}
+
+	code, _, stderr := runCLIEnv(t, env, srv, "me@example.com\nhunter2\n", "login", "--profile", "household")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, `profile "household"`)
+
+	calls := stub.callsTo("/auth/login/")
+	require.Len(t, calls, 1)
+	assert.Equal(t, "me@example.com", calls[0]["username"])
+	assert.Equal(t, "hunter2", calls[0]["password"])
+
+	data, err := os.ReadFile(filepath.Join(configDir, "profiles", "household", "session.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "new-token")
+}
+
+func TestLogin_PromptsForEmailCode(t *testing.T) {
+	stub, srv := newGraphQLStub(t, nil)
+	stub.funcs = map[string]func(vars map[string]interface{}) string{
+		"/auth/login/": func(body map[string]interface{}) string {
+			if body["email_otp"] == "135790" {
+				return `{"token": "otp-token", "userId": "user-1"}`
+			}
+			return `{"error_code": "EMAIL_OTP_REQUIRED"}`
+		},
+	}
+	env := map[string]string{"MONARCH_EMAIL": "me@example.com", "MONARCH_PASSWORD": "hunter2"}
+
+	code, _, stderr := runCLIEnv(t, env, srv, "135790\n", "login")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "Email code: ")
+	assert.Len(t, stub.callsTo("/auth/login/"), 2)
+}
+
+func TestLogin_TOTPFromEnv(t *testing.T) {
+	var totps []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		totp, _ := body["totp"].(string)
+		if totp == "" {
+			_, _ = w.Write([]byte(`{"error_code": "MFA_REQUIRED"}`))
+			return
+		}
+		totps = append(totps, totp)
+		_, _ = w.Write([]byte(`{"token": "mfa-token", "userId": "user-1"}`))
+	}))
+	defer srv.Close()
+	env := map[string]string{
+		"MONARCH_EMAIL":       "me@example.com",
+		"MONARCH_PASSWORD":    "hunter2",
+		"MONARCH_TOTP_SECRET": "JBSWY3DPEHPK3PXP",
+	}
+
+	code, _, stderr := runCLIEnv(t, env, srv, "", "login")
+
+	require.Equal(t, exitOK, code, stderr)
+	require.Len(t, totps, 1)
+	assert.Len(t, totps[0], 6)
+}
+
+func TestLogin_InvalidCredentials(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{
+		"/auth/login/": `{"error_code": "INVALID_CREDENTIALS"}`,
+	})
+	env := map[string]string{"MONARCH_EMAIL": "me@example.com", "MONARCH_PASSWORD": "wrong"}
+
+	code, _, stderr := runCLIEnv(t, env, srv, "", "login")
+
+	assert.Equal(t, exitAuth, code)
+	assert.Contains(t, stderr, "login failed")
+}
+
+func TestLogout(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+	configDir := t.TempDir()
+	path := writeSession(t, configDir, "test", time.Now().Add(time.Hour))
+	env := map[string]string{"MONARCH_CONFIG_DIR": configDir, "MONARCH_PROFILE": "test"}
+
+	code, _, _ := runCLIEnv(t, env, srv, "", "logout")
+	require.Equal(t, exitOK, code)
+	assert.NoFileExists(t, path)
+
+	code, _, stderr := runCLIEnv(t, env, srv, "", "logout")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "No saved session")
+}
+
+func TestWhoami_ProfileSelection(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+	configDir := t.TempDir()
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	writeSession(t, configDir, "household", expires)
+	writeSession(t, configDir, "test", expires)
+	env := map[string]string{"MONARCH_CONFIG_DIR": configDir, "MONARCH_PROFILE": "household"}
+
+	code, stdout, _ := runCLIEnv(t, env, srv, "", "whoami", "-o", "json")
+	require.Equal(t, exitOK, code)
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &info))
+	assert.Equal(t, "household", info["profile"])
+	assert.Equal(t, "household@example.com", info["email"])
+	assert.Equal(t, "2030-01-02T03:04:05Z", info["expiresAt"])
+
+	// --profile overrides MONARCH_PROFILE
+	code, stdout, _ = runCLIEnv(t, env, srv, "", "--profile", "test", "whoami")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "test@example.com")
+}
+
+func TestWhoami_ExpiredSession(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+	configDir := t.TempDir()
+	writeSession(t, configDir, "default", time.Now().Add(-time.Hour))
+
+	code, _, stderr := runCLIEnv(t, map[string]string{"MONARCH_CONFIG_DIR": configDir}, srv, "", "whoami")
+
+	assert.Equal(t, exitAuth, code)
+	assert.Contains(t, stderr, "expired")
+}
+
+func TestWhoami_Token(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, stdout, _ := runCLI(t, srv, "", "whoami", "-o", "csv")
+
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "Source,MONARCH_TOKEN\n")
+}
+
+func TestSessionPath_InvalidProfile(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, _ := runCLIEnv(t, nil, srv, "", "--profile", "../etc", "whoami")
+
+	assert.Equal(t, exitUsage, code)
+}
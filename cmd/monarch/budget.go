@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// monthLayout is the month format accepted on the command line
+const monthLayout = "2006-01"
+
+// budgetCommand builds the "budget" command group
+func budgetCommand() *command {
+	return &command{
+		name:    "budget",
+		summary: "Show and edit monthly budgets",
+		subcommands: []*command{
+			{name: "show", summary: "Show budgeted, spent and remaining amounts for a month", run: runBudgetShow},
+			{name: "set", summary: "Set the budget for a category", run: runBudgetSet},
+			{name: "copy", summary: "Copy budgeted amounts from one month to another", run: runBudgetCopy},
+		},
+	}
+}
+
+// budgetRow is a single category line of the budget report
+type budgetRow struct {
+	Group        string  `json:"group"`
+	CategoryID   string  `json:"categoryId"`
+	Category     string  `json:"category"`
+	Budgeted     float64 `json:"budgeted"`
+	Spent        float64 `json:"spent"`
+	Remaining    float64 `json:"remaining"`
+	Rollover     float64 `json:"rollover"`
+	RolloverType string  `json:"rolloverType,omitempty"`
+	OverBudget   bool    `json:"overBudget"`
+}
+
+func runBudgetShow(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("budget show", "[YYYY-MM] [--all]")
+	all := fs.Bool("all", false, "include categories with nothing budgeted or spent")
+	args, err := parseFlags(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	var month string
+	if len(args) == 1 {
+		month = args[0]
+	}
+	start, err := parseMonth(month)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	budgets, err := client.Budgets.ListWithGoals(ctx, start, monthEnd(start))
+	if err != nil {
+		return err
+	}
+
+	// Group rows, keeping groups in the order the API returns them
+	var groups []string
+	byGroup := make(map[string][]*budgetRow)
+	for _, b := range budgets {
+		if b.Budget == nil || b.Category == nil {
+			continue
+		}
+		if !*all && b.Amount == 0 && b.Spent == 0 && b.RolloverAmount == 0 {
+			continue
+		}
+		row := &budgetRow{
+			Group:        "Other",
+			CategoryID:   b.CategoryID,
+			Category:     b.Category.Name,
+			Budgeted:     b.Amount,
+			Spent:        b.Spent,
+			Remaining:    b.Remaining,
+			Rollover:     b.RolloverAmount,
+			RolloverType: b.RolloverType,
+		}
+		if g := b.Category.Group; g != nil {
+			row.Group = g.Name
+			row.OverBudget = g.Type != "income" && b.Remaining < 0
+		} else {
+			row.OverBudget = b.Remaining < 0
+		}
+		if _, ok := byGroup[row.Group]; !ok {
+			groups = append(groups, row.Group)
+		}
+		byGroup[row.Group] = append(byGroup[row.Group], row)
+	}
+
+	rows := make([]*budgetRow, 0, len(budgets))
+	for _, g := range groups {
+		rows = append(rows, byGroup[g]...)
+	}
+
+	// Tables show each group once with a subtotal; CSV keeps one row per
+	// category so it stays machine readable
+	grouped := a.global.output == formatTable || a.global.output == ""
+	t := &table{headers: []string{"GROUP", "CATEGORY", "BUDGETED", "SPENT", "REMAINING", "ROLLOVER", "STATUS"}}
+	for _, g := range groups {
+		var total budgetRow
+		for i, row := range byGroup[g] {
+			group := row.Group
+			if grouped && i > 0 {
+				group = ""
+			}
+			cells := []string{group, row.Category, formatAmount(row.Budgeted), formatAmount(row.Spent),
+				formatAmount(row.Remaining), formatAmount(row.Rollover), ""}
+			if row.OverBudget {
+				cells[6] = "over"
+				t.addHighlightedRow(cells...)
+			} else {
+				t.addRow(cells...)
+			}
+			total.Budgeted += row.Budgeted
+			total.Spent += row.Spent
+			total.Remaining += row.Remaining
+			total.Rollover += row.Rollover
+		}
+		if grouped {
+			t.addRow("", "Total", formatAmount(total.Budgeted), formatAmount(total.Spent),
+				formatAmount(total.Remaining), formatAmount(total.Rollover), "")
+		}
+	}
+
+	return a.render(rows, t)
+}
+
+func runBudgetSet(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("budget set", "<category> <amount> [--rollover] [--month YYYY-MM]")
+	rollover := fs.Bool("rollover", false, "roll unspent amounts over to the next month")
+	month := fs.String("month", "", "month to budget (default current month)")
+	args, err := parseFlags(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	amount, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || amount < 0 {
+		return usagef("budget set: invalid amount %q", args[1])
+	}
+	start, err := parseMonth(*month)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	categories, err := categoryItems(ctx, client)
+	if err != nil {
+		return err
+	}
+	categoryID, err := resolveID("category", args[0], categories)
+	if err != nil {
+		return err
+	}
+
+	// Budgets are keyed by category
+	if err := client.Budgets.SetAmount(ctx, categoryID, amount, *rollover, start); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Set %s budget for %s to %s\n", itemName(categories, categoryID), start.Format(monthLayout), formatAmount(amount))
+	return nil
+}
+
+// budgetCopy is the outcome of copying one category's budget
+type budgetCopy struct {
+	CategoryID string  `json:"categoryId"`
+	Category   string  `json:"category"`
+	From       float64 `json:"from"`
+	To         float64 `json:"to"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+}
+
+func runBudgetCopy(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("budget copy", "--from YYYY-MM --to YYYY-MM [--dry-run]")
+	from := fs.String("from", "", "month to copy from (required)")
+	to := fs.String("to", "", "month to copy to (required)")
+	dryRun := fs.Bool("dry-run", false, "show the changes without applying them")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		fs.Usage()
+		return usagef("budget copy: --from and --to are required")
+	}
+
+	fromStart, err := parseMonth(*from)
+	if err != nil {
+		return err
+	}
+	toStart, err := parseMonth(*to)
+	if err != nil {
+		return err
+	}
+	if fromStart.Equal(toStart) {
+		return usagef("budget copy: --from and --to are the same month")
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	source, err := client.Budgets.List(ctx, fromStart, monthEnd(fromStart))
+	if err != nil {
+		return err
+	}
+	target, err := client.Budgets.List(ctx, toStart, monthEnd(toStart))
+	if err != nil {
+		return err
+	}
+	current := make(map[string]float64, len(target))
+	for _, b := range target {
+		current[b.CategoryID] = b.Amount
+	}
+
+	var results []*budgetCopy
+	failed := 0
+	for _, b := range source {
+		if b.Amount == 0 {
+			continue
+		}
+		r := &budgetCopy{CategoryID: b.CategoryID, From: b.Amount, To: current[b.CategoryID]}
+		if b.Category != nil {
+			r.Category = b.Category.Name
+		}
+		results = append(results, r)
+
+		switch {
+		case r.From == r.To:
+			r.Status = statusUnchanged
+		case *dryRun:
+			r.Status = statusDryRun
+		default:
+			if err := client.Budgets.SetAmount(ctx, b.CategoryID, b.Amount, b.Rollover, toStart); err != nil {
+				r.Status = statusFailed
+				r.Error = err.Error()
+				failed++
+			} else {
+				r.Status = statusUpdated
+			}
+		}
+	}
+
+	t := &table{headers: []string{"CATEGORY", fromStart.Format(monthLayout), toStart.Format(monthLayout), "STATUS"}}
+	for _, r := range results {
+		t.addRow(r.Category, formatAmount(r.From), formatAmount(r.To), r.Status)
+	}
+	if err := a.render(results, t); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(a.stderr, "  %s: %s\n", r.Category, r.Error)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d budgets failed to copy", failed, len(results))
+	}
+	return nil
+}
+
+// parseMonth parses YYYY-MM into the first day of the month. An empty string
+// is the current month.
+func parseMonth(s string) (time.Time, error) {
+	if s == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	month, err := time.Parse(monthLayout, s)
+	if err != nil {
+		return time.Time{}, usagef("invalid month %q, expected YYYY-MM", s)
+	}
+	return month, nil
+}
+
+// monthEnd returns the last day of the month starting at start
+func monthEnd(start time.Time) time.Time {
+	return start.AddDate(0, 1, -1)
+}
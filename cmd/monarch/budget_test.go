@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// budgetCategory is a category entry of a budgetData response
+func budgetCategory(id, name, group, groupType string, planned, actual, remaining float64) string {
+	return fmt.Sprintf(`{
+		"category": {"id": %q, "name": %q, "group": {"id": "g-%s", "name": %q, "type": %q}},
+		"monthlyAmounts": [{
+			"month": "2026-10-01",
+			"plannedCashFlowAmount": %v,
+			"actualAmount": %v,
+			"remainingAmount": %v,
+			"previousMonthRolloverAmount": 0,
+			"rolloverType": ""
+		}]
+	}`, id, name, group, group, groupType, planned, actual, remaining)
+}
+
+// budgetResponse builds a budgetData response from category entries
+func budgetResponse(categories ...string) string {
+	return `{"budgetData": {"monthlyAmountsByCategory": [` + strings.Join(categories, ",") + `]}, "goalsV2": {"goals": []}}`
+}
+
+func TestBudgetShow_GroupedTable(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetBudgetsWithGoals": budgetResponse(
+			budgetCategory("cat-1", "Groceries", "Food", "expense", 500, -620, -120),
+			budgetCategory("cat-3", "Dining", "Food", "expense", 200, -50, 150),
+			budgetCategory("cat-2", "Paychecks", "Income", "income", 5000, 4000, -1000),
+			budgetCategory("cat-4", "Travel", "Other", "expense", 0, 0, 0),
+		),
+	})
+
+	code, stdout, stderr := runCLI(t, srv, "", "budget", "show", "2026-10")
+
+	require.Equal(t, exitOK, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 6)
+	assert.Regexp(t, `^Food\s+Groceries\s+500.00\s+620.00\s+-120.00\s+0.00\s+over$`, lines[1])
+	assert.Regexp(t, `^\s+Dining\s+200.00\s+50.00\s+150.00\s+0.00\s*$`, lines[2])
+	assert.Regexp(t, `^\s+Total\s+700.00\s+670.00\s+30.00`, lines[3])
+	assert.NotContains(t, lines[4], "over", "income shortfall is not over budget")
+	assert.NotContains(t, stdout, "Travel")
+
+	vars := stub.callsTo("GetBudgetsWithGoals")[0]
+	assert.Equal(t, "2026-10-01", vars["startDate"])
+	assert.Equal(t, "2026-10-31", vars["endDate"])
+}
+
+func TestBudgetShow_JSONAndHighlight(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{
+		"GetBudgetsWithGoals": budgetResponse(budgetCategory("cat-1", "Groceries", "Food", "expense", 500, -620, -120)),
+	})
+
+	code, stdout, _ := runCLI(t, srv, "", "budget", "show", "2026-10", "-o", "json")
+	require.Equal(t, exitOK, code)
+	var rows []budgetRow
+	require.NoError(t, json.Unmarshal([]byte(stdout), &rows))
+	require.Len(t, rows, 1)
+	assert.True(t, rows[0].OverBudget)
+	assert.Equal(t, 620.0, rows[0].Spent)
+
+	var out strings.Builder
+	a := newApp(nil, &out, &out, func(string) string { return "" })
+	a.color = true
+	tbl := &table{headers: []string{"A"}}
+	tbl.addRow("ok")
+	tbl.addHighlightedRow("over")
+	require.NoError(t, a.render(nil, tbl))
+	assert.Equal(t, ansiDefault+"A"+ansiReset+"\n"+ansiDefault+"ok"+ansiReset+"\n"+ansiRed+"over"+ansiReset+"\n", out.String())
+}
+
+func TestBudgetShow_InvalidMonth(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, _ := runCLI(t, srv, "", "budget", "show", "October")
+
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestBudgetSet_ResolvesCategoryName(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetCategories":   categoriesResponse,
+		"SetBudgetAmount": `{"setBudgetAmount": {"budget": {"id": "b-1"}, "errors": []}}`,
+	})
+
+	code, _, stderr := runCLI(t, srv, "", "budget", "set", "dining", "250", "--rollover", "--month", "2026-11")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "Set Dining budget for 2026-11 to 250.00")
+	assert.Equal(t, map[string]interface{}{
+		"budgetId":  "cat-3",
+		"amount":    250.0,
+		"rollover":  true,
+		"startDate": "2026-11-01",
+	}, stub.callsTo("SetBudgetAmount")[0]["input"])
+}
+
+func TestBudgetSet_InvalidAmount(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, _ := runCLI(t, srv, "", "budget", "set", "Dining", "lots")
+
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestBudgetCopy(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"SetBudgetAmount": `{"setBudgetAmount": {"budget": {"id": "b-1"}, "errors": []}}`,
+	})
+	stub.funcs = map[string]func(map[string]interface{}) string{
+		"Common_GetJointPlanningData": func(vars map[string]interface{}) string {
+			if vars["startDate"] == "2026-09-01" {
+				return budgetResponse(
+					budgetCategory("cat-1", "Groceries", "Food", "expense", 500, 0, 0),
+					budgetCategory("cat-3", "Dining", "Food", "expense", 200, 0, 0),
+					budgetCategory("cat-4", "Travel", "Other", "expense", 0, 0, 0),
+				)
+			}
+			return budgetResponse(
+				budgetCategory("cat-1", "Groceries", "Food", "expense", 500, 0, 0),
+				budgetCategory("cat-3", "Dining", "Food", "expense", 0, 0, 0),
+			)
+		},
+	}
+
+	code, stdout, _ := runCLI(t, srv, "", "budget", "copy", "--from", "2026-09", "--to", "2026-10", "--dry-run", "-o", "csv")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "CATEGORY,2026-09,2026-10,STATUS\nGroceries,500.00,500.00,unchanged\nDining,200.00,0.00,dry-run\n", stdout)
+	assert.Empty(t, stub.callsTo("SetBudgetAmount"))
+
+	code, _, _ = runCLI(t, srv, "", "budget", "copy", "--from", "2026-09", "--to", "2026-10")
+	require.Equal(t, exitOK, code)
+	calls := stub.callsTo("SetBudgetAmount")
+	require.Len(t, calls, 1)
+	input := calls[0]["input"].(map[string]interface{})
+	assert.Equal(t, "cat-3", input["budgetId"])
+	assert.Equal(t, 200.0, input["amount"])
+	assert.Equal(t, "2026-10-01", input["startDate"])
+}
+
+func TestBudgetCopy_RequiresMonths(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, _ := runCLI(t, srv, "", "budget", "copy", "--from", "2026-09")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI(t, srv, "", "budget", "copy", "--from", "2026-09", "--to", "2026-09")
+	assert.Equal(t, exitUsage, code)
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// holdingsCommand builds the "holdings" command group
+func holdingsCommand() *command {
+	return &command{
+		name:    "holdings",
+		summary: "Manage holdings in manual investment accounts",
+		subcommands: []*command{
+			{name: "list", summary: "List the holdings of an account", run: runHoldingsList},
+			{name: "add", summary: "Add a holding by ticker", run: runHoldingsAdd},
+			{name: "set", summary: "Set the quantity of a holding", run: runHoldingsSet},
+			{name: "rm", summary: "Remove a holding", run: runHoldingsRemove},
+			{name: "search", summary: "Search securities by ticker or name", run: runHoldingsSearch},
+			{name: "sync", summary: "Reconcile an account with a brokerage positions CSV", run: runHoldingsSync},
+		},
+	}
+}
+
+// quantityTolerance is the smallest quantity difference treated as a change
+const quantityTolerance = 1e-6
+
+func runHoldingsList(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("holdings list", "--account ACCOUNT")
+	account := fs.String("account", "", "account name or ID (required)")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *account == "" {
+		fs.Usage()
+		return usagef("holdings list: --account is required")
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+	acc, err := resolveAccount(ctx, client, *account)
+	if err != nil {
+		return err
+	}
+
+	holdings, err := client.Accounts.GetHoldings(ctx, acc.ID)
+	if err != nil {
+		return err
+	}
+
+	t := &table{headers: []string{"ID", "SYMBOL", "NAME", "QUANTITY", "PRICE", "VALUE"}}
+	for _, h := range holdings {
+		t.addRow(h.ID, h.Symbol, h.Name, formatQuantity(h.Quantity), formatAmount(h.Price), formatAmount(h.Value))
+	}
+	return a.render(holdings, t)
+}
+
+func runHoldingsAdd(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("holdings add", "--account ACCOUNT <ticker> <quantity>")
+	account := fs.String("account", "", "account name or ID (required)")
+	args, err := parseFlags(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if *account == "" {
+		fs.Usage()
+		return usagef("holdings add: --account is required")
+	}
+	quantity, err := parseQuantity(args[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+	acc, err := resolveAccount(ctx, client, *account)
+	if err != nil {
+		return err
+	}
+
+	holding, err := client.Accounts.CreateHoldingByTicker(ctx, acc.ID, strings.ToUpper(args[0]), quantity)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Added %s %s to %s\n", formatQuantity(quantity), holding.Symbol, acc.DisplayName)
+	return a.render(holding, holdingDetailTable(holding))
+}
+
+func runHoldingsSet(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("holdings set", "--account ACCOUNT <ticker|holding-id> <quantity>")
+	account := fs.String("account", "", "account name or ID (required)")
+	args, err := parseFlags(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if *account == "" {
+		fs.Usage()
+		return usagef("holdings set: --account is required")
+	}
+	quantity, err := parseQuantity(args[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+	acc, holding, err := findHolding(ctx, client, *account, args[0])
+	if err != nil {
+		return err
+	}
+
+	updated, err := client.Accounts.UpdateHoldingQuantity(ctx, acc.ID, holding.ID, quantity)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Set %s in %s from %s to %s\n", holding.Symbol, acc.DisplayName,
+		formatQuantity(holding.Quantity), formatQuantity(updated.Quantity))
+	return nil
+}
+
+func runHoldingsRemove(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("holdings rm", "--account ACCOUNT <ticker|holding-id> [--yes]")
+	account := fs.String("account", "", "account name or ID (required)")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	args, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *account == "" {
+		fs.Usage()
+		return usagef("holdings rm: --account is required")
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+	acc, holding, err := findHolding(ctx, client, *account, args[0])
+	if err != nil {
+		return err
+	}
+
+	if !*yes && !a.confirm(fmt.Sprintf("Remove %s %s from %s?", formatQuantity(holding.Quantity), holding.Symbol, acc.DisplayName)) {
+		return fmt.Errorf("aborted")
+	}
+
+	if err := client.Accounts.DeleteHolding(ctx, holding.ID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Removed %s from %s\n", holding.Symbol, acc.DisplayName)
+	return nil
+}
+
+func runHoldingsSearch(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("holdings search", "<query> [--limit N]")
+	limit := fs.Int("limit", 10, "maximum number of results")
+	args, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	securities, err := client.Accounts.SearchSecurities(ctx, args[0], *limit)
+	if err != nil {
+		return err
+	}
+
+	t := &table{headers: []string{"ID", "TICKER", "NAME", "PRICE"}}
+	for _, s := range securities {
+		t.addRow(s.ID, s.Ticker, s.Name, formatAmount(s.CurrentPrice))
+	}
+	return a.render(securities, t)
+}
+
+// Actions planned by holdings sync
+const (
+	syncAdd    = "add"
+	syncUpdate = "update"
+	syncRemove = "remove"
+)
+
+// holdingChange is a single step of a holdings sync
+type holdingChange struct {
+	Action   string  `json:"action"`
+	Symbol   string  `json:"symbol"`
+	Current  float64 `json:"current"`
+	Target   float64 `json:"target"`
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	holdings []*monarch.Holding
+}
+
+func runHoldingsSync(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("holdings sync", "--account ACCOUNT --file positions.csv [--dry-run] [--yes]\n\n"+
+		"  The CSV needs a symbol (or ticker) column and a quantity (or shares) column.\n"+
+		"  Rows without a numeric quantity, such as totals, are skipped. Tickers in the\n"+
+		"  file but not the account are added, changed quantities are updated and\n"+
+		"  holdings missing from the file are removed.")
+	account := fs.String("account", "", "manual investment account name or ID (required)")
+	file := fs.String("file", "", "brokerage positions export (required)")
+	dryRun := fs.Bool("dry-run", false, "show the changes without applying them")
+	yes := fs.Bool("yes", false, "apply without asking for confirmation")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *account == "" || *file == "" {
+		fs.Usage()
+		return usagef("holdings sync: --account and --file are required")
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("read positions: %w", err)
+	}
+	positions, skipped, err := parsePositions(data)
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		fmt.Fprintf(a.stderr, "Skipped %d rows without a symbol and quantity\n", skipped)
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+	acc, err := resolveAccount(ctx, client, *account)
+	if err != nil {
+		return err
+	}
+	if !acc.IsManual {
+		return fmt.Errorf("account %q is not a manual account; only manual holdings can be synced", acc.DisplayName)
+	}
+
+	holdings, err := client.Accounts.GetHoldings(ctx, acc.ID)
+	if err != nil {
+		return err
+	}
+
+	changes := planHoldingsSync(holdings, positions)
+
+	t := &table{headers: []string{"ACTION", "SYMBOL", "CURRENT", "TARGET", "STATUS"}}
+	if len(changes) == 0 {
+		fmt.Fprintf(a.stderr, "%s already matches %s\n", acc.DisplayName, *file)
+		return a.render(changes, t)
+	}
+
+	if *dryRun || !*yes {
+		for _, c := range changes {
+			c.Status = statusDryRun
+		}
+		if err := a.render(changes, syncTable(t, changes)); err != nil {
+			return err
+		}
+		if *dryRun {
+			return nil
+		}
+		if !a.confirm(fmt.Sprintf("Apply %d changes to %s?", len(changes), acc.DisplayName)) {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	failed := 0
+	for _, c := range changes {
+		if err := applyHoldingChange(ctx, client, acc.ID, c); err != nil {
+			c.Status = statusFailed
+			c.Error = err.Error()
+			failed++
+		} else {
+			c.Status = statusUpdated
+		}
+	}
+
+	if *yes {
+		if err := a.render(changes, syncTable(t, changes)); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(a.stderr, "%d changes applied, %d failed\n", len(changes)-failed, failed)
+	for _, c := range changes {
+		if c.Error != "" {
+			fmt.Fprintf(a.stderr, "  %s %s: %s\n", c.Action, c.Symbol, c.Error)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d holding changes failed", failed, len(changes))
+	}
+	return nil
+}
+
+// syncTable adds a row per change to t
+func syncTable(t *table, changes []*holdingChange) *table {
+	for _, c := range changes {
+		t.addRow(c.Action, c.Symbol, formatQuantity(c.Current), formatQuantity(c.Target), c.Status)
+	}
+	return t
+}
+
+// planHoldingsSync compares holdings with target positions keyed by symbol
+func planHoldingsSync(holdings []*monarch.Holding, positions map[string]float64) []*holdingChange {
+	bySymbol := make(map[string][]*monarch.Holding)
+	for _, h := range holdings {
+		symbol := strings.ToUpper(h.Symbol)
+		bySymbol[symbol] = append(bySymbol[symbol], h)
+	}
+
+	var changes []*holdingChange
+	for symbol, target := range positions {
+		current := bySymbol[symbol]
+		if len(current) == 0 {
+			changes = append(changes, &holdingChange{Action: syncAdd, Symbol: symbol, Target: target})
+			continue
+		}
+		var quantity float64
+		for _, h := range current {
+			quantity += h.Quantity
+		}
+		if math.Abs(quantity-target) > quantityTolerance {
+			changes = append(changes, &holdingChange{Action: syncUpdate, Symbol: symbol, Current: quantity, Target: target, holdings: current})
+		}
+	}
+	for symbol, current := range bySymbol {
+		if _, ok := positions[symbol]; ok {
+			continue
+		}
+		var quantity float64
+		for _, h := range current {
+			quantity += h.Quantity
+		}
+		changes = append(changes, &holdingChange{Action: syncRemove, Symbol: symbol, Current: quantity, holdings: current})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Action != changes[j].Action {
+			return changes[i].Action < changes[j].Action
+		}
+		return changes[i].Symbol < changes[j].Symbol
+	})
+	return changes
+}
+
+// applyHoldingChange performs a planned sync step. When a symbol is held more
+// than once, an update sets the first holding and removes the others.
+func applyHoldingChange(ctx context.Context, client *monarch.Client, accountID string, c *holdingChange) error {
+	switch c.Action {
+	case syncAdd:
+		_, err := client.Accounts.CreateHoldingByTicker(ctx, accountID, c.Symbol, c.Target)
+		return err
+	case syncUpdate:
+		if _, err := client.Accounts.UpdateHoldingQuantity(ctx, accountID, c.holdings[0].ID, c.Target); err != nil {
+			return err
+		}
+		for _, h := range c.holdings[1:] {
+			if err := client.Accounts.DeleteHolding(ctx, h.ID); err != nil {
+				return err
+			}
+		}
+	case syncRemove:
+		for _, h := range c.holdings {
+			if err := client.Accounts.DeleteHolding(ctx, h.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parsePositions reads symbol and quantity columns from a positions CSV.
+// Quantities for repeated symbols (e.g. tax lots) are summed. It returns the
+// number of rows skipped for lacking a symbol or numeric quantity, and an
+// error when no row has both.
+func parsePositions(data []byte) (map[string]float64, int, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, 0, usagef("parse positions CSV: %v", err)
+	}
+
+	// Brokerage exports sometimes start with title lines; use the first row
+	// that names both columns as the header
+	symbolCol, quantityCol, headerRow := -1, -1, -1
+	for i, record := range records {
+		symbolCol, quantityCol = -1, -1
+		for j, h := range record {
+			switch strings.ToLower(strings.TrimSpace(h)) {
+			case "symbol", "ticker":
+				symbolCol = j
+			case "quantity", "shares", "qty":
+				quantityCol = j
+			}
+		}
+		if symbolCol >= 0 && quantityCol >= 0 {
+			headerRow = i
+			break
+		}
+	}
+	if headerRow < 0 {
+		return nil, 0, usagef("positions CSV: no header with symbol and quantity columns")
+	}
+
+	positions := make(map[string]float64)
+	skipped := 0
+	for _, record := range records[headerRow+1:] {
+		if symbolCol >= len(record) || quantityCol >= len(record) {
+			skipped++
+			continue
+		}
+		symbol := strings.ToUpper(strings.TrimRight(strings.TrimSpace(record[symbolCol]), "*"))
+		quantity, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(record[quantityCol]), ",", ""), 64)
+		if symbol == "" || err != nil {
+			skipped++
+			continue
+		}
+		positions[symbol] += quantity
+	}
+	// An empty plan would remove every holding in the account
+	if len(positions) == 0 {
+		return nil, skipped, usagef("positions CSV: no rows with a symbol and quantity")
+	}
+	return positions, skipped, nil
+}
+
+// findHolding resolves an account and one of its holdings by ticker or ID
+func findHolding(ctx context.Context, client *monarch.Client, account, ref string) (*monarch.Account, *monarch.Holding, error) {
+	acc, err := resolveAccount(ctx, client, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	holdings, err := client.Accounts.GetHoldings(ctx, acc.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var matches []*monarch.Holding
+	for _, h := range holdings {
+		if h.ID == ref {
+			return acc, h, nil
+		}
+		if strings.EqualFold(h.Symbol, ref) {
+			matches = append(matches, h)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil, fmt.Errorf("%w: no holding %q in %s", monarch.ErrNotFound, ref, acc.DisplayName)
+	case 1:
+		return acc, matches[0], nil
+	default:
+		return nil, nil, usagef("%s is held %d times in %s, use a holding ID", ref, len(matches), acc.DisplayName)
+	}
+}
+
+// holdingDetailTable renders a single holding as field/value rows
+func holdingDetailTable(h *monarch.Holding) *table {
+	t := &table{headers: []string{"FIELD", "VALUE"}}
+	t.addRow("ID", h.ID)
+	t.addRow("Account", h.AccountID)
+	t.addRow("Symbol", h.Symbol)
+	t.addRow("Quantity", formatQuantity(h.Quantity))
+	return t
+}
+
+// parseQuantity parses a non-negative share quantity
+func parseQuantity(s string) (float64, error) {
+	q, err := strconv.ParseFloat(s, 64)
+	if err != nil || q < 0 {
+		return 0, usagef("invalid quantity %q", s)
+	}
+	return q, nil
+}
+
+// formatQuantity formats a share quantity without trailing zeros
+func formatQuantity(q float64) string {
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
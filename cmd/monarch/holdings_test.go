@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const brokerageAccountsResponse = `{
+	"accounts": [
+		{"id": "acc-9", "displayName": "Brokerage", "isManual": true, "type": {"name": "brokerage", "display": "Investments"}},
+		{"id": "acc-1", "displayName": "Checking", "type": {"name": "depository", "display": "Cash"}}
+	],
+	"householdPreferences": null
+}`
+
+// holdingNode is an aggregate holding entry of a holdings response
+func holdingNode(id, ticker string, quantity, price float64) string {
+	return fmt.Sprintf(`{"node": {
+		"id": "agg-%s",
+		"quantity": %v,
+		"totalValue": %v,
+		"holdings": [{"id": %q, "ticker": %q}],
+		"security": {"id": "sec-%s", "name": "%s Inc", "ticker": %q, "currentPrice": %v}
+	}}`, id, quantity, quantity*price, id, ticker, ticker, ticker, ticker, price)
+}
+
+// holdingsResponse builds a holdings response from holding nodes
+func holdingsResponse(nodes ...string) string {
+	return `{"portfolio": {"aggregateHoldings": {"edges": [` + strings.Join(nodes, ",") + `]}}}`
+}
+
+const (
+	createHoldingResponse = `{"createManualHolding": {"holding": {"id": "h-new", "ticker": "VTI"}, "errors": []}}`
+	updateHoldingResponse = `{"updateHolding": {"holding": {"id": "h-1", "quantity": 12}, "errors": []}}`
+	deleteHoldingResponse = `{"deleteHolding": {"deleted": true, "errors": []}}`
+)
+
+func TestHoldingsList(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":     brokerageAccountsResponse,
+		"Web_GetHoldings": holdingsResponse(holdingNode("h-1", "AAPL", 10, 150)),
+	})
+
+	code, stdout, stderr := runCLI(t, srv, "", "holdings", "list", "--account", "brokerage", "-o", "csv")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "ID,SYMBOL,NAME,QUANTITY,PRICE,VALUE\nh-1,AAPL,AAPL Inc,10,150.00,1500.00\n", stdout)
+	input := stub.callsTo("Web_GetHoldings")[0]["input"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"acc-9"}, input["accountIds"])
+}
+
+func TestHoldingsAddSetRemove(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":                  brokerageAccountsResponse,
+		"Web_GetHoldings":              holdingsResponse(holdingNode("h-1", "AAPL", 10, 150)),
+		"SecuritySearch":               `{"securities": [{"id": "sec-vti", "name": "Vanguard Total Stock Market", "ticker": "VTI", "currentPrice": 250}]}`,
+		"Common_CreateManualHolding":   createHoldingResponse,
+		"Common_UpdateHoldingMutation": updateHoldingResponse,
+		"Common_DeleteHolding":         deleteHoldingResponse,
+	})
+
+	code, _, stderr := runCLI(t, srv, "", "holdings", "add", "--account", "acc-9", "vti", "5")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "Added 5 VTI to Brokerage")
+	assert.Equal(t, map[string]interface{}{"accountId": "acc-9", "securityId": "sec-vti", "quantity": 5.0},
+		stub.callsTo("Common_CreateManualHolding")[0]["input"])
+
+	code, _, stderr = runCLI(t, srv, "", "holdings", "set", "--account", "Brokerage", "aapl", "12")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "Set AAPL in Brokerage from 10 to 12")
+	assert.Equal(t, map[string]interface{}{"id": "h-1", "quantity": 12.0},
+		stub.callsTo("Common_UpdateHoldingMutation")[0]["input"])
+
+	code, _, _ = runCLI(t, srv, "n\n", "holdings", "rm", "--account", "Brokerage", "AAPL")
+	assert.Equal(t, exitError, code)
+	assert.Empty(t, stub.callsTo("Common_DeleteHolding"))
+
+	code, _, stderr = runCLI(t, srv, "y\n", "holdings", "rm", "--account", "Brokerage", "h-1")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "h-1", stub.callsTo("Common_DeleteHolding")[0]["id"])
+}
+
+func TestHoldingsSet_UnknownTicker(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":     brokerageAccountsResponse,
+		"Web_GetHoldings": holdingsResponse(),
+	})
+
+	code, _, _ := runCLI(t, srv, "", "holdings", "set", "--account", "Brokerage", "MSFT", "1")
+
+	assert.Equal(t, exitNotFound, code)
+}
+
+func TestHoldingsSearch(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"SecuritySearch": `{"securities": [{"id": "sec-vti", "name": "Vanguard Total Stock Market", "ticker": "VTI", "currentPrice": 250}]}`,
+	})
+
+	code, stdout, _ := runCLI(t, srv, "", "holdings", "search", "vanguard", "--limit", "3")
+
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "VTI")
+	assert.Contains(t, stdout, "250.00")
+	vars := stub.callsTo("SecuritySearch")[0]
+	assert.Equal(t, "vanguard", vars["search"])
+	assert.Equal(t, 3.0, vars["limit"])
+}
+
+func TestHoldingsSync(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts": brokerageAccountsResponse,
+		"Web_GetHoldings": holdingsResponse(
+			holdingNode("h-1", "AAPL", 10, 150),
+			holdingNode("h-2", "MSFT", 4, 400),
+			holdingNode("h-3", "GME", 1, 20),
+		),
+		"SecuritySearch":               `{"securities": [{"id": "sec-vti", "name": "Vanguard Total Stock Market", "ticker": "VTI", "currentPrice": 250}]}`,
+		"Common_CreateManualHolding":   createHoldingResponse,
+		"Common_UpdateHoldingMutation": updateHoldingResponse,
+		"Common_DeleteHolding":         deleteHoldingResponse,
+	})
+	path := writePatch(t, "positions.csv", "Account Summary\n"+
+		"Symbol,Description,Quantity,Price\n"+
+		"AAPL,Apple,10,150\n"+
+		"msft*,Microsoft,\"3,000\",400\n"+
+		"VTI,Vanguard,2,250\n"+
+		"VTI,Vanguard,3,250\n"+
+		"Total,,--,\n")
+
+	code, stdout, stderr := runCLI(t, srv, "", "holdings", "sync", "--account", "Brokerage", "--file", path, "--dry-run", "-o", "csv")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "ACTION,SYMBOL,CURRENT,TARGET,STATUS\n"+
+		"add,VTI,0,5,dry-run\n"+
+		"remove,GME,1,0,dry-run\n"+
+		"update,MSFT,4,3000,dry-run\n", stdout)
+	assert.Contains(t, stderr, "Skipped 1 rows")
+	assert.Empty(t, stub.callsTo("Common_CreateManualHolding"))
+
+	code, _, _ = runCLI(t, srv, "no\n", "holdings", "sync", "--account", "Brokerage", "--file", path)
+	assert.Equal(t, exitError, code)
+	assert.Empty(t, stub.callsTo("Common_DeleteHolding"))
+
+	code, _, stderr = runCLI(t, srv, "", "holdings", "sync", "--account", "Brokerage", "--file", path, "--yes")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "3 changes applied, 0 failed")
+	assert.Equal(t, 5.0, stub.callsTo("Common_CreateManualHolding")[0]["input"].(map[string]interface{})["quantity"])
+	assert.Equal(t, map[string]interface{}{"id": "h-2", "quantity": 3000.0}, stub.callsTo("Common_UpdateHoldingMutation")[0]["input"])
+	assert.Equal(t, "h-3", stub.callsTo("Common_DeleteHolding")[0]["id"])
+}
+
+func TestHoldingsSync_RequiresManualAccount(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{"GetAccounts": brokerageAccountsResponse})
+	path := writePatch(t, "positions.csv", "Symbol,Quantity\nAAPL,1\n")
+
+	code, _, stderr := runCLI(t, srv, "", "holdings", "sync", "--account", "Checking", "--file", path)
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "not a manual account")
+	assert.Empty(t, stub.callsTo("Web_GetHoldings"))
+}
+
+func TestParsePositions_MissingColumns(t *testing.T) {
+	_, _, err := parsePositions([]byte("Name,Value\nApple,100\n"))
+
+	assert.Error(t, err)
+}
+
+func TestHoldingsSync_NoPositions(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{"GetAccounts": brokerageAccountsResponse})
+	path := writePatch(t, "positions.csv", "Symbol,Quantity\nAAPL,n/a\nTotal,\n")
+
+	code, _, stderr := runCLI(t, srv, "", "holdings", "sync", "--account", "Brokerage", "--file", path, "--yes")
+
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "no rows with a symbol and quantity")
+	assert.Empty(t, stub.callsTo("Web_GetHoldings"))
+	assert.Empty(t, stub.callsTo("Common_DeleteHolding"))
+}
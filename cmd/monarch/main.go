@@ -1,12 +1,292 @@
+// Command monarch is a command-line client for the Monarch Money API.
+//
+// Usage:
+//
+//	monarch [global flags] <command> [subcommand] [flags] [args]
+//
+// Authentication is read from the MONARCH_TOKEN environment variable, from a
+// session file (--session-file or MONARCH_SESSION_FILE), or from the session
+// saved by "monarch login" for the selected profile (--profile or
+// MONARCH_PROFILE).
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"golang.org/x/term"
+)
+
+// Exit codes returned by the CLI
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitAuth     = 3
+	exitNotFound = 4
 )
 
 func main() {
-	fmt.Println("Monarch Go Client CLI - Coming Soon")
-	fmt.Println("This CLI tool will provide command-line access to the Monarch Money API")
-	os.Exit(0)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	a := newApp(os.Stdin, os.Stdout, os.Stderr, os.Getenv)
+	a.color = os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && term.IsTerminal(int(os.Stdout.Fd()))
+	code := a.run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// globalOptions are flags accepted by every command
+type globalOptions struct {
+	output      string
+	sessionFile string
+	profile     string
+}
+
+// app holds the state shared by all commands
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	// stdinReader buffers stdin for prompts; see readLine
+	stdinReader *bufio.Reader
+
+	global globalOptions
+	root   *command
+
+	// color enables highlighting in table output
+	color bool
+
+	// newClient creates the API client; tests may replace it
+	newClient func(opts *monarch.ClientOptions) (*monarch.Client, error)
+	client    *monarch.Client
+}
+
+// newApp creates a CLI application bound to the given streams and environment
+func newApp(stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) *app {
+	a := &app{
+		stdin:     stdin,
+		stdout:    stdout,
+		stderr:    stderr,
+		getenv:    getenv,
+		global:    globalOptions{output: "table"},
+		newClient: monarch.NewClient,
+	}
+	a.root = &command{
+		name:    "monarch",
+		summary: "Command-line client for Monarch Money",
+		subcommands: []*command{
+			loginCommand(),
+			logoutCommand(),
+			whoamiCommand(),
+			accountsCommand(),
+			txCommand(),
+			budgetCommand(),
+			holdingsCommand(),
+			refreshCommand(),
+		},
+	}
+	return a
+}
+
+// command is a node in the CLI command tree. Leaf commands have a run
+// function; group commands have subcommands.
+type command struct {
+	name        string
+	summary     string
+	subcommands []*command
+	run         func(ctx context.Context, a *app, args []string) error
+}
+
+// usageError indicates the command line was invalid
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// usagef creates a usage error
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// run parses args, executes the selected command and returns the exit code
+func (a *app) run(ctx context.Context, args []string) int {
+	defer func() {
+		if a.client != nil {
+			a.client.Close()
+		}
+	}()
+
+	fs := a.flagSet("monarch")
+	fs.Usage = func() { a.printUsage(a.root, nil) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	err := a.dispatch(ctx, a.root, nil, fs.Args())
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	fmt.Fprintf(a.stderr, "Error: %v\n", err)
+	return exitCode(err)
+}
+
+// dispatch walks the command tree and runs the selected leaf command
+func (a *app) dispatch(ctx context.Context, cmd *command, path []string, args []string) error {
+	path = append(path, cmd.name)
+	if cmd.run != nil {
+		return cmd.run(ctx, a, args)
+	}
+
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		a.printUsage(cmd, path[:len(path)-1])
+		if len(args) == 0 {
+			return usagef("%s: missing subcommand", strings.Join(path, " "))
+		}
+		return flag.ErrHelp
+	}
+
+	for _, sub := range cmd.subcommands {
+		if sub.name == args[0] {
+			return a.dispatch(ctx, sub, path, args[1:])
+		}
+	}
+
+	return usagef("%s: unknown command %q", strings.Join(path, " "), args[0])
+}
+
+// printUsage prints help for a group command
+func (a *app) printUsage(cmd *command, parents []string) {
+	name := strings.TrimSpace(strings.Join(append(parents, cmd.name), " "))
+	fmt.Fprintf(a.stderr, "%s\n\nUsage:\n  %s <command> [flags]\n\nCommands:\n", cmd.summary, name)
+	for _, sub := range cmd.subcommands {
+		fmt.Fprintf(a.stderr, "  %-12s %s\n", sub.name, sub.summary)
+	}
+	fmt.Fprintf(a.stderr, "\nGlobal flags:\n")
+	fs := a.flagSet(name)
+	fs.SetOutput(a.stderr)
+	fs.PrintDefaults()
+}
+
+// flagSet creates a flag set with the global flags registered. Global flags
+// may appear before the command or after the subcommand.
+func (a *app) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.StringVar(&a.global.output, "o", a.global.output, "output format: table, json or csv")
+	fs.StringVar(&a.global.output, "output", a.global.output, "output format: table, json or csv")
+	fs.StringVar(&a.global.sessionFile, "session-file", a.global.sessionFile, "path to a saved session file (default $MONARCH_SESSION_FILE)")
+	fs.StringVar(&a.global.profile, "profile", a.global.profile, "login profile to use (default $MONARCH_PROFILE or \"default\")")
+	return fs
+}
+
+// leafFlags creates the flag set for a leaf command with a usage line
+func (a *app) leafFlags(cmd, usage string) *flag.FlagSet {
+	fs := a.flagSet(cmd)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage:\n  monarch %s %s\n\nFlags:\n", cmd, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses leaf command flags, which may be interspersed with
+// positional arguments, and checks the positional argument count. A negative
+// maxArgs means no upper limit.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	n := len(positional)
+	if n < minArgs || (maxArgs >= 0 && n > maxArgs) {
+		fs.Usage()
+		return nil, usagef("%s: wrong number of arguments", fs.Name())
+	}
+	return positional, nil
+}
+
+// flagWasSet reports whether the named flag was given on the command line
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// getClient returns an authenticated client, creating it on first use
+func (a *app) getClient() (*monarch.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+
+	opts := &monarch.ClientOptions{
+		BaseURL: a.getenv("MONARCH_BASE_URL"),
+		Token:   a.getenv("MONARCH_TOKEN"),
+	}
+
+	client, err := a.newClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Token == "" {
+		if err := a.loadSession(client); err != nil {
+			return nil, err
+		}
+	}
+
+	a.client = client
+	return client, nil
+}
+
+// exitCode maps an error to a process exit code
+func exitCode(err error) int {
+	var uerr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &uerr):
+		return exitUsage
+	case monarch.IsAuthError(err):
+		return exitAuth
+	case errors.Is(err, monarch.ErrNotFound):
+		return exitNotFound
+	default:
+		return exitError
+	}
 }
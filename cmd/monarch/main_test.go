@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphQLStub serves canned GraphQL responses keyed by operationName and
+// records the variables each operation was called with. Operations in funcs
+// compute their response from the variables.
+type graphQLStub struct {
+	t         *testing.T
+	responses map[string]string
+	funcs     map[string]func(vars map[string]interface{}) string
+
+	mu    sync.Mutex
+	calls map[string][]map[string]interface{}
+}
+
+func newGraphQLStub(t *testing.T, responses map[string]string) (*graphQLStub, *httptest.Server) {
+	stub := &graphQLStub{t: t, responses: responses, calls: make(map[string][]map[string]interface{})}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func (s *graphQLStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Non-GraphQL endpoints such as /auth/login/ are keyed by path and
+	// record the whole request body
+	op := req.OperationName
+	if r.URL.Path != "/graphql" {
+		op = r.URL.Path
+		req.Variables = nil
+		_ = json.Unmarshal(body, &req.Variables)
+	}
+
+	s.mu.Lock()
+	s.calls[op] = append(s.calls[op], req.Variables)
+	s.mu.Unlock()
+
+	data, ok := s.responses[op]
+	if fn, found := s.funcs[op]; found {
+		data, ok = fn(req.Variables), true
+	}
+	if !ok {
+		s.t.Errorf("unexpected operation %q", op)
+		http.Error(w, "unexpected operation", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path != "/graphql" {
+		_, _ = w.Write([]byte(data))
+		return
+	}
+	_, _ = w.Write([]byte(`{"data":` + data + `}`))
+}
+
+// callsTo returns the recorded variables for an operation
+func (s *graphQLStub) callsTo(op string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[op]
+}
+
+// runCLI runs the CLI against srv and returns the exit code, stdout and stderr
+func runCLI(t *testing.T, srv *httptest.Server, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	return runCLIEnv(t, map[string]string{"MONARCH_TOKEN": "test-token"}, srv, stdin, args...)
+}
+
+// runCLIEnv is like runCLI but with the given environment. MONARCH_BASE_URL
+// and, unless set, MONARCH_CONFIG_DIR are filled in.
+func runCLIEnv(t *testing.T, env map[string]string, srv *httptest.Server, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	vars := map[string]string{"MONARCH_BASE_URL": srv.URL, "MONARCH_CONFIG_DIR": t.TempDir()}
+	for k, v := range env {
+		vars[k] = v
+	}
+	var stdout, stderr bytes.Buffer
+	a := newApp(strings.NewReader(stdin), &stdout, &stderr, func(k string) string { return vars[k] })
+	code := a.run(context.Background(), args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, stderr := runCLI(t, srv, "", "bogus")
+
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown command "bogus"`)
+}
+
+func TestRun_MissingSubcommandPrintsUsage(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, stderr := runCLI(t, srv, "", "accounts")
+
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "list")
+	assert.Contains(t, stderr, "types")
+}
+
+func TestRun_Help(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, stderr := runCLI(t, srv, "", "help")
+
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "accounts")
+}
+
+func TestRun_NoCredentials(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, stderr := runCLIEnv(t, nil, srv, "", "accounts", "list")
+
+	assert.Equal(t, exitAuth, code)
+	assert.Contains(t, stderr, "MONARCH_TOKEN")
+}
+
+func TestRun_UnauthorizedResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	code, _, _ := runCLI(t, srv, "", "accounts", "list")
+
+	assert.Equal(t, exitAuth, code)
+}
+
+func TestRender_Formats(t *testing.T) {
+	tbl := &table{headers: []string{"A", "B"}}
+	tbl.addRow("1", "x,y")
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{formatTable, "A  B\n1  x,y\n"},
+		{formatCSV, "A,B\n1,\"x,y\"\n"},
+		{formatJSON, "{\n  \"a\": 1\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			a := newApp(nil, &out, &out, func(string) string { return "" })
+			a.global.output = tt.format
+
+			require.NoError(t, a.render(map[string]int{"a": 1}, tbl))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestRender_UnknownFormat(t *testing.T) {
+	var out bytes.Buffer
+	a := newApp(nil, &out, &out, func(string) string { return "" })
+	a.global.output = "xml"
+
+	err := a.render(nil, &table{})
+
+	assert.Equal(t, exitUsage, exitCode(err))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Supported output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+
+	// formatNDJSON writes one JSON object per line; only commands that
+	// stream results support it
+	formatNDJSON = "ndjson"
+)
+
+// ANSI colors used to highlight table rows. Both codes have the same length
+// so that tabwriter column widths stay aligned.
+const (
+	ansiDefault = "\x1b[39m"
+	ansiRed     = "\x1b[31m"
+	ansiReset   = "\x1b[0m"
+)
+
+// table is a tabular rendering of command output
+type table struct {
+	headers     []string
+	rows        [][]string
+	highlighted map[int]bool
+}
+
+// addRow appends a row to the table
+func (t *table) addRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// addHighlightedRow appends a row that is shown in red on color terminals
+func (t *table) addHighlightedRow(cells ...string) {
+	if t.highlighted == nil {
+		t.highlighted = make(map[int]bool)
+	}
+	t.highlighted[len(t.rows)] = true
+	t.addRow(cells...)
+}
+
+// render writes v as JSON or t as a table/CSV depending on --output
+func (a *app) render(v interface{}, t *table) error {
+	switch a.global.output {
+	case formatJSON:
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatCSV:
+		w := csv.NewWriter(a.stdout)
+		if err := w.Write(t.headers); err != nil {
+			return err
+		}
+		if err := w.WriteAll(t.rows); err != nil {
+			return err
+		}
+		return w.Error()
+	case formatTable, "":
+		return a.writeTable(a.stdout, t)
+	default:
+		return usagef("unknown output format %q (expected table, json or csv)", a.global.output)
+	}
+}
+
+// writeTable writes t as an aligned table, whatever the output format
+func (a *app) writeTable(w io.Writer, t *table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if !a.color {
+		fmt.Fprintln(tw, strings.Join(t.headers, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+	fmt.Fprintln(tw, ansiDefault+strings.Join(t.headers, "\t")+ansiReset)
+	for i, row := range t.rows {
+		color := ansiDefault
+		if t.highlighted[i] {
+			color = ansiRed
+		}
+		fmt.Fprintln(tw, color+strings.Join(row, "\t")+ansiReset)
+	}
+	return tw.Flush()
+}
+
+// confirm asks a yes/no question on stdin; anything but "y" or "yes" is no
+func (a *app) confirm(prompt string) bool {
+	fmt.Fprintf(a.stderr, "%s [y/N]: ", prompt)
+	line, _ := a.readLine()
+	answer := strings.ToLower(line)
+	return answer == "y" || answer == "yes"
+}
+
+// readLine reads a trimmed line from stdin. The reader is shared so that
+// successive prompts do not lose buffered input.
+func (a *app) readLine() (string, error) {
+	if a.stdinReader == nil {
+		a.stdinReader = bufio.NewReader(a.stdin)
+	}
+	line, err := a.stdinReader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// formatAmount formats a currency amount with two decimals
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// formatBool formats a bool as yes/no
+func formatBool(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+// formatTime formats a timestamp, returning an empty string for zero values
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
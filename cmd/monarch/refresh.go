@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// refreshProgressInterval is how often progress is checked while waiting
+const refreshProgressInterval = 500 * time.Millisecond
+
+// refreshCommand builds the "refresh" command
+func refreshCommand() *command {
+	return &command{
+		name:    "refresh",
+		summary: "Refresh linked accounts from their institutions",
+		run:     runRefresh,
+	}
+}
+
+// refreshAccount is the outcome of refreshing one account
+type refreshAccount struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Institution string `json:"institution,omitempty"`
+	Refreshed   bool   `json:"refreshed"`
+}
+
+// refreshSummary is the final report of a refresh job
+type refreshSummary struct {
+	ID             string            `json:"id"`
+	Status         string            `json:"status"`
+	StartTime      time.Time         `json:"startTime"`
+	EndTime        *time.Time        `json:"endTime,omitempty"`
+	Duration       float64           `json:"durationSeconds"`
+	AccountCount   int               `json:"accountCount"`
+	CompletedCount int               `json:"completedCount"`
+	CheckCount     int               `json:"checkCount"`
+	LastError      string            `json:"lastError,omitempty"`
+	Accounts       []*refreshAccount `json:"accounts"`
+}
+
+func runRefresh(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("refresh", "(--all | --account ACCOUNT...) [--wait] [--timeout 10m]")
+	all := fs.Bool("all", false, "refresh every linked account")
+	var accounts stringList
+	fs.Var(&accounts, "account", "account name or ID to refresh (repeatable)")
+	wait := fs.Bool("wait", false, "wait for the refresh to finish and report the result")
+	timeout := fs.Duration("timeout", 10*time.Minute, "how long --wait waits before giving up")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *all == (len(accounts) > 0) {
+		fs.Usage()
+		return usagef("refresh: use either --all or --account")
+	}
+	if *timeout <= 0 {
+		return usagef("refresh: --timeout must be positive")
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	targets, err := refreshTargets(ctx, client, *all, accounts)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no linked accounts to refresh")
+	}
+	ids := make([]string, len(targets))
+	for i, acc := range targets {
+		ids[i] = acc.ID
+	}
+
+	job, err := client.Accounts.Refresh(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	if !*wait {
+		fmt.Fprintf(a.stderr, "Refresh requested for %d accounts\n", len(targets))
+		return nil
+	}
+
+	fmt.Fprintf(a.stderr, "Refreshing %d accounts...\n", len(targets))
+	done := make(chan error, 1)
+	go func() {
+		done <- job.Wait(ctx, *timeout)
+	}()
+
+	// Report each account as it finishes; plain lines keep cron logs readable
+	reported := make(map[string]bool)
+	report := func() {
+		progress := job.GetProgress()
+		for _, acc := range targets {
+			if progress[acc.ID] && !reported[acc.ID] {
+				reported[acc.ID] = true
+				fmt.Fprintf(a.stderr, "  [%d/%d] %s refreshed\n", len(reported), len(targets), refreshLabel(acc))
+			}
+		}
+	}
+	ticker := time.NewTicker(refreshProgressInterval)
+	defer ticker.Stop()
+	var waitErr error
+loop:
+	for {
+		select {
+		case waitErr = <-done:
+			break loop
+		case <-ticker.C:
+			report()
+		}
+	}
+	report()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	metrics := job.GetMetrics()
+	progress := job.GetProgress()
+	summary := &refreshSummary{
+		ID:             metrics.ID,
+		Status:         metrics.Status,
+		StartTime:      metrics.StartTime,
+		EndTime:        metrics.EndTime,
+		Duration:       metrics.Duration.Seconds(),
+		AccountCount:   metrics.AccountCount,
+		CompletedCount: metrics.CompletedCount,
+		CheckCount:     metrics.CheckCount,
+	}
+	if metrics.LastError != nil {
+		summary.LastError = metrics.LastError.Error()
+	}
+	for _, acc := range targets {
+		summary.Accounts = append(summary.Accounts, &refreshAccount{
+			ID:          acc.ID,
+			Name:        acc.DisplayName,
+			Institution: institutionName(acc),
+			Refreshed:   progress[acc.ID],
+		})
+		if !progress[acc.ID] {
+			fmt.Fprintf(a.stderr, "  %s did not finish\n", refreshLabel(acc))
+		}
+	}
+
+	t := &table{headers: []string{"FIELD", "VALUE"}}
+	t.addRow("Job", summary.ID)
+	t.addRow("Status", summary.Status)
+	t.addRow("Duration", metrics.Duration.Round(time.Millisecond).String())
+	t.addRow("Accounts", fmt.Sprintf("%d of %d refreshed", summary.CompletedCount, summary.AccountCount))
+	t.addRow("Status checks", fmt.Sprint(summary.CheckCount))
+	if summary.LastError != "" {
+		t.addRow("Last error", summary.LastError)
+	}
+	if err := a.render(summary, t); err != nil {
+		return err
+	}
+
+	switch job.Status() {
+	case monarch.RefreshStatusTimeout:
+		return fmt.Errorf("refresh timed out after %s", *timeout)
+	case monarch.RefreshStatusFailed:
+		return fmt.Errorf("refresh failed: %w", waitErr)
+	}
+	return waitErr
+}
+
+// refreshTargets picks the accounts to refresh. Manual accounts never sync,
+// so --all skips them and naming one is an error.
+func refreshTargets(ctx context.Context, client *monarch.Client, all bool, names []string) ([]*monarch.Account, error) {
+	accounts, err := client.Accounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if all {
+		var targets []*monarch.Account
+		for _, acc := range accounts {
+			if !acc.IsManual {
+				targets = append(targets, acc)
+			}
+		}
+		return targets, nil
+	}
+
+	items := make([]namedItem, len(accounts))
+	byID := make(map[string]*monarch.Account, len(accounts))
+	for i, acc := range accounts {
+		items[i] = namedItem{id: acc.ID, name: acc.DisplayName}
+		byID[acc.ID] = acc
+	}
+	ids, err := resolveIDs("account", names, items)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*monarch.Account
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		acc := byID[id]
+		if acc.IsManual {
+			return nil, usagef("account %q is a manual account and cannot be refreshed", acc.DisplayName)
+		}
+		if !seen[id] {
+			seen[id] = true
+			targets = append(targets, acc)
+		}
+	}
+	return targets, nil
+}
+
+// refreshLabel names an account together with its institution
+func refreshLabel(acc *monarch.Account) string {
+	if inst := institutionName(acc); inst != "" && !strings.EqualFold(inst, acc.DisplayName) {
+		return fmt.Sprintf("%s (%s)", acc.DisplayName, inst)
+	}
+	return acc.DisplayName
+}
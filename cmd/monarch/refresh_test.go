@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const refreshAccountsResponse = `{
+	"accounts": [
+		{"id": "acc-1", "displayName": "Checking", "institution": {"name": "Chase"}},
+		{"id": "acc-2", "displayName": "Visa", "credential": {"institution": {"name": "Citi"}}},
+		{"id": "acc-9", "displayName": "Brokerage", "isManual": true}
+	],
+	"householdPreferences": null
+}`
+
+const forceRefreshResponse = `{"forceRefreshAccounts": {"success": true, "errors": []}}`
+
+// refreshStatusFunc reports the requested accounts as synced just now, or
+// still syncing for IDs in pending
+func refreshStatusFunc(pending ...string) func(map[string]interface{}) string {
+	return func(vars map[string]interface{}) string {
+		synced := time.Now().Add(time.Minute).Format(time.RFC3339)
+		var accounts []string
+		for _, id := range vars["accountIds"].([]interface{}) {
+			syncing := false
+			for _, p := range pending {
+				syncing = syncing || p == id
+			}
+			accounts = append(accounts, fmt.Sprintf(`{"id": %q, "syncing": %v, "lastSyncedAt": %q}`, id, syncing, synced))
+		}
+		return `{"accounts": [` + strings.Join(accounts, ",") + `]}`
+	}
+}
+
+func TestRefresh_AllWait(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":                         refreshAccountsResponse,
+		"Common_ForceRefreshAccountsMutation": forceRefreshResponse,
+	})
+	stub.funcs = map[string]func(map[string]interface{}) string{"CheckRefreshStatus": refreshStatusFunc()}
+
+	code, stdout, stderr := runCLI(t, srv, "", "refresh", "--all", "--wait", "-o", "json")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "Checking (Chase) refreshed")
+	assert.Contains(t, stderr, "Visa (Citi) refreshed")
+	assert.Equal(t, []interface{}{"acc-1", "acc-2"},
+		stub.callsTo("Common_ForceRefreshAccountsMutation")[0]["input"].(map[string]interface{})["accountIds"])
+
+	var summary refreshSummary
+	require.NoError(t, json.Unmarshal([]byte(stdout), &summary))
+	assert.Equal(t, "completed", summary.Status)
+	assert.Equal(t, 2, summary.CompletedCount)
+	require.Len(t, summary.Accounts, 2)
+	assert.Equal(t, "Citi", summary.Accounts[1].Institution)
+	assert.True(t, summary.Accounts[1].Refreshed)
+}
+
+func TestRefresh_TimeoutExitsNonZero(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":                         refreshAccountsResponse,
+		"Common_ForceRefreshAccountsMutation": forceRefreshResponse,
+	})
+	stub.funcs = map[string]func(map[string]interface{}) string{"CheckRefreshStatus": refreshStatusFunc("acc-2")}
+
+	code, stdout, stderr := runCLI(t, srv, "", "refresh", "--account", "Visa", "--wait", "--timeout", "10ms")
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "Visa (Citi) did not finish")
+	assert.Contains(t, stderr, "refresh timed out")
+	assert.Regexp(t, `Status\s+timeout`, stdout)
+	assert.Regexp(t, `Accounts\s+0 of 1 refreshed`, stdout)
+}
+
+func TestRefresh_NoWait(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":                         refreshAccountsResponse,
+		"Common_ForceRefreshAccountsMutation": forceRefreshResponse,
+	})
+
+	code, stdout, stderr := runCLI(t, srv, "", "refresh", "--account", "checking")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "Refresh requested for 1 accounts")
+	assert.Empty(t, stub.callsTo("CheckRefreshStatus"))
+}
+
+func TestRefresh_InvalidUsage(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetAccounts": refreshAccountsResponse})
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no selection", []string{"refresh"}},
+		{"both selections", []string{"refresh", "--all", "--account", "Visa"}},
+		{"manual account", []string{"refresh", "--account", "Brokerage"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := runCLI(t, srv, "", tt.args...)
+			assert.Equal(t, exitUsage, code)
+		})
+	}
+}
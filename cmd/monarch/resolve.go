@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// stringList is a repeatable flag whose values may also be comma separated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
+
+// namedItem is an ID/name pair used to resolve command-line references
+type namedItem struct {
+	id   string
+	name string
+}
+
+// resolveIDs maps each value to an item ID. Values match an ID exactly or a
+// name case-insensitively; a name shared by several items is an error.
+func resolveIDs(kind string, values []string, items []namedItem) ([]string, error) {
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		id, err := resolveID(kind, v, items)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// resolveID maps a single value to an item ID, see resolveIDs
+func resolveID(kind, value string, items []namedItem) (string, error) {
+	var matches []namedItem
+	for _, item := range items {
+		if item.id == value {
+			return item.id, nil
+		}
+		if strings.EqualFold(item.name, value) {
+			matches = append(matches, item)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: no %s named %q", monarch.ErrNotFound, kind, value)
+	case 1:
+		return matches[0].id, nil
+	default:
+		ids := make([]string, len(matches))
+		for i, m := range matches {
+			ids[i] = m.id
+		}
+		return "", usagef("%s name %q is ambiguous, use one of the IDs: %s", kind, value, strings.Join(ids, ", "))
+	}
+}
+
+// accountItems lists accounts as named items
+func accountItems(ctx context.Context, client *monarch.Client) ([]namedItem, error) {
+	accounts, err := client.Accounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]namedItem, len(accounts))
+	for i, acc := range accounts {
+		items[i] = namedItem{id: acc.ID, name: acc.DisplayName}
+	}
+	return items, nil
+}
+
+// categoryItems lists transaction categories as named items
+func categoryItems(ctx context.Context, client *monarch.Client) ([]namedItem, error) {
+	categories, err := client.Transactions.Categories().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]namedItem, len(categories))
+	for i, cat := range categories {
+		items[i] = namedItem{id: cat.ID, name: cat.Name}
+	}
+	return items, nil
+}
+
+// tagItems lists transaction tags as named items
+func tagItems(ctx context.Context, client *monarch.Client) ([]namedItem, error) {
+	tags, err := client.Tags.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]namedItem, len(tags))
+	for i, tag := range tags {
+		items[i] = namedItem{id: tag.ID, name: tag.Name}
+	}
+	return items, nil
+}
+
+// resolveNamed resolves values against the items returned by list, which is
+// only called when there is something to resolve
+func resolveNamed(ctx context.Context, client *monarch.Client, kind string, values []string,
+	list func(context.Context, *monarch.Client) ([]namedItem, error)) ([]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	items, err := list(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return resolveIDs(kind, values, items)
+}
+
+// resolveAccount finds a single account by name or ID
+func resolveAccount(ctx context.Context, client *monarch.Client, value string) (*monarch.Account, error) {
+	accounts, err := client.Accounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]namedItem, len(accounts))
+	for i, acc := range accounts {
+		items[i] = namedItem{id: acc.ID, name: acc.DisplayName}
+	}
+	id, err := resolveID("account", value, items)
+	if err != nil {
+		return nil, err
+	}
+	for _, acc := range accounts {
+		if acc.ID == id {
+			return acc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: account %s", monarch.ErrNotFound, id)
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// dateLayout is the date format accepted on the command line
+const dateLayout = "2006-01-02"
+
+// txCommand builds the "tx" command group
+func txCommand() *command {
+	return &command{
+		name:    "tx",
+		summary: "Query, export and bulk edit transactions",
+		subcommands: []*command{
+			{name: "list", summary: "List or stream transactions", run: runTxList},
+			{name: "apply", summary: "Bulk edit transactions from a CSV or JSON patch file", run: runTxApply},
+			{name: "dupes", summary: "Find and merge duplicate transactions", run: runTxDupes},
+		},
+	}
+}
+
+func runTxList(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("tx list", "[--from DATE --to DATE] [--account A] [--category C] [--tag T] [--search TEXT] [--min N] [--max N] [--limit N | --all]\n\n"+
+		"  Accounts, categories and tags may be given by name or ID, repeated or comma separated.\n"+
+		"  --all streams every matching transaction; use -o ndjson or -o csv for large exports.")
+	from := fs.String("from", "", "start date, YYYY-MM-DD")
+	to := fs.String("to", "", "end date, YYYY-MM-DD (default today)")
+	var accounts, categories, tags stringList
+	fs.Var(&accounts, "account", "account name or ID (repeatable)")
+	fs.Var(&categories, "category", "category name or ID (repeatable)")
+	fs.Var(&tags, "tag", "tag name or ID (repeatable)")
+	search := fs.String("search", "", "search merchants, names and notes")
+	minAmount := fs.Float64("min", 0, "minimum absolute amount")
+	maxAmount := fs.Float64("max", 0, "maximum absolute amount")
+	limit := fs.Int("limit", 100, "maximum number of transactions")
+	all := fs.Bool("all", false, "stream all matching transactions, ignoring --limit")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	if *limit <= 0 && !*all {
+		return usagef("tx list: --limit must be positive")
+	}
+
+	out, err := newTxWriter(a.stdout, a.global.output)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	q := client.Transactions.Query()
+	if !start.IsZero() {
+		q = q.Between(start, end)
+	}
+	accountIDs, err := resolveNamed(ctx, client, "account", accounts, accountItems)
+	if err != nil {
+		return err
+	}
+	if len(accountIDs) > 0 {
+		q = q.WithAccounts(accountIDs...)
+	}
+	categoryIDs, err := resolveNamed(ctx, client, "category", categories, categoryItems)
+	if err != nil {
+		return err
+	}
+	if len(categoryIDs) > 0 {
+		q = q.WithCategories(categoryIDs...)
+	}
+	tagIDs, err := resolveNamed(ctx, client, "tag", tags, tagItems)
+	if err != nil {
+		return err
+	}
+	if len(tagIDs) > 0 {
+		q = q.WithTags(tagIDs...)
+	}
+	if *search != "" {
+		q = q.Search(*search)
+	}
+	if *minAmount > 0 {
+		q = q.WithMinAmount(*minAmount)
+	}
+	if *maxAmount > 0 {
+		q = q.WithMaxAmount(*maxAmount)
+	}
+
+	if err := out.begin(); err != nil {
+		return err
+	}
+
+	if *all {
+		// Cancelling stops the stream if writing fails part way
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		txns, errs := q.Stream(ctx)
+		for txn := range txns {
+			if err := out.write(txn); err != nil {
+				return err
+			}
+		}
+		if err := <-errs; err != nil {
+			// Keep what was already written well-formed
+			_ = out.end()
+			return err
+		}
+	} else {
+		result, err := q.Limit(*limit).Execute(ctx)
+		if err != nil {
+			return err
+		}
+		for _, txn := range result.Transactions {
+			if err := out.write(txn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return out.end()
+}
+
+// parseDateRange parses --from/--to. An empty range returns zero times; a
+// missing end defaults to today.
+func parseDateRange(from, to string) (time.Time, time.Time, error) {
+	if from == "" {
+		if to != "" {
+			return time.Time{}, time.Time{}, usagef("--to requires --from")
+		}
+		return time.Time{}, time.Time{}, nil
+	}
+
+	start, err := time.Parse(dateLayout, from)
+	if err != nil {
+		return time.Time{}, time.Time{}, usagef("invalid --from date %q, expected YYYY-MM-DD", from)
+	}
+	end := time.Now()
+	if to != "" {
+		if end, err = time.Parse(dateLayout, to); err != nil {
+			return time.Time{}, time.Time{}, usagef("invalid --to date %q, expected YYYY-MM-DD", to)
+		}
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, usagef("--to is before --from")
+	}
+	return start, end, nil
+}
+
+// txHeaders are the columns written for transactions in table and CSV output
+var txHeaders = []string{"ID", "DATE", "AMOUNT", "MERCHANT", "CATEGORY", "ACCOUNT", "TAGS", "NOTES", "PENDING"}
+
+// txRow renders a transaction as a row matching txHeaders
+func txRow(txn *monarch.Transaction) []string {
+	var merchant, category, account string
+	if txn.Merchant != nil {
+		merchant = txn.Merchant.Name
+	}
+	if txn.Category != nil {
+		category = txn.Category.Name
+	}
+	if txn.Account != nil {
+		account = txn.Account.DisplayName
+	}
+	tagNames := make([]string, 0, len(txn.Tags))
+	for _, tag := range txn.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	return []string{
+		txn.ID,
+		txn.Date.String(),
+		formatAmount(txn.Amount),
+		merchant,
+		category,
+		account,
+		strings.Join(tagNames, ";"),
+		txn.Notes,
+		formatBool(txn.Pending),
+	}
+}
+
+// txWriter writes transactions one at a time so that streamed results are
+// not held in memory
+type txWriter struct {
+	format string
+	w      io.Writer
+	csv    *csv.Writer
+	tw     *tabwriter.Writer
+	n      int
+}
+
+// newTxWriter creates a writer for the given output format
+func newTxWriter(w io.Writer, format string) (*txWriter, error) {
+	out := &txWriter{format: format, w: w}
+	switch format {
+	case formatTable, "":
+		out.tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	case formatCSV:
+		out.csv = csv.NewWriter(w)
+	case formatJSON, formatNDJSON:
+	default:
+		return nil, usagef("unknown output format %q (expected table, json, ndjson or csv)", format)
+	}
+	return out, nil
+}
+
+// begin writes the header or opening bracket
+func (t *txWriter) begin() error {
+	switch {
+	case t.tw != nil:
+		_, err := fmt.Fprintln(t.tw, strings.Join(txHeaders, "\t"))
+		return err
+	case t.csv != nil:
+		return t.csv.Write(txHeaders)
+	case t.format == formatJSON:
+		_, err := io.WriteString(t.w, "[")
+		return err
+	}
+	return nil
+}
+
+// write writes a single transaction
+func (t *txWriter) write(txn *monarch.Transaction) error {
+	defer func() { t.n++ }()
+	switch {
+	case t.tw != nil:
+		_, err := fmt.Fprintln(t.tw, strings.Join(txRow(txn), "\t"))
+		return err
+	case t.csv != nil:
+		return t.csv.Write(txRow(txn))
+	case t.format == formatJSON:
+		// Matches the indentation of render's JSON output
+		data, err := json.MarshalIndent(txn, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if t.n == 0 {
+			sep = "\n  "
+		}
+		_, err = io.WriteString(t.w, sep+string(data))
+		return err
+	default:
+		data, err := json.Marshal(txn)
+		if err != nil {
+			return err
+		}
+		_, err = t.w.Write(append(data, '\n'))
+		return err
+	}
+}
+
+// end flushes buffered output and writes the closing bracket
+func (t *txWriter) end() error {
+	switch {
+	case t.tw != nil:
+		return t.tw.Flush()
+	case t.csv != nil:
+		t.csv.Flush()
+		return t.csv.Error()
+	case t.format == formatJSON:
+		closing := "]\n"
+		if t.n > 0 {
+			closing = "\n]\n"
+		}
+		_, err := io.WriteString(t.w, closing)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// txPatch is one row of a patch file. Nil fields are left unchanged.
+type txPatch struct {
+	// source identifies the row in error messages
+	source string
+
+	ID              string    `json:"id"`
+	Category        *string   `json:"category"`
+	Notes           *string   `json:"notes"`
+	Merchant        *string   `json:"merchant"`
+	HideFromReports *bool     `json:"hideFromReports"`
+	NeedsReview     *bool     `json:"needsReview"`
+	Tags            *[]string `json:"tags"`
+}
+
+// fieldChange is a single field difference between a transaction and a patch
+type fieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Statuses reported for each patched transaction
+const (
+	statusDryRun    = "dry-run"
+	statusUpdated   = "updated"
+	statusUnchanged = "unchanged"
+	statusFailed    = "failed"
+)
+
+// txApplyResult is the outcome of applying one patch
+type txApplyResult struct {
+	ID      string        `json:"id"`
+	Status  string        `json:"status"`
+	Changes []fieldChange `json:"changes,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+func runTxApply(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("tx apply", "<patch.csv|patch.json|-> [--dry-run] [--concurrency N] [--format csv|json]\n\n"+
+		"  Each row needs an id column plus any of: category, notes, merchant,\n"+
+		"  hideFromReports, needsReview, tags. Categories and tags may be names or IDs;\n"+
+		"  separate tags with ';'. Empty CSV cells are left unchanged; use JSON with\n"+
+		"  \"\" or [] to clear notes or tags. Output of 'tx list -o csv' can be edited\n"+
+		"  and applied directly.")
+	dryRun := fs.Bool("dry-run", false, "show the changes without applying them")
+	concurrency := fs.Int("concurrency", 4, "number of transactions updated in parallel")
+	format := fs.String("format", "", "patch format, csv or json (default from the file extension)")
+	args, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *concurrency < 1 {
+		return usagef("tx apply: --concurrency must be at least 1")
+	}
+
+	patches, err := a.readPatchFile(args[0], *format)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := newPatchResolver(ctx, client, patches)
+	if err != nil {
+		return err
+	}
+
+	results := make([]*txApplyResult, len(patches))
+	sem := make(chan struct{}, *concurrency)
+	var wg sync.WaitGroup
+	for i, p := range patches {
+		wg.Add(1)
+		go func(i int, p *txPatch) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = applyPatch(ctx, client, res, p, *dryRun)
+		}(i, p)
+	}
+	wg.Wait()
+
+	t := &table{headers: []string{"ID", "FIELD", "FROM", "TO", "STATUS"}}
+	counts := make(map[string]int)
+	var failures []*txApplyResult
+	for _, r := range results {
+		counts[r.Status]++
+		if r.Status == statusFailed {
+			failures = append(failures, r)
+		}
+		for _, c := range r.Changes {
+			t.addRow(r.ID, c.Field, c.From, c.To, r.Status)
+		}
+	}
+	if err := a.render(results, t); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Fprintf(a.stderr, "Dry run: %d to update, %d unchanged, %d failed\n",
+			counts[statusDryRun], counts[statusUnchanged], counts[statusFailed])
+	} else {
+		fmt.Fprintf(a.stderr, "%d updated, %d unchanged, %d failed\n",
+			counts[statusUpdated], counts[statusUnchanged], counts[statusFailed])
+	}
+	for _, f := range failures {
+		fmt.Fprintf(a.stderr, "  %s: %s\n", f.ID, f.Error)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d transactions failed", len(failures), len(patches))
+	}
+	return nil
+}
+
+// applyPatch diffs a patch against the current transaction and, unless
+// dryRun is set, applies the changed fields
+func applyPatch(ctx context.Context, client *monarch.Client, res *patchResolver, p *txPatch, dryRun bool) *txApplyResult {
+	result := &txApplyResult{ID: p.ID}
+	fail := func(err error) *txApplyResult {
+		result.Status = statusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	current, err := client.Transactions.Get(ctx, p.ID)
+	if err != nil {
+		return fail(err)
+	}
+	if current.Transaction == nil {
+		return fail(monarch.ErrNotFound)
+	}
+
+	params, tagIDs, changes := res.diff(current.Transaction, p)
+	result.Changes = changes
+	switch {
+	case len(changes) == 0:
+		result.Status = statusUnchanged
+		return result
+	case dryRun:
+		result.Status = statusDryRun
+		return result
+	}
+
+	if *params != (monarch.UpdateTransactionParams{}) {
+		if _, err := client.Transactions.Update(ctx, p.ID, params); err != nil {
+			return fail(err)
+		}
+	}
+	if tagIDs != nil {
+		if err := client.Tags.SetTransactionTags(ctx, p.ID, tagIDs...); err != nil {
+			return fail(err)
+		}
+	}
+
+	result.Status = statusUpdated
+	return result
+}
+
+// patchResolver maps category and tag names in patches to IDs
+type patchResolver struct {
+	categories []namedItem
+	tags       []namedItem
+}
+
+// newPatchResolver loads the categories and tags referenced by patches and
+// checks that every reference resolves
+func newPatchResolver(ctx context.Context, client *monarch.Client, patches []*txPatch) (*patchResolver, error) {
+	res := &patchResolver{}
+	var needCategories, needTags bool
+	for _, p := range patches {
+		needCategories = needCategories || p.Category != nil
+		needTags = needTags || (p.Tags != nil && len(*p.Tags) > 0)
+	}
+
+	var err error
+	if needCategories {
+		if res.categories, err = categoryItems(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+	if needTags {
+		if res.tags, err = tagItems(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range patches {
+		if p.Category != nil {
+			if _, err := resolveID("category", *p.Category, res.categories); err != nil {
+				return nil, fmt.Errorf("%s: %w", p.source, err)
+			}
+		}
+		if p.Tags != nil {
+			if _, err := resolveIDs("tag", *p.Tags, res.tags); err != nil {
+				return nil, fmt.Errorf("%s: %w", p.source, err)
+			}
+		}
+	}
+	return res, nil
+}
+
+// diff compares a transaction with a patch. It returns the update params for
+// changed fields, the new tag IDs if the tags changed (nil otherwise) and a
+// description of every change.
+func (r *patchResolver) diff(txn *monarch.Transaction, p *txPatch) (*monarch.UpdateTransactionParams, []string, []fieldChange) {
+	params := &monarch.UpdateTransactionParams{}
+	var changes []fieldChange
+
+	if p.Category != nil {
+		id, _ := resolveID("category", *p.Category, r.categories)
+		var currentID, currentName string
+		if txn.Category != nil {
+			currentID, currentName = txn.Category.ID, txn.Category.Name
+		}
+		if id != currentID {
+			params.CategoryID = &id
+			changes = append(changes, fieldChange{"category", currentName, itemName(r.categories, id)})
+		}
+	}
+
+	if p.Merchant != nil {
+		var current string
+		if txn.Merchant != nil {
+			current = txn.Merchant.Name
+		}
+		if *p.Merchant != current {
+			params.Merchant = p.Merchant
+			changes = append(changes, fieldChange{"merchant", current, *p.Merchant})
+		}
+	}
+
+	if p.Notes != nil && *p.Notes != txn.Notes {
+		params.Notes = p.Notes
+		changes = append(changes, fieldChange{"notes", txn.Notes, *p.Notes})
+	}
+
+	if p.HideFromReports != nil && *p.HideFromReports != txn.HideFromReports {
+		params.HideFromReports = p.HideFromReports
+		changes = append(changes, fieldChange{"hideFromReports", strconv.FormatBool(txn.HideFromReports), strconv.FormatBool(*p.HideFromReports)})
+	}
+
+	if p.NeedsReview != nil && *p.NeedsReview != txn.NeedsReview {
+		params.NeedsReview = p.NeedsReview
+		changes = append(changes, fieldChange{"needsReview", strconv.FormatBool(txn.NeedsReview), strconv.FormatBool(*p.NeedsReview)})
+	}
+
+	var tagIDs []string
+	if p.Tags != nil {
+		ids, _ := resolveIDs("tag", *p.Tags, r.tags)
+		currentIDs := make([]string, 0, len(txn.Tags))
+		currentNames := make([]string, 0, len(txn.Tags))
+		for _, tag := range txn.Tags {
+			currentIDs = append(currentIDs, tag.ID)
+			currentNames = append(currentNames, tag.Name)
+		}
+		if !sameSet(ids, currentIDs) {
+			tagIDs = ids
+			names := make([]string, len(ids))
+			for i, id := range ids {
+				names[i] = itemName(r.tags, id)
+			}
+			changes = append(changes, fieldChange{"tags", strings.Join(currentNames, ";"), strings.Join(names, ";")})
+		}
+	}
+
+	return params, tagIDs, changes
+}
+
+// itemName returns the name of the item with the given ID
+func itemName(items []namedItem, id string) string {
+	for _, item := range items {
+		if item.id == id {
+			return item.name
+		}
+	}
+	return id
+}
+
+// sameSet reports whether a and b contain the same strings, ignoring order
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// readPatchFile reads patches from path, or stdin for "-"
+func (a *app) readPatchFile(path, format string) ([]*txPatch, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(a.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read patch file: %w", err)
+	}
+
+	if format == "" {
+		format = formatCSV
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			format = formatJSON
+		}
+	}
+
+	var patches []*txPatch
+	switch format {
+	case formatCSV:
+		patches, err = parseCSVPatches(data)
+	case formatJSON:
+		patches, err = parseJSONPatches(data)
+	default:
+		return nil, usagef("tx apply: unknown patch format %q (expected csv or json)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(patches) == 0 {
+		return nil, usagef("tx apply: %s contains no patches", path)
+	}
+	seen := make(map[string]string, len(patches))
+	for _, p := range patches {
+		if p.ID == "" {
+			return nil, usagef("%s: missing transaction id", p.source)
+		}
+		if prev, ok := seen[p.ID]; ok {
+			return nil, usagef("%s: transaction %s is also patched by %s", p.source, p.ID, prev)
+		}
+		seen[p.ID] = p.source
+	}
+	return patches, nil
+}
+
+// readOnlyColumns are columns written by 'tx list -o csv' that cannot be
+// patched; they are ignored so exported files can be applied as-is
+var readOnlyColumns = map[string]bool{"date": true, "amount": true, "account": true, "pending": true}
+
+// parseCSVPatches parses a CSV patch file with a header row
+func parseCSVPatches(data []byte) ([]*txPatch, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, usagef("parse patch CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	hasID := false
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		header[i] = h
+		switch h {
+		case "id":
+			hasID = true
+		case "category", "notes", "merchant", "hidefromreports", "needsreview", "tags":
+		default:
+			if !readOnlyColumns[h] {
+				return nil, usagef("patch CSV: unknown column %q", h)
+			}
+		}
+	}
+	if !hasID {
+		return nil, usagef("patch CSV: missing id column")
+	}
+
+	patches := make([]*txPatch, 0, len(records)-1)
+	for n, record := range records[1:] {
+		p := &txPatch{source: fmt.Sprintf("line %d", n+2)}
+		for i, value := range record {
+			if i >= len(header) {
+				return nil, usagef("%s: more fields than columns", p.source)
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			v := value
+			switch header[i] {
+			case "id":
+				p.ID = v
+			case "category":
+				p.Category = &v
+			case "notes":
+				p.Notes = &v
+			case "merchant":
+				p.Merchant = &v
+			case "hidefromreports":
+				if p.HideFromReports, err = parsePatchBool(v); err != nil {
+					return nil, usagef("%s: hideFromReports: %v", p.source, err)
+				}
+			case "needsreview":
+				if p.NeedsReview, err = parsePatchBool(v); err != nil {
+					return nil, usagef("%s: needsReview: %v", p.source, err)
+				}
+			case "tags":
+				var tags stringList
+				_ = tags.Set(strings.ReplaceAll(v, ";", ","))
+				list := []string(tags)
+				p.Tags = &list
+			}
+		}
+		patches = append(patches, p)
+	}
+	return patches, nil
+}
+
+// parsePatchBool parses true/false, yes/no or 1/0
+func parsePatchBool(v string) (*bool, error) {
+	var b bool
+	switch strings.ToLower(v) {
+	case "true", "yes", "y", "1":
+		b = true
+	case "false", "no", "n", "0":
+		b = false
+	default:
+		return nil, fmt.Errorf("invalid boolean %q", v)
+	}
+	return &b, nil
+}
+
+// parseJSONPatches parses a JSON array of patch objects
+func parseJSONPatches(data []byte) ([]*txPatch, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var patches []*txPatch
+	if err := dec.Decode(&patches); err != nil {
+		return nil, usagef("parse patch JSON: %v", err)
+	}
+	for i, p := range patches {
+		if p == nil {
+			return nil, usagef("patch %d: null entry", i+1)
+		}
+		p.source = fmt.Sprintf("patch %d", i+1)
+	}
+	return patches, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const transactionDetailsResponse = `{"getTransaction": {
+	"id": "txn-1",
+	"date": "2026-10-01",
+	"amount": -42.5,
+	"notes": "weekly shop",
+	"merchant": {"id": "m-1", "name": "Grocer"},
+	"category": {"id": "cat-1", "name": "Groceries"},
+	"tags": [{"id": "tag-1", "name": "Shared"}]
+}}`
+
+// writePatch writes a patch file into a temporary directory
+func writePatch(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestTxApply_DryRunShowsDiff(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetTransactionDetails":       transactionDetailsResponse,
+		"GetCategories":               categoriesResponse,
+		"GetHouseholdTransactionTags": tagsResponse,
+	})
+	path := writePatch(t, "patch.csv", "id,category,notes,tags,needsReview\n"+
+		"txn-1,Dining,,Shared;Food,no\n"+
+		"txn-2,Groceries,weekly shop,,\n")
+
+	code, stdout, stderr := runCLI(t, srv, "", "tx", "apply", path, "--dry-run", "-o", "csv")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "ID,FIELD,FROM,TO,STATUS\n"+
+		"txn-1,category,Groceries,Dining,dry-run\n"+
+		"txn-1,tags,Shared,Shared;Food,dry-run\n", stdout)
+	assert.Contains(t, stderr, "Dry run: 1 to update, 1 unchanged, 0 failed")
+	assert.Empty(t, stub.callsTo("UpdateTransaction"))
+	assert.Empty(t, stub.callsTo("Web_SetTransactionTags"))
+}
+
+func TestTxApply_JSONPatch(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetTransactionDetails":  transactionDetailsResponse,
+		"GetCategories":          categoriesResponse,
+		"UpdateTransaction":      `{"updateTransaction": {"transaction": {"id": "txn-1"}, "errors": []}}`,
+		"Web_SetTransactionTags": `{"setTransactionTags": {"transaction": {"id": "txn-1"}, "errors": []}}`,
+	})
+	path := writePatch(t, "patch.json", `[
+		{"id": "txn-1", "category": "cat-3", "merchant": "Corner Grocer", "hideFromReports": true, "tags": []}
+	]`)
+
+	code, _, stderr := runCLI(t, srv, "", "tx", "apply", path)
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "1 updated, 0 unchanged, 0 failed")
+
+	updates := stub.callsTo("UpdateTransaction")
+	require.Len(t, updates, 1)
+	assert.Equal(t, map[string]interface{}{
+		"id":              "txn-1",
+		"category":        "cat-3",
+		"name":            "Corner Grocer",
+		"hideFromReports": true,
+	}, updates[0]["input"])
+
+	tags := stub.callsTo("Web_SetTransactionTags")
+	require.Len(t, tags, 1)
+	assert.Equal(t, []interface{}{}, tags[0]["input"].(map[string]interface{})["tagIds"])
+}
+
+func TestTxApply_FailureSummary(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{
+		"GetTransactionDetails": transactionDetailsResponse,
+		"UpdateTransaction":     `{"updateTransaction": {"transaction": null, "errors": [{"code": "INVALID", "message": "notes too long"}]}}`,
+	})
+	path := writePatch(t, "patch.csv", "id,notes\ntxn-1,new note\ntxn-2,other note\n")
+
+	code, stdout, stderr := runCLI(t, srv, "", "tx", "apply", path, "--concurrency", "1", "-o", "json")
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "0 updated, 0 unchanged, 2 failed")
+	assert.Contains(t, stderr, "txn-2: INVALID: notes too long")
+
+	var results []txApplyResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &results))
+	require.Len(t, results, 2)
+	assert.Equal(t, statusFailed, results[0].Status)
+}
+
+func TestTxApply_ExportedCSV(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetTransactionDetails":       transactionDetailsResponse,
+		"GetCategories":               categoriesResponse,
+		"GetHouseholdTransactionTags": tagsResponse,
+	})
+	path := writePatch(t, "export.csv", "ID,DATE,AMOUNT,MERCHANT,CATEGORY,ACCOUNT,TAGS,NOTES,PENDING\n"+
+		"txn-1,2026-10-01,-42.50,Grocer,Groceries,Checking,Shared,weekly shop,no\n")
+
+	code, _, stderr := runCLI(t, srv, "", "tx", "apply", path)
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "0 updated, 1 unchanged, 0 failed")
+	assert.Empty(t, stub.callsTo("UpdateTransaction"))
+}
+
+func TestTxApply_InvalidPatches(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetCategories": categoriesResponse})
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		code    int
+	}{
+		{"unknown column", "p.csv", "id,colour\ntxn-1,red\n", exitUsage},
+		{"missing id column", "p.csv", "notes\nhello\n", exitUsage},
+		{"duplicate id", "p.csv", "id,notes\ntxn-1,a\ntxn-1,b\n", exitUsage},
+		{"bad bool", "p.csv", "id,needsReview\ntxn-1,maybe\n", exitUsage},
+		{"unknown JSON field", "p.json", `[{"id": "txn-1", "colour": "red"}]`, exitUsage},
+		{"empty", "p.json", `[]`, exitUsage},
+		{"unknown category", "p.csv", "id,category\ntxn-1,Travel\n", exitNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := runCLI(t, srv, "", "tx", "apply", writePatch(t, tt.file, tt.content))
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestTxApply_Stdin(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetTransactionDetails": transactionDetailsResponse})
+
+	code, stdout, stderr := runCLI(t, srv, `[{"id": "txn-1", "notes": ""}]`, "tx", "apply", "-", "--format", "json", "--dry-run")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "weekly shop")
+}
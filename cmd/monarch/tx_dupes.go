@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/duplicates"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// statusMerged is reported for a resolved duplicate pair
+const statusMerged = "merged"
+
+// txDupesResult is a duplicate pair and what was done with it
+type txDupesResult struct {
+	*duplicates.Pair
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func runTxDupes(ctx context.Context, a *app, args []string) error {
+	fs := a.leafFlags("tx dupes", "[--from DATE --to DATE] [--account A] [--days N] [--min-score S] [--include-synced] [--resolve] [--yes]\n\n"+
+		"  Pairs transactions with the same amount, close dates and similar names,\n"+
+		"  where at least one looks manual: in a manual account, imported, or without\n"+
+		"  an original bank name. --resolve copies the notes, tags and category of\n"+
+		"  each manual copy to the transaction kept and deletes the copy.")
+	from := fs.String("from", "", "start date, YYYY-MM-DD (default 90 days ago)")
+	to := fs.String("to", "", "end date, YYYY-MM-DD (default today)")
+	var accounts stringList
+	fs.Var(&accounts, "account", "account name or ID (repeatable)")
+	days := fs.Int("days", 3, "maximum days between duplicates")
+	minScore := fs.Float64("min-score", 0.6, "minimum score from 0 to 1")
+	includeSynced := fs.Bool("include-synced", false, "also pair transactions that were both synced")
+	resolve := fs.Bool("resolve", false, "merge each pair and delete the copy")
+	yes := fs.Bool("yes", false, "resolve without asking for confirmation")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *days < 1 {
+		return usagef("tx dupes: --days must be at least 1")
+	}
+	if *minScore <= 0 || *minScore > 1 {
+		return usagef("tx dupes: --min-score must be above 0 and at most 1")
+	}
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	if start.IsZero() {
+		end = time.Now()
+		start = end.AddDate(0, 0, -90)
+	}
+
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+
+	q := client.Transactions.Query().Between(start, end)
+	accountIDs, err := resolveNamed(ctx, client, "account", accounts, accountItems)
+	if err != nil {
+		return err
+	}
+	if len(accountIDs) > 0 {
+		q = q.WithAccounts(accountIDs...)
+	}
+
+	pairs, err := duplicates.Scan(ctx, client, q, &duplicates.Options{
+		Days: *days, MinScore: *minScore, IncludeSynced: *includeSynced,
+	})
+	if err != nil {
+		return err
+	}
+	results := make([]*txDupesResult, len(pairs))
+	for i, p := range pairs {
+		results[i] = &txDupesResult{Pair: p, Status: statusDryRun}
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(a.stderr, "No duplicates found")
+		return a.render(results, dupesTable(results))
+	}
+	if !*resolve {
+		if err := a.render(results, dupesTable(results)); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stderr, "%d likely duplicates; run with --resolve to merge them\n", len(results))
+		return nil
+	}
+	if !*yes {
+		// preview on stderr so stdout only gets the results after resolving
+		if err := a.writeTable(a.stderr, dupesTable(results)); err != nil {
+			return err
+		}
+		if !a.confirm(fmt.Sprintf("Merge %d duplicates, deleting the copies?", len(results))) {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	failed := 0
+	for _, r := range results {
+		if err := duplicates.Resolve(ctx, client, r.Pair); err != nil {
+			r.Status = statusFailed
+			r.Error = err.Error()
+			failed++
+		} else {
+			r.Status = statusMerged
+		}
+	}
+
+	if err := a.render(results, dupesTable(results)); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "%d merged, %d failed\n", len(results)-failed, failed)
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(a.stderr, "  %s: %s\n", r.Remove.ID, r.Error)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d duplicates failed to merge", failed, len(results))
+	}
+	return nil
+}
+
+// dupesTable renders duplicate pairs, the transaction kept first
+func dupesTable(results []*txDupesResult) *table {
+	t := &table{headers: []string{"SCORE", "AMOUNT", "KEEP", "KEEP DATE", "KEEP ACCOUNT", "REMOVE", "REMOVE DATE", "REMOVE ACCOUNT", "MERCHANT", "STATUS"}}
+	for _, r := range results {
+		var merchant string
+		if r.Keep.Merchant != nil {
+			merchant = r.Keep.Merchant.Name
+		}
+		t.addRow(
+			strconv.FormatFloat(r.Score, 'f', -1, 64),
+			formatAmount(r.Keep.Amount),
+			r.Keep.ID, r.Keep.Date.String(), accountName(r.Keep),
+			r.Remove.ID, r.Remove.Date.String(), accountName(r.Remove),
+			merchant,
+			r.Status,
+		)
+	}
+	return t
+}
+
+func accountName(txn *monarch.Transaction) string {
+	if txn.Account == nil {
+		return ""
+	}
+	return txn.Account.DisplayName
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dupesAccountsResponse = `{"accounts": [
+	{"id": "acc-1", "displayName": "Checking"},
+	{"id": "acc-cash", "displayName": "Cash", "isManual": true}
+]}`
+
+const dupesTransactionsResponse = `{
+	"allTransactions": {
+		"totalCount": 3,
+		"results": [
+			{
+				"id": "txn-synced",
+				"date": "2026-10-03",
+				"amount": -42.5,
+				"plaidName": "CORNER MARKET #12",
+				"merchant": {"id": "m-1", "name": "Corner Market"},
+				"category": {"id": "cat-1", "name": "Groceries"},
+				"account": {"id": "acc-1", "displayName": "Checking"},
+				"tags": []
+			},
+			{
+				"id": "txn-manual",
+				"date": "2026-10-01",
+				"amount": -42.5,
+				"notes": "split with Sam",
+				"merchant": {"id": "m-1", "name": "Corner Market"},
+				"category": {"id": "cat-1", "name": "Groceries"},
+				"account": {"id": "acc-cash", "displayName": "Cash"},
+				"tags": [{"id": "tag-1", "name": "Shared"}]
+			},
+			{
+				"id": "txn-other",
+				"date": "2026-10-02",
+				"amount": -9,
+				"plaidName": "CAFE",
+				"merchant": {"id": "m-2", "name": "Cafe"},
+				"account": {"id": "acc-1", "displayName": "Checking"}
+			}
+		]
+	}
+}`
+
+func TestTxDupes_List(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":         dupesAccountsResponse,
+		"GetTransactionsList": dupesTransactionsResponse,
+	})
+
+	code, stdout, stderr := runCLI(t, srv, "", "tx", "dupes", "--from", "2026-10-01", "--to", "2026-10-15", "-o", "csv")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "SCORE,AMOUNT,KEEP,KEEP DATE,KEEP ACCOUNT,REMOVE,REMOVE DATE,REMOVE ACCOUNT,MERCHANT,STATUS\n"+
+		"0.85,-42.50,txn-synced,2026-10-03,Checking,txn-manual,2026-10-01,Cash,Corner Market,dry-run\n", stdout)
+	assert.Contains(t, stderr, "1 likely duplicates; run with --resolve")
+	assert.Empty(t, stub.callsTo("Common_DeleteTransactionMutation"))
+
+	filters := stub.callsTo("GetTransactionsList")[0]["filters"].(map[string]interface{})
+	assert.Equal(t, "2026-10-01", filters["startDate"])
+}
+
+func TestTxDupes_Resolve(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":                      dupesAccountsResponse,
+		"GetTransactionsList":              dupesTransactionsResponse,
+		"UpdateTransaction":                `{"updateTransaction": {"transaction": {"id": "txn-synced"}, "errors": []}}`,
+		"Web_SetTransactionTags":           `{"setTransactionTags": {"transaction": {"id": "txn-synced"}, "errors": []}}`,
+		"Common_DeleteTransactionMutation": `{"deleteTransaction": {"deleted": true, "errors": []}}`,
+	})
+
+	code, stdout, stderr := runCLI(t, srv, "", "tx", "dupes", "--resolve", "--yes", "-o", "json")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "1 merged, 0 failed")
+	var results []txDupesResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, statusMerged, results[0].Status)
+
+	updates := stub.callsTo("UpdateTransaction")
+	require.Len(t, updates, 1)
+	assert.Equal(t, map[string]interface{}{"id": "txn-synced", "notes": "split with Sam"}, updates[0]["input"])
+	tags := stub.callsTo("Web_SetTransactionTags")
+	require.Len(t, tags, 1)
+	assert.Equal(t, []interface{}{"tag-1"}, tags[0]["input"].(map[string]interface{})["tagIds"])
+	deletes := stub.callsTo("Common_DeleteTransactionMutation")
+	require.Len(t, deletes, 1)
+	assert.Equal(t, "txn-manual", deletes[0]["input"].(map[string]interface{})["transactionId"])
+}
+
+func TestTxDupes_ResolveConfirmed(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":                      dupesAccountsResponse,
+		"GetTransactionsList":              dupesTransactionsResponse,
+		"UpdateTransaction":                `{"updateTransaction": {"transaction": {"id": "txn-synced"}, "errors": []}}`,
+		"Web_SetTransactionTags":           `{"setTransactionTags": {"transaction": {"id": "txn-synced"}, "errors": []}}`,
+		"Common_DeleteTransactionMutation": `{"deleteTransaction": {"deleted": true, "errors": []}}`,
+	})
+
+	code, stdout, stderr := runCLI(t, srv, "y\n", "tx", "dupes", "--resolve", "-o", "json")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "txn-manual")
+	assert.Contains(t, stderr, "Merge 1 duplicates, deleting the copies? [y/N]")
+	var results []txDupesResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &results), "stdout holds only the results after resolving")
+	require.Len(t, results, 1)
+	assert.Equal(t, statusMerged, results[0].Status)
+}
+
+func TestTxDupes_ResolveAborted(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetAccounts":         dupesAccountsResponse,
+		"GetTransactionsList": dupesTransactionsResponse,
+	})
+
+	code, _, stderr := runCLI(t, srv, "n\n", "tx", "dupes", "--resolve")
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "Merge 1 duplicates, deleting the copies? [y/N]")
+	assert.Empty(t, stub.callsTo("Common_DeleteTransactionMutation"))
+}
+
+func TestTxDupes_InvalidFlags(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	code, _, _ := runCLI(t, srv, "", "tx", "dupes", "--days", "0")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI(t, srv, "", "tx", "dupes", "--min-score", "2")
+	assert.Equal(t, exitUsage, code)
+}
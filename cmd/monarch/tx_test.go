@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const transactionsResponse = `{
+	"allTransactions": {
+		"totalCount": 2,
+		"results": [
+			{
+				"id": "txn-1",
+				"date": "2026-10-01",
+				"amount": -42.5,
+				"merchant": {"id": "m-1", "name": "Grocer"},
+				"category": {"id": "cat-1", "name": "Groceries"},
+				"account": {"id": "acc-1", "displayName": "Checking"},
+				"tags": [{"id": "tag-1", "name": "Shared"}, {"id": "tag-2", "name": "Food"}],
+				"notes": "weekly shop"
+			},
+			{
+				"id": "txn-2",
+				"date": "2026-10-02",
+				"amount": 1000,
+				"merchant": {"id": "m-2", "name": "Employer"},
+				"category": {"id": "cat-2", "name": "Paychecks"},
+				"account": {"id": "acc-1", "displayName": "Checking"},
+				"pending": true
+			}
+		]
+	}
+}`
+
+const categoriesResponse = `{"categories": [
+	{"id": "cat-1", "name": "Groceries"},
+	{"id": "cat-2", "name": "Paychecks"},
+	{"id": "cat-3", "name": "Dining"}
+]}`
+
+const tagsResponse = `{"householdTransactionTags": [
+	{"id": "tag-1", "name": "Shared"},
+	{"id": "tag-2", "name": "Food"}
+]}`
+
+func TestTxList_Table(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{"GetTransactionsList": transactionsResponse})
+
+	code, stdout, stderr := runCLI(t, srv, "", "tx", "list")
+
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "ID     DATE")
+	assert.Contains(t, stdout, "Shared;Food")
+	assert.Contains(t, stdout, "-42.50")
+
+	vars := stub.callsTo("GetTransactionsList")[0]
+	assert.Equal(t, float64(100), vars["limit"])
+	assert.Empty(t, vars["filters"])
+}
+
+func TestTxList_FiltersResolveNames(t *testing.T) {
+	stub, srv := newGraphQLStub(t, map[string]string{
+		"GetTransactionsList":         transactionsResponse,
+		"GetAccounts":                 accountsResponse,
+		"GetCategories":               categoriesResponse,
+		"GetHouseholdTransactionTags": tagsResponse,
+	})
+
+	code, _, stderr := runCLI(t, srv, "", "tx", "list",
+		"--from", "2026-01-01", "--to", "2026-06-30",
+		"--account", "checking", "--account", "acc-2",
+		"--category", "Groceries,cat-3",
+		"--tag", "shared",
+		"--search", "grocer", "--limit", "10")
+
+	require.Equal(t, exitOK, code, stderr)
+	vars := stub.callsTo("GetTransactionsList")[0]
+	assert.Equal(t, float64(10), vars["limit"])
+	assert.Equal(t, map[string]interface{}{
+		"startDate":  "2026-01-01",
+		"endDate":    "2026-06-30",
+		"accounts":   []interface{}{"acc-1", "acc-2"},
+		"categories": []interface{}{"cat-1", "cat-3"},
+		"tags":       []interface{}{"tag-1"},
+		"search":     "grocer",
+	}, vars["filters"])
+}
+
+func TestTxList_UnknownName(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetCategories": categoriesResponse})
+
+	code, _, stderr := runCLI(t, srv, "", "tx", "list", "--category", "Travel")
+
+	assert.Equal(t, exitNotFound, code)
+	assert.Contains(t, stderr, `no category named "Travel"`)
+}
+
+func TestTxList_InvalidDates(t *testing.T) {
+	_, srv := newGraphQLStub(t, nil)
+
+	for _, args := range [][]string{
+		{"--from", "01/02/2026"},
+		{"--to", "2026-01-01"},
+		{"--from", "2026-02-01", "--to", "2026-01-01"},
+	} {
+		code, _, _ := runCLI(t, srv, "", append([]string{"tx", "list"}, args...)...)
+		assert.Equal(t, exitUsage, code, args)
+	}
+}
+
+func TestTxList_StreamNDJSON(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetTransactionsList": transactionsResponse})
+
+	code, stdout, stderr := runCLI(t, srv, "", "tx", "list", "--all", "--min", "100", "-o", "ndjson")
+
+	require.Equal(t, exitOK, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 1)
+	var txn map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &txn))
+	assert.Equal(t, "txn-2", txn["id"])
+}
+
+func TestTxList_StreamCSV(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetTransactionsList": transactionsResponse})
+
+	code, stdout, _ := runCLI(t, srv, "", "tx", "list", "--all", "-o", "csv")
+
+	require.Equal(t, exitOK, code)
+	assert.Equal(t,
+		"ID,DATE,AMOUNT,MERCHANT,CATEGORY,ACCOUNT,TAGS,NOTES,PENDING\n"+
+			"txn-1,2026-10-01,-42.50,Grocer,Groceries,Checking,Shared;Food,weekly shop,no\n"+
+			"txn-2,2026-10-02,1000.00,Employer,Paychecks,Checking,,,yes\n",
+		stdout)
+}
+
+func TestTxList_JSONArray(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{"GetTransactionsList": transactionsResponse})
+
+	code, stdout, _ := runCLI(t, srv, "", "tx", "list", "-o", "json")
+
+	require.Equal(t, exitOK, code)
+	var txns []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &txns))
+	require.Len(t, txns, 2)
+	assert.Equal(t, "txn-1", txns[0]["id"])
+}
+
+func TestTxList_EmptyJSONArray(t *testing.T) {
+	_, srv := newGraphQLStub(t, map[string]string{
+		"GetTransactionsList": `{"allTransactions": {"totalCount": 0, "results": []}}`,
+	})
+
+	code, stdout, _ := runCLI(t, srv, "", "tx", "list", "--all", "-o", "json")
+
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "[]\n", stdout)
+}
+
+func TestResolveID_Ambiguous(t *testing.T) {
+	items := []namedItem{{id: "a", name: "Savings"}, {id: "b", name: "savings"}}
+
+	_, err := resolveID("account", "SAVINGS", items)
+
+	assert.Equal(t, exitUsage, exitCode(err))
+	assert.Contains(t, err.Error(), "a, b")
+}
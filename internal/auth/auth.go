@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"crypto/hmac"
@@ -13,7 +12,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -69,8 +67,7 @@ func (s *Service) Login(ctx context.Context, email, password string) error {
 // LoginWithMFA performs login with MFA code
 func (s *Service) LoginWithMFA(ctx context.Context, email, password, mfaCode string) error {
 	// First attempt login
-	err := s.login(ctx, email, password, "")
-	if err != nil && err.Error() != "MFA required" && err.Error() != "Email OTP required" {
+	if err := s.login(ctx, email, password, ""); !isChallenge(err) {
 		return err
 	}
 
@@ -87,8 +84,7 @@ func (s *Service) LoginWithTOTP(ctx context.Context, email, password, totpSecret
 	}
 
 	// First attempt login
-	err = s.login(ctx, email, password, "")
-	if err != nil && err.Error() != "MFA required" && err.Error() != "Email OTP required" {
+	if err := s.login(ctx, email, password, ""); !isChallenge(err) {
 		return err
 	}
 
@@ -103,120 +99,90 @@ func (s *Service) LoginWithEmailOTP(ctx context.Context, email, password, otpCod
 	return s.submitEmailOTP(ctx, email, password, otpCode)
 }
 
-// LoginInteractive performs interactive login with prompts for MFA/OTP when needed
-func (s *Service) LoginInteractive(ctx context.Context, email, password string) error {
+// LoginWithChallenge performs login, asking handler for a code when an MFA
+// or email OTP challenge is returned
+func (s *Service) LoginWithChallenge(ctx context.Context, email, password string, handler types.ChallengeHandler) error {
 	// First attempt login
 	err := s.login(ctx, email, password, "")
-	if err == nil {
-		// Login succeeded without MFA/OTP
-		return nil
-	}
-
-	// Check if MFA or Email OTP is required
-	if err.Error() == "Email OTP required" {
-		// Prompt for Email OTP
-		fmt.Println("\n📧 Email OTP Required!")
-		fmt.Println("An OTP code has been sent to your email address.")
-		fmt.Print("Enter the code from your email: ")
-
-		reader := bufio.NewReader(os.Stdin)
-		otpCode, readErr := reader.ReadString('\n')
-		if readErr != nil {
-			return errors.Wrap(readErr, "failed to read OTP code")
-		}
-
-		// Trim whitespace and newline
-		otpCode = strings.TrimSpace(otpCode)
 
-		// Submit OTP code via email_otp field
-		return s.submitEmailOTP(ctx, email, password, otpCode)
-
-	} else if err.Error() == "MFA required" {
-		// Prompt for MFA code
-		fmt.Println("\n📱 MFA Required!")
-		fmt.Print("Enter your authenticator code: ")
-
-		reader := bufio.NewReader(os.Stdin)
-		mfaCode, readErr := reader.ReadString('\n')
-		if readErr != nil {
-			return errors.Wrap(readErr, "failed to read MFA code")
+	var mfaErr *types.MFARequiredError
+	var otpErr *types.EmailOTPRequiredError
+	switch {
+	case errors.As(err, &otpErr):
+		code, codeErr := handler.Code(ctx, types.Challenge{Kind: types.ChallengeEmailOTP, Email: email})
+		if codeErr != nil {
+			return errors.Wrap(codeErr, "failed to get email OTP code")
 		}
-
-		// Trim whitespace and newline
-		mfaCode = strings.TrimSpace(mfaCode)
-
-		// Submit MFA code
-		return s.submitMFA(ctx, email, password, mfaCode)
+		return s.submitEmailOTP(ctx, email, password, code)
+	case errors.As(err, &mfaErr):
+		code, codeErr := handler.Code(ctx, types.Challenge{Kind: types.ChallengeMFA, Email: email})
+		if codeErr != nil {
+			return errors.Wrap(codeErr, "failed to get MFA code")
+		}
+		return s.submitMFA(ctx, email, password, code)
 	}
 
-	// Some other error occurred
+	// Login succeeded without MFA/OTP, or some other error occurred
 	return err
 }
 
+// LoginInteractive performs interactive login, prompting on stdout and
+// reading codes from stdin when needed
+func (s *Service) LoginInteractive(ctx context.Context, email, password string) error {
+	return s.LoginWithChallenge(ctx, email, password, NewPromptHandler(os.Stdin, os.Stdout))
+}
+
 // GetSession returns the current session
 func (s *Service) GetSession() (*types.Session, error) {
 	if s.session == nil {
-		return nil, errors.New("not authenticated")
+		return nil, types.ErrNotAuthenticated
 	}
 	return s.session, nil
 }
 
 // SaveSession saves session to file
 func (s *Service) SaveSession(path string) error {
-	if s.session == nil {
-		return errors.New("not authenticated")
-	}
+	return s.SaveSessionTo(NewFileStore(path))
+}
 
-	// Create directory if needed
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return errors.Wrap(err, "failed to create session directory")
-	}
+// LoadSession loads session from file
+func (s *Service) LoadSession(path string) error {
+	return s.LoadSessionFrom(NewFileStore(path))
+}
 
-	// Marshal session to JSON (not pickle like Python)
-	data, err := json.MarshalIndent(s.session, "", "  ")
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal session")
+// SaveSessionTo saves the session to a store
+func (s *Service) SaveSessionTo(store SessionStore) error {
+	if s.session == nil {
+		return types.ErrNotAuthenticated
 	}
 
-	// Write to file with restrictive permissions
-	if err := os.WriteFile(path, data, 0600); err != nil {
-		return errors.Wrap(err, "failed to write session file")
+	if err := store.Save(s.session); err != nil {
+		return err
 	}
 
 	if s.logger != nil {
-		s.logger.Info("Session saved", "path", path)
+		s.logger.Info("Session saved")
 	}
 
 	return nil
 }
 
-// LoadSession loads session from file
-func (s *Service) LoadSession(path string) error {
-	// Read file
-	data, err := os.ReadFile(path)
+// LoadSessionFrom loads the session from a store
+func (s *Service) LoadSessionFrom(store SessionStore) error {
+	session, err := store.Load()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return errors.New("not authenticated")
-		}
-		return errors.Wrap(err, "failed to read session file")
-	}
-
-	// Unmarshal session
-	var session types.Session
-	if err := json.Unmarshal(data, &session); err != nil {
-		return errors.Wrap(err, "failed to unmarshal session")
+		return err
 	}
 
 	// Check expiry
 	if !session.ExpiresAt.IsZero() && time.Now().After(session.ExpiresAt) {
-		return errors.New("session expired")
+		return types.ErrSessionExpired
 	}
 
-	s.session = &session
+	s.session = session
 
 	if s.logger != nil {
-		s.logger.Info("Session loaded", "path", path, "email", session.Email)
+		s.logger.Info("Session loaded", "email", session.Email)
 	}
 
 	return nil
@@ -293,11 +259,11 @@ func (s *Service) login(ctx context.Context, email, password, mfaCode string) er
 	if loginResp.ErrorCode != "" {
 		switch loginResp.ErrorCode {
 		case "MFA_REQUIRED":
-			return errors.New("MFA required")
+			return &types.MFARequiredError{Email: email}
 		case "EMAIL_OTP_REQUIRED":
-			return errors.New("Email OTP required")
+			return &types.EmailOTPRequiredError{Email: email}
 		case "INVALID_CREDENTIALS":
-			return errors.New("login failed")
+			return types.ErrLoginFailed
 		default:
 			return &types.Error{
 				Code:    loginResp.ErrorCode,
@@ -309,7 +275,7 @@ func (s *Service) login(ctx context.Context, email, password, mfaCode string) er
 	// Check status
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusUnauthorized {
-			return errors.New("login failed")
+			return types.ErrLoginFailed
 		}
 		return &types.Error{
 			Code:       "LOGIN_FAILED",
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/types"
+	"github.com/pkg/errors"
+)
+
+// isChallenge reports whether a login error asks for a second factor
+func isChallenge(err error) bool {
+	var mfaErr *types.MFARequiredError
+	var otpErr *types.EmailOTPRequiredError
+	return errors.As(err, &mfaErr) || errors.As(err, &otpErr)
+}
+
+// promptHandler asks for challenge codes on a terminal
+type promptHandler struct {
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+// NewPromptHandler returns a ChallengeHandler that writes a prompt to out and
+// reads the code as a line from in
+func NewPromptHandler(in io.Reader, out io.Writer) types.ChallengeHandler {
+	return &promptHandler{reader: bufio.NewReader(in), out: out}
+}
+
+// Code prompts for the code of a challenge
+func (p *promptHandler) Code(ctx context.Context, challenge types.Challenge) (string, error) {
+	switch challenge.Kind {
+	case types.ChallengeEmailOTP:
+		fmt.Fprintln(p.out, "\n📧 Email OTP Required!")
+		fmt.Fprintln(p.out, "An OTP code has been sent to your email address.")
+		fmt.Fprint(p.out, "Enter the code from your email: ")
+	default:
+		fmt.Fprintln(p.out, "\n📱 MFA Required!")
+		fmt.Fprint(p.out, "Enter your authenticator code: ")
+	}
+
+	code, err := p.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || code == "") {
+		return "", errors.Wrap(err, "failed to read code")
+	}
+
+	// Trim whitespace and newline
+	return strings.TrimSpace(code), nil
+}
@@ -0,0 +1,367 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/types"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// SessionStore persists a session. Load returns types.ErrNotAuthenticated
+// when nothing is stored.
+type SessionStore interface {
+	Load() (*types.Session, error)
+	Save(session *types.Session) error
+	Delete() error
+}
+
+// FileStore keeps the session as plaintext JSON in a file
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store for the file at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the session from the file
+func (s *FileStore) Load() (*types.Session, error) {
+	data, err := readFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var session types.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal session")
+	}
+	return &session, nil
+}
+
+// Save writes the session to the file
+func (s *FileStore) Save(session *types.Session) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal session")
+	}
+	return writeFile(s.path, data)
+}
+
+// Delete removes the file
+func (s *FileStore) Delete() error {
+	return removeFile(s.path)
+}
+
+// Encrypted session files are JSON envelopes around AES-256-GCM ciphertext.
+// With a passphrase the key is derived with scrypt from a random salt kept in
+// the envelope.
+const (
+	envelopeVersion = 1
+	keySize         = 32
+	saltSize        = 16
+
+	kdfNone   = "none"
+	kdfScrypt = "scrypt"
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// envelope is the on-disk form of an encrypted session
+type envelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFileStore keeps the session encrypted with AES-GCM in a file
+type EncryptedFileStore struct {
+	path       string
+	key        []byte
+	passphrase string
+}
+
+// NewEncryptedFileStore creates a store encrypting with a 32-byte key
+func NewEncryptedFileStore(path string, key []byte) (*EncryptedFileStore, error) {
+	if len(key) != keySize {
+		return nil, errors.Errorf("encryption key must be %d bytes, got %d", keySize, len(key))
+	}
+	return &EncryptedFileStore{path: path, key: append([]byte(nil), key...)}, nil
+}
+
+// NewPassphraseFileStore creates a store encrypting with a key derived from
+// passphrase
+func NewPassphraseFileStore(path, passphrase string) (*EncryptedFileStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required")
+	}
+	return &EncryptedFileStore{path: path, passphrase: passphrase}, nil
+}
+
+// Load decrypts the session from the file
+func (s *EncryptedFileStore) Load() (*types.Session, error) {
+	data, err := readFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, errors.Wrap(err, "failed to parse encrypted session")
+	}
+	if env.Version != envelopeVersion {
+		return nil, errors.Errorf("unsupported encrypted session version %d", env.Version)
+	}
+
+	key, err := s.deriveKey(env.KDF, env.Salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce in encrypted session")
+	}
+	plaintext, err := gcm.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt session: wrong key or corrupted file")
+	}
+
+	var session types.Session
+	if err := json.Unmarshal(plaintext, &session); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal session")
+	}
+	return &session, nil
+}
+
+// Save encrypts the session to the file
+func (s *EncryptedFileStore) Save(session *types.Session) error {
+	plaintext, err := json.Marshal(session)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal session")
+	}
+
+	env := envelope{Version: envelopeVersion, KDF: kdfNone}
+	if s.passphrase != "" {
+		env.KDF = kdfScrypt
+		env.Salt = make([]byte, saltSize)
+		if _, err := rand.Read(env.Salt); err != nil {
+			return errors.Wrap(err, "failed to generate salt")
+		}
+	}
+	key, err := s.deriveKey(env.KDF, env.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return errors.Wrap(err, "failed to generate nonce")
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal encrypted session")
+	}
+	return writeFile(s.path, data)
+}
+
+// Delete removes the file
+func (s *EncryptedFileStore) Delete() error {
+	return removeFile(s.path)
+}
+
+// deriveKey returns the AES key for a file written with kdf
+func (s *EncryptedFileStore) deriveKey(kdf string, salt []byte) ([]byte, error) {
+	switch kdf {
+	case kdfNone:
+		if s.key == nil {
+			return nil, errors.New("session was encrypted with a key, not a passphrase")
+		}
+		return s.key, nil
+	case kdfScrypt:
+		if s.passphrase == "" {
+			return nil, errors.New("session was encrypted with a passphrase, not a key")
+		}
+		key, err := scrypt.Key([]byte(s.passphrase), salt, scryptN, scryptR, scryptP, keySize)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to derive key")
+		}
+		return key, nil
+	default:
+		return nil, errors.Errorf("unsupported key derivation %q", kdf)
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create GCM")
+	}
+	return gcm, nil
+}
+
+// MemoryStore keeps the session in memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	session *types.Session
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Load returns a copy of the stored session
+func (s *MemoryStore) Load() (*types.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.session == nil {
+		return nil, types.ErrNotAuthenticated
+	}
+	session := *s.session
+	return &session, nil
+}
+
+// Save stores a copy of the session
+func (s *MemoryStore) Save(session *types.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *session
+	s.session = &c
+	return nil
+}
+
+// Delete forgets the session
+func (s *MemoryStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = nil
+	return nil
+}
+
+// SecretsDirStore keeps the session in a named file in a secrets directory,
+// such as one mounted by Docker or Kubernetes. The file may hold session JSON
+// or just a token, so a secret provisioned with a bare token can be used as is.
+type SecretsDirStore struct {
+	dir  string
+	name string
+}
+
+// NewSecretsDirStore creates a store for the secret called name in dir
+func NewSecretsDirStore(dir, name string) (*SecretsDirStore, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, errors.Errorf("invalid secret name %q", name)
+	}
+	return &SecretsDirStore{dir: dir, name: name}, nil
+}
+
+// Load reads the session, or a bare token, from the secret
+func (s *SecretsDirStore) Load() (*types.Session, error) {
+	data, err := readFile(s.path())
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return nil, types.ErrNotAuthenticated
+	}
+	if !strings.HasPrefix(content, "{") {
+		return &types.Session{Token: content}, nil
+	}
+	var session types.Session
+	if err := json.Unmarshal([]byte(content), &session); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal session")
+	}
+	return &session, nil
+}
+
+// Save writes the session JSON to the secret
+func (s *SecretsDirStore) Save(session *types.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal session")
+	}
+	return writeFile(s.path(), data)
+}
+
+// Delete removes the secret
+func (s *SecretsDirStore) Delete() error {
+	return removeFile(s.path())
+}
+
+func (s *SecretsDirStore) path() string {
+	return filepath.Join(s.dir, s.name)
+}
+
+// readFile reads a session file, returning types.ErrNotAuthenticated when it
+// does not exist
+func readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, types.ErrNotAuthenticated
+		}
+		return nil, errors.Wrap(err, "failed to read session file")
+	}
+	return data, nil
+}
+
+// writeFile replaces a session file atomically, readable only by the owner
+func writeFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "failed to create session directory")
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to write session file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write session file")
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write session file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write session file")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(err, "failed to write session file")
+	}
+	return nil
+}
+
+// removeFile deletes a session file; a missing file is not an error
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove session file")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Package importnote defines how imported transactions are marked in their
+// notes. pkg/importer writes the marks to skip what it imported before, and
+// pkg/duplicates reads them to tell statement lines apart and carries them
+// over when it merges an imported transaction into another.
+//
+// The notes of an imported transaction end with
+//
+//	[import:<fingerprint>]
+//
+// where the fingerprint is 16 lowercase hex digits hashed from the line's
+// date, amount and description. When an imported transaction is merged into
+// another and deleted, the transaction kept gets
+//
+//	[merged-import:<fingerprint>@<account ID>]
+//
+// naming the manual account the line was imported into.
+package importnote
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	markPattern   = regexp.MustCompile(`\[import:([0-9a-f]{16})\]`)
+	mergedPattern = regexp.MustCompile(`\[merged-import:([0-9a-f]{16})@([^\]\s]+)\]`)
+)
+
+// Merge is a fingerprint carried over from a deleted copy
+type Merge struct {
+	Fingerprint string
+	AccountID   string
+}
+
+// Mark returns the mark of an imported transaction
+func Mark(fingerprint string) string {
+	return fmt.Sprintf("[import:%s]", fingerprint)
+}
+
+// MergedMark returns the mark of a fingerprint carried over from a copy in
+// an account
+func MergedMark(fingerprint, accountID string) string {
+	return fmt.Sprintf("[merged-import:%s@%s]", fingerprint, accountID)
+}
+
+// Fingerprints returns the fingerprints of the import marks in notes
+func Fingerprints(notes string) []string {
+	var out []string
+	for _, m := range markPattern.FindAllStringSubmatch(notes, -1) {
+		out = append(out, m[1])
+	}
+	return out
+}
+
+// Merges returns the fingerprints carried over into notes
+func Merges(notes string) []Merge {
+	var out []Merge
+	for _, m := range mergedPattern.FindAllStringSubmatch(notes, -1) {
+		out = append(out, Merge{Fingerprint: m[1], AccountID: m[2]})
+	}
+	return out
+}
+
+// CarryOver rewrites the import marks in the notes of a transaction of an
+// account as merged marks, for the transaction it is merged into
+func CarryOver(notes, accountID string) string {
+	return strings.TrimSpace(markPattern.ReplaceAllStringFunc(notes, func(mark string) string {
+		return MergedMark(markPattern.FindStringSubmatch(mark)[1], accountID)
+	}))
+}
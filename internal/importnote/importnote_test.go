@@ -0,0 +1,20 @@
+package importnote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarks(t *testing.T) {
+	notes := "POS PURCHASE\n" + Mark("0123456789abcdef")
+	assert.Equal(t, []string{"0123456789abcdef"}, Fingerprints(notes))
+	assert.Empty(t, Merges(notes))
+
+	merged := CarryOver(notes, "acc-cash")
+	assert.Equal(t, "POS PURCHASE\n[merged-import:0123456789abcdef@acc-cash]", merged)
+	assert.Empty(t, Fingerprints(merged), "merged marks are not import marks")
+	assert.Equal(t, []Merge{{Fingerprint: "0123456789abcdef", AccountID: "acc-cash"}}, Merges(merged))
+
+	assert.Empty(t, Fingerprints("[import:short]"))
+}
@@ -0,0 +1,176 @@
+package sim
+
+import (
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// Book is the data that budget and cashflow figures are computed from. Its
+// slices are shared with the dataset it was made from, not copied.
+type Book struct {
+	Accounts       []*monarch.Account
+	Transactions   []*monarch.Transaction
+	Splits         map[string][]*monarch.TransactionSplit
+	Categories     []*monarch.TransactionCategory
+	CategoryGroups []*monarch.CategoryGroup
+	Budgets        []*monarch.Budget
+}
+
+// Account returns the account with an ID, or nil
+func (b Book) Account(id string) *monarch.Account {
+	for _, a := range b.Accounts {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// Category returns the category with an ID, or nil
+func (b Book) Category(id string) *monarch.TransactionCategory {
+	for _, c := range b.Categories {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// Group returns the category group with an ID, or nil
+func (b Book) Group(id string) *monarch.CategoryGroup {
+	for _, g := range b.CategoryGroups {
+		if g.ID == id {
+			return g
+		}
+	}
+	return nil
+}
+
+// GroupOf returns the group of a category, if it has one
+func (b Book) GroupOf(c *monarch.TransactionCategory) *monarch.CategoryGroup {
+	id := c.GroupID
+	if id == "" && c.Group != nil {
+		id = c.Group.ID
+	}
+	return b.Group(id)
+}
+
+// GroupType returns the type of the group a category belongs to
+func (b Book) GroupType(categoryID string) string {
+	if c := b.Category(categoryID); c != nil {
+		if g := b.GroupOf(c); g != nil {
+			return g.Type
+		}
+	}
+	return ""
+}
+
+// Allocation is the part of a transaction booked to one category: the whole
+// amount, or one split of a split transaction
+type Allocation struct {
+	Transaction *monarch.Transaction
+	CategoryID  string
+	Amount      float64
+}
+
+// Allocations breaks transactions down by category, leaving out those hidden
+// from reports
+func (b Book) Allocations(txns []*monarch.Transaction) []Allocation {
+	var out []Allocation
+	for _, t := range txns {
+		if t.HideFromReports {
+			continue
+		}
+		if acc := b.Account(AccountOf(t)); acc != nil && acc.HideTransactionsFromReports {
+			continue
+		}
+		if splits := b.Splits[t.ID]; len(splits) > 0 {
+			for _, split := range splits {
+				out = append(out, Allocation{Transaction: t, CategoryID: split.CategoryID, Amount: split.Amount})
+			}
+			continue
+		}
+		out = append(out, Allocation{Transaction: t, CategoryID: CategoryOf(t), Amount: t.Amount})
+	}
+	return out
+}
+
+// Cashflow tallies the income and expense allocations of transactions,
+// leaving out transfers, and calls each, when set, for every allocation
+// counted. Expense is negative.
+func (b Book) Cashflow(txns []*monarch.Transaction, each func(a Allocation, groupType string)) (income, expense float64) {
+	for _, a := range b.Allocations(txns) {
+		typ := b.GroupType(a.CategoryID)
+		switch typ {
+		case "transfer":
+			continue
+		case "income":
+			income += a.Amount
+		default:
+			expense += a.Amount
+		}
+		if each != nil {
+			each(a, typ)
+		}
+	}
+	return income, expense
+}
+
+// Budget returns the stored budget of a category for a month, or nil
+func (b Book) Budget(categoryID string, month time.Time) *monarch.Budget {
+	for _, budget := range b.Budgets {
+		if budget.CategoryID == categoryID && MonthOf(budget.StartDate).Equal(month) {
+			return budget
+		}
+	}
+	return nil
+}
+
+// Planned returns the budgeted amount of a category for a month and whether
+// it rolls over
+func (b Book) Planned(categoryID string, month time.Time) (float64, bool) {
+	if budget := b.Budget(categoryID, month); budget != nil {
+		return budget.Amount, budget.Rollover
+	}
+	return 0, false
+}
+
+// Actual sums what was booked to a category in a month
+func (b Book) Actual(categoryID string, month time.Time) float64 {
+	var total float64
+	for _, a := range b.Allocations(b.Transactions) {
+		if a.CategoryID == categoryID && MonthOf(a.Transaction.Date.Time).Equal(month) {
+			total += a.Amount
+		}
+	}
+	return Round(total)
+}
+
+// MonthlyAmount computes the budget figures of a category for a month.
+// Expense remaining is planned plus actual (actual is negative) plus any
+// rollover from last month; income remaining is what was earned beyond plan.
+func (b Book) MonthlyAmount(categoryID, groupType string, month time.Time) *monarch.BudgetMonthlyAmount {
+	planned, rollover := b.Planned(categoryID, month)
+	actual := b.Actual(categoryID, month)
+
+	amount := &monarch.BudgetMonthlyAmount{
+		Month:                 month.Format("2006-01-02"),
+		PlannedCashFlowAmount: planned,
+		ActualAmount:          actual,
+	}
+	if groupType == "income" {
+		amount.RemainingAmount = Round(actual - planned)
+		return amount
+	}
+
+	if rollover {
+		amount.RolloverType = "monthly"
+		prev := month.AddDate(0, -1, 0)
+		if prevPlanned, prevRollover := b.Planned(categoryID, prev); prevRollover {
+			amount.PreviousMonthRolloverAmount = Round(prevPlanned + b.Actual(categoryID, prev))
+		}
+	}
+	amount.RemainingAmount = Round(planned + actual + amount.PreviousMonthRolloverAmount)
+	return amount
+}
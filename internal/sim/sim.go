@@ -0,0 +1,126 @@
+// Package sim holds the domain logic shared by the monarchtest server and
+// the monarchfake services, so that both compute balances, budgets,
+// cashflow and recurring occurrences the same way.
+package sim
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// Round rounds an amount to cents
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// MonthOf returns the first day of a date's month
+func MonthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Occurrences lists the dates of a recurring stream that fall between start
+// and end. Streams with an unknown frequency occur once, on next.
+func Occurrences(next time.Time, frequency string, start, end time.Time) []time.Time {
+	var step func(t time.Time, n int) time.Time
+	switch frequency {
+	case "weekly":
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, 0, 7*n) }
+	case "biweekly":
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, 0, 14*n) }
+	case "monthly":
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, n, 0) }
+	case "quarterly":
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, 3*n, 0) }
+	case "yearly", "annually":
+		step = func(t time.Time, n int) time.Time { return t.AddDate(n, 0, 0) }
+	default:
+		if next.Before(start) || next.After(end) {
+			return nil
+		}
+		return []time.Time{next}
+	}
+
+	// Step from next so every date stays on the stream's schedule
+	n := 0
+	for step(next, n).After(start) {
+		n--
+	}
+	var dates []time.Time
+	for d := step(next, n); !d.After(end); n, d = n+1, step(next, n+1) {
+		if !d.Before(start) {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// ByAmount orders the keys of a total by amount, largest magnitude first
+// and then by key, keeping at most limit when it is positive
+func ByAmount(totals map[string]float64, limit int) []string {
+	keys := make([]string, 0, len(totals))
+	for k := range totals {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return math.Abs(totals[keys[i]]) > math.Abs(totals[keys[j]])
+	})
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
+// SetDisplayBalance sets an account's balance from its display balance,
+// which is positive for liabilities
+func SetDisplayBalance(acc *monarch.Account, balance float64) {
+	acc.DisplayBalance = balance
+	acc.CurrentBalance = balance
+	if !acc.IsAsset {
+		acc.CurrentBalance = -balance
+	}
+}
+
+// TypeName returns the name of an account's type, or ""
+func TypeName(acc *monarch.Account) string {
+	if acc.Type == nil {
+		return ""
+	}
+	return acc.Type.Name
+}
+
+// SubtypeName returns the name of an account's subtype, or ""
+func SubtypeName(acc *monarch.Account) string {
+	if acc.Subtype == nil {
+		return ""
+	}
+	return acc.Subtype.Name
+}
+
+// AccountOf returns the account a transaction references
+func AccountOf(t *monarch.Transaction) string {
+	if t.Account == nil {
+		return ""
+	}
+	return t.Account.ID
+}
+
+// CategoryOf returns the category a transaction references
+func CategoryOf(t *monarch.Transaction) string {
+	if t.Category == nil {
+		return ""
+	}
+	return t.Category.ID
+}
+
+// Set returns the values as a set
+func Set(values []string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
@@ -0,0 +1,25 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOccurrences(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+
+	assert.Equal(t, []time.Time{day(1, 15), day(2, 15), day(3, 15)},
+		Occurrences(day(2, 15), "monthly", day(1, 1), day(3, 31)))
+	assert.Equal(t, []time.Time{day(1, 3), day(1, 17), day(1, 31)},
+		Occurrences(day(1, 17), "biweekly", day(1, 1), day(1, 31)))
+	assert.Nil(t, Occurrences(day(5, 1), "unknown", day(1, 1), day(3, 31)))
+}
+
+func TestByAmount(t *testing.T) {
+	totals := map[string]float64{"a": -5, "b": 20, "c": -20, "d": 1}
+
+	assert.Equal(t, []string{"b", "c", "a", "d"}, ByAmount(totals, 0))
+	assert.Equal(t, []string{"b", "c"}, ByAmount(totals, 2))
+}
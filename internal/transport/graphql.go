@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/eshaffer321/monarchmoney-go/internal/types"
@@ -30,9 +32,12 @@ type GraphQLTransport struct {
 	httpClient  *http.Client
 	retryClient *retryablehttp.Client
 	headers     map[string]string
-	session     *types.Session
 	logger      types.Logger
 	hooks       *types.Hooks
+
+	// mu guards session, which may be replaced while requests are running
+	mu      sync.RWMutex
+	session *types.Session
 }
 
 // GraphQLRequest represents a GraphQL request
@@ -106,13 +111,17 @@ func NewGraphQLTransport(opts *Options) *GraphQLTransport {
 
 // Execute executes a GraphQL query
 func (t *GraphQLTransport) Execute(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	t.mu.RLock()
+	session := t.session
+	t.mu.RUnlock()
+
 	// Check authentication
-	if t.session == nil || t.session.Token == "" {
+	if session == nil || session.Token == "" {
 		return types.ErrNotAuthenticated
 	}
 
 	// Check session expiry
-	if !t.session.ExpiresAt.IsZero() && time.Now().After(t.session.ExpiresAt) {
+	if !session.ExpiresAt.IsZero() && time.Now().After(session.ExpiresAt) {
 		return types.ErrSessionExpired
 	}
 
@@ -152,13 +161,11 @@ func (t *GraphQLTransport) Execute(ctx context.Context, query string, variables
 	}
 
 	// Set auth header
-	if t.session != nil && t.session.Token != "" {
-		httpReq.Header.Set(authHeaderKey, fmt.Sprintf("Token %s", t.session.Token))
-	}
+	httpReq.Header.Set(authHeaderKey, fmt.Sprintf("Token %s", session.Token))
 
 	// Add device UUID if in session
-	if t.session != nil && t.session.DeviceUUID != "" {
-		httpReq.Header.Set("device-uuid", t.session.DeviceUUID)
+	if session.DeviceUUID != "" {
+		httpReq.Header.Set("device-uuid", session.DeviceUUID)
 	}
 
 	// Call request hook
@@ -171,9 +178,10 @@ func (t *GraphQLTransport) Execute(ctx context.Context, query string, variables
 		t.logger.Debug("GraphQL request", "query", truncateQuery(query), "variables", variables)
 	}
 
-	// Execute request
+	// Execute request. Mutations are sent once: retrying a request whose
+	// response was lost could apply the mutation twice.
 	start := time.Now()
-	resp, err := t.doRequest(httpReq)
+	resp, err := t.doRequest(httpReq, isMutation(query))
 	duration := time.Since(start)
 
 	if err != nil {
@@ -202,7 +210,11 @@ func (t *GraphQLTransport) Execute(ctx context.Context, query string, variables
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return t.handleHTTPError(resp.StatusCode, respBody)
+		err := t.handleHTTPError(resp.StatusCode, respBody)
+		if apiErr, ok := err.(*types.Error); ok {
+			apiErr.RetryAfter = ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return err
 	}
 
 	// Parse response
@@ -228,20 +240,27 @@ func (t *GraphQLTransport) Execute(ctx context.Context, query string, variables
 
 // SetAuth sets the authentication token
 func (t *GraphQLTransport) SetAuth(token string) {
-	if t.session == nil {
-		t.session = &types.Session{}
-	}
-	t.session.Token = token
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Copy rather than modify the session, which a request may be reading
+	session := &types.Session{}
+	if t.session != nil {
+		*session = *t.session
+	}
+	session.Token = token
+	t.session = session
 }
 
 // SetSession sets the session
 func (t *GraphQLTransport) SetSession(session *types.Session) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.session = session
 }
 
 // doRequest executes the HTTP request with retry if configured
-func (t *GraphQLTransport) doRequest(req *http.Request) (*http.Response, error) {
-	if t.retryClient != nil {
+func (t *GraphQLTransport) doRequest(req *http.Request, mutation bool) (*http.Response, error) {
+	if t.retryClient != nil && !mutation {
 		// Convert to retryable request
 		retryReq, err := retryablehttp.FromRequest(req)
 		if err != nil {
@@ -275,7 +294,12 @@ func (t *GraphQLTransport) handleHTTPError(statusCode int, body []byte) error {
 	case http.StatusNotFound:
 		return types.ErrNotFound
 	case http.StatusTooManyRequests:
-		return types.ErrRateLimited
+		return &types.Error{
+			Code:       "RATE_LIMITED",
+			Message:    "rate limited",
+			StatusCode: statusCode,
+			Err:        types.ErrRateLimited,
+		}
 	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
 		return types.ErrTimeout
 	case http.StatusBadRequest:
@@ -351,6 +375,29 @@ func httpStatusDescription(statusCode int) string {
 	return descriptions[statusCode]
 }
 
+// isMutation reports whether a GraphQL document is a mutation
+func isMutation(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "mutation")
+}
+
+// ParseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date, returning zero when it is missing or invalid
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
 // truncateQuery truncates long queries for logging
 func truncateQuery(query string) string {
 	const maxLen = 100
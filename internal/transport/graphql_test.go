@@ -3,8 +3,10 @@ package transport
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -247,3 +249,54 @@ func TestSetSession(t *testing.T) {
 	transport.SetSession(session)
 	assert.Equal(t, session, transport.session)
 }
+
+func TestExecute_RetryConfigSkipsMutations(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	transport := NewGraphQLTransport(&Options{
+		BaseURL:     server.URL,
+		RetryConfig: &types.RetryConfig{MaxRetries: 2, RetryWait: time.Millisecond, MaxWait: time.Millisecond},
+	})
+	transport.SetAuth("test-token")
+
+	err := transport.Execute(context.Background(), "mutation Create { create { id } }", nil, nil)
+	assert.ErrorIs(t, err, types.ErrServerError)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "a mutation is sent once")
+
+	atomic.StoreInt32(&calls, 0)
+	_ = transport.Execute(context.Background(), "query Get { get { id } }", nil, nil)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls), "a query is retried")
+}
+
+func TestExecute_RateLimitedCarriesRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transport := NewGraphQLTransport(&Options{BaseURL: server.URL})
+	transport.SetAuth("test-token")
+
+	err := transport.Execute(context.Background(), "query Get { get { id } }", nil, nil)
+	assert.ErrorIs(t, err, types.ErrRateLimited)
+	var apiErr *types.Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, 7*time.Second, apiErr.RetryAfter)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 30*time.Second, ParseRetryAfter("30", now))
+	assert.Equal(t, 2*time.Minute, ParseRetryAfter(now.Add(2*time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, ParseRetryAfter("", now))
+	assert.Zero(t, ParseRetryAfter("-5", now))
+	assert.Zero(t, ParseRetryAfter("soon", now))
+	assert.Zero(t, ParseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
@@ -1,6 +1,10 @@
 package types
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // Error represents an API error
 type Error struct {
@@ -10,6 +14,9 @@ type Error struct {
 	Details    map[string]interface{} `json:"details,omitempty"`
 	RequestID  string                 `json:"requestId,omitempty"`
 	Err        error                  `json:"-"`
+
+	// RetryAfter is the server's Retry-After, when it sent one
+	RetryAfter time.Duration `json:"-"`
 }
 
 func (e *Error) Error() string {
@@ -22,6 +29,11 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("error: %s", e.Code)
 }
 
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 // GraphQLError represents a GraphQL error
 type GraphQLError struct {
 	Message    string                 `json:"message"`
@@ -41,3 +53,63 @@ func (e *GraphQLErrors) Error() string {
 	}
 	return e.Errors[0].Message
 }
+
+// ChallengeKind identifies the second factor a login asks for
+type ChallengeKind string
+
+const (
+	// ChallengeMFA asks for a code from an authenticator app
+	ChallengeMFA ChallengeKind = "mfa"
+
+	// ChallengeEmailOTP asks for a one-time code sent by email
+	ChallengeEmailOTP ChallengeKind = "email_otp"
+)
+
+// Challenge describes a second factor requested during login
+type Challenge struct {
+	Kind  ChallengeKind
+	Email string
+}
+
+// MFARequiredError is returned when a login needs an authenticator code.
+// It matches ErrMFARequired.
+type MFARequiredError struct {
+	Email string
+}
+
+func (e *MFARequiredError) Error() string {
+	return "MFA required"
+}
+
+// Is reports whether target is ErrMFARequired
+func (e *MFARequiredError) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// EmailOTPRequiredError is returned when a login needs the one-time code
+// Monarch has just emailed. It matches ErrMFARequired.
+type EmailOTPRequiredError struct {
+	Email string
+}
+
+func (e *EmailOTPRequiredError) Error() string {
+	return "Email OTP required"
+}
+
+// Is reports whether target is ErrMFARequired
+func (e *EmailOTPRequiredError) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// ChallengeHandler returns the code for a login challenge
+type ChallengeHandler interface {
+	Code(ctx context.Context, challenge Challenge) (string, error)
+}
+
+// ChallengeHandlerFunc adapts a function to a ChallengeHandler
+type ChallengeHandlerFunc func(ctx context.Context, challenge Challenge) (string, error)
+
+// Code calls f
+func (f ChallengeHandlerFunc) Code(ctx context.Context, challenge Challenge) (string, error) {
+	return f(ctx, challenge)
+}
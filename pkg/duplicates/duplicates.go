@@ -0,0 +1,260 @@
+// Package duplicates finds transactions that were recorded twice, such as a
+// manual entry that a synced transaction later duplicated, or statement
+// imports that overlap, and merges each pair into one transaction.
+//
+//	pairs, err := duplicates.Scan(ctx, client, client.Transactions.Query().Between(start, end), nil)
+//	...
+//	for _, p := range pairs {
+//		fmt.Printf("%.2f: keep %s, remove %s\n", p.Score, p.Keep.ID, p.Remove.ID)
+//		err := duplicates.Resolve(ctx, client, p)
+//		...
+//	}
+//
+// Two transactions are candidates when they have the same amount, are dated
+// at most Options.Days apart, and have similar merchant or original names.
+// Two imported transactions are only candidates when they were imported from
+// the same statement line into the same account; otherwise both are real.
+package duplicates
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/importnote"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/pkg/errors"
+)
+
+// Options configures duplicate detection
+type Options struct {
+	// Days is how far apart the dates of duplicates may be (default 3).
+	// Manual entries are often dated when the purchase was made and synced
+	// ones when it posted.
+	Days int
+
+	// MinScore is the lowest score reported, from 0 to 1 (default 0.6)
+	MinScore float64
+
+	// IncludeSynced also pairs transactions that both came from a data
+	// provider. By default one of each pair must look manual, since two
+	// synced charges of the same amount are usually real.
+	IncludeSynced bool
+}
+
+// withDefaults returns a copy of the options with zero values defaulted
+func (o *Options) withDefaults() Options {
+	var out Options
+	if o != nil {
+		out = *o
+	}
+	if out.Days <= 0 {
+		out.Days = 3
+	}
+	if out.MinScore <= 0 {
+		out.MinScore = 0.6
+	}
+	return out
+}
+
+// Pair is a likely duplicate. Keep is the transaction to keep: the synced
+// one if only one of them is synced, otherwise the one created first.
+type Pair struct {
+	Keep   *monarch.Transaction `json:"keep"`
+	Remove *monarch.Transaction `json:"remove"`
+
+	// Score weighs name similarity and date distance, from 0 to 1
+	Score float64 `json:"score"`
+
+	// Similarity of the names, from 0 to 1
+	Similarity float64 `json:"similarity"`
+
+	// Days between the two dates
+	Days int `json:"days"`
+}
+
+// Scan streams the transactions of a query and finds the duplicates among
+// them. Accounts are listed to tell manual accounts from synced ones.
+func Scan(ctx context.Context, client *monarch.Client, query monarch.TransactionQueryBuilder, opts *Options) ([]*Pair, error) {
+	accounts, err := client.Accounts.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list accounts")
+	}
+
+	txns, errs := query.Stream(ctx)
+	var all []*monarch.Transaction
+	for t := range txns {
+		all = append(all, t)
+	}
+	if err := <-errs; err != nil {
+		return nil, errors.Wrap(err, "failed to stream transactions")
+	}
+	return Find(all, accounts, opts), nil
+}
+
+// Find returns the likely duplicates among transactions, best first. Each
+// transaction is in at most one pair, the one with its best score. Accounts
+// tell manual accounts from synced ones; transactions of accounts not listed
+// are judged by their notes and original name alone.
+func Find(transactions []*monarch.Transaction, accounts []*monarch.Account, opts *Options) []*Pair {
+	o := opts.withDefaults()
+	manualAccounts := make(map[string]bool)
+	for _, a := range accounts {
+		if a.IsManual {
+			manualAccounts[a.ID] = true
+		}
+	}
+	manual := func(t *monarch.Transaction) bool {
+		return (t.Account != nil && manualAccounts[t.Account.ID]) ||
+			len(importnote.Fingerprints(t.Notes)) > 0 || t.PlaidName == ""
+	}
+
+	// Only transactions of the same amount can match
+	byAmount := make(map[int64][]*monarch.Transaction)
+	for _, t := range transactions {
+		if t.IsSplitTransaction {
+			continue
+		}
+		cents := int64(math.Round(t.Amount * 100))
+		byAmount[cents] = append(byAmount[cents], t)
+	}
+
+	var candidates []*Pair
+	for _, group := range byAmount {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Date.Before(group[j].Date.Time) })
+		for i, a := range group {
+			for _, b := range group[i+1:] {
+				days := int(math.Round(b.Date.Sub(a.Date.Time).Hours() / 24))
+				if days > o.Days {
+					break
+				}
+				aManual, bManual := manual(a), manual(b)
+				if !aManual && !bManual && !o.IncludeSynced {
+					continue
+				}
+				if distinctLines(a, b) {
+					continue
+				}
+
+				similarity := nameSimilarity(a, b)
+				score := 0.7*similarity + 0.3*(1-float64(days)/float64(o.Days+1))
+				if score < o.MinScore {
+					continue
+				}
+
+				keep, remove := a, b
+				switch {
+				case aManual && !bManual:
+					keep, remove = b, a
+				case aManual == bManual && b.CreatedAt.Before(a.CreatedAt.Time):
+					keep, remove = b, a
+				}
+				candidates = append(candidates, &Pair{
+					Keep: keep, Remove: remove,
+					Score: round(score), Similarity: round(similarity), Days: days,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Keep.Date.After(candidates[j].Keep.Date.Time)
+	})
+	used := make(map[string]bool)
+	var pairs []*Pair
+	for _, p := range candidates {
+		if used[p.Keep.ID] || used[p.Remove.ID] {
+			continue
+		}
+		used[p.Keep.ID], used[p.Remove.ID] = true, true
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+// Resolve merges a pair: the notes, tags and category of Remove are carried
+// over to Keep, and then Remove is deleted. The category is carried over
+// unless Remove is uncategorized, and an import mark becomes a merged mark,
+// so the importer does not import the line again. Resolving again after a
+// failure is safe.
+func Resolve(ctx context.Context, client *monarch.Client, pair *Pair) error {
+	keep, remove := pair.Keep, pair.Remove
+	params := &monarch.UpdateTransactionParams{}
+
+	removed := remove.Notes
+	if remove.Account != nil {
+		removed = importnote.CarryOver(removed, remove.Account.ID)
+	}
+	if notes := mergeNotes(keep.Notes, removed); notes != keep.Notes {
+		params.Notes = &notes
+	}
+	if c := remove.Category; c != nil && c.ID != "" && !strings.EqualFold(c.Name, "Uncategorized") &&
+		(keep.Category == nil || keep.Category.ID != c.ID) {
+		params.CategoryID = &c.ID
+	}
+	if *params != (monarch.UpdateTransactionParams{}) {
+		if _, err := client.Transactions.Update(ctx, keep.ID, params); err != nil {
+			return errors.Wrapf(err, "failed to update transaction %s", keep.ID)
+		}
+	}
+
+	tagIDs := make([]string, 0, len(keep.Tags)+len(remove.Tags))
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]*monarch.Tag(nil), keep.Tags...), remove.Tags...) {
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tagIDs = append(tagIDs, tag.ID)
+		}
+	}
+	if len(tagIDs) > len(keep.Tags) {
+		if err := client.Tags.SetTransactionTags(ctx, keep.ID, tagIDs...); err != nil {
+			return errors.Wrapf(err, "failed to set tags of transaction %s", keep.ID)
+		}
+	}
+
+	if err := client.Transactions.Delete(ctx, remove.ID); err != nil {
+		return errors.Wrapf(err, "failed to delete transaction %s", remove.ID)
+	}
+	return nil
+}
+
+// distinctLines reports whether two transactions were both imported, from
+// different statement lines or into different accounts
+func distinctLines(a, b *monarch.Transaction) bool {
+	aLines, bLines := importnote.Fingerprints(a.Notes), importnote.Fingerprints(b.Notes)
+	if len(aLines) == 0 || len(bLines) == 0 {
+		return false
+	}
+	if a.Account == nil || b.Account == nil || a.Account.ID != b.Account.ID {
+		return true
+	}
+	for _, x := range aLines {
+		for _, y := range bLines {
+			if x == y {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// mergeNotes appends notes to existing unless they are already there
+func mergeNotes(existing, notes string) string {
+	notes = strings.TrimSpace(notes)
+	switch {
+	case notes == "" || strings.Contains(existing, notes):
+		return existing
+	case strings.TrimSpace(existing) == "":
+		return notes
+	default:
+		return existing + "\n" + notes
+	}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,233 @@
+package duplicates
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/importer"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchfake"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) monarch.Date {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return monarch.Date{Time: d}
+}
+
+func txn(id, account, date string, amount float64, merchant, plaidName string) *monarch.Transaction {
+	return &monarch.Transaction{
+		ID: id, Date: day(date), Amount: amount, PlaidName: plaidName,
+		Merchant:  &monarch.Merchant{Name: merchant},
+		Account:   &monarch.Account{ID: account},
+		Category:  &monarch.TransactionCategory{ID: "cat-uncategorized", Name: "Uncategorized"},
+		CreatedAt: day(date),
+	}
+}
+
+var testAccounts = []*monarch.Account{
+	{ID: "acc-checking", DisplayName: "Checking", IsAsset: true},
+	{ID: "acc-cash", DisplayName: "Cash", IsAsset: true, IsManual: true},
+}
+
+func TestFind(t *testing.T) {
+	manual := txn("txn-manual", "acc-cash", "2026-10-01", -42.5, "Corner Market", "")
+	synced := txn("txn-synced", "acc-checking", "2026-10-03", -42.5, "Corner Market", "CORNER MARKET #12 SEATTLE")
+
+	// Two synced coffees of the same amount are both real
+	coffee1 := txn("txn-coffee-1", "acc-checking", "2026-10-02", -4.5, "Starbucks", "STARBUCKS")
+	coffee2 := txn("txn-coffee-2", "acc-checking", "2026-10-02", -4.5, "Starbucks", "STARBUCKS")
+
+	// Overlapping imports: both manual, the later import is removed
+	first := txn("txn-import-1", "acc-cash", "2026-10-05", -15, "PHARMACY 22", "")
+	first.Notes = "[import:0123456789abcdef]"
+	second := txn("txn-import-2", "acc-cash", "2026-10-05", -15, "Pharmacy", "")
+	second.CreatedAt = day("2026-10-10")
+
+	tooFar := txn("txn-far", "acc-cash", "2026-10-09", -42.5, "Corner Market", "")
+	other := txn("txn-other", "acc-cash", "2026-10-02", -42.5, "Gas Station", "")
+	split := txn("txn-split", "acc-cash", "2026-10-03", -42.5, "Corner Market", "")
+	split.IsSplitTransaction = true
+
+	pairs := Find([]*monarch.Transaction{second, coffee1, synced, tooFar, coffee2, first, other, split, manual}, testAccounts, nil)
+	require.Len(t, pairs, 2)
+
+	assert.Equal(t, "txn-import-1", pairs[0].Keep.ID)
+	assert.Equal(t, "txn-import-2", pairs[0].Remove.ID)
+	assert.Equal(t, 0, pairs[0].Days)
+
+	assert.Equal(t, "txn-synced", pairs[1].Keep.ID, "the synced transaction survives")
+	assert.Equal(t, "txn-manual", pairs[1].Remove.ID)
+	assert.Equal(t, 2, pairs[1].Days)
+	assert.Equal(t, 1.0, pairs[1].Similarity)
+	assert.Equal(t, 0.85, pairs[1].Score)
+
+	pairs = Find([]*monarch.Transaction{coffee1, coffee2}, testAccounts, &Options{IncludeSynced: true})
+	require.Len(t, pairs, 1)
+	assert.Equal(t, 1.0, pairs[0].Score)
+
+	pairs = Find([]*monarch.Transaction{manual, tooFar}, testAccounts, &Options{Days: 10, MinScore: 0.9})
+	assert.Empty(t, pairs, "eight days apart scores below 0.9")
+}
+
+func TestFind_DistinctImports(t *testing.T) {
+	group := &monarch.CategoryGroup{ID: "grp", Name: "Spending", Type: "expense"}
+	fake := monarchfake.New(&monarchtest.Dataset{
+		Accounts:       testAccounts,
+		CategoryGroups: []*monarch.CategoryGroup{group},
+		Categories: []*monarch.TransactionCategory{
+			{ID: "cat-uncategorized", Name: "Uncategorized", Group: group, GroupID: group.ID},
+		},
+	})
+	ctx := context.Background()
+	client := fake.Client()
+
+	// Two coffees on one statement are two purchases
+	coffee := &importer.Record{Date: day("2026-10-02").Time, Amount: -4.5, Description: "Coffee"}
+	again := *coffee
+	result, err := importer.Import(ctx, client, []*importer.Record{coffee, &again}, &importer.Options{AccountID: "acc-cash"})
+	require.NoError(t, err)
+	require.Len(t, result.Created, 2)
+
+	pairs, err := Scan(ctx, client, client.Transactions.Query(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, pairs)
+
+	// The same line imported twice is a duplicate
+	list, err := client.Transactions.Query().Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, list.Transactions, 2)
+	copied := txn("txn-copy", "acc-cash", "2026-10-02", -4.5, "Coffee", "")
+	copied.Notes = list.Transactions[0].Notes
+	copied.CreatedAt = day("2026-10-20")
+	pairs = Find(append(list.Transactions, copied), testAccounts, nil)
+	require.Len(t, pairs, 1)
+	assert.Equal(t, list.Transactions[0].ID, pairs[0].Keep.ID)
+	assert.Equal(t, "txn-copy", pairs[0].Remove.ID)
+
+	// The same line imported into another account is a charge of its own
+	copied.Account = &monarch.Account{ID: "acc-store-card"}
+	pairs = Find(append(list.Transactions, copied), testAccounts, nil)
+	assert.Empty(t, pairs)
+}
+
+func TestScanAndResolve(t *testing.T) {
+	manual := txn("txn-manual", "acc-cash", "2026-10-01", -42.5, "Corner Market", "")
+	manual.Notes = "split with Sam"
+	manual.Category = &monarch.TransactionCategory{ID: "cat-groceries"}
+	manual.Tags = []*monarch.Tag{{ID: "tag-shared"}}
+	synced := txn("txn-synced", "acc-checking", "2026-10-02", -42.5, "Corner Market", "CORNER MARKET #12")
+	synced.Notes = "receipt in drive"
+	synced.Tags = []*monarch.Tag{{ID: "tag-food"}}
+
+	group := &monarch.CategoryGroup{ID: "grp", Name: "Spending", Type: "expense"}
+	fake := monarchfake.New(&monarchtest.Dataset{
+		Accounts:       testAccounts,
+		CategoryGroups: []*monarch.CategoryGroup{group},
+		Categories: []*monarch.TransactionCategory{
+			{ID: "cat-uncategorized", Name: "Uncategorized", Group: group, GroupID: group.ID},
+			{ID: "cat-groceries", Name: "Groceries", Group: group, GroupID: group.ID},
+		},
+		Tags:         []*monarch.Tag{{ID: "tag-shared", Name: "Shared"}, {ID: "tag-food", Name: "Food"}},
+		Transactions: []*monarch.Transaction{manual, synced},
+	})
+	fake.SetNow(func() time.Time { return time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC) })
+	ctx := context.Background()
+	client := fake.Client()
+
+	pairs, err := Scan(ctx, client, client.Transactions.Query(), nil)
+	require.NoError(t, err)
+	require.Len(t, pairs, 1)
+	require.Equal(t, "txn-synced", pairs[0].Keep.ID)
+
+	require.NoError(t, Resolve(ctx, client, pairs[0]))
+
+	_, err = client.Transactions.Get(ctx, "txn-manual")
+	assert.Error(t, err, "the manual copy is deleted")
+	details, err := client.Transactions.Get(ctx, "txn-synced")
+	require.NoError(t, err)
+	kept := details.Transaction
+	assert.Equal(t, "receipt in drive\nsplit with Sam", kept.Notes)
+	assert.Equal(t, "cat-groceries", kept.Category.ID)
+	var tags []string
+	for _, tag := range kept.Tags {
+		tags = append(tags, tag.ID)
+	}
+	assert.ElementsMatch(t, []string{"tag-food", "tag-shared"}, tags)
+
+	pairs, err = Scan(ctx, client, client.Transactions.Query(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, pairs)
+}
+
+func TestResolve_ImportedCopyIsNotImportedAgain(t *testing.T) {
+	group := &monarch.CategoryGroup{ID: "grp", Name: "Spending", Type: "expense"}
+	fake := monarchfake.New(&monarchtest.Dataset{
+		Accounts:       testAccounts,
+		CategoryGroups: []*monarch.CategoryGroup{group},
+		Categories: []*monarch.TransactionCategory{
+			{ID: "cat-uncategorized", Name: "Uncategorized", Group: group, GroupID: group.ID},
+		},
+		Transactions: []*monarch.Transaction{
+			txn("txn-synced", "acc-checking", "2026-10-04", -42.5, "Corner Market", "CORNER MARKET #12"),
+		},
+	})
+	ctx := context.Background()
+	client := fake.Client()
+
+	statement := []*importer.Record{{Date: day("2026-10-01").Time, Amount: -42.5, Description: "Corner Market"}}
+	opts := &importer.Options{AccountID: "acc-cash"}
+	_, err := importer.Import(ctx, client, statement, opts)
+	require.NoError(t, err)
+
+	pairs, err := Scan(ctx, client, client.Transactions.Query(), nil)
+	require.NoError(t, err)
+	require.Len(t, pairs, 1)
+	require.Equal(t, "txn-synced", pairs[0].Keep.ID)
+	require.NoError(t, Resolve(ctx, client, pairs[0]))
+
+	details, err := client.Transactions.Get(ctx, "txn-synced")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\[merged-import:[0-9a-f]{16}@acc-cash\]$`, details.Transaction.Notes)
+	pairs, err = Scan(ctx, client, client.Transactions.Query(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, pairs, "the synced transaction does not look imported")
+
+	// The merged mark tells the importer the line is already there
+	result, err := importer.Import(ctx, client, statement, opts)
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Len(t, result.Skipped, 1)
+}
+
+func TestResolve_UncategorizedCopy(t *testing.T) {
+	group := &monarch.CategoryGroup{ID: "grp", Name: "Spending", Type: "expense"}
+	fake := monarchfake.New(&monarchtest.Dataset{
+		Accounts:       testAccounts,
+		CategoryGroups: []*monarch.CategoryGroup{group},
+		Categories: []*monarch.TransactionCategory{
+			{ID: "cat-uncategorized", Name: "Uncategorized", Group: group, GroupID: group.ID},
+		},
+		Transactions: []*monarch.Transaction{
+			txn("txn-manual", "acc-cash", "2026-10-01", -5, "Cafe", ""),
+			txn("txn-synced", "acc-checking", "2026-10-01", -5, "Cafe", "CAFE"),
+		},
+	})
+	ctx := context.Background()
+	list, err := fake.Client().Transactions.Query().Execute(ctx)
+	require.NoError(t, err)
+	pairs := Find(list.Transactions, testAccounts, nil)
+	require.Len(t, pairs, 1)
+	pairs[0].Keep.Category = &monarch.TransactionCategory{ID: "cat-coffee", Name: "Coffee"}
+
+	require.NoError(t, Resolve(ctx, fake.Client(), pairs[0]))
+	assert.NotContains(t, fake.Calls(), "Transactions.Update", "nothing to carry over")
+	assert.NotContains(t, fake.Calls(), "Tags.SetTransactionTags")
+	assert.Contains(t, fake.Calls(), "Transactions.Delete")
+}
@@ -0,0 +1,108 @@
+package duplicates
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// noiseWords appear in bank descriptions without telling merchants apart
+var noiseWords = map[string]bool{
+	"pos": true, "purchase": true, "debit": true, "credit": true, "card": true, "ach": true,
+	"sq": true, "tst": true, "www": true, "com": true, "inc": true, "llc": true, "the": true,
+}
+
+// nameSimilarity compares the merchant and original names of two
+// transactions, returning the best match between any two of them
+func nameSimilarity(a, b *monarch.Transaction) float64 {
+	best := 0.0
+	for _, x := range names(a) {
+		for _, y := range names(b) {
+			if s := similarity(x, y); s > best {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+func names(t *monarch.Transaction) []string {
+	var out []string
+	if t.Merchant != nil && t.Merchant.Name != "" {
+		out = append(out, normalize(t.Merchant.Name))
+	}
+	if t.PlaidName != "" {
+		out = append(out, normalize(t.PlaidName))
+	}
+	return out
+}
+
+// normalize lowercases a name and keeps only its words, dropping store
+// numbers, punctuation and noise words: "SQ *BLUE BOTTLE #12" is
+// "blue bottle"
+func normalize(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	kept := words[:0]
+	for _, w := range words {
+		w = strings.ReplaceAll(w, "'", "")
+		if w != "" && !noiseWords[w] {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// similarity scores two normalized names from 0 to 1 with the Dice
+// coefficient of their letter pairs. A name that starts the other, like
+// "costco" and "costco wholesale", scores at least 0.9.
+func similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	score := 0.0
+	short, long := a, b
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) >= 4 && strings.HasPrefix(long, short+" ") {
+		score = 0.9
+	}
+
+	pairs := make(map[string]int)
+	for _, p := range bigrams(a) {
+		pairs[p]++
+	}
+	bPairs := bigrams(b)
+	shared := 0
+	for _, p := range bPairs {
+		if pairs[p] > 0 {
+			pairs[p]--
+			shared++
+		}
+	}
+	if total := len(bigrams(a)) + len(bPairs); total > 0 {
+		if dice := 2 * float64(shared) / float64(total); dice > score {
+			score = dice
+		}
+	}
+	return score
+}
+
+// bigrams returns the letter pairs of each word
+func bigrams(s string) []string {
+	var out []string
+	for _, w := range strings.Fields(s) {
+		r := []rune(w)
+		for i := 0; i+1 < len(r); i++ {
+			out = append(out, string(r[i:i+2]))
+		}
+	}
+	return out
+}
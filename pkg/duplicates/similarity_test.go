@@ -0,0 +1,26 @@
+package duplicates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"SQ *BLUE BOTTLE #12":        "blue bottle",
+		"Trader Joe's":               "trader joes",
+		"POS PURCHASE COSTCO WHSE 4": "costco whse",
+		"1234":                       "",
+	} {
+		assert.Equal(t, want, normalize(in), in)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, similarity("blue bottle", "blue bottle"))
+	assert.Equal(t, 0.9, similarity("costco", "costco wholesale"))
+	assert.Equal(t, 0.0, similarity("", "costco"))
+	assert.Greater(t, similarity("trader joes", "trader joe"), 0.8)
+	assert.Less(t, similarity("shell", "starbucks"), 0.3)
+}
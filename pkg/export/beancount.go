@@ -0,0 +1,114 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// WriteBeancount writes the journal in Beancount syntax. Accounts are
+// opened on the first date of the journal; tags, Monarch IDs and split
+// details become metadata. Balance assertions are dated the day after the
+// balance they check, since Beancount checks balances at the start of a day.
+func (j *Journal) WriteBeancount(w io.Writer) error {
+	entries, accounts := j.journalEntries()
+	currency := j.currency()
+
+	var b strings.Builder
+	b.WriteString("; Exported from Monarch Money\n")
+	fmt.Fprintf(&b, "option \"operating_currency\" %s\n", beancountString(currency))
+
+	if len(entries) > 0 {
+		b.WriteString("\n")
+		opened := entries[0].date.Format("2006-01-02")
+		for _, a := range accounts {
+			fmt.Fprintf(&b, "%s open %s %s\n", opened, beancountAccount(a), currency)
+		}
+	}
+
+	for _, e := range entries {
+		b.WriteString("\n")
+		if e.kind == entryAssertion {
+			for _, p := range e.postings {
+				fmt.Fprintf(&b, "%s balance %s  %s\n", e.date.AddDate(0, 0, 1).Format("2006-01-02"),
+					beancountAccount(p.account), formatAmount(p.balance, currency))
+			}
+			continue
+		}
+
+		flag := "*"
+		if e.pending {
+			flag = "!"
+		}
+		fmt.Fprintf(&b, "%s %s %s", e.date.Format("2006-01-02"), flag, beancountString(e.payee))
+		if e.narration != "" {
+			b.WriteString(" " + beancountString(e.narration))
+		}
+		b.WriteString("\n")
+		for _, m := range e.meta {
+			fmt.Fprintf(&b, "  %s: %s\n", m[0], beancountString(m[1]))
+		}
+		for _, p := range e.postings {
+			fmt.Fprintf(&b, "  %s  %s\n", beancountAccount(p.account), formatAmount(p.amount, currency))
+			for _, m := range p.meta {
+				fmt.Fprintf(&b, "    %s: %s\n", m[0], beancountString(m[1]))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err, "failed to write beancount")
+}
+
+// beancountAccount joins account components, each reduced to the letters,
+// digits and dashes Beancount allows and starting with a capital or digit
+func beancountAccount(components []string) string {
+	parts := make([]string, len(components))
+	for i, c := range components {
+		parts[i] = beancountComponent(c)
+	}
+	return strings.Join(parts, ":")
+}
+
+func beancountComponent(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		case r == '\'' || r == '’':
+			// "Joe's" reads better as "Joes" than "Joe-s"
+		default:
+			dash = true
+		}
+	}
+
+	out := b.String()
+	if out == "" {
+		return "Unknown"
+	}
+	first, size := utf8.DecodeRuneInString(out)
+	if unicode.IsUpper(first) || unicode.IsDigit(first) {
+		return out
+	}
+	if upper := unicode.ToUpper(first); unicode.IsUpper(upper) {
+		return string(upper) + out[size:]
+	}
+	return "X" + out
+}
+
+// beancountString quotes s on one line
+func beancountString(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+	return `"` + s + `"`
+}
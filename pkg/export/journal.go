@@ -0,0 +1,337 @@
+package export
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/pkg/errors"
+)
+
+// Journal holds what a double-entry journal is rendered from. Monarch
+// accounts become Assets or Liabilities accounts named by institution and
+// account, and categories become Income, Expenses or Equity:Transfers
+// accounts named by group and category.
+type Journal struct {
+	// Transactions to write; pending ones are marked as such
+	Transactions []*monarch.Transaction
+
+	// Accounts names the accounts of the transactions and tells assets
+	// from liabilities
+	Accounts []*monarch.Account
+
+	// Categories names the categories of the transactions by group
+	Categories []*monarch.TransactionCategory
+
+	// Splits maps transaction IDs to their splits, which become one posting
+	// each. Transactions listed here as splits of another are skipped.
+	Splits map[string][]*monarch.TransactionSplit
+
+	// Histories provide an opening balance for each account the day before
+	// the first transaction, and balance assertions at the end of each
+	// month and of the journal. Balances are signed like
+	// Account.CurrentBalance: negative when a liability is owed. Monarch
+	// leaves pending transactions out of balances, so an account is not
+	// asserted on or after the day of its first pending transaction.
+	Histories []*monarch.AccountHistory
+
+	// Currency of the amounts (default "USD")
+	Currency string
+}
+
+// LoadJournal builds a journal of transactions, fetching the accounts,
+// categories, the splits of split transactions and the balance history of
+// every account the transactions use
+func LoadJournal(ctx context.Context, client *monarch.Client, transactions []*monarch.Transaction) (*Journal, error) {
+	accounts, err := client.Accounts.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list accounts")
+	}
+	categories, err := client.Transactions.Categories().List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list categories")
+	}
+
+	j := &Journal{
+		Transactions: transactions,
+		Accounts:     accounts,
+		Categories:   categories,
+		Splits:       make(map[string][]*monarch.TransactionSplit),
+	}
+	used := make(map[string]bool)
+	for _, t := range transactions {
+		if t.Account != nil && t.Account.ID != "" && !used[t.Account.ID] {
+			used[t.Account.ID] = true
+			history, err := client.Accounts.GetHistory(ctx, t.Account.ID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get balance history of account %s", t.Account.ID)
+			}
+			j.Histories = append(j.Histories, history)
+		}
+		if t.HasSplits {
+			splits, err := client.Transactions.GetSplits(ctx, t.ID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get splits of transaction %s", t.ID)
+			}
+			j.Splits[t.ID] = splits
+		}
+	}
+	return j, nil
+}
+
+// entry kinds, in the order entries of one day are written
+const (
+	entryOpening = iota
+	entryTransaction
+	entryAssertion
+)
+
+// entry is a dated journal entry in neutral form; the writers render it
+type entry struct {
+	date      time.Time
+	kind      int
+	pending   bool
+	payee     string
+	narration string
+	meta      [][2]string
+	postings  []posting
+}
+
+// posting moves amount into account. An assertion posting moves nothing
+// and checks that the account then holds balance.
+type posting struct {
+	account   []string
+	amount    float64
+	assertion bool
+	balance   float64
+	meta      [][2]string
+}
+
+// journalEntries turns the journal into sorted entries and the accounts
+// they use
+func (j *Journal) journalEntries() ([]*entry, [][]string) {
+	accounts := make(map[string]*monarch.Account, len(j.Accounts))
+	for _, a := range j.Accounts {
+		accounts[a.ID] = a
+	}
+	categories := make(map[string]*monarch.TransactionCategory, len(j.Categories))
+	for _, c := range j.Categories {
+		categories[c.ID] = c
+	}
+	splitIDs := make(map[string]bool)
+	for _, splits := range j.Splits {
+		for _, s := range splits {
+			splitIDs[s.ID] = true
+		}
+	}
+
+	var entries []*entry
+	var start, end time.Time
+	pending := make(map[string]time.Time)
+	for _, t := range j.Transactions {
+		if splitIDs[t.ID] || t.Account == nil {
+			continue
+		}
+		if first, ok := pending[t.Account.ID]; t.Pending && (!ok || t.Date.Before(first)) {
+			pending[t.Account.ID] = t.Date.Time
+		}
+		account := accountName(t.Account, accounts)
+		e := &entry{
+			date:      t.Date.Time,
+			kind:      entryTransaction,
+			pending:   t.Pending,
+			payee:     payee(t),
+			narration: t.Notes,
+			meta:      [][2]string{{"id", t.ID}},
+		}
+		if tags := tagNames(t.Tags); tags != "" {
+			e.meta = append(e.meta, [2]string{"tags", tags})
+		}
+		e.postings = append(e.postings, posting{account: account, amount: round(t.Amount)})
+
+		remaining := round(t.Amount)
+		for _, s := range j.Splits[t.ID] {
+			p := posting{account: categoryName(splitCategory(s), categories), amount: round(-s.Amount)}
+			if s.Merchant != nil && s.Merchant.Name != "" {
+				p.meta = append(p.meta, [2]string{"merchant", s.Merchant.Name})
+			}
+			if s.Notes != "" {
+				p.meta = append(p.meta, [2]string{"notes", s.Notes})
+			}
+			e.postings = append(e.postings, p)
+			remaining = round(remaining - s.Amount)
+		}
+		if len(j.Splits[t.ID]) == 0 || remaining != 0 {
+			// Whatever the splits do not cover stays in the category
+			e.postings = append(e.postings, posting{account: categoryName(t.Category, categories), amount: -remaining})
+		}
+		entries = append(entries, e)
+
+		if start.IsZero() || t.Date.Before(start) {
+			start = t.Date.Time
+		}
+		if t.Date.After(end) {
+			end = t.Date.Time
+		}
+	}
+
+	if len(entries) > 0 {
+		for _, h := range j.Histories {
+			entries = append(entries, j.balanceEntries(h, accounts, start, end, pending[h.AccountID])...)
+		}
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		if !entries[a].date.Equal(entries[b].date) {
+			return entries[a].date.Before(entries[b].date)
+		}
+		return entries[a].kind < entries[b].kind
+	})
+
+	seen := make(map[string]bool)
+	var used [][]string
+	for _, e := range entries {
+		for _, p := range e.postings {
+			key := strings.Join(p.account, ":")
+			if !seen[key] {
+				seen[key] = true
+				used = append(used, p.account)
+			}
+		}
+	}
+	sort.Slice(used, func(a, b int) bool { return strings.Join(used[a], ":") < strings.Join(used[b], ":") })
+	return entries, used
+}
+
+// balanceEntries opens an account with its balance the day before start and
+// asserts its balance at each month end from start to end, and at end. No
+// assertions are made from pending on, unless it is zero.
+func (j *Journal) balanceEntries(h *monarch.AccountHistory, accounts map[string]*monarch.Account, start, end, pending time.Time) []*entry {
+	if h == nil || len(h.Balances) == 0 {
+		return nil
+	}
+	name := accountName(&monarch.Account{ID: h.AccountID}, accounts)
+
+	balances := append([]*monarch.BalanceEntry(nil), h.Balances...)
+	sort.SliceStable(balances, func(a, b int) bool { return balances[a].Date.Before(balances[b].Date.Time) })
+	// balanceOn is the last known balance at the end of day, or false when
+	// the history starts later
+	balanceOn := func(day time.Time) (float64, bool) {
+		i := sort.Search(len(balances), func(i int) bool { return balances[i].Date.After(day) })
+		if i == 0 {
+			return 0, false
+		}
+		return round(balances[i-1].Balance), true
+	}
+
+	var entries []*entry
+	opening := start.AddDate(0, 0, -1)
+	if balance, ok := balanceOn(opening); ok && balance != 0 {
+		entries = append(entries, &entry{
+			date: opening, kind: entryOpening, payee: "Opening balance",
+			postings: []posting{
+				{account: name, amount: balance},
+				{account: []string{"Equity", "Opening Balances"}, amount: -balance},
+			},
+		})
+	}
+
+	var days []time.Time
+	for m := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location()); ; m = m.AddDate(0, 1, 0) {
+		monthEnd := m.AddDate(0, 1, -1)
+		if !monthEnd.Before(end) {
+			break
+		}
+		days = append(days, monthEnd)
+	}
+	days = append(days, end)
+	for _, day := range days {
+		if !pending.IsZero() && !day.Before(pending) {
+			break
+		}
+		if balance, ok := balanceOn(day); ok {
+			entries = append(entries, &entry{
+				date: day, kind: entryAssertion, payee: "Balance assertion",
+				postings: []posting{{account: name, assertion: true, balance: balance}},
+			})
+		}
+	}
+	return entries
+}
+
+// accountName names a Monarch account by its institution and display name.
+// Accounts not in accounts, of which only the ID is known, are assets.
+func accountName(a *monarch.Account, accounts map[string]*monarch.Account) []string {
+	if full := accounts[a.ID]; full != nil {
+		a = full
+	}
+	root := "Assets"
+	if !a.IsAsset && a.Type != nil {
+		root = "Liabilities"
+	}
+	name := a.DisplayName
+	if name == "" {
+		name = a.ID
+	}
+	if a.Institution != nil && a.Institution.Name != "" {
+		return []string{root, a.Institution.Name, name}
+	}
+	return []string{root, name}
+}
+
+// categoryName names a category by its group
+func categoryName(c *monarch.TransactionCategory, categories map[string]*monarch.TransactionCategory) []string {
+	if c == nil {
+		return []string{"Expenses", "Uncategorized"}
+	}
+	if full := categories[c.ID]; full != nil {
+		c = full
+	}
+	name := c.Name
+	if name == "" {
+		name = c.ID
+	}
+	if c.Group == nil {
+		return []string{"Expenses", name}
+	}
+	root := "Expenses"
+	switch c.Group.Type {
+	case "income":
+		root = "Income"
+	case "transfer":
+		return []string{"Equity", "Transfers", name}
+	}
+	return []string{root, c.Group.Name, name}
+}
+
+func splitCategory(s *monarch.TransactionSplit) *monarch.TransactionCategory {
+	if s.Category != nil {
+		return s.Category
+	}
+	if s.CategoryID != "" {
+		return &monarch.TransactionCategory{ID: s.CategoryID}
+	}
+	return nil
+}
+
+func payee(t *monarch.Transaction) string {
+	if t.Merchant != nil && t.Merchant.Name != "" {
+		return t.Merchant.Name
+	}
+	return t.PlaidName
+}
+
+func tagNames(tags []*monarch.Tag) string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,310 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchfake"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) monarch.Date {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return monarch.Date{Time: d}
+}
+
+func testJournal() *Journal {
+	chase := &monarch.Institution{Name: "Chase"}
+	food := &monarch.CategoryGroup{ID: "grp-food", Name: "Food & Dining", Type: "expense"}
+	income := &monarch.CategoryGroup{ID: "grp-income", Name: "Income", Type: "income"}
+	groceries := &monarch.TransactionCategory{ID: "cat-groceries", Name: "Groceries", Group: food}
+	household := &monarch.TransactionCategory{ID: "cat-household", Name: "Household", Group: food}
+
+	salary := &monarch.Transaction{
+		ID: "txn-1", Date: date("2026-09-30"), Amount: 2500, Merchant: &monarch.Merchant{Name: "Acme Payroll"},
+		Account:  &monarch.Account{ID: "acc-checking"},
+		Category: &monarch.TransactionCategory{ID: "cat-salary"},
+	}
+	market := &monarch.Transaction{
+		ID: "txn-2", Date: date("2026-10-02"), Amount: -100, Merchant: &monarch.Merchant{Name: `Trader Joe's`},
+		Notes:     `weekly "big" shop`,
+		Account:   &monarch.Account{ID: "acc-checking"},
+		Category:  &monarch.TransactionCategory{ID: "cat-groceries"},
+		Tags:      []*monarch.Tag{{ID: "tag-trip", Name: "Trip"}, {ID: "tag-work", Name: "Work"}},
+		HasSplits: true,
+	}
+	card := &monarch.Transaction{
+		ID: "txn-3", Date: date("2026-10-03"), Amount: -20, Pending: true, PlaidName: "CORNER CAFE",
+		Account: &monarch.Account{ID: "acc-card"},
+	}
+	book := &monarch.Transaction{
+		ID: "txn-4", Date: date("2026-10-01"), Amount: -15, PlaidName: "BOOKSTORE",
+		Account: &monarch.Account{ID: "acc-card"},
+	}
+	// The split lines of txn-2 also appear as transactions of their own
+	splitLine := &monarch.Transaction{
+		ID: "split-1", Date: date("2026-10-02"), Amount: -70, IsSplitTransaction: true,
+		Account: &monarch.Account{ID: "acc-checking"},
+	}
+
+	return &Journal{
+		Transactions: []*monarch.Transaction{market, card, salary, book, splitLine},
+		Accounts: []*monarch.Account{
+			{ID: "acc-checking", DisplayName: "Checking", IsAsset: true, Institution: chase, Type: &monarch.AccountTypeInfo{Name: "depository"}},
+			{ID: "acc-card", DisplayName: "Sapphire: Reserve", Institution: chase, Type: &monarch.AccountTypeInfo{Name: "credit"}},
+		},
+		Categories: []*monarch.TransactionCategory{
+			groceries, household,
+			{ID: "cat-salary", Name: "Paychecks", Group: income},
+		},
+		Splits: map[string][]*monarch.TransactionSplit{
+			"txn-2": {
+				{ID: "split-1", Amount: -70, CategoryID: "cat-groceries"},
+				{ID: "split-2", Amount: -30, Category: household, Notes: "paper towels"},
+			},
+		},
+		Histories: []*monarch.AccountHistory{
+			{AccountID: "acc-checking", Balances: []*monarch.BalanceEntry{
+				{Date: date("2026-09-28"), Balance: 500},
+				{Date: date("2026-09-29"), Balance: 500},
+				{Date: date("2026-09-30"), Balance: 3000},
+				{Date: date("2026-10-03"), Balance: 2900},
+			}},
+			// Monarch's balances leave out the pending txn-3
+			{AccountID: "acc-card", Balances: []*monarch.BalanceEntry{
+				{Date: date("2026-09-28"), Balance: -35},
+				{Date: date("2026-10-01"), Balance: -50},
+				{Date: date("2026-10-03"), Balance: -50},
+			}},
+		},
+	}
+}
+
+func TestJournal_WriteLedger(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testJournal().WriteLedger(&buf))
+
+	assert.Equal(t, `; Exported from Monarch Money
+
+account Assets:Chase:Checking
+account Equity:Opening Balances
+account Expenses:Food & Dining:Groceries
+account Expenses:Food & Dining:Household
+account Expenses:Uncategorized
+account Income:Income:Paychecks
+account Liabilities:Chase:Sapphire Reserve
+
+2026-09-29 Opening balance
+    Assets:Chase:Checking  500.00 USD
+    Equity:Opening Balances  -500.00 USD
+
+2026-09-29 Opening balance
+    Liabilities:Chase:Sapphire Reserve  -35.00 USD
+    Equity:Opening Balances  35.00 USD
+
+2026-09-30 * Acme Payroll
+    ; id: txn-1
+    Assets:Chase:Checking  2500.00 USD
+    Income:Income:Paychecks  -2500.00 USD
+
+2026-09-30 Balance assertion
+    Assets:Chase:Checking  0.00 USD = 3000.00 USD
+
+2026-09-30 Balance assertion
+    Liabilities:Chase:Sapphire Reserve  0.00 USD = -35.00 USD
+
+2026-10-01 * BOOKSTORE
+    ; id: txn-4
+    Liabilities:Chase:Sapphire Reserve  -15.00 USD
+    Expenses:Uncategorized  15.00 USD
+
+2026-10-02 * Trader Joe's
+    ; id: txn-2
+    ; tags: Trip, Work
+    ; notes: weekly "big" shop
+    Assets:Chase:Checking  -100.00 USD
+    Expenses:Food & Dining:Groceries  70.00 USD
+    Expenses:Food & Dining:Household  30.00 USD
+        ; notes: paper towels
+
+2026-10-03 ! CORNER CAFE
+    ; id: txn-3
+    Liabilities:Chase:Sapphire Reserve  -20.00 USD
+    Expenses:Uncategorized  20.00 USD
+
+2026-10-03 Balance assertion
+    Assets:Chase:Checking  0.00 USD = 2900.00 USD
+`, buf.String())
+}
+
+func TestJournal_WriteBeancount(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testJournal().WriteBeancount(&buf))
+
+	assert.Equal(t, `; Exported from Monarch Money
+option "operating_currency" "USD"
+
+2026-09-29 open Assets:Chase:Checking USD
+2026-09-29 open Equity:Opening-Balances USD
+2026-09-29 open Expenses:Food-Dining:Groceries USD
+2026-09-29 open Expenses:Food-Dining:Household USD
+2026-09-29 open Expenses:Uncategorized USD
+2026-09-29 open Income:Income:Paychecks USD
+2026-09-29 open Liabilities:Chase:Sapphire-Reserve USD
+
+2026-09-29 * "Opening balance"
+  Assets:Chase:Checking  500.00 USD
+  Equity:Opening-Balances  -500.00 USD
+
+2026-09-29 * "Opening balance"
+  Liabilities:Chase:Sapphire-Reserve  -35.00 USD
+  Equity:Opening-Balances  35.00 USD
+
+2026-09-30 * "Acme Payroll"
+  id: "txn-1"
+  Assets:Chase:Checking  2500.00 USD
+  Income:Income:Paychecks  -2500.00 USD
+
+2026-10-01 balance Assets:Chase:Checking  3000.00 USD
+
+2026-10-01 balance Liabilities:Chase:Sapphire-Reserve  -35.00 USD
+
+2026-10-01 * "BOOKSTORE"
+  id: "txn-4"
+  Liabilities:Chase:Sapphire-Reserve  -15.00 USD
+  Expenses:Uncategorized  15.00 USD
+
+2026-10-02 * "Trader Joe's" "weekly \"big\" shop"
+  id: "txn-2"
+  tags: "Trip, Work"
+  Assets:Chase:Checking  -100.00 USD
+  Expenses:Food-Dining:Groceries  70.00 USD
+  Expenses:Food-Dining:Household  30.00 USD
+    notes: "paper towels"
+
+2026-10-03 ! "CORNER CAFE"
+  id: "txn-3"
+  Liabilities:Chase:Sapphire-Reserve  -20.00 USD
+  Expenses:Uncategorized  20.00 USD
+
+2026-10-04 balance Assets:Chase:Checking  2900.00 USD
+`, buf.String())
+}
+
+func TestBeancountComponent(t *testing.T) {
+	for in, want := range map[string]string{
+		"Food & Dining":  "Food-Dining",
+		"Trader Joe's":   "Trader-Joes",
+		"401k":           "401k",
+		"groceries":      "Groceries",
+		"  ":             "Unknown",
+		"Café (Paris)":   "Café-Paris",
+		"Sapphire: Card": "Sapphire-Card",
+	} {
+		assert.Equal(t, want, beancountComponent(in), in)
+	}
+}
+
+func TestLoadJournal(t *testing.T) {
+	now := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	food := &monarch.CategoryGroup{ID: "grp-food", Name: "Food", Type: "expense"}
+	fake := monarchfake.New(&monarchtest.Dataset{
+		Accounts: []*monarch.Account{{
+			ID: "acc-checking", DisplayName: "Checking", IsAsset: true, CurrentBalance: 900, DisplayBalance: 900,
+			Type: &monarch.AccountTypeInfo{Name: "depository"},
+		}},
+		CategoryGroups: []*monarch.CategoryGroup{food},
+		Categories: []*monarch.TransactionCategory{
+			{ID: "cat-groceries", Name: "Groceries", Group: food, GroupID: food.ID},
+			{ID: "cat-household", Name: "Household", Group: food, GroupID: food.ID},
+		},
+		Transactions: []*monarch.Transaction{{
+			ID: "txn-1", Date: date("2026-10-10"), Amount: -100,
+			Account:  &monarch.Account{ID: "acc-checking"},
+			Category: &monarch.TransactionCategory{ID: "cat-groceries"},
+		}},
+	})
+	fake.SetNow(func() time.Time { return now })
+	ctx := context.Background()
+	require.NoError(t, fake.Transactions.UpdateSplits(ctx, "txn-1", []*monarch.TransactionSplit{
+		{Amount: -60, CategoryID: "cat-groceries"},
+		{Amount: -40, CategoryID: "cat-household"},
+	}))
+
+	list, err := fake.Client().Transactions.Query().Execute(ctx)
+	require.NoError(t, err)
+	j, err := LoadJournal(ctx, fake.Client(), list.Transactions)
+	require.NoError(t, err)
+	assert.Len(t, j.Splits["txn-1"], 2)
+	require.Len(t, j.Histories, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, j.WriteBeancount(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "2026-10-09 * \"Opening balance\"\n  Assets:Checking  1000.00 USD\n")
+	assert.Contains(t, out, "  Expenses:Food:Groceries  60.00 USD\n  Expenses:Food:Household  40.00 USD\n")
+	assert.Contains(t, out, "2026-10-11 balance Assets:Checking  900.00 USD\n")
+}
+
+func TestJournal_UnknownAccount(t *testing.T) {
+	j := testJournal()
+	j.Accounts = j.Accounts[:1]
+
+	var card []float64
+	entries, _ := j.journalEntries()
+	for _, e := range entries {
+		for _, p := range e.postings {
+			assert.NotEqual(t, "Liabilities", p.account[0], "accounts without details are assets")
+			if p.assertion && p.account[1] == "acc-card" {
+				card = append(card, p.balance)
+			}
+		}
+	}
+	assert.Equal(t, []float64{-35}, card, "balances are asserted as Monarch reports them")
+}
+
+func TestJournal_DefaultDataset(t *testing.T) {
+	ctx := context.Background()
+	data := monarchtest.DefaultDataset()
+	// The dataset's transactions fall early in this month; have the history
+	// reach past the last of them
+	var last time.Time
+	for _, t := range data.Transactions {
+		if t.Date.After(last) {
+			last = t.Date.Time
+		}
+	}
+	fake := monarchfake.New(data)
+	fake.SetNow(func() time.Time { return last.AddDate(0, 0, 1) })
+	list, err := fake.Client().Transactions.Query().Execute(ctx)
+	require.NoError(t, err)
+	j, err := LoadJournal(ctx, fake.Client(), list.Transactions)
+	require.NoError(t, err)
+
+	// Replaying the postings must satisfy every assertion
+	entries, _ := j.journalEntries()
+	held := make(map[string]float64)
+	asserted := make(map[string]float64)
+	for _, e := range entries {
+		for _, p := range e.postings {
+			key := strings.Join(p.account, ":")
+			if p.assertion {
+				assert.InDelta(t, p.balance, held[key], 0.001, "%s on %s", key, e.date.Format("2006-01-02"))
+				asserted[key] = p.balance
+				continue
+			}
+			held[key] = round(held[key] + p.amount)
+		}
+	}
+	assert.Equal(t, -850.0, asserted["Liabilities:American Express:Credit Card"], "card debt is negative")
+	assert.Equal(t, 5000.0, asserted["Assets:Chase:Checking"])
+}
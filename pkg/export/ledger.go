@@ -0,0 +1,93 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WriteLedger writes the journal in Ledger syntax, which hledger also reads.
+// Each transaction carries its Monarch ID, tags and notes as metadata, and
+// balance assertions are zero postings with "= balance".
+func (j *Journal) WriteLedger(w io.Writer) error {
+	entries, accounts := j.journalEntries()
+	currency := j.currency()
+
+	var b strings.Builder
+	b.WriteString("; Exported from Monarch Money\n\n")
+	for _, a := range accounts {
+		fmt.Fprintf(&b, "account %s\n", ledgerAccount(a))
+	}
+
+	for _, e := range entries {
+		b.WriteString("\n")
+		b.WriteString(e.date.Format("2006-01-02"))
+		switch {
+		case e.kind != entryTransaction:
+		case e.pending:
+			b.WriteString(" !")
+		default:
+			b.WriteString(" *")
+		}
+		if e.payee != "" {
+			b.WriteString(" " + ledgerText(e.payee))
+		}
+		b.WriteString("\n")
+		for _, m := range e.meta {
+			fmt.Fprintf(&b, "    ; %s: %s\n", m[0], ledgerText(m[1]))
+		}
+		if e.narration != "" {
+			fmt.Fprintf(&b, "    ; notes: %s\n", ledgerText(e.narration))
+		}
+		for _, p := range e.postings {
+			if p.assertion {
+				fmt.Fprintf(&b, "    %s  %s = %s\n", ledgerAccount(p.account), formatAmount(0, currency), formatAmount(p.balance, currency))
+			} else {
+				fmt.Fprintf(&b, "    %s  %s\n", ledgerAccount(p.account), formatAmount(p.amount, currency))
+			}
+			for _, m := range p.meta {
+				fmt.Fprintf(&b, "        ; %s: %s\n", m[0], ledgerText(m[1]))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err, "failed to write ledger")
+}
+
+// ledgerAccount joins account components. Ledger ends an account name at
+// two spaces, so whitespace is collapsed, and ":" separates components.
+func ledgerAccount(components []string) string {
+	parts := make([]string, len(components))
+	for i, c := range components {
+		c = strings.Join(strings.Fields(strings.ReplaceAll(c, ":", " ")), " ")
+		if c == "" {
+			c = "Unknown"
+		}
+		parts[i] = c
+	}
+	return strings.Join(parts, ":")
+}
+
+// ledgerText keeps text on one line; hledger reads "|" in a payee as the
+// start of a note
+func ledgerText(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", "/")
+}
+
+// formatAmount writes an amount with two decimals and its commodity
+func formatAmount(v float64, currency string) string {
+	if v == 0 {
+		v = 0 // no "-0.00"
+	}
+	return fmt.Sprintf("%.2f %s", v, currency)
+}
+
+func (j *Journal) currency() string {
+	if j.Currency == "" {
+		return "USD"
+	}
+	return j.Currency
+}
@@ -0,0 +1,484 @@
+// Package export writes Monarch transactions in formats that other financial
+// software imports.
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/pkg/errors"
+)
+
+// ofxHeader starts an OFX 2.2 document
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+	`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+
+// OFXOptions configures OFX and QFX output
+type OFXOptions struct {
+	// QFX adds the Intuit elements that Quicken and QuickBooks require
+	QFX bool
+
+	// IntuitBankID is the INTU.BID written to QFX files (default "3000",
+	// which Quicken accepts for any bank)
+	IntuitBankID string
+
+	// Org and FID identify the institution in QFX files (default "Monarch
+	// Money" and "3000")
+	Org string
+	FID string
+
+	// BankID is the routing number written to bank statements (default
+	// "000000000"); Monarch does not know it
+	BankID string
+
+	// Currency is the statement currency (default "USD")
+	Currency string
+
+	// Now is the server time of the document (default time.Now)
+	Now time.Time
+}
+
+// withDefaults returns a copy of the options with empty values defaulted
+func (o *OFXOptions) withDefaults() OFXOptions {
+	var out OFXOptions
+	if o != nil {
+		out = *o
+	}
+	if out.IntuitBankID == "" {
+		out.IntuitBankID = "3000"
+	}
+	if out.Org == "" {
+		out.Org = "Monarch Money"
+	}
+	if out.FID == "" {
+		out.FID = "3000"
+	}
+	if out.BankID == "" {
+		out.BankID = "000000000"
+	}
+	if out.Currency == "" {
+		out.Currency = "USD"
+	}
+	if out.Now.IsZero() {
+		out.Now = time.Now()
+	}
+	return out
+}
+
+// WriteOFX writes the transactions of list as an OFX 2.2 document, or a QFX
+// document when opts.QFX is set. See WriteOFXTransactions.
+func WriteOFX(w io.Writer, list *monarch.TransactionList, accounts []*monarch.Account, opts *OFXOptions) error {
+	if list == nil {
+		return WriteOFXTransactions(w, nil, accounts, opts)
+	}
+	return WriteOFXTransactions(w, list.Transactions, accounts, opts)
+}
+
+// WriteOFXStream writes the transactions of a TransactionQueryBuilder.Stream
+// as an OFX document. Nothing is written when the stream fails.
+func WriteOFXStream(w io.Writer, txns <-chan *monarch.Transaction, errs <-chan error, accounts []*monarch.Account, opts *OFXOptions) error {
+	var all []*monarch.Transaction
+	for t := range txns {
+		all = append(all, t)
+	}
+	if err := <-errs; err != nil {
+		return errors.Wrap(err, "failed to stream transactions")
+	}
+	return WriteOFXTransactions(w, all, accounts, opts)
+}
+
+// WriteOFXTransactions writes an OFX document with one statement per account
+// that has transactions. Accounts are looked up in accounts by the ID of
+// Transaction.Account, falling back to Transaction.Account itself.
+//
+// Credit accounts become credit card statements, brokerage accounts
+// investment statements holding cash transactions, and the rest bank
+// statements whose type follows the account subtype. The ledger balance is
+// Account.CurrentBalance, which like OFX is negative when owed. Monarch does
+// not split brokerage balances into cash and positions, so investment
+// statements report no available cash and the account value in BALLIST.
+// Each transaction's ID is its FITID. Pending transactions are left out,
+// since their IDs change when they post.
+func WriteOFXTransactions(w io.Writer, transactions []*monarch.Transaction, accounts []*monarch.Account, opts *OFXOptions) error {
+	o := opts.withDefaults()
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   okStatus,
+			DTServer: ofxDateTime(o.Now),
+			Language: "ENG",
+		},
+	}
+	if o.QFX {
+		doc.SignOn.FI = &ofxFI{Org: o.Org, FID: o.FID}
+		doc.SignOn.IntuitBankID = o.IntuitBankID
+	}
+
+	byID := make(map[string]*monarch.Account, len(accounts))
+	for _, a := range accounts {
+		byID[a.ID] = a
+	}
+
+	for i, stmt := range groupByAccount(transactions, byID) {
+		trnUID := strconv.Itoa(i + 1)
+		list := ofxBankTranList{DTStart: ofxDate(stmt.start), DTEnd: ofxDate(stmt.end)}
+		for _, t := range stmt.transactions {
+			list.Transactions = append(list.Transactions, newOFXTransaction(t))
+		}
+		ledger := ofxBalance{Amount: ofxAmount(stmt.account.CurrentBalance), DTAsOf: ofxDateTime(o.Now)}
+		acctID := ofxAccountID(stmt.account.ID)
+
+		switch ofxStatementKind(stmt.account) {
+		case "creditcard":
+			if doc.CreditCard == nil {
+				doc.CreditCard = &ofxCreditCardMessages{}
+			}
+			doc.CreditCard.Statements = append(doc.CreditCard.Statements, ofxCreditCardStatementResponse{
+				TrnUID: trnUID,
+				Status: okStatus,
+				Statement: ofxCreditCardStatement{
+					Currency:     o.Currency,
+					Account:      ofxCreditCardAccount{AcctID: acctID},
+					Transactions: list,
+					Ledger:       ledger,
+				},
+			})
+		case "investment":
+			if doc.Investment == nil {
+				doc.Investment = &ofxInvestmentMessages{}
+			}
+			inv := ofxInvTranList{DTStart: list.DTStart, DTEnd: list.DTEnd}
+			for _, t := range list.Transactions {
+				inv.Transactions = append(inv.Transactions, ofxInvBankTransaction{Transaction: t, SubAccount: "CASH"})
+			}
+			doc.Investment.Statements = append(doc.Investment.Statements, ofxInvestmentStatementResponse{
+				TrnUID: trnUID,
+				Status: okStatus,
+				Statement: ofxInvestmentStatement{
+					DTAsOf:       ofxDateTime(o.Now),
+					Currency:     o.Currency,
+					Account:      ofxInvestmentAccount{BrokerID: o.Org, AcctID: acctID},
+					Transactions: inv,
+					Balance: ofxInvestmentBalance{
+						AvailCash:     ofxAmount(0),
+						MarginBalance: ofxAmount(0),
+						ShortBalance:  ofxAmount(0),
+						Balances: []ofxBal{{
+							Name:    "Account value",
+							Desc:    "Market value of the account in Monarch",
+							BalType: "DOLLAR",
+							Value:   ofxAmount(stmt.account.CurrentBalance),
+							DTAsOf:  ofxDateTime(o.Now),
+						}},
+					},
+				},
+			})
+		default:
+			if doc.Bank == nil {
+				doc.Bank = &ofxBankMessages{}
+			}
+			doc.Bank.Statements = append(doc.Bank.Statements, ofxBankStatementResponse{
+				TrnUID: trnUID,
+				Status: okStatus,
+				Statement: ofxBankStatement{
+					Currency:     o.Currency,
+					Account:      ofxBankAccount{BankID: o.BankID, AcctID: acctID, AcctType: ofxBankAccountType(stmt.account)},
+					Transactions: list,
+					Ledger:       ledger,
+				},
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return errors.Wrap(err, "failed to write OFX")
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return errors.Wrap(err, "failed to write OFX")
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.Wrap(err, "failed to write OFX")
+}
+
+// statement is the transactions of one account
+type statement struct {
+	account      *monarch.Account
+	transactions []*monarch.Transaction
+	start, end   time.Time
+}
+
+// groupByAccount groups posted transactions by account, ordering statements
+// by account ID and transactions by date
+func groupByAccount(transactions []*monarch.Transaction, accounts map[string]*monarch.Account) []*statement {
+	byAccount := make(map[string]*statement)
+	for _, t := range transactions {
+		if t.Pending || t.Account == nil || t.Account.ID == "" {
+			continue
+		}
+		stmt := byAccount[t.Account.ID]
+		if stmt == nil {
+			account := accounts[t.Account.ID]
+			if account == nil {
+				account = t.Account
+			}
+			stmt = &statement{account: account, start: t.Date.Time, end: t.Date.Time}
+			byAccount[t.Account.ID] = stmt
+		}
+		stmt.transactions = append(stmt.transactions, t)
+		if t.Date.Before(stmt.start) {
+			stmt.start = t.Date.Time
+		}
+		if t.Date.After(stmt.end) {
+			stmt.end = t.Date.Time
+		}
+	}
+
+	statements := make([]*statement, 0, len(byAccount))
+	for _, stmt := range byAccount {
+		sort.SliceStable(stmt.transactions, func(i, j int) bool {
+			return stmt.transactions[i].Date.Before(stmt.transactions[j].Date.Time)
+		})
+		statements = append(statements, stmt)
+	}
+	sort.Slice(statements, func(i, j int) bool { return statements[i].account.ID < statements[j].account.ID })
+	return statements
+}
+
+// ofxStatementKind picks the statement type for an account
+func ofxStatementKind(a *monarch.Account) string {
+	if a.Type == nil {
+		return "bank"
+	}
+	switch a.Type.Name {
+	case "credit":
+		return "creditcard"
+	case "brokerage":
+		return "investment"
+	}
+	return "bank"
+}
+
+// ofxBankAccountType maps an account to an OFX ACCTTYPE
+func ofxBankAccountType(a *monarch.Account) string {
+	if a.Type != nil && (a.Type.Name == "loan" || a.Type.Name == "other_liability") {
+		return "CREDITLINE"
+	}
+	if a.Subtype != nil {
+		switch a.Subtype.Name {
+		case "savings":
+			return "SAVINGS"
+		case "money_market":
+			return "MONEYMRKT"
+		case "cd":
+			return "CD"
+		}
+	}
+	return "CHECKING"
+}
+
+func newOFXTransaction(t *monarch.Transaction) ofxTransaction {
+	trnType := "CREDIT"
+	if t.Amount < 0 {
+		trnType = "DEBIT"
+	}
+	name := t.PlaidName
+	if t.Merchant != nil && t.Merchant.Name != "" {
+		name = t.Merchant.Name
+	}
+	if name == "" {
+		name = "Unknown"
+	}
+
+	ot := ofxTransaction{
+		TrnType:  trnType,
+		DTPosted: ofxDate(t.Date.Time),
+		FITID:    truncate(t.ID, 255),
+		Name:     truncate(name, 32),
+		Memo:     truncate(t.Notes, 255),
+	}
+	if ot.Name != name {
+		ot.ExtdName = truncate(name, 100)
+	}
+	ot.Amount = ofxAmount(t.Amount)
+	return ot
+}
+
+// ofxAccountID fits an account ID into the 22 characters OFX allows,
+// keeping its end
+func ofxAccountID(id string) string {
+	if len(id) > 22 {
+		return id[len(id)-22:]
+	}
+	return id
+}
+
+func ofxDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+func ofxDateTime(t time.Time) string {
+	t = t.UTC()
+	return t.Format("20060102150405.000") + "[0:GMT]"
+}
+
+func ofxAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// The types below follow the element order of the OFX 2.2 schema
+
+var okStatus = ofxStatus{Code: 0, Severity: "INFO"}
+
+type ofxDocument struct {
+	XMLName    xml.Name               `xml:"OFX"`
+	SignOn     ofxSignOn              `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank       *ofxBankMessages       `xml:"BANKMSGSRSV1,omitempty"`
+	CreditCard *ofxCreditCardMessages `xml:"CREDITCARDMSGSRSV1,omitempty"`
+	Investment *ofxInvestmentMessages `xml:"INVSTMTMSGSRSV1,omitempty"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status       ofxStatus `xml:"STATUS"`
+	DTServer     string    `xml:"DTSERVER"`
+	Language     string    `xml:"LANGUAGE"`
+	FI           *ofxFI    `xml:"FI,omitempty"`
+	IntuitBankID string    `xml:"INTU.BID,omitempty"`
+}
+
+type ofxFI struct {
+	Org string `xml:"ORG"`
+	FID string `xml:"FID"`
+}
+
+type ofxBankMessages struct {
+	Statements []ofxBankStatementResponse `xml:"STMTTRNRS"`
+}
+
+type ofxBankStatementResponse struct {
+	TrnUID    string           `xml:"TRNUID"`
+	Status    ofxStatus        `xml:"STATUS"`
+	Statement ofxBankStatement `xml:"STMTRS"`
+}
+
+type ofxBankStatement struct {
+	Currency     string          `xml:"CURDEF"`
+	Account      ofxBankAccount  `xml:"BANKACCTFROM"`
+	Transactions ofxBankTranList `xml:"BANKTRANLIST"`
+	Ledger       ofxBalance      `xml:"LEDGERBAL"`
+}
+
+type ofxBankAccount struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxCreditCardMessages struct {
+	Statements []ofxCreditCardStatementResponse `xml:"CCSTMTTRNRS"`
+}
+
+type ofxCreditCardStatementResponse struct {
+	TrnUID    string                 `xml:"TRNUID"`
+	Status    ofxStatus              `xml:"STATUS"`
+	Statement ofxCreditCardStatement `xml:"CCSTMTRS"`
+}
+
+type ofxCreditCardStatement struct {
+	Currency     string               `xml:"CURDEF"`
+	Account      ofxCreditCardAccount `xml:"CCACCTFROM"`
+	Transactions ofxBankTranList      `xml:"BANKTRANLIST"`
+	Ledger       ofxBalance           `xml:"LEDGERBAL"`
+}
+
+type ofxCreditCardAccount struct {
+	AcctID string `xml:"ACCTID"`
+}
+
+type ofxInvestmentMessages struct {
+	Statements []ofxInvestmentStatementResponse `xml:"INVSTMTTRNRS"`
+}
+
+type ofxInvestmentStatementResponse struct {
+	TrnUID    string                 `xml:"TRNUID"`
+	Status    ofxStatus              `xml:"STATUS"`
+	Statement ofxInvestmentStatement `xml:"INVSTMTRS"`
+}
+
+type ofxInvestmentStatement struct {
+	DTAsOf       string               `xml:"DTASOF"`
+	Currency     string               `xml:"CURDEF"`
+	Account      ofxInvestmentAccount `xml:"INVACCTFROM"`
+	Transactions ofxInvTranList       `xml:"INVTRANLIST"`
+	Balance      ofxInvestmentBalance `xml:"INVBAL"`
+}
+
+type ofxInvestmentBalance struct {
+	AvailCash     string   `xml:"AVAILCASH"`
+	MarginBalance string   `xml:"MARGINBALANCE"`
+	ShortBalance  string   `xml:"SHORTBALANCE"`
+	Balances      []ofxBal `xml:"BALLIST>BAL"`
+}
+
+type ofxBal struct {
+	Name    string `xml:"NAME"`
+	Desc    string `xml:"DESC"`
+	BalType string `xml:"BALTYPE"`
+	Value   string `xml:"VALUE"`
+	DTAsOf  string `xml:"DTASOF"`
+}
+
+type ofxInvestmentAccount struct {
+	BrokerID string `xml:"BROKERID"`
+	AcctID   string `xml:"ACCTID"`
+}
+
+type ofxInvTranList struct {
+	DTStart      string                  `xml:"DTSTART"`
+	DTEnd        string                  `xml:"DTEND"`
+	Transactions []ofxInvBankTransaction `xml:"INVBANKTRAN"`
+}
+
+type ofxInvBankTransaction struct {
+	Transaction ofxTransaction `xml:"STMTTRN"`
+	SubAccount  string         `xml:"SUBACCTFUND"`
+}
+
+type ofxBankTranList struct {
+	DTStart      string           `xml:"DTSTART"`
+	DTEnd        string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	Amount   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME"`
+	ExtdName string `xml:"EXTDNAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
@@ -0,0 +1,300 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC)
+
+func testAccounts() []*monarch.Account {
+	return []*monarch.Account{
+		{
+			ID: "acc-checking", DisplayName: "Checking", IsAsset: true, CurrentBalance: 1000,
+			Type: &monarch.AccountTypeInfo{Name: "depository"}, Subtype: &monarch.AccountSubtypeInfo{Name: "checking"},
+		},
+		{
+			ID: "acc-savings", DisplayName: "Savings", IsAsset: true, CurrentBalance: 5000,
+			Type: &monarch.AccountTypeInfo{Name: "depository"}, Subtype: &monarch.AccountSubtypeInfo{Name: "savings"},
+		},
+		{
+			ID: "acc-card", DisplayName: "Card", CurrentBalance: -300, DisplayBalance: 300,
+			Type: &monarch.AccountTypeInfo{Name: "credit"}, Subtype: &monarch.AccountSubtypeInfo{Name: "credit_card"},
+		},
+		{
+			ID: "acc-brokerage", DisplayName: "Brokerage", IsAsset: true, CurrentBalance: 1500,
+			Type: &monarch.AccountTypeInfo{Name: "brokerage"}, Subtype: &monarch.AccountSubtypeInfo{Name: "brokerage"},
+		},
+	}
+}
+
+func txn(id, accountID, date string, amount float64, merchant string) *monarch.Transaction {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		panic(err)
+	}
+	return &monarch.Transaction{
+		ID: id, Date: monarch.Date{Time: d}, Amount: amount,
+		Account:  &monarch.Account{ID: accountID},
+		Merchant: &monarch.Merchant{Name: merchant},
+	}
+}
+
+func testTransactions() []*monarch.Transaction {
+	notes := txn("txn-2", "acc-checking", "2026-10-02", -42.5, "Trader Joe's & Co")
+	notes.Notes = "weekly <groceries>"
+	pending := txn("txn-pending", "acc-checking", "2026-10-14", -9, "Coffee")
+	pending.Pending = true
+	return []*monarch.Transaction{
+		notes,
+		txn("txn-1", "acc-checking", "2026-10-01", 2500, "Payroll"),
+		txn("txn-3", "acc-savings", "2026-10-03", 100, "Transfer"),
+		txn("txn-4", "acc-card", "2026-10-04", -19.99, "A Merchant Name Longer Than Thirty-Two Characters"),
+		txn("txn-5", "acc-brokerage", "2026-10-05", 12.34, "Dividend"),
+		pending,
+	}
+}
+
+// parsedOFX is the subset of an OFX document the tests check
+type parsedOFX struct {
+	SignOn struct {
+		DTServer string `xml:"DTSERVER"`
+		Org      string `xml:"FI>ORG"`
+		BID      string `xml:"INTU.BID"`
+	} `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank []struct {
+		Account struct {
+			AcctID   string `xml:"ACCTID"`
+			AcctType string `xml:"ACCTTYPE"`
+		} `xml:"STMTRS>BANKACCTFROM"`
+		Start        string           `xml:"STMTRS>BANKTRANLIST>DTSTART"`
+		End          string           `xml:"STMTRS>BANKTRANLIST>DTEND"`
+		Transactions []ofxTransaction `xml:"STMTRS>BANKTRANLIST>STMTTRN"`
+		Balance      string           `xml:"STMTRS>LEDGERBAL>BALAMT"`
+	} `xml:"BANKMSGSRSV1>STMTTRNRS"`
+	CreditCard []struct {
+		AcctID       string           `xml:"CCSTMTRS>CCACCTFROM>ACCTID"`
+		Transactions []ofxTransaction `xml:"CCSTMTRS>BANKTRANLIST>STMTTRN"`
+		Balance      string           `xml:"CCSTMTRS>LEDGERBAL>BALAMT"`
+	} `xml:"CREDITCARDMSGSRSV1>CCSTMTTRNRS"`
+	Investment []struct {
+		AcctID       string           `xml:"INVSTMTRS>INVACCTFROM>ACCTID"`
+		Transactions []ofxTransaction `xml:"INVSTMTRS>INVTRANLIST>INVBANKTRAN>STMTTRN"`
+		AvailCash    string           `xml:"INVSTMTRS>INVBAL>AVAILCASH"`
+		Value        string           `xml:"INVSTMTRS>INVBAL>BALLIST>BAL>VALUE"`
+	} `xml:"INVSTMTMSGSRSV1>INVSTMTTRNRS"`
+}
+
+func writeAndParse(t *testing.T, opts *OFXOptions) (string, parsedOFX) {
+	t.Helper()
+	var buf bytes.Buffer
+	list := &monarch.TransactionList{Transactions: testTransactions()}
+	require.NoError(t, WriteOFX(&buf, list, testAccounts(), opts))
+
+	var doc parsedOFX
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	return buf.String(), doc
+}
+
+func TestWriteOFX(t *testing.T) {
+	out, doc := writeAndParse(t, &OFXOptions{Now: testNow})
+
+	assert.True(t, strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`+"\n"+
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`+"\n<OFX>"))
+	assert.Equal(t, "20261015123000.000[0:GMT]", doc.SignOn.DTServer)
+	assert.NotContains(t, out, "INTU.BID", "INTU.BID is only written to QFX")
+	assert.NotContains(t, out, "<FI>")
+
+	require.Len(t, doc.Bank, 2)
+	checking := doc.Bank[0]
+	assert.Equal(t, "acc-checking", checking.Account.AcctID)
+	assert.Equal(t, "CHECKING", checking.Account.AcctType)
+	assert.Equal(t, "20261001", checking.Start)
+	assert.Equal(t, "20261002", checking.End)
+	assert.Equal(t, "1000.00", checking.Balance)
+	require.Len(t, checking.Transactions, 2, "pending transactions are left out")
+	assert.Equal(t, ofxTransaction{
+		TrnType: "CREDIT", DTPosted: "20261001", Amount: "2500.00", FITID: "txn-1", Name: "Payroll",
+	}, checking.Transactions[0])
+	assert.Equal(t, ofxTransaction{
+		TrnType: "DEBIT", DTPosted: "20261002", Amount: "-42.50", FITID: "txn-2",
+		Name: "Trader Joe's & Co", Memo: "weekly <groceries>",
+	}, checking.Transactions[1])
+	assert.Equal(t, "SAVINGS", doc.Bank[1].Account.AcctType)
+
+	require.Len(t, doc.CreditCard, 1)
+	card := doc.CreditCard[0]
+	assert.Equal(t, "acc-card", card.AcctID)
+	assert.Equal(t, "-300.00", card.Balance, "amounts owed are negative")
+	require.Len(t, card.Transactions, 1)
+	assert.Equal(t, "A Merchant Name Longer Than Thir", card.Transactions[0].Name)
+	assert.Equal(t, "A Merchant Name Longer Than Thirty-Two Characters", card.Transactions[0].ExtdName)
+
+	require.Len(t, doc.Investment, 1)
+	assert.Equal(t, "acc-brokerage", doc.Investment[0].AcctID)
+	require.Len(t, doc.Investment[0].Transactions, 1)
+	assert.Equal(t, "txn-5", doc.Investment[0].Transactions[0].FITID)
+	assert.Equal(t, "0.00", doc.Investment[0].AvailCash)
+	assert.Equal(t, "1500.00", doc.Investment[0].Value)
+}
+
+// ofxChildren maps each element of an OFX document to the child sequences
+// of its occurrences, with runs of repeated children collapsed to one
+func ofxChildren(t *testing.T, out string) map[string][][]string {
+	t.Helper()
+	dec := xml.NewDecoder(strings.NewReader(out))
+	children := make(map[string][][]string)
+	type open struct {
+		name     string
+		children []string
+	}
+	var stack []*open
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if n := len(stack); n > 0 {
+				parent := stack[n-1]
+				if k := len(parent.children); k == 0 || parent.children[k-1] != el.Name.Local {
+					parent.children = append(parent.children, el.Name.Local)
+				}
+			}
+			stack = append(stack, &open{name: el.Name.Local})
+		case xml.EndElement:
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(top.children) > 0 {
+				children[top.name] = append(children[top.name], top.children)
+			}
+		}
+	}
+	return children
+}
+
+func TestWriteOFX_ElementOrder(t *testing.T) {
+	out, _ := writeAndParse(t, &OFXOptions{Now: testNow})
+	children := ofxChildren(t, out)
+
+	// Sequences of the OFX 2.2 schema, leaving out optional elements that
+	// are never written
+	schema := map[string][]string{
+		"OFX":                {"SIGNONMSGSRSV1", "BANKMSGSRSV1", "CREDITCARDMSGSRSV1", "INVSTMTMSGSRSV1"},
+		"SIGNONMSGSRSV1":     {"SONRS"},
+		"SONRS":              {"STATUS", "DTSERVER", "LANGUAGE"},
+		"STATUS":             {"CODE", "SEVERITY"},
+		"BANKMSGSRSV1":       {"STMTTRNRS"},
+		"STMTTRNRS":          {"TRNUID", "STATUS", "STMTRS"},
+		"STMTRS":             {"CURDEF", "BANKACCTFROM", "BANKTRANLIST", "LEDGERBAL"},
+		"BANKACCTFROM":       {"BANKID", "ACCTID", "ACCTTYPE"},
+		"BANKTRANLIST":       {"DTSTART", "DTEND", "STMTTRN"},
+		"LEDGERBAL":          {"BALAMT", "DTASOF"},
+		"CREDITCARDMSGSRSV1": {"CCSTMTTRNRS"},
+		"CCSTMTTRNRS":        {"TRNUID", "STATUS", "CCSTMTRS"},
+		"CCSTMTRS":           {"CURDEF", "CCACCTFROM", "BANKTRANLIST", "LEDGERBAL"},
+		"CCACCTFROM":         {"ACCTID"},
+		"INVSTMTMSGSRSV1":    {"INVSTMTTRNRS"},
+		"INVSTMTTRNRS":       {"TRNUID", "STATUS", "INVSTMTRS"},
+		"INVSTMTRS":          {"DTASOF", "CURDEF", "INVACCTFROM", "INVTRANLIST", "INVBAL"},
+		"INVACCTFROM":        {"BROKERID", "ACCTID"},
+		"INVTRANLIST":        {"DTSTART", "DTEND", "INVBANKTRAN"},
+		"INVBANKTRAN":        {"STMTTRN", "SUBACCTFUND"},
+		"INVBAL":             {"AVAILCASH", "MARGINBALANCE", "SHORTBALANCE", "BALLIST"},
+		"BALLIST":            {"BAL"},
+		"BAL":                {"NAME", "DESC", "BALTYPE", "VALUE", "DTASOF"},
+	}
+	for name, sequences := range children {
+		if name == "STMTTRN" {
+			continue
+		}
+		want, ok := schema[name]
+		require.True(t, ok, "unexpected aggregate %s", name)
+		for _, got := range sequences {
+			assert.Equal(t, want, got, name)
+		}
+	}
+	for name := range schema {
+		assert.Contains(t, children, name)
+	}
+
+	// STMTTRN has many optional elements; those written must keep their order
+	stmttrn := []string{"TRNTYPE", "DTPOSTED", "TRNAMT", "FITID", "NAME", "EXTDNAME", "MEMO"}
+	require.NotEmpty(t, children["STMTTRN"])
+	for _, got := range children["STMTTRN"] {
+		pos := 0
+		for _, el := range got {
+			i := indexOf(stmttrn[pos:], el)
+			require.GreaterOrEqual(t, i, 0, "STMTTRN: %s out of order in %v", el, got)
+			pos += i + 1
+		}
+	}
+}
+
+func indexOf(values []string, v string) int {
+	for i, s := range values {
+		if s == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestWriteOFX_DefaultDataset(t *testing.T) {
+	data := monarchtest.DefaultDataset()
+	var buf bytes.Buffer
+	require.NoError(t, WriteOFXTransactions(&buf, data.Transactions, data.Accounts, &OFXOptions{Now: testNow}))
+
+	var doc parsedOFX
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Len(t, doc.CreditCard, 1)
+	assert.Equal(t, "-850.00", doc.CreditCard[0].Balance, "card debt is negative as in OFX")
+	require.NotEmpty(t, doc.Bank)
+	assert.Equal(t, "5000.00", doc.Bank[0].Balance)
+}
+
+func TestWriteOFX_QFX(t *testing.T) {
+	out, doc := writeAndParse(t, &OFXOptions{Now: testNow, QFX: true, IntuitBankID: "12345"})
+	assert.Equal(t, "Monarch Money", doc.SignOn.Org)
+	assert.Equal(t, "12345", doc.SignOn.BID)
+	assert.Less(t, strings.Index(out, "<LANGUAGE>"), strings.Index(out, "<FI>"))
+	assert.Less(t, strings.Index(out, "</FI>"), strings.Index(out, "<INTU.BID>"))
+
+	sonrs := ofxChildren(t, out)["SONRS"]
+	require.Len(t, sonrs, 1)
+	assert.Equal(t, []string{"STATUS", "DTSERVER", "LANGUAGE", "FI", "INTU.BID"}, sonrs[0])
+}
+
+func TestWriteOFXStream(t *testing.T) {
+	txns := make(chan *monarch.Transaction, 1)
+	errs := make(chan error, 1)
+	txns <- txn("txn-1", "acc-checking", "2026-10-01", -5, "Coffee")
+	close(txns)
+	errs <- errors.New("boom")
+	close(errs)
+
+	var buf bytes.Buffer
+	err := WriteOFXStream(&buf, txns, errs, testAccounts(), nil)
+	assert.Error(t, err)
+	assert.Empty(t, buf.String())
+
+	txns = make(chan *monarch.Transaction, 1)
+	errs = make(chan error)
+	txns <- txn("txn-1", "acc-unknown", "2026-10-01", -5, "Coffee")
+	close(txns)
+	close(errs)
+	require.NoError(t, WriteOFXStream(&buf, txns, errs, testAccounts(), nil))
+	assert.Contains(t, buf.String(), "<ACCTTYPE>CHECKING</ACCTTYPE>", "unknown accounts are written as checking")
+}
@@ -0,0 +1,222 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CSVLayout describes a bank's CSV export. Columns are named by their
+// header, matched case-insensitively, or by 1-based number when NoHeader is
+// set. Layouts can be kept in JSON configuration.
+type CSVLayout struct {
+	// Date column of each row
+	Date string `json:"date"`
+
+	// Amount column, for files with one signed amount column
+	Amount string `json:"amount,omitempty"`
+
+	// Debit and Credit columns, for files that split money out and money
+	// in. Debits become negative whatever their sign in the file.
+	Debit  string `json:"debit,omitempty"`
+	Credit string `json:"credit,omitempty"`
+
+	// Description column, which becomes the merchant
+	Description string `json:"description"`
+
+	// Memo column, kept in the notes (optional)
+	Memo string `json:"memo,omitempty"`
+
+	// DateFormat is a Go time layout (default tries "2006-01-02",
+	// "01/02/2006", "1/2/2006" and "01/02/06")
+	DateFormat string `json:"dateFormat,omitempty"`
+
+	// InvertSign is for files that list spending as positive amounts, as
+	// many credit card exports do
+	InvertSign bool `json:"invertSign,omitempty"`
+
+	// NoHeader is set when the first row is already data
+	NoHeader bool `json:"noHeader,omitempty"`
+
+	// SkipLines is the number of lines before the header, for banks that
+	// start the file with account details
+	SkipLines int `json:"skipLines,omitempty"`
+
+	// Delimiter between fields (default ",")
+	Delimiter string `json:"delimiter,omitempty"`
+}
+
+var defaultDateFormats = []string{"2006-01-02", "01/02/2006", "1/2/2006", "01/02/06"}
+
+// ReadCSV reads the records of a CSV file laid out as described. Rows with
+// an empty date, such as totals at the end of the file, are skipped.
+func ReadCSV(r io.Reader, layout *CSVLayout) ([]*Record, error) {
+	if layout == nil || layout.Date == "" || layout.Description == "" {
+		return nil, errors.New("the layout needs date and description columns")
+	}
+	if layout.Amount == "" && layout.Debit == "" && layout.Credit == "" {
+		return nil, errors.New("the layout needs an amount column, or debit and credit columns")
+	}
+
+	// Preambles are skipped as raw lines; they are often not valid CSV
+	buffered := bufio.NewReader(r)
+	for i := 0; i < layout.SkipLines; i++ {
+		if _, err := buffered.ReadString('\n'); err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to read CSV")
+		}
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if layout.Delimiter != "" {
+		reader.Comma = []rune(layout.Delimiter)[0]
+	}
+
+	var header []string
+	if !layout.NoHeader {
+		var err error
+		if header, err = reader.Read(); err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to read CSV header")
+		}
+	}
+	column := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		if layout.NoHeader {
+			n, err := strconv.Atoi(name)
+			if err != nil || n < 1 {
+				return 0, errors.Errorf("column %q is not a column number", name)
+			}
+			return n - 1, nil
+		}
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")), strings.TrimSpace(name)) {
+				return i, nil
+			}
+		}
+		return 0, errors.Errorf("column %q not found in header", name)
+	}
+
+	var cols [6]int
+	for i, name := range []string{layout.Date, layout.Amount, layout.Debit, layout.Credit, layout.Description, layout.Memo} {
+		var err error
+		if cols[i], err = column(name); err != nil {
+			return nil, err
+		}
+	}
+	dateCol, amountCol, debitCol, creditCol, descCol, memoCol := cols[0], cols[1], cols[2], cols[3], cols[4], cols[5]
+
+	var records []*Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read CSV")
+		}
+		line, _ := reader.FieldPos(0)
+		line += layout.SkipLines
+
+		field := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		if field(dateCol) == "" {
+			continue
+		}
+
+		date, err := parseDate(field(dateCol), layout.DateFormat)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+
+		var amount float64
+		if amountCol >= 0 {
+			if amount, err = parseAmount(field(amountCol)); err != nil {
+				return nil, errors.Wrapf(err, "line %d", line)
+			}
+		}
+		if debitCol >= 0 {
+			debit, err := parseAmount(field(debitCol))
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", line)
+			}
+			if debit < 0 {
+				debit = -debit
+			}
+			amount -= debit
+		}
+		if creditCol >= 0 {
+			credit, err := parseAmount(field(creditCol))
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", line)
+			}
+			if credit < 0 {
+				credit = -credit
+			}
+			amount += credit
+		}
+		if layout.InvertSign {
+			amount = -amount
+		}
+
+		records = append(records, &Record{
+			Date:        date,
+			Amount:      amount,
+			Description: field(descCol),
+			Memo:        field(memoCol),
+		})
+	}
+	return records, nil
+}
+
+func parseDate(s, format string) (time.Time, error) {
+	if format != "" {
+		d, err := time.Parse(format, s)
+		return d, errors.Wrapf(err, "invalid date %q", s)
+	}
+	for _, f := range defaultDateFormats {
+		if d, err := time.Parse(f, s); err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid date %q; set the layout's DateFormat", s)
+}
+
+// parseAmount reads amounts like "-1,234.56", "$12.00", "(12.00)" and
+// "12.00-". An empty field is zero.
+func parseAmount(s string) (float64, error) {
+	v := strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+	if v == "" {
+		return 0, nil
+	}
+	negative := false
+	if strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		negative, v = true, v[1:len(v)-1]
+	}
+	if strings.HasSuffix(v, "-") {
+		negative, v = true, strings.TrimSuffix(v, "-")
+	}
+	amount, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCSV_SignedAmount(t *testing.T) {
+	file := "Posting Date,Description,Amount,Memo\n" +
+		"10/02/2026,CORNER MARKET,\"-1,042.50\",groceries\n" +
+		"10/05/2026, Payroll ,$1200.00,\n" +
+		",Total,157.50,\n"
+
+	records, err := ReadCSV(strings.NewReader(file), &CSVLayout{
+		Date: "posting date", Amount: "Amount", Description: "Description", Memo: "Memo",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*Record{
+		{Date: day("2026-10-02"), Amount: -1042.5, Description: "CORNER MARKET", Memo: "groceries"},
+		{Date: day("2026-10-05"), Amount: 1200, Description: "Payroll"},
+	}, records)
+}
+
+func TestReadCSV_DebitCredit(t *testing.T) {
+	file := "Statement for account 1234\n" +
+		"\n" +
+		"Date;Payee;Out;In\n" +
+		"02.10.2026;Market;42.50;\n" +
+		"05.10.2026;Refund;;(0.00)\n" +
+		"06.10.2026;Salary;;1200\n"
+
+	records, err := ReadCSV(strings.NewReader(file), &CSVLayout{
+		Date: "Date", Debit: "Out", Credit: "In", Description: "Payee",
+		DateFormat: "02.01.2006", SkipLines: 2, Delimiter: ";",
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, -42.5, records[0].Amount)
+	assert.Equal(t, 0.0, records[1].Amount)
+	assert.Equal(t, 1200.0, records[2].Amount)
+	assert.Equal(t, day("2026-10-06"), records[2].Date)
+}
+
+func TestReadCSV_NoHeaderInvertSign(t *testing.T) {
+	file := "2026-10-02,AMAZON,19.99\n2026-10-03,PAYMENT THANK YOU,(500.00)\n"
+
+	records, err := ReadCSV(strings.NewReader(file), &CSVLayout{
+		Date: "1", Description: "2", Amount: "3", NoHeader: true, InvertSign: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, -19.99, records[0].Amount, "card charges become outflows")
+	assert.Equal(t, 500.0, records[1].Amount)
+}
+
+func TestReadCSV_Errors(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("Date,Amount\n"), &CSVLayout{Date: "Date", Amount: "Amount"})
+	assert.ErrorContains(t, err, "description")
+
+	_, err = ReadCSV(strings.NewReader("Date,Amount,Name\n"), &CSVLayout{Date: "Date", Amount: "Total", Description: "Name"})
+	assert.ErrorContains(t, err, `column "Total" not found`)
+
+	_, err = ReadCSV(strings.NewReader("Date,Amount,Name\n2026-10-02,abc,Market\n"),
+		&CSVLayout{Date: "Date", Amount: "Amount", Description: "Name"})
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = ReadCSV(strings.NewReader("Date,Amount,Name\n2 Oct 2026,1,Market\n"),
+		&CSVLayout{Date: "Date", Amount: "Amount", Description: "Name"})
+	assert.ErrorContains(t, err, "DateFormat")
+}
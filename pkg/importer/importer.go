@@ -0,0 +1,208 @@
+// Package importer creates manual transactions from bank statement files,
+// for institutions that never sync through Monarch's data providers.
+//
+//	f, err := os.Open("statement.qfx")
+//	...
+//	records, err := importer.ReadOFX(f)
+//	...
+//	result, err := importer.Import(ctx, client, records, &importer.Options{
+//		AccountID: "manual-account-id",
+//	})
+//
+// Each created transaction carries a fingerprint of its date, amount and
+// description in its notes, so importing the same file, or a statement that
+// overlaps an earlier one, skips the transactions already there.
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/importnote"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/pkg/errors"
+)
+
+// Record is one statement line. Amounts are signed like Monarch's:
+// negative for money leaving the account.
+type Record struct {
+	Date        time.Time
+	Amount      float64
+	Description string
+	Memo        string
+
+	// Account is the account number the file lists the record under, when
+	// it has one. A file with several accounts can be filtered on it.
+	Account string
+}
+
+// Options configures an import
+type Options struct {
+	// AccountID is the manual account the transactions are created in
+	AccountID string
+
+	// CategoryID of the created transactions (default the "Uncategorized"
+	// category, since Monarch requires one)
+	CategoryID string
+
+	// UpdateBalance moves the account balance by each created transaction.
+	// Leave it off when the balance is kept up to date by hand.
+	UpdateBalance bool
+}
+
+// Result reports what an import did
+type Result struct {
+	// Created holds the new transactions, in record order
+	Created []*monarch.Transaction
+
+	// Skipped holds the records whose fingerprint was already imported into
+	// the account
+	Skipped []*Record
+}
+
+// mergeDays is how far outside a statement's dates merged fingerprints are
+// looked for. pkg/duplicates merges imported transactions into synced ones,
+// which are often dated a few days apart.
+const mergeDays = 14
+
+// Import creates a transaction in the manual account for each record not
+// imported before. Records are compared by fingerprint against the
+// account's transactions between the first and last record dates, and
+// against the fingerprints of the account's transactions that were merged
+// into transactions of other accounts.
+func Import(ctx context.Context, client *monarch.Client, records []*Record, opts *Options) (*Result, error) {
+	if opts == nil || opts.AccountID == "" {
+		return nil, errors.New("an account ID is required")
+	}
+
+	account, err := client.Accounts.Get(ctx, opts.AccountID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get account %s", opts.AccountID)
+	}
+	if !account.IsManual {
+		return nil, errors.Errorf("account %s is not a manual account", opts.AccountID)
+	}
+
+	categoryID := opts.CategoryID
+	if categoryID == "" {
+		if categoryID, err = uncategorized(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Result{}
+	if len(records) == 0 {
+		return result, nil
+	}
+
+	fingerprints := Fingerprints(records)
+	existing, err := importedFingerprints(ctx, client, opts.AccountID, records)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range records {
+		if existing[fingerprints[i]] {
+			result.Skipped = append(result.Skipped, r)
+			continue
+		}
+
+		notes := importnote.Mark(fingerprints[i])
+		if memo := strings.TrimSpace(r.Memo); memo != "" {
+			notes = memo + "\n" + notes
+		}
+		params := &monarch.CreateTransactionParams{
+			Date:                monarch.Date{Time: r.Date},
+			AccountID:           opts.AccountID,
+			Amount:              r.Amount,
+			CategoryID:          categoryID,
+			Notes:               notes,
+			ShouldUpdateBalance: &opts.UpdateBalance,
+		}
+		if desc := strings.TrimSpace(r.Description); desc != "" {
+			params.Merchant = &monarch.Merchant{Name: desc}
+		}
+
+		txn, err := client.Transactions.Create(ctx, params)
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to create transaction for %s %.2f %q",
+				r.Date.Format("2006-01-02"), r.Amount, r.Description)
+		}
+		result.Created = append(result.Created, txn)
+		existing[fingerprints[i]] = true
+	}
+	return result, nil
+}
+
+// Fingerprints returns the fingerprint of each record: a hash of its date,
+// amount and normalized description. Identical records in one file, like two
+// coffees on the same day, are told apart by how many came before them.
+func Fingerprints(records []*Record) []string {
+	out := make([]string, len(records))
+	seen := make(map[string]int)
+	for i, r := range records {
+		key := fmt.Sprintf("%s|%.2f|%s", r.Date.Format("2006-01-02"), r.Amount,
+			strings.ToLower(strings.Join(strings.Fields(r.Description), " ")))
+		hashed := key
+		if n := seen[key]; n > 0 {
+			hashed = fmt.Sprintf("%s|%d", key, n)
+		}
+		seen[key]++
+		sum := sha256.Sum256([]byte(hashed))
+		out[i] = hex.EncodeToString(sum[:8])
+	}
+	return out
+}
+
+// importedFingerprints collects the fingerprints in the notes of the
+// account's transactions, and those carried over from them into other
+// accounts, over the records' date range widened by mergeDays
+func importedFingerprints(ctx context.Context, client *monarch.Client, accountID string, records []*Record) (map[string]bool, error) {
+	start, end := records[0].Date, records[0].Date
+	for _, r := range records {
+		if r.Date.Before(start) {
+			start = r.Date
+		}
+		if r.Date.After(end) {
+			end = r.Date
+		}
+	}
+
+	start, end = start.AddDate(0, 0, -mergeDays), end.AddDate(0, 0, mergeDays)
+	txns, errs := client.Transactions.Query().Between(start, end).Stream(ctx)
+	found := make(map[string]bool)
+	for t := range txns {
+		if t.Account != nil && t.Account.ID == accountID {
+			for _, fp := range importnote.Fingerprints(t.Notes) {
+				found[fp] = true
+			}
+		}
+		for _, m := range importnote.Merges(t.Notes) {
+			if m.AccountID == accountID {
+				found[m.Fingerprint] = true
+			}
+		}
+	}
+	if err := <-errs; err != nil {
+		return nil, errors.Wrap(err, "failed to list imported transactions")
+	}
+	return found, nil
+}
+
+// uncategorized finds the ID of the "Uncategorized" category
+func uncategorized(ctx context.Context, client *monarch.Client) (string, error) {
+	categories, err := client.Transactions.Categories().List(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list categories")
+	}
+	for _, c := range categories {
+		if strings.EqualFold(c.Name, "Uncategorized") {
+			return c.ID, nil
+		}
+	}
+	return "", errors.New(`no "Uncategorized" category found; set Options.CategoryID`)
+}
@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/importnote"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchfake"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFake() *monarchfake.Fake {
+	other := &monarch.CategoryGroup{ID: "grp-other", Name: "Other", Type: "expense"}
+	fake := monarchfake.New(&monarchtest.Dataset{
+		Accounts: []*monarch.Account{
+			{ID: "acc-union", DisplayName: "Credit Union", IsAsset: true, IsManual: true, CurrentBalance: 100, DisplayBalance: 100},
+			{ID: "acc-store-card", DisplayName: "Store Card", IsManual: true},
+			{ID: "acc-synced", DisplayName: "Checking", IsAsset: true},
+		},
+		CategoryGroups: []*monarch.CategoryGroup{other},
+		Categories: []*monarch.TransactionCategory{
+			{ID: "cat-uncategorized", Name: "Uncategorized", Group: other, GroupID: other.ID},
+			{ID: "cat-groceries", Name: "Groceries", Group: other, GroupID: other.ID},
+		},
+	})
+	fake.SetNow(func() time.Time { return time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC) })
+	return fake
+}
+
+func statement() []*Record {
+	return []*Record{
+		{Date: day("2026-10-02"), Amount: -42.5, Description: "CORNER MARKET", Memo: "POS PURCHASE"},
+		{Date: day("2026-10-03"), Amount: -4.25, Description: "Coffee"},
+		{Date: day("2026-10-03"), Amount: -4.25, Description: "coffee "},
+		{Date: day("2026-10-05"), Amount: 1200, Description: "Payroll"},
+	}
+}
+
+func TestImport(t *testing.T) {
+	fake := newFake()
+	ctx := context.Background()
+
+	result, err := Import(ctx, fake.Client(), statement(), &Options{AccountID: "acc-union"})
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 4, "identical rows are imported once each")
+	assert.Empty(t, result.Skipped)
+
+	list, err := fake.Client().Transactions.Query().WithAccounts("acc-union").Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, list.Transactions, 4)
+	for _, txn := range list.Transactions {
+		assert.Equal(t, "cat-uncategorized", txn.Category.ID)
+		assert.Regexp(t, `\[import:[0-9a-f]{16}\]$`, txn.Notes)
+	}
+	market := list.Transactions[3]
+	assert.Equal(t, "CORNER MARKET", market.Merchant.Name)
+	assert.True(t, strings.HasPrefix(market.Notes, "POS PURCHASE\n[import:"))
+
+	account, err := fake.Client().Accounts.Get(ctx, "acc-union")
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, account.CurrentBalance, "the balance is left alone by default")
+
+	// A later statement overlaps the first one
+	next := append(statement()[1:], &Record{Date: day("2026-10-09"), Amount: -15, Description: "Pharmacy"})
+	result, err = Import(ctx, fake.Client(), next, &Options{AccountID: "acc-union", CategoryID: "cat-groceries"})
+	require.NoError(t, err)
+	require.Len(t, result.Created, 1)
+	assert.Equal(t, -15.0, result.Created[0].Amount)
+	assert.Len(t, result.Skipped, 3)
+}
+
+func TestImport_SameLineInTwoAccounts(t *testing.T) {
+	fake := newFake()
+	ctx := context.Background()
+	fee := []*Record{{Date: day("2026-10-01"), Amount: -95, Description: "ANNUAL FEE"}}
+
+	for _, account := range []string{"acc-union", "acc-store-card"} {
+		result, err := Import(ctx, fake.Client(), fee, &Options{AccountID: account})
+		require.NoError(t, err)
+		assert.Len(t, result.Created, 1, account)
+		assert.Empty(t, result.Skipped, account)
+	}
+}
+
+func TestImport_MergedCopies(t *testing.T) {
+	fake := newFake()
+	ctx := context.Background()
+	records := statement()
+	fingerprints := Fingerprints(records)
+
+	// pkg/duplicates merged the market run into a synced transaction posted
+	// days later, and the coffee of another account's statement
+	for _, notes := range []string{
+		importnote.MergedMark(fingerprints[0], "acc-union"),
+		importnote.MergedMark(fingerprints[1], "acc-store-card"),
+	} {
+		_, err := fake.Client().Transactions.Create(ctx, &monarch.CreateTransactionParams{
+			Date: monarch.Date{Time: day("2026-10-06")}, AccountID: "acc-synced", Amount: -1,
+			CategoryID: "cat-groceries", Notes: notes,
+		})
+		require.NoError(t, err)
+	}
+
+	result, err := Import(ctx, fake.Client(), records, &Options{AccountID: "acc-union"})
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 3)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "CORNER MARKET", result.Skipped[0].Description)
+}
+
+func TestImport_Errors(t *testing.T) {
+	fake := newFake()
+	ctx := context.Background()
+
+	_, err := Import(ctx, fake.Client(), statement(), nil)
+	assert.Error(t, err)
+
+	_, err = Import(ctx, fake.Client(), statement(), &Options{AccountID: "acc-synced"})
+	assert.ErrorContains(t, err, "not a manual account")
+
+	fake.Fail("Transactions.Create", &monarch.Error{Code: "BAD_REQUEST", Message: "boom"})
+	result, err := Import(ctx, fake.Client(), statement(), &Options{AccountID: "acc-union"})
+	assert.Error(t, err)
+	assert.Empty(t, result.Created)
+}
+
+func TestFingerprints(t *testing.T) {
+	records := statement()
+	fingerprints := Fingerprints(records)
+	assert.NotEqual(t, fingerprints[1], fingerprints[2], "repeated rows get their own fingerprint")
+	assert.Equal(t, fingerprints, Fingerprints(statement()), "fingerprints are stable")
+
+	records[0].Memo = "changed"
+	records[0].Account = "other"
+	assert.Equal(t, fingerprints[0], Fingerprints(records)[0], "only date, amount and description count")
+}
@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ofxTag matches an OFX tag and the text after it. OFX 1.x files are SGML
+// and leave elements with a value unclosed, so values end at the next tag
+// rather than at their closing tag; this reads both that and OFX 2.x XML.
+var ofxTag = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+var ofxText = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ")
+
+// ReadOFX reads the transactions of an OFX or QFX statement file, in either
+// the SGML format of OFX 1.x or the XML format of OFX 2.x. The description
+// is the payee name and the memo is kept; each record's Account is the
+// ACCTID of its statement.
+func ReadOFX(r io.Reader) ([]*Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read OFX")
+	}
+	body := string(data)
+	start := strings.Index(strings.ToUpper(body), "<OFX>")
+	if start < 0 {
+		return nil, errors.New("not an OFX file: no <OFX> element")
+	}
+
+	var records []*Record
+	var account string
+	var txn map[string]string
+	for _, m := range ofxTag.FindAllStringSubmatch(body[start:], -1) {
+		closing, name := m[1] == "/", strings.ToUpper(m[2])
+		value := strings.TrimSpace(ofxText.Replace(m[3]))
+
+		// Leaf values may or may not be closed; only STMTTRN's end matters
+		switch {
+		case closing && name == "STMTTRN" && txn != nil:
+			record, err := ofxRecord(txn, account)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, record)
+			txn = nil
+		case closing:
+		case name == "STMTTRN":
+			txn = make(map[string]string)
+		case value == "":
+		case txn != nil:
+			// PAYEE aggregates hold a NAME too; keep the first one
+			if _, ok := txn[name]; !ok {
+				txn[name] = value
+			}
+		case name == "ACCTID":
+			account = value
+		}
+	}
+	return records, nil
+}
+
+// ofxRecord turns the fields of a STMTTRN aggregate into a record
+func ofxRecord(fields map[string]string, account string) (*Record, error) {
+	posted := fields["DTPOSTED"]
+	if len(posted) < 8 {
+		return nil, errors.Errorf("transaction %s: invalid DTPOSTED %q", fields["FITID"], posted)
+	}
+	date, err := time.Parse("20060102", posted[:8])
+	if err != nil {
+		return nil, errors.Wrapf(err, "transaction %s: invalid DTPOSTED %q", fields["FITID"], posted)
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(fields["TRNAMT"], ",", ".", 1), 64)
+	if err != nil {
+		return nil, errors.Errorf("transaction %s: invalid TRNAMT %q", fields["FITID"], fields["TRNAMT"])
+	}
+
+	description := fields["NAME"]
+	memo := fields["MEMO"]
+	if description == "" {
+		description, memo = memo, ""
+	}
+	return &Record{
+		Date:        date,
+		Amount:      amount,
+		Description: description,
+		Memo:        memo,
+		Account:     account,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20261015120000<LANGUAGE>ENG</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>1<STATUS><CODE>0<SEVERITY>INFO</STATUS>
+<STMTRS><CURDEF>USD
+<BANKACCTFROM><BANKID>123456789<ACCTID>000111222<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST><DTSTART>20261001<DTEND>20261015
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20261002120000.000[-5:EST]
+<TRNAMT>-42.50
+<FITID>2026100201
+<NAME>CORNER MARKET &amp; DELI
+<MEMO>POS PURCHASE
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20261005
+<TRNAMT>1200.00
+<FITID>2026100501
+<MEMO>PAYROLL DEPOSIT
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>1157.50<DTASOF>20261015</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlStatement = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <TRNUID>1</TRNUID>
+      <CCSTMTRS>
+        <CURDEF>USD</CURDEF>
+        <CCACCTFROM><ACCTID>4111222233334444</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <DTSTART>20261001</DTSTART>
+          <DTEND>20261015</DTEND>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20261003</DTPOSTED>
+            <TRNAMT>-9.75</TRNAMT>
+            <FITID>abc</FITID>
+            <PAYEE><NAME>Cafe Luna</NAME><ADDR1>1 Main St</ADDR1></PAYEE>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestReadOFX_SGML(t *testing.T) {
+	records, err := ReadOFX(strings.NewReader(sgmlStatement))
+	require.NoError(t, err)
+	assert.Equal(t, []*Record{
+		{Date: day("2026-10-02"), Amount: -42.5, Description: "CORNER MARKET & DELI", Memo: "POS PURCHASE", Account: "000111222"},
+		{Date: day("2026-10-05"), Amount: 1200, Description: "PAYROLL DEPOSIT", Account: "000111222"},
+	}, records)
+}
+
+func TestReadOFX_XML(t *testing.T) {
+	records, err := ReadOFX(strings.NewReader(xmlStatement))
+	require.NoError(t, err)
+	assert.Equal(t, []*Record{
+		{Date: day("2026-10-03"), Amount: -9.75, Description: "Cafe Luna", Account: "4111222233334444"},
+	}, records)
+}
+
+func TestReadOFX_Invalid(t *testing.T) {
+	_, err := ReadOFX(strings.NewReader("Date,Amount\n"))
+	assert.Error(t, err)
+
+	_, err = ReadOFX(strings.NewReader("<OFX><STMTTRN><DTPOSTED>2026<TRNAMT>1</STMTTRN></OFX>"))
+	assert.ErrorContains(t, err, "DTPOSTED")
+}
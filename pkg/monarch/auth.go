@@ -56,8 +56,8 @@ func (a *authService) Login(ctx context.Context, email, password string) error {
 	a.client.transport.SetSession(session)
 
 	// Save session if configured
-	if a.client.options.SessionFile != "" {
-		_ = a.service.SaveSession(a.client.options.SessionFile)
+	if a.client.options.SessionStore != nil {
+		_ = a.service.SaveSessionTo(internalStore(a.client.options.SessionStore))
 	}
 
 	return nil
@@ -79,8 +79,8 @@ func (a *authService) LoginWithMFA(ctx context.Context, email, password, mfaCode
 	a.client.transport.SetSession(session)
 
 	// Save session if configured
-	if a.client.options.SessionFile != "" {
-		_ = a.service.SaveSession(a.client.options.SessionFile)
+	if a.client.options.SessionStore != nil {
+		_ = a.service.SaveSessionTo(internalStore(a.client.options.SessionStore))
 	}
 
 	return nil
@@ -102,8 +102,8 @@ func (a *authService) LoginWithEmailOTP(ctx context.Context, email, password, ot
 	a.client.transport.SetSession(session)
 
 	// Save session if configured
-	if a.client.options.SessionFile != "" {
-		_ = a.service.SaveSession(a.client.options.SessionFile)
+	if a.client.options.SessionStore != nil {
+		_ = a.service.SaveSessionTo(internalStore(a.client.options.SessionStore))
 	}
 
 	return nil
@@ -125,8 +125,31 @@ func (a *authService) LoginWithTOTP(ctx context.Context, email, password, totpSe
 	a.client.transport.SetSession(session)
 
 	// Save session if configured
-	if a.client.options.SessionFile != "" {
-		_ = a.service.SaveSession(a.client.options.SessionFile)
+	if a.client.options.SessionStore != nil {
+		_ = a.service.SaveSessionTo(internalStore(a.client.options.SessionStore))
+	}
+
+	return nil
+}
+
+// LoginWithChallenge performs login, asking handler for MFA/OTP codes
+func (a *authService) LoginWithChallenge(ctx context.Context, email, password string, handler ChallengeHandler) error {
+	if err := a.service.LoginWithChallenge(ctx, email, password, handler); err != nil {
+		return err
+	}
+
+	// Get session and update client
+	session, err := a.service.GetSession()
+	if err != nil {
+		return err
+	}
+
+	a.client.session = a.convertSession(session)
+	a.client.transport.SetSession(session)
+
+	// Save session if configured
+	if a.client.options.SessionStore != nil {
+		_ = a.service.SaveSessionTo(internalStore(a.client.options.SessionStore))
 	}
 
 	return nil
@@ -148,8 +171,8 @@ func (a *authService) LoginInteractive(ctx context.Context, email, password stri
 	a.client.transport.SetSession(session)
 
 	// Save session if configured
-	if a.client.options.SessionFile != "" {
-		_ = a.service.SaveSession(a.client.options.SessionFile)
+	if a.client.options.SessionStore != nil {
+		_ = a.service.SaveSessionTo(internalStore(a.client.options.SessionStore))
 	}
 
 	return nil
@@ -166,12 +189,22 @@ func (a *authService) GetSession() (*Session, error) {
 
 // SaveSession saves session to file
 func (a *authService) SaveSession(path string) error {
-	return a.service.SaveSession(path)
+	return a.SaveSessionTo(NewFileSessionStore(path))
+}
+
+// SaveSessionTo saves session to a store
+func (a *authService) SaveSessionTo(store SessionStore) error {
+	return a.service.SaveSessionTo(internalStore(store))
 }
 
 // LoadSession loads session from file
 func (a *authService) LoadSession(path string) error {
-	if err := a.service.LoadSession(path); err != nil {
+	return a.LoadSessionFrom(NewFileSessionStore(path))
+}
+
+// LoadSessionFrom loads session from a store
+func (a *authService) LoadSessionFrom(store SessionStore) error {
+	if err := a.service.LoadSessionFrom(internalStore(store)); err != nil {
 		return err
 	}
 
@@ -0,0 +1,98 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newChallengeServer asks logins for a second factor: challenge is the
+// error_code returned until the request carries field set to "246810"
+func newChallengeServer(t *testing.T, challenge, field string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		if body[field] == "246810" {
+			fmt.Fprint(w, `{"token": "challenge-token", "userId": "user-1"}`)
+			return
+		}
+		if body[field] != nil {
+			fmt.Fprint(w, `{"error_code": "INVALID_CODE", "message": "invalid code"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"error_code": %q}`, challenge)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAuth_LoginReturnsTypedChallengeErrors(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := NewClient(&ClientOptions{BaseURL: newChallengeServer(t, "MFA_REQUIRED", "totp").URL})
+	require.NoError(t, err)
+	err = client.Auth.Login(ctx, "user@example.com", "secret")
+	var mfaErr *MFARequiredError
+	require.True(t, errors.As(err, &mfaErr), "got %v", err)
+	assert.Equal(t, "user@example.com", mfaErr.Email)
+	assert.True(t, errors.Is(err, ErrMFARequired))
+
+	client, err = NewClient(&ClientOptions{BaseURL: newChallengeServer(t, "EMAIL_OTP_REQUIRED", "email_otp").URL})
+	require.NoError(t, err)
+	err = client.Auth.Login(ctx, "user@example.com", "secret")
+	var otpErr *EmailOTPRequiredError
+	require.True(t, errors.As(err, &otpErr), "got %v", err)
+	assert.True(t, IsAuthError(err))
+}
+
+func TestAuth_LoginWithChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge string
+		field     string
+		kind      ChallengeKind
+	}{
+		{"mfa", "MFA_REQUIRED", "totp", ChallengeMFA},
+		{"email otp", "EMAIL_OTP_REQUIRED", "email_otp", ChallengeEmailOTP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(&ClientOptions{BaseURL: newChallengeServer(t, tt.challenge, tt.field).URL})
+			require.NoError(t, err)
+
+			var got []Challenge
+			handler := ChallengeHandlerFunc(func(ctx context.Context, c Challenge) (string, error) {
+				got = append(got, c)
+				return "246810", nil
+			})
+			require.NoError(t, client.Auth.LoginWithChallenge(context.Background(), "user@example.com", "secret", handler))
+			assert.Equal(t, []Challenge{{Kind: tt.kind, Email: "user@example.com"}}, got)
+			assert.Equal(t, "challenge-token", client.GetSession().Token)
+		})
+	}
+}
+
+func TestAuth_LoginWithChallengeErrors(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewClient(&ClientOptions{BaseURL: newChallengeServer(t, "MFA_REQUIRED", "totp").URL})
+	require.NoError(t, err)
+
+	cancelled := errors.New("user cancelled")
+	err = client.Auth.LoginWithChallenge(ctx, "user@example.com", "secret",
+		ChallengeHandlerFunc(func(ctx context.Context, c Challenge) (string, error) { return "", cancelled }))
+	assert.True(t, errors.Is(err, cancelled), "got %v", err)
+
+	err = client.Auth.LoginWithChallenge(ctx, "user@example.com", "secret",
+		ChallengeHandlerFunc(func(ctx context.Context, c Challenge) (string, error) { return "000000", nil }))
+	assert.EqualError(t, err, "invalid code")
+	assert.Nil(t, client.GetSession())
+}
@@ -0,0 +1,228 @@
+package monarch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Operation groups invalidated together by mutations
+var (
+	transactionQueries = []string{
+		"GetTransactionsList", "GetTransactionsPage", "GetTransactionDetails",
+		"GetTransactionSplits", "TransactionSplitQuery",
+	}
+	cashflowQueries = []string{
+		"Web_GetCashFlowPage", "GetCashflowSimple", "Web_GetCashFlowSummary", "GetCashflowTest",
+	}
+	budgetQueries = []string{
+		"Common_GetJointPlanningData", "GetBudgetsWithGoals",
+	}
+	accountQueries = []string{
+		"GetAccounts", "GetAccountRecentBalances", "GetAccountHistory",
+		"GetSnapshotsByAccountType", "GetAggregateSnapshots", "Web_GetHoldings",
+	}
+	categoryQueries = []string{
+		"GetCategories", "ManageGetCategoryGroups", "GetTransactionCategories", "GetCategoryGroups",
+	}
+)
+
+// CacheOptions configures a Cache
+type CacheOptions struct {
+	// Store holds the cached responses (default NewMemoryCacheStore)
+	Store CacheStore
+
+	// TTLs maps query operation names to how long their responses are
+	// cached. Operations not listed are not cached.
+	TTLs map[string]time.Duration
+
+	// Invalidates maps mutation operation names to the query operations
+	// they make stale. A mutation that is not listed clears the whole cache.
+	Invalidates map[string][]string
+}
+
+// DefaultCacheOptions caches categories, tags and account types for an hour
+// or more and accounts for a minute, and invalidates them on the mutations
+// of this package
+func DefaultCacheOptions() *CacheOptions {
+	ttls := map[string]time.Duration{
+		"GetAccountTypeOptions":       24 * time.Hour,
+		"GetHouseholdTransactionTags": time.Hour,
+		"GetAccounts":                 time.Minute,
+	}
+	for _, op := range categoryQueries {
+		ttls[op] = time.Hour
+	}
+
+	transactionWrites := concat(transactionQueries, cashflowQueries, budgetQueries, accountQueries)
+	accountWrites := concat(accountQueries, transactionQueries, cashflowQueries, budgetQueries)
+	categoryWrites := concat(categoryQueries, budgetQueries, cashflowQueries, transactionQueries)
+	return &CacheOptions{
+		TTLs: ttls,
+		Invalidates: map[string][]string{
+			"Common_CreateTransactionMutation":      transactionWrites,
+			"Common_DeleteTransactionMutation":      transactionWrites,
+			"UpdateTransaction":                     transactionWrites,
+			"Common_SplitTransactionMutation":       transactionWrites,
+			"Web_SetTransactionTags":                transactionQueries,
+			"SetTransactionTags":                    transactionQueries,
+			"Common_CreateTransactionTag":           {"GetHouseholdTransactionTags"},
+			"Web_CreateCategory":                    categoryWrites,
+			"Web_DeleteCategory":                    categoryWrites,
+			"CreateTransactionCategory":             categoryWrites,
+			"DeleteTransactionCategory":             categoryWrites,
+			"SetBudgetAmount":                       budgetQueries,
+			"Web_CreateManualAccount":               accountQueries,
+			"Common_CreateManualInvestmentsAccount": accountQueries,
+			"Common_UpdateAccount":                  accountWrites,
+			"Common_DeleteAccount":                  accountWrites,
+			"Common_ForceRefreshAccountsMutation":   accountWrites,
+			"Common_CreateManualHolding":            accountQueries,
+			"Common_UpdateHoldingMutation":          accountQueries,
+			"Common_DeleteHolding":                  accountQueries,
+		},
+	}
+}
+
+// Cache serves repeated queries from a CacheStore and drops entries when
+// mutations change the data behind them. Set it as ClientOptions.Cache.
+type Cache struct {
+	store       CacheStore
+	ttls        map[string]time.Duration
+	invalidates map[string][]string
+
+	// generation counts invalidations, so a query that was in flight
+	// during one does not store its possibly stale response
+	mu         sync.Mutex
+	generation uint64
+}
+
+// NewCache creates a cache. A nil opts uses DefaultCacheOptions.
+func NewCache(opts *CacheOptions) *Cache {
+	if opts == nil {
+		opts = DefaultCacheOptions()
+	}
+	store := opts.Store
+	if store == nil {
+		store = NewMemoryCacheStore()
+	}
+
+	c := &Cache{
+		store:       store,
+		ttls:        make(map[string]time.Duration, len(opts.TTLs)),
+		invalidates: make(map[string][]string, len(opts.Invalidates)),
+	}
+	for op, ttl := range opts.TTLs {
+		c.ttls[op] = ttl
+	}
+	for op, queries := range opts.Invalidates {
+		c.invalidates[op] = append([]string(nil), queries...)
+	}
+	return c
+}
+
+// Invalidate drops the cached responses of the given query operations
+func (c *Cache) Invalidate(operations ...string) error {
+	c.bump()
+	for _, op := range operations {
+		if err := c.store.DeletePrefix(op + "-"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear drops every cached response
+func (c *Cache) Clear() error {
+	c.bump()
+	return c.store.DeletePrefix("")
+}
+
+// execute serves a query from the cache or runs it with next, storing the
+// response. Mutations run with next and then invalidate what they affect,
+// even when they fail, since a failed mutation may still have applied.
+func (c *Cache) execute(ctx context.Context, query string, variables map[string]interface{}, result interface{}, next func(context.Context, string, map[string]interface{}, interface{}) error) error {
+	operation := extractOperationName(query)
+
+	if operationType(query) == "mutation" {
+		err := next(ctx, query, variables, result)
+		var invalidateErr error
+		if queries, ok := c.invalidates[operation]; ok {
+			invalidateErr = c.Invalidate(queries...)
+		} else {
+			invalidateErr = c.Clear()
+		}
+		if err != nil {
+			return err
+		}
+		return invalidateErr
+	}
+
+	ttl := c.ttls[operation]
+	if ttl <= 0 {
+		return next(ctx, query, variables, result)
+	}
+
+	key, err := cacheKey(operation, variables)
+	if err != nil {
+		return next(ctx, query, variables, result)
+	}
+	if data, ok, err := c.store.Get(key); err == nil && ok {
+		if err := json.Unmarshal(data, result); err == nil {
+			return nil
+		}
+	}
+
+	generation := c.currentGeneration()
+	var raw json.RawMessage
+	if err := next(ctx, query, variables, &raw); err != nil {
+		return err
+	}
+	if result != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, result); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation && len(raw) > 0 {
+		// A failure to cache does not fail the query
+		_ = c.store.Set(key, raw, ttl)
+	}
+	return nil
+}
+
+func (c *Cache) bump() {
+	c.mu.Lock()
+	c.generation++
+	c.mu.Unlock()
+}
+
+func (c *Cache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// cacheKey identifies a query by its operation name and variables;
+// encoding/json sorts map keys, which makes the encoding canonical
+func cacheKey(operation string, variables map[string]interface{}) (string, error) {
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(operation+"\n"), encoded...))
+	return operation + "-" + hex.EncodeToString(sum[:16]), nil
+}
+
+func concat(groups ...[]string) []string {
+	var out []string
+	for _, g := range groups {
+		out = append(out, g...)
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package monarch
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CacheStore holds cached responses for a Cache. Keys start with the
+// operation name followed by "-", so DeletePrefix can drop every entry of an
+// operation.
+type CacheStore interface {
+	// Get returns the value stored under key, or false when it is missing
+	// or expired
+	Get(key string) ([]byte, bool, error)
+
+	// Set stores value under key for ttl
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// DeletePrefix removes every key starting with prefix; "" removes all
+	DeletePrefix(prefix string) error
+}
+
+// MemoryCacheStore keeps cached responses in memory
+type MemoryCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	now     func() time.Time
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCacheStore creates an empty in-memory cache store
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{entries: make(map[string]memoryCacheEntry), now: time.Now}
+}
+
+// Get returns a live entry
+func (s *MemoryCacheStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !s.now().Before(entry.expires) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set stores a copy of value
+func (s *MemoryCacheStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryCacheEntry{
+		value:   append([]byte(nil), value...),
+		expires: s.now().Add(ttl),
+	}
+	return nil
+}
+
+// DeletePrefix removes matching entries
+func (s *MemoryCacheStore) DeletePrefix(prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
+
+// FileCacheStore keeps cached responses as files in a directory, so they
+// survive restarts and can be shared by processes. Responses contain
+// financial data, so files are readable only by their owner.
+type FileCacheStore struct {
+	dir string
+	now func() time.Time
+}
+
+// fileCacheEntry is the content of one cache file
+type fileCacheEntry struct {
+	Expires time.Time       `json:"expires"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// NewFileCacheStore creates a cache store in dir, creating it if needed
+func NewFileCacheStore(dir string) (*FileCacheStore, error) {
+	if dir == "" {
+		return nil, errors.New("file cache requires a directory")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "failed to create cache directory")
+	}
+	return &FileCacheStore{dir: dir, now: time.Now}, nil
+}
+
+// Get reads a live entry, removing it if it has expired
+func (s *FileCacheStore) Get(key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to read cache entry")
+	}
+
+	var entry fileCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || !s.now().Before(entry.Expires) {
+		_ = os.Remove(s.path(key))
+		return nil, false, nil
+	}
+	return entry.Value, true, nil
+}
+
+// Set writes an entry, replacing it atomically
+func (s *FileCacheStore) Set(key string, value []byte, ttl time.Duration) error {
+	data, err := json.Marshal(fileCacheEntry{Expires: s.now().Add(ttl), Value: value})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode cache entry")
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+	return nil
+}
+
+// DeletePrefix removes matching entry files
+func (s *FileCacheStore) DeletePrefix(prefix string) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to list cache directory")
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "failed to delete cache entry")
+		}
+	}
+	return nil
+}
+
+func (s *FileCacheStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
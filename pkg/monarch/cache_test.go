@@ -0,0 +1,172 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheTestServer(t *testing.T) *retryServer {
+	return newRetryServer(t, 0, 0, map[string]string{
+		"GetCategories":                    `{"data": {"categories": [{"id": "cat-1", "name": "Groceries"}]}}`,
+		"GetTransactionsList":              `{"data": {"allTransactions": {"totalCount": 0, "results": []}}}`,
+		"Common_CreateTransactionMutation": createTransactionResponse,
+		"Common_CreateTransactionTag":      `{"data": {"createTransactionTag": {"tag": {"id": "tag-1", "name": "Trip"}, "errors": []}}}`,
+	})
+}
+
+func newCachedClient(t *testing.T, url string, cache *Cache) *Client {
+	t.Helper()
+	client, err := NewClient(&ClientOptions{BaseURL: url, Token: "test-token", Cache: cache})
+	require.NoError(t, err)
+	return client
+}
+
+func TestCache_ServesRepeatedQueries(t *testing.T) {
+	srv := newCacheTestServer(t)
+	client := newCachedClient(t, srv.URL, NewCache(nil))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		categories, err := client.Transactions.Categories().List(ctx)
+		require.NoError(t, err)
+		require.Len(t, categories, 1)
+		assert.Equal(t, "Groceries", categories[0].Name)
+	}
+	assert.Equal(t, int32(1), srv.callCount("GetCategories"))
+}
+
+func TestCache_KeysIncludeVariables(t *testing.T) {
+	srv := newCacheTestServer(t)
+	opts := DefaultCacheOptions()
+	opts.TTLs["GetTransactionsList"] = time.Minute
+	client := newCachedClient(t, srv.URL, NewCache(opts))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Transactions.Query().Limit(10).Execute(ctx)
+		require.NoError(t, err)
+		_, err = client.Transactions.Query().Limit(20).Execute(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), srv.callCount("GetTransactionsList"))
+}
+
+func TestCache_MutationInvalidatesRelatedQueries(t *testing.T) {
+	srv := newCacheTestServer(t)
+	opts := DefaultCacheOptions()
+	opts.TTLs["GetTransactionsList"] = time.Minute
+	client := newCachedClient(t, srv.URL, NewCache(opts))
+	ctx := context.Background()
+
+	list := func() {
+		_, err := client.Transactions.Query().Limit(10).Execute(ctx)
+		require.NoError(t, err)
+	}
+	categories := func() {
+		_, err := client.Transactions.Categories().List(ctx)
+		require.NoError(t, err)
+	}
+
+	list()
+	categories()
+	_, err := client.Transactions.Create(ctx, createParams())
+	require.NoError(t, err)
+	list()
+	categories()
+
+	assert.Equal(t, int32(2), srv.callCount("GetTransactionsList"), "creating a transaction invalidates transaction lists")
+	assert.Equal(t, int32(1), srv.callCount("GetCategories"), "categories are unaffected")
+}
+
+func TestCache_UnlistedMutationClearsEverything(t *testing.T) {
+	srv := newCacheTestServer(t)
+	cache := NewCache(&CacheOptions{TTLs: map[string]time.Duration{"GetCategories": time.Hour}})
+	client := newCachedClient(t, srv.URL, cache)
+	ctx := context.Background()
+
+	_, err := client.Transactions.Categories().List(ctx)
+	require.NoError(t, err)
+	_, err = client.Tags.Create(ctx, "Trip", "#ff0000")
+	require.NoError(t, err)
+	_, err = client.Transactions.Categories().List(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), srv.callCount("GetCategories"))
+}
+
+func TestCache_InFlightQueryNotStoredAfterInvalidation(t *testing.T) {
+	cache := NewCache(&CacheOptions{TTLs: map[string]time.Duration{"GetCategories": time.Hour}})
+	query := "query GetCategories { categories { id } }"
+	ctx := context.Background()
+
+	var result json.RawMessage
+	err := cache.execute(ctx, query, nil, &result, func(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+		require.NoError(t, cache.Invalidate("GetCategories"))
+		*result.(*json.RawMessage) = json.RawMessage(`{"categories": []}`)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"categories": []}`, string(result))
+
+	key, err := cacheKey("GetCategories", nil)
+	require.NoError(t, err)
+	_, ok, err := cache.store.Get(key)
+	require.NoError(t, err)
+	assert.False(t, ok, "the response may predate the invalidation")
+}
+
+func TestMemoryCacheStore_Expires(t *testing.T) {
+	store := NewMemoryCacheStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set("Op-1", []byte(`1`), time.Minute))
+	value, ok, err := store.Get("Op-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte(`1`), value)
+
+	now = now.Add(time.Minute)
+	_, ok, err = store.Get("Op-1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFileCacheStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	store, err := NewFileCacheStore(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set("GetCategories-abc", []byte(`{"categories": []}`), time.Hour))
+	require.NoError(t, store.Set("GetAccounts-def", []byte(`{"accounts": []}`), time.Hour))
+
+	info, err := os.Stat(filepath.Join(dir, "GetCategories-abc.json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := NewFileCacheStore(dir)
+	require.NoError(t, err)
+	value, ok, err := reopened.Get("GetCategories-abc")
+	require.NoError(t, err)
+	require.True(t, ok, "entries survive a restart")
+	assert.JSONEq(t, `{"categories": []}`, string(value))
+
+	require.NoError(t, reopened.DeletePrefix("GetCategories-"))
+	_, ok, _ = reopened.Get("GetCategories-abc")
+	assert.False(t, ok)
+	_, ok, _ = reopened.Get("GetAccounts-def")
+	assert.True(t, ok)
+
+	reopened.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, ok, _ = reopened.Get("GetAccounts-def")
+	assert.False(t, ok, "expired entries are dropped")
+	_, err = os.Stat(filepath.Join(dir, "GetAccounts-def.json"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/eshaffer321/monarchmoney-go/internal/transport"
 	internalTypes "github.com/eshaffer321/monarchmoney-go/internal/types"
 	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -44,6 +46,9 @@ type Client struct {
 	options     *ClientOptions
 	session     *Session
 	queryLoader *graphql.QueryLoader
+	reauth      *reauthState
+	telemetry   *telemetry
+	coalescer   *coalescer
 }
 
 // ClientOptions configures the client
@@ -63,16 +68,30 @@ type ClientOptions struct {
 	// SessionFile path for session persistence
 	SessionFile string
 
+	// SessionStore persists the session, for example encrypted at rest.
+	// Defaults to a plaintext file store for SessionFile when that is set.
+	SessionStore SessionStore
+
+	// CredentialProvider supplies credentials to log in again when the
+	// session expires or is rejected. The failed request is retried once.
+	CredentialProvider CredentialProvider
+
 	// UserAgent overrides the default user-agent string for auth requests
 	UserAgent string
 
 	// Logger for debug logging
 	Logger Logger
 
-	// RetryConfig configures retry behavior
+	// RetryConfig configures retry behavior for HTTP requests. Mutations
+	// are never retried at this level.
 	RetryConfig *internalTypes.RetryConfig
 
-	// RateLimiter for rate limiting
+	// RetryPolicy retries failed operations, classifying errors and
+	// guarding mutations. See DefaultRetryPolicy.
+	RetryPolicy *RetryPolicy
+
+	// RateLimiter for rate limiting. See NewTokenBucket,
+	// NewOperationRateLimiter and NewAdaptiveRateLimiter.
 	RateLimiter RateLimiter
 
 	// Hooks for observability
@@ -83,6 +102,26 @@ type ClientOptions struct {
 
 	// SentryOptions allows custom Sentry configuration
 	SentryOptions *sentry.ClientOptions
+
+	// Recorder records GraphQL responses to fixtures, or replays them
+	// without touching the network
+	Recorder *Recorder
+
+	// TracerProvider enables an OpenTelemetry span for each GraphQL
+	// operation when set
+	TracerProvider trace.TracerProvider
+
+	// MeterProvider enables OpenTelemetry metrics for request duration,
+	// errors and rate limiter waits when set
+	MeterProvider metric.MeterProvider
+
+	// Cache serves repeated queries without calling the API and is
+	// invalidated by mutations. See NewCache.
+	Cache *Cache
+
+	// CoalesceQueries shares one request among concurrent callers of the
+	// same query with the same variables. Mutations are always sent.
+	CoalesceQueries bool
 }
 
 // Logger interface for logging
@@ -154,10 +193,38 @@ func NewClient(opts *ClientOptions) (*Client, error) {
 		opts.HTTPClient.Timeout = opts.Timeout
 	}
 
+	if opts.SessionStore == nil && opts.SessionFile != "" {
+		opts.SessionStore = NewFileSessionStore(opts.SessionFile)
+	}
+
+	// Route requests through the recorder without changing the caller's client
+	httpClient := opts.HTTPClient
+	if opts.Recorder != nil {
+		recorded := *httpClient
+		recorded.Transport = opts.Recorder.wrap(httpClient.Transport)
+		httpClient = &recorded
+	}
+
+	// Report responses to rate limiters that adapt to throttling
+	if feedback, ok := opts.RateLimiter.(RateLimitFeedback); ok {
+		next := httpClient.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		observed := *httpClient
+		observed.Transport = &rateLimitObserver{next: next, feedback: feedback}
+		httpClient = &observed
+	}
+
+	tel, err := newTelemetry(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create telemetry instruments: %w", err)
+	}
+
 	// Create transport using the internal package
 	transportOpts := &transport.Options{
 		BaseURL:     opts.BaseURL,
-		HTTPClient:  opts.HTTPClient,
+		HTTPClient:  httpClient,
 		RetryConfig: opts.RetryConfig,
 		Logger:      opts.Logger,
 		Hooks:       opts.Hooks,
@@ -172,18 +239,23 @@ func NewClient(opts *ClientOptions) (*Client, error) {
 	// Create client
 	c := &Client{
 		baseURL:     opts.BaseURL,
-		httpClient:  opts.HTTPClient,
+		httpClient:  httpClient,
 		transport:   trans,
 		options:     opts,
 		queryLoader: graphql.NewQueryLoader(),
+		reauth:      &reauthState{},
+		telemetry:   tel,
+	}
+	if opts.CoalesceQueries {
+		c.coalescer = &coalescer{}
 	}
 
 	// Initialize services
 	c.initServices()
 
-	// Load session if file specified
-	if opts.SessionFile != "" {
-		if err := c.loadSession(opts.SessionFile); err != nil && opts.Logger != nil {
+	// Load session if a store is configured
+	if opts.SessionStore != nil {
+		if err := c.loadSession(opts.SessionStore); err != nil && opts.Logger != nil {
 			opts.Logger.Warn("Failed to load session", "error", err)
 		}
 	}
@@ -245,16 +317,34 @@ func (c *Client) loadQuery(queryPath string) string {
 	return query
 }
 
-// loadSession loads session from file
-func (c *Client) loadSession(path string) error {
+// loadSession loads session from a store
+func (c *Client) loadSession(store SessionStore) error {
 	if c.Auth != nil {
-		return c.Auth.LoadSession(path)
+		return c.Auth.LoadSessionFrom(store)
 	}
 	return nil
 }
 
-// executeGraphQL executes a GraphQL query
+// executeGraphQL executes a GraphQL query, through the cache if one is set
 func (c *Client) executeGraphQL(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	if c.options.Cache != nil {
+		return c.options.Cache.execute(ctx, query, variables, result, c.executeShared)
+	}
+	return c.executeShared(ctx, query, variables, result)
+}
+
+// executeShared coalesces identical concurrent queries when enabled
+func (c *Client) executeShared(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	if c.coalescer != nil {
+		return c.coalescer.execute(ctx, query, variables, result, c.executeOperation)
+	}
+	return c.executeOperation(ctx, query, variables, result)
+}
+
+// executeOperation sends a GraphQL operation to the API
+func (c *Client) executeOperation(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	ctx = withOperation(ctx, extractOperationName(query))
+
 	// Add hooks
 	if c.options.Hooks != nil && c.options.Hooks.OnRequest != nil {
 		// Create pseudo request for hook
@@ -262,23 +352,28 @@ func (c *Client) executeGraphQL(ctx context.Context, query string, variables map
 		c.options.Hooks.OnRequest(ctx, req)
 	}
 
+	ctx, span := c.telemetry.startSpan(ctx, extractOperationName(query), query, variables)
+
 	// Rate limiting
 	if c.options.RateLimiter != nil {
-		if err := c.options.RateLimiter.Wait(ctx); err != nil {
+		if err := c.waitForRateLimit(ctx); err != nil {
 			// Capture rate limiter errors in Sentry
 			if hub := sentry.GetHubFromContext(ctx); hub != nil {
 				hub.CaptureException(err)
 			} else {
 				sentry.CaptureException(err)
 			}
-			return fmt.Errorf("rate limiter: %w", err)
+			err = fmt.Errorf("rate limiter: %w", err)
+			c.telemetry.end(ctx, span, extractOperationName(query), 0, 0, err)
+			return err
 		}
 	}
 
 	// Execute query
 	start := time.Now()
-	err := c.transport.Execute(ctx, query, variables, result)
+	retries, err := c.executeWithRetry(ctx, query, variables, result)
 	duration := time.Since(start)
+	c.telemetry.end(ctx, span, extractOperationName(query), retries, duration, err)
 
 	// Capture errors in Sentry
 	if err != nil {
@@ -324,6 +419,17 @@ func (c *Client) executeGraphQL(ctx context.Context, query string, variables map
 	return err
 }
 
+// waitForRateLimit waits on the RateLimiter, if any, and records the wait
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	if c.options.RateLimiter == nil {
+		return nil
+	}
+	start := time.Now()
+	err := c.options.RateLimiter.Wait(ctx)
+	c.telemetry.recordRateLimitWait(ctx, time.Since(start))
+	return err
+}
+
 // Close flushes any pending Sentry events and performs cleanup
 func (c *Client) Close() {
 	// Flush Sentry events with a 2 second timeout
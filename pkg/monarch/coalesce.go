@@ -0,0 +1,51 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescer shares one request among concurrent callers of the same query.
+// Callers each wait on their own context; the shared request runs without
+// the first caller's cancellation, so one caller giving up does not fail
+// the others.
+type coalescer struct {
+	group singleflight.Group
+}
+
+// execute runs a query through next at most once for all callers with the
+// same query text and variables. Mutations are always sent.
+func (co *coalescer) execute(ctx context.Context, query string, variables map[string]interface{}, result interface{}, next func(context.Context, string, map[string]interface{}, interface{}) error) error {
+	if operationType(query) == "mutation" {
+		return next(ctx, query, variables, result)
+	}
+
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return next(ctx, query, variables, result)
+	}
+	key := query + "\x00" + string(encoded)
+
+	ch := co.group.DoChan(key, func() (interface{}, error) {
+		var raw json.RawMessage
+		err := next(context.WithoutCancel(ctx), query, variables, &raw)
+		return raw, err
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		// Each caller decodes its own copy of the shared response
+		raw := res.Val.(json.RawMessage)
+		if result == nil || len(raw) == 0 {
+			return nil
+		}
+		return json.Unmarshal(raw, result)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,162 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingNext answers every call with response once release is closed
+func blockingNext(calls *int32, started chan<- struct{}, release <-chan struct{}, response string) func(context.Context, string, map[string]interface{}, interface{}) error {
+	return func(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+		atomic.AddInt32(calls, 1)
+		started <- struct{}{}
+		<-release
+		return json.Unmarshal([]byte(response), result)
+	}
+}
+
+func TestCoalescer_SharesConcurrentQueries(t *testing.T) {
+	var co coalescer
+	var calls int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	next := blockingNext(&calls, started, release, `{"categories": [{"id": "cat-1"}]}`)
+	query := "query GetCategories { categories { id } }"
+
+	const callers = 5
+	results := make([]map[string]interface{}, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = co.execute(context.Background(), query, nil, &results[i], next)
+		}(i)
+	}
+
+	<-started
+	// Give the other callers time to join the in-flight request
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for i := 0; i < callers; i++ {
+		require.NoError(t, errs[i])
+		assert.Len(t, results[i]["categories"], 1)
+	}
+	results[0]["categories"] = nil
+	assert.Len(t, results[1]["categories"], 1, "each caller decodes its own copy")
+}
+
+func TestCoalescer_KeysIncludeVariables(t *testing.T) {
+	var co coalescer
+	var calls int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	next := blockingNext(&calls, started, release, `{}`)
+	query := "query GetTransactionsList { allTransactions { totalCount } }"
+
+	var wg sync.WaitGroup
+	for _, limit := range []int{10, 20} {
+		wg.Add(1)
+		go func(limit int) {
+			defer wg.Done()
+			var result json.RawMessage
+			assert.NoError(t, co.execute(context.Background(), query, map[string]interface{}{"limit": limit}, &result, next))
+		}(limit)
+	}
+	<-started
+	<-started
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCoalescer_MutationsAreNotShared(t *testing.T) {
+	var co coalescer
+	var calls int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	next := blockingNext(&calls, started, release, `{}`)
+	query := "mutation Common_CreateTransactionMutation { createTransaction { transaction { id } } }"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result json.RawMessage
+			assert.NoError(t, co.execute(context.Background(), query, nil, &result, next))
+		}()
+	}
+	<-started
+	<-started
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCoalescer_CancelledCallerDoesNotFailOthers(t *testing.T) {
+	var co coalescer
+	var calls int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	next := blockingNext(&calls, started, release, `{"ok": true}`)
+	query := "query GetAccounts { accounts { id } }"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		var result json.RawMessage
+		firstErr <- co.execute(ctx, query, nil, &result, next)
+	}()
+	<-started
+
+	secondErr := make(chan error, 1)
+	var second map[string]bool
+	go func() {
+		secondErr <- co.execute(context.Background(), query, nil, &second, next)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(release)
+	require.NoError(t, <-secondErr)
+	assert.True(t, second["ok"])
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_CoalesceQueries(t *testing.T) {
+	srv := newCacheTestServer(t)
+	client, err := NewClient(&ClientOptions{BaseURL: srv.URL, Token: "test-token", CoalesceQueries: true})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			categories, err := client.Transactions.Categories().List(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, categories, 1)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, srv.callCount("GetCategories"), int32(3))
+
+	_, err = client.Transactions.Create(context.Background(), createParams())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), srv.callCount("Common_CreateTransactionMutation"))
+}
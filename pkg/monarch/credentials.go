@@ -0,0 +1,146 @@
+package monarch
+
+import (
+	"context"
+	"sync"
+
+	internalTypes "github.com/eshaffer321/monarchmoney-go/internal/types"
+	"github.com/pkg/errors"
+)
+
+// ChallengeKind identifies the second factor a login asks for
+type ChallengeKind = internalTypes.ChallengeKind
+
+const (
+	// ChallengeMFA asks for a code from an authenticator app
+	ChallengeMFA = internalTypes.ChallengeMFA
+
+	// ChallengeEmailOTP asks for a one-time code sent by email
+	ChallengeEmailOTP = internalTypes.ChallengeEmailOTP
+)
+
+// Challenge describes a second factor requested during login
+type Challenge = internalTypes.Challenge
+
+// ChallengeHandler returns the code for a login challenge, so logins can
+// complete without a terminal
+type ChallengeHandler = internalTypes.ChallengeHandler
+
+// ChallengeHandlerFunc adapts a function to a ChallengeHandler
+type ChallengeHandlerFunc = internalTypes.ChallengeHandlerFunc
+
+// Credentials are used to log in again when the session expires
+type Credentials struct {
+	Email    string
+	Password string
+
+	// TOTPSecret generates MFA codes. When empty, ChallengeHandler is asked
+	// instead.
+	TOTPSecret string
+
+	// ChallengeHandler returns an MFA or email OTP code when the login asks
+	// for one
+	ChallengeHandler ChallengeHandler
+}
+
+// CredentialProvider supplies the credentials for a new login
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (*Credentials, error)
+}
+
+// CredentialProviderFunc adapts a function to a CredentialProvider
+type CredentialProviderFunc func(ctx context.Context) (*Credentials, error)
+
+// Credentials calls f
+func (f CredentialProviderFunc) Credentials(ctx context.Context) (*Credentials, error) {
+	return f(ctx)
+}
+
+// StaticCredentials returns a provider that always supplies creds
+func StaticCredentials(creds Credentials) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context) (*Credentials, error) {
+		c := creds
+		return &c, nil
+	})
+}
+
+// reauthState makes concurrent requests that fail with the same session log
+// in only once
+type reauthState struct {
+	mu         sync.Mutex
+	generation uint64
+}
+
+// current returns the number of logins done so far
+func (r *reauthState) current() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.generation
+}
+
+// execute runs a query, logging in again and retrying once when the session
+// has expired or was rejected
+func (c *Client) execute(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	if c.options.CredentialProvider == nil || c.reauth == nil {
+		return c.transport.Execute(ctx, query, variables, result)
+	}
+
+	generation := c.reauth.current()
+	err := c.transport.Execute(ctx, query, variables, result)
+	if !errors.Is(err, ErrSessionExpired) && !errors.Is(err, ErrNotAuthenticated) {
+		return err
+	}
+
+	if c.options.Logger != nil {
+		c.options.Logger.Info("Session rejected, logging in again", "error", err)
+	}
+	if loginErr := c.reauthenticate(ctx, generation); loginErr != nil {
+		return &Error{
+			Code:    "REAUTH_FAILED",
+			Message: loginErr.Error(),
+			Err:     err,
+		}
+	}
+	return c.transport.Execute(ctx, query, variables, result)
+}
+
+// reauthenticate logs in with the provider's credentials unless another
+// request already did since generation was read
+func (c *Client) reauthenticate(ctx context.Context, generation uint64) error {
+	c.reauth.mu.Lock()
+	defer c.reauth.mu.Unlock()
+
+	if c.reauth.generation != generation {
+		return nil
+	}
+
+	creds, err := c.options.CredentialProvider.Credentials(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get credentials")
+	}
+	if creds == nil {
+		return errors.New("credential provider returned no credentials")
+	}
+	if err := c.loginWithCredentials(ctx, creds); err != nil {
+		return errors.Wrap(err, "failed to log in again")
+	}
+
+	c.reauth.generation++
+	if c.options.Logger != nil {
+		c.options.Logger.Info("Logged in again", "email", creds.Email)
+	}
+	return nil
+}
+
+// loginWithCredentials logs in through the auth service, which swaps the new
+// session into the transport and saves it to the session store
+func (c *Client) loginWithCredentials(ctx context.Context, creds *Credentials) error {
+	if creds.TOTPSecret != "" {
+		return c.Auth.LoginWithTOTP(ctx, creds.Email, creds.Password, creds.TOTPSecret)
+	}
+
+	if creds.ChallengeHandler != nil {
+		return c.Auth.LoginWithChallenge(ctx, creds.Email, creds.Password, creds.ChallengeHandler)
+	}
+	return c.Auth.Login(ctx, creds.Email, creds.Password)
+}
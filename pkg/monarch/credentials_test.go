@@ -0,0 +1,195 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authServer accepts the token issued by its last login. With mfa set, a
+// login must include the code "123456".
+type authServer struct {
+	*httptest.Server
+
+	mfa    bool
+	logins int32
+
+	mu    sync.Mutex
+	token string
+}
+
+func newAuthServer(t *testing.T, mfa bool) *authServer {
+	t.Helper()
+	s := &authServer{mfa: mfa, token: "valid-token"}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *authServer) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/auth/login/":
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		if s.mfa && body["totp"] != "123456" {
+			fmt.Fprint(w, `{"error_code": "MFA_REQUIRED"}`)
+			return
+		}
+		n := atomic.AddInt32(&s.logins, 1)
+		s.mu.Lock()
+		s.token = fmt.Sprintf("token-%d", n)
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"token": "token-%d", "userId": "user-1"}`, n)
+	case "/graphql":
+		s.mu.Lock()
+		token := s.token
+		s.mu.Unlock()
+		if r.Header.Get("Authorization") != "Token "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"detail": "Invalid token."}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"accounts": [{"id": "acc-1", "displayName": "Checking"}]}}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestClient_ReauthenticatesWhenTokenRejected(t *testing.T) {
+	srv := newAuthServer(t, false)
+	sessionFile := filepath.Join(t.TempDir(), "session.json")
+
+	client, err := NewClient(&ClientOptions{
+		BaseURL:            srv.URL,
+		Token:              "expired-token",
+		SessionFile:        sessionFile,
+		CredentialProvider: StaticCredentials(Credentials{Email: "user@example.com", Password: "secret"}),
+	})
+	require.NoError(t, err)
+
+	accounts, err := client.Accounts.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&srv.logins))
+	assert.Equal(t, "token-1", client.GetSession().Token)
+
+	data, err := os.ReadFile(sessionFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "token-1", "the new session is persisted")
+
+	_, err = client.Accounts.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&srv.logins), "the new session is reused")
+}
+
+func TestClient_ReauthenticatesWithChallengeHandler(t *testing.T) {
+	srv := newAuthServer(t, true)
+	var asked int32
+
+	client, err := NewClient(&ClientOptions{
+		BaseURL: srv.URL,
+		CredentialProvider: CredentialProviderFunc(func(ctx context.Context) (*Credentials, error) {
+			return &Credentials{
+				Email:    "user@example.com",
+				Password: "secret",
+				ChallengeHandler: ChallengeHandlerFunc(func(ctx context.Context, challenge Challenge) (string, error) {
+					atomic.AddInt32(&asked, 1)
+					assert.Equal(t, ChallengeMFA, challenge.Kind)
+					return "123456", nil
+				}),
+			}, nil
+		}),
+	})
+	require.NoError(t, err)
+
+	_, err = client.Accounts.List(context.Background())
+	require.NoError(t, err, "a client without a session logs in on first use")
+	assert.Equal(t, int32(1), asked)
+}
+
+func TestClient_ReauthenticationRetriesOnce(t *testing.T) {
+	var logins, queries int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/login/" {
+			atomic.AddInt32(&logins, 1)
+			fmt.Fprint(w, `{"token": "still-rejected"}`)
+			return
+		}
+		atomic.AddInt32(&queries, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&ClientOptions{
+		BaseURL:            srv.URL,
+		Token:              "expired-token",
+		CredentialProvider: StaticCredentials(Credentials{Email: "user@example.com", Password: "secret"}),
+	})
+	require.NoError(t, err)
+
+	_, err = client.Accounts.List(context.Background())
+	assert.True(t, errors.Is(err, ErrNotAuthenticated), "got %v", err)
+	assert.Equal(t, int32(1), logins)
+	assert.Equal(t, int32(2), queries)
+}
+
+func TestClient_ReauthenticationFailure(t *testing.T) {
+	srv := newAuthServer(t, false)
+	client, err := NewClient(&ClientOptions{
+		BaseURL:            srv.URL,
+		Token:              "expired-token",
+		CredentialProvider: StaticCredentials(Credentials{Email: "user@example.com", Password: "wrong"}),
+	})
+	require.NoError(t, err)
+
+	_, err = client.Accounts.List(context.Background())
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	assert.Equal(t, "REAUTH_FAILED", apiErr.Code)
+	assert.Contains(t, apiErr.Message, ErrLoginFailed.Error())
+	assert.True(t, IsAuthError(err))
+}
+
+func TestClient_ConcurrentRequestsLogInOnce(t *testing.T) {
+	srv := newAuthServer(t, false)
+	client, err := NewClient(&ClientOptions{
+		BaseURL:            srv.URL,
+		Token:              "expired-token",
+		CredentialProvider: StaticCredentials(Credentials{Email: "user@example.com", Password: "secret"}),
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Accounts.List(context.Background())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&srv.logins))
+}
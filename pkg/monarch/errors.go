@@ -1,45 +1,63 @@
 package monarch
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+
+	internalTypes "github.com/eshaffer321/monarchmoney-go/internal/types"
 )
 
+// The sentinels shared with the transport layer alias the internal values so
+// that errors.Is matches errors returned from GraphQL calls.
 var (
 	// ErrNotAuthenticated is returned when authentication is required
-	ErrNotAuthenticated = errors.New("not authenticated")
+	ErrNotAuthenticated = internalTypes.ErrNotAuthenticated
 
 	// ErrMFARequired is returned when MFA is required
-	ErrMFARequired = errors.New("multi-factor authentication required")
+	ErrMFARequired = internalTypes.ErrMFARequired
 
 	// ErrLoginFailed is returned when login fails
-	ErrLoginFailed = errors.New("login failed")
+	ErrLoginFailed = internalTypes.ErrLoginFailed
 
 	// ErrSessionExpired is returned when session has expired
-	ErrSessionExpired = errors.New("session expired")
+	ErrSessionExpired = internalTypes.ErrSessionExpired
 
 	// ErrRateLimited is returned when rate limited
-	ErrRateLimited = errors.New("rate limited")
+	ErrRateLimited = internalTypes.ErrRateLimited
 
 	// ErrTimeout is returned on timeout
-	ErrTimeout = errors.New("request timeout")
+	ErrTimeout = internalTypes.ErrTimeout
 
 	// ErrNotFound is returned when resource not found
-	ErrNotFound = errors.New("resource not found")
+	ErrNotFound = internalTypes.ErrNotFound
 
 	// ErrInvalidRequest is returned for invalid requests
 	ErrInvalidRequest = errors.New("invalid request")
 
 	// ErrServerError is returned for server errors
-	ErrServerError = errors.New("server error")
+	ErrServerError = internalTypes.ErrServerError
 
 	// ErrRefreshInProgress is returned when refresh is already in progress
 	ErrRefreshInProgress = errors.New("refresh already in progress")
 
 	// ErrRefreshTimeout is returned when refresh times out
 	ErrRefreshTimeout = errors.New("refresh timeout")
+
+	// ErrMutationApplied is returned when a mutation failed but a retry
+	// policy's dedupe check found that it was applied, so it was not retried
+	ErrMutationApplied = errors.New("mutation was applied despite the error")
 )
 
+// MFARequiredError is returned by Login when the account needs an
+// authenticator code. It matches ErrMFARequired.
+type MFARequiredError = internalTypes.MFARequiredError
+
+// EmailOTPRequiredError is returned by Login when Monarch has emailed a
+// one-time code. It matches ErrMFARequired.
+type EmailOTPRequiredError = internalTypes.EmailOTPRequiredError
+
 // Error represents an API error
 type Error struct {
 	Code       string                 `json:"code"`
@@ -160,6 +178,10 @@ func IsAuthError(err error) bool {
 
 // IsRetryable checks if error is retryable
 func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
 	if errors.Is(err, ErrRateLimited) ||
 		errors.Is(err, ErrTimeout) ||
 		errors.Is(err, ErrServerError) {
@@ -171,5 +193,7 @@ func IsRetryable(err error) bool {
 		return apiErr.StatusCode >= 500 || apiErr.StatusCode == 429
 	}
 
-	return false
+	// Connection failures and resets
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
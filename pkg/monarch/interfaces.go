@@ -214,6 +214,10 @@ type AuthService interface {
 	// LoginWithTOTP performs login with TOTP secret
 	LoginWithTOTP(ctx context.Context, email, password, totpSecret string) error
 
+	// LoginWithChallenge performs login, asking handler for a code when MFA
+	// or email OTP is required
+	LoginWithChallenge(ctx context.Context, email, password string, handler ChallengeHandler) error
+
 	// LoginInteractive performs interactive login with prompts for MFA/OTP
 	LoginInteractive(ctx context.Context, email, password string) error
 
@@ -225,6 +229,12 @@ type AuthService interface {
 
 	// LoadSession loads session from file
 	LoadSession(path string) error
+
+	// SaveSessionTo saves session to a store
+	SaveSessionTo(store SessionStore) error
+
+	// LoadSessionFrom loads session from a store
+	LoadSessionFrom(store SessionStore) error
 }
 
 // SubscriptionService handles subscription operations
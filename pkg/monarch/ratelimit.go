@@ -0,0 +1,289 @@
+package monarch
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/transport"
+)
+
+// RateLimitFeedback is implemented by rate limiters that adapt to the
+// server. When ClientOptions.RateLimiter implements it, the client reports
+// the outcome of every HTTP request, including those retried by RetryConfig.
+type RateLimitFeedback interface {
+	// Throttled is called when the server answers 429 Too Many Requests.
+	// retryAfter is the server's Retry-After, or zero when it sent none.
+	Throttled(ctx context.Context, retryAfter time.Duration)
+
+	// Succeeded is called when a request was not rate limited
+	Succeeded(ctx context.Context)
+}
+
+type operationKey struct{}
+
+// OperationFromContext returns the GraphQL operation name the client is
+// running, such as "GetAccounts", or "" outside a request. Rate limiters can
+// use it to treat operations differently.
+func OperationFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(operationKey{}).(string)
+	return name
+}
+
+// withOperation records the operation name for rate limiters
+func withOperation(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, operationKey{}, name)
+}
+
+// TokenBucket is a RateLimiter that allows bursts of up to burst requests
+// and refills at a steady rate
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a limiter allowing perSecond requests per second on
+// average and bursts of up to burst requests. It starts full.
+func NewTokenBucket(perSecond float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request may be sent or ctx is done
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Take a token now, even if that leaves the bucket in debt, and wait for
+	// the debt to be repaid. Waiters are served in order of arrival.
+	b.mu.Lock()
+	b.refill(time.Now())
+	b.tokens--
+	delay := b.debt()
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the token back for the next waiter
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Rate returns the refill rate in requests per second
+func (b *TokenBucket) Rate() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate
+}
+
+// SetRate changes the refill rate. Tokens already accrued are kept.
+func (b *TokenBucket) SetRate(perSecond float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.rate = perSecond
+}
+
+// refill adds the tokens accrued since the last call
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// debt returns how long until the bucket is no longer negative
+func (b *TokenBucket) debt() time.Duration {
+	if b.tokens >= 0 {
+		return 0
+	}
+	if b.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// OperationRateLimiter applies a separate limiter to each GraphQL operation
+type OperationRateLimiter struct {
+	fallback   RateLimiter
+	operations map[string]RateLimiter
+}
+
+// NewOperationRateLimiter limits each operation named in operations with its
+// own limiter and every other operation with fallback, which may be nil for
+// no limit
+func NewOperationRateLimiter(fallback RateLimiter, operations map[string]RateLimiter) *OperationRateLimiter {
+	ops := make(map[string]RateLimiter, len(operations))
+	for name, limiter := range operations {
+		ops[name] = limiter
+	}
+	return &OperationRateLimiter{fallback: fallback, operations: ops}
+}
+
+// Wait waits on the limiter for the operation in ctx
+func (l *OperationRateLimiter) Wait(ctx context.Context) error {
+	if limiter := l.limiter(ctx); limiter != nil {
+		return limiter.Wait(ctx)
+	}
+	return ctx.Err()
+}
+
+// Throttled passes the feedback to the operation's limiter if it adapts
+func (l *OperationRateLimiter) Throttled(ctx context.Context, retryAfter time.Duration) {
+	if feedback, ok := l.limiter(ctx).(RateLimitFeedback); ok {
+		feedback.Throttled(ctx, retryAfter)
+	}
+}
+
+// Succeeded passes the feedback to the operation's limiter if it adapts
+func (l *OperationRateLimiter) Succeeded(ctx context.Context) {
+	if feedback, ok := l.limiter(ctx).(RateLimitFeedback); ok {
+		feedback.Succeeded(ctx)
+	}
+}
+
+func (l *OperationRateLimiter) limiter(ctx context.Context) RateLimiter {
+	if limiter, ok := l.operations[OperationFromContext(ctx)]; ok {
+		return limiter
+	}
+	return l.fallback
+}
+
+// AdaptiveRateLimiterOptions configures an AdaptiveRateLimiter
+type AdaptiveRateLimiterOptions struct {
+	// MaxRate is the starting and highest rate in requests per second
+	// (default 5)
+	MaxRate float64
+
+	// MinRate is the lowest rate the limiter slows to (default 0.1)
+	MinRate float64
+
+	// Burst is the largest burst allowed (default 1)
+	Burst int
+
+	// Backoff multiplies the rate on each 429 (default 0.5)
+	Backoff float64
+
+	// Recovery is added to the rate, as a fraction of MaxRate, after each
+	// request that was not throttled (default 0.05)
+	Recovery float64
+}
+
+// AdaptiveRateLimiter is a token bucket that slows down when the server
+// answers 429 Too Many Requests and speeds up again as requests succeed.
+// While a Retry-After is pending, every Wait blocks until it has passed.
+type AdaptiveRateLimiter struct {
+	bucket *TokenBucket
+	opts   AdaptiveRateLimiterOptions
+
+	mu         sync.Mutex
+	pausedTill time.Time
+}
+
+// NewAdaptiveRateLimiter creates an adaptive limiter starting at MaxRate
+func NewAdaptiveRateLimiter(opts AdaptiveRateLimiterOptions) *AdaptiveRateLimiter {
+	if opts.MaxRate <= 0 {
+		opts.MaxRate = 5
+	}
+	if opts.MinRate <= 0 {
+		opts.MinRate = 0.1
+	}
+	if opts.MinRate > opts.MaxRate {
+		opts.MinRate = opts.MaxRate
+	}
+	if opts.Backoff <= 0 || opts.Backoff >= 1 {
+		opts.Backoff = 0.5
+	}
+	if opts.Recovery <= 0 {
+		opts.Recovery = 0.05
+	}
+	return &AdaptiveRateLimiter{
+		bucket: NewTokenBucket(opts.MaxRate, opts.Burst),
+		opts:   opts,
+	}
+}
+
+// Wait blocks until any Retry-After has passed and a token is available
+func (l *AdaptiveRateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	pause := time.Until(l.pausedTill)
+	l.mu.Unlock()
+
+	if pause > 0 {
+		timer := time.NewTimer(pause)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return l.bucket.Wait(ctx)
+}
+
+// Throttled cuts the rate and pauses for retryAfter
+func (l *AdaptiveRateLimiter) Throttled(ctx context.Context, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(retryAfter); until.After(l.pausedTill) {
+		l.pausedTill = until
+	}
+	l.bucket.SetRate(math.Max(l.opts.MinRate, l.bucket.Rate()*l.opts.Backoff))
+}
+
+// Succeeded raises the rate a step towards MaxRate
+func (l *AdaptiveRateLimiter) Succeeded(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rate := l.bucket.Rate(); rate < l.opts.MaxRate {
+		l.bucket.SetRate(math.Min(l.opts.MaxRate, rate+l.opts.MaxRate*l.opts.Recovery))
+	}
+}
+
+// Rate returns the current rate in requests per second
+func (l *AdaptiveRateLimiter) Rate() float64 {
+	return l.bucket.Rate()
+}
+
+// rateLimitObserver reports each HTTP response to a RateLimitFeedback
+type rateLimitObserver struct {
+	next     http.RoundTripper
+	feedback RateLimitFeedback
+}
+
+// RoundTrip sends the request and reports whether it was rate limited
+func (o *rateLimitObserver) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := o.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		o.feedback.Throttled(req.Context(), transport.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	} else {
+		o.feedback.Succeeded(req.Context())
+	}
+	return resp, nil
+}
@@ -0,0 +1,139 @@
+package monarch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket_BurstThenRate(t *testing.T) {
+	ctx := context.Background()
+	bucket := NewTokenBucket(20, 3)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, bucket.Wait(ctx))
+	}
+	assert.Less(t, time.Since(start), 30*time.Millisecond, "the burst is not delayed")
+
+	require.NoError(t, bucket.Wait(ctx))
+	require.NoError(t, bucket.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond, "two more tokens take 100ms at 20/s")
+}
+
+func TestTokenBucket_CancelReturnsToken(t *testing.T) {
+	bucket := NewTokenBucket(1, 1)
+	require.NoError(t, bucket.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := bucket.Wait(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+	assert.Greater(t, bucket.tokens, -0.5, "the cancelled wait gave its token back")
+}
+
+// countingLimiter counts the operations it is asked to wait for
+type countingLimiter struct {
+	mu  sync.Mutex
+	ops []string
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ops = append(l.ops, OperationFromContext(ctx))
+	return nil
+}
+
+func TestOperationRateLimiter_RoutesByOperation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"accounts": [], "householdTransactionTags": []}}`)
+	}))
+	defer srv.Close()
+
+	accounts, fallback := &countingLimiter{}, &countingLimiter{}
+	client, err := NewClient(&ClientOptions{
+		BaseURL:     srv.URL,
+		Token:       "test-token",
+		RateLimiter: NewOperationRateLimiter(fallback, map[string]RateLimiter{"GetAccounts": accounts}),
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = client.Accounts.List(ctx)
+	require.NoError(t, err)
+	_, err = client.Tags.List(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"GetAccounts"}, accounts.ops)
+	require.Len(t, fallback.ops, 1)
+	assert.NotEqual(t, "GetAccounts", fallback.ops[0])
+
+	unlimited := NewOperationRateLimiter(nil, nil)
+	assert.NoError(t, unlimited.Wait(ctx))
+}
+
+func TestAdaptiveRateLimiter_BacksOffAndRecovers(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewAdaptiveRateLimiter(AdaptiveRateLimiterOptions{MaxRate: 10, MinRate: 1, Recovery: 0.1})
+	assert.Equal(t, 10.0, limiter.Rate())
+
+	limiter.Throttled(ctx, 0)
+	assert.Equal(t, 5.0, limiter.Rate())
+	for i := 0; i < 5; i++ {
+		limiter.Throttled(ctx, 0)
+	}
+	assert.Equal(t, 1.0, limiter.Rate(), "the rate never drops below MinRate")
+
+	limiter.Succeeded(ctx)
+	assert.InDelta(t, 2.0, limiter.Rate(), 1e-9)
+	for i := 0; i < 20; i++ {
+		limiter.Succeeded(ctx)
+	}
+	assert.Equal(t, 10.0, limiter.Rate(), "the rate recovers up to MaxRate")
+}
+
+func TestAdaptiveRateLimiter_HonorsRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"accounts": []}}`)
+	}))
+	defer srv.Close()
+
+	limiter := NewAdaptiveRateLimiter(AdaptiveRateLimiterOptions{MaxRate: 100, Burst: 10})
+	client, err := NewClient(&ClientOptions{BaseURL: srv.URL, Token: "test-token", RateLimiter: limiter})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = client.Accounts.List(ctx)
+	assert.True(t, errors.Is(err, ErrRateLimited), "got %v", err)
+	assert.Equal(t, 50.0, limiter.Rate())
+
+	start := time.Now()
+	_, err = client.Accounts.List(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond, "the next request waits for Retry-After")
+	assert.Greater(t, limiter.Rate(), 50.0)
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	limiter.Throttled(ctx, time.Minute)
+	assert.True(t, errors.Is(limiter.Wait(short), context.DeadlineExceeded))
+}
@@ -0,0 +1,382 @@
+package monarch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RecordMode selects whether a Recorder talks to the API or serves fixtures
+type RecordMode int
+
+const (
+	// RecordModeReplay serves recorded responses and never touches the network
+	RecordModeReplay RecordMode = iota
+
+	// RecordModeRecord sends requests to the API and saves the responses,
+	// replacing any fixtures recorded earlier for the same request
+	RecordModeRecord
+)
+
+// ErrNoRecording is returned in replay mode for a request that has no fixture
+var ErrNoRecording = errors.New("no recorded response for request")
+
+// redactedValue replaces scrubbed strings in fixtures
+const redactedValue = "REDACTED"
+
+// scrubbedEmail replaces email addresses in fixtures
+const scrubbedEmail = "user@example.com"
+
+// emailPattern matches email addresses inside any string
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// secretKeys are JSON keys whose values are replaced outright
+var secretKeys = map[string]bool{
+	"token":        true,
+	"accesstoken":  true,
+	"refreshtoken": true,
+	"password":     true,
+	"totp":         true,
+	"secret":       true,
+	"csrftoken":    true,
+}
+
+// accountNumberKeys are JSON keys holding account numbers; their digits are
+// zeroed so the value keeps its shape
+var accountNumberKeys = map[string]bool{
+	"mask":                  true,
+	"accountnumber":         true,
+	"routingnumber":         true,
+	"dataprovideraccountid": true,
+}
+
+// RecorderOptions configures a Recorder
+type RecorderOptions struct {
+	// Dir is the directory holding the fixture files
+	Dir string
+
+	// Mode selects recording or replay; the default is replay
+	Mode RecordMode
+
+	// IgnoreVariables names variables left out when matching requests to
+	// fixtures, at any depth; use it for values that change between runs,
+	// such as today's date
+	IgnoreVariables []string
+
+	// Transport sends requests in record mode; defaults to the client's
+	// transport
+	Transport http.RoundTripper
+}
+
+// Recorder is an http.RoundTripper that records GraphQL exchanges to fixture
+// files and replays them. Requests are matched by operation name and their
+// variables, so a replayed client needs no network or credentials. Tokens,
+// emails and account numbers are scrubbed before anything is written.
+//
+// A request made several times, like GetAccounts before and after a
+// mutation, replays its responses in the order they were recorded; the last
+// one repeats once they run out.
+type Recorder struct {
+	opts   RecorderOptions
+	mu     sync.Mutex
+	next   http.RoundTripper
+	played map[string]int
+	saved  map[string]bool
+	ignore map[string]bool
+}
+
+// fixture is the file stored for one request
+type fixture struct {
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Responses     []*recordedResponse    `json:"responses"`
+}
+
+// recordedResponse is one response to a recorded request
+type recordedResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// NewRecorder creates a recorder for the fixtures in opts.Dir
+func NewRecorder(opts *RecorderOptions) (*Recorder, error) {
+	if opts == nil || opts.Dir == "" {
+		return nil, errors.New("recorder requires a fixture directory")
+	}
+	if opts.Mode == RecordModeRecord {
+		if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+			return nil, errors.Wrap(err, "failed to create fixture directory")
+		}
+	}
+
+	r := &Recorder{
+		opts:   *opts,
+		next:   opts.Transport,
+		played: make(map[string]int),
+		saved:  make(map[string]bool),
+		ignore: make(map[string]bool),
+	}
+	for _, name := range opts.IgnoreVariables {
+		r.ignore[name] = true
+	}
+	return r, nil
+}
+
+// wrap returns the recorder sending through base, unless a transport was set
+func (r *Recorder) wrap(base http.RoundTripper) http.RoundTripper {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next == nil {
+		r.next = base
+	}
+	if r.next == nil {
+		r.next = http.DefaultTransport
+	}
+	return r
+}
+
+// RoundTrip records or replays a request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || !strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/graphql") {
+		// Only GraphQL is recorded; logins and uploads carry credentials
+		if r.opts.Mode == RecordModeReplay {
+			return nil, errors.Wrapf(ErrNoRecording, "%s %s is not a GraphQL request", req.Method, req.URL.Path)
+		}
+		return r.transport().RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	var gql struct {
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &gql); err != nil {
+		return nil, errors.Wrap(err, "failed to decode GraphQL request")
+	}
+	variables, _ := scrub(gql.Variables).(map[string]interface{})
+	key, err := r.key(gql.OperationName, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.opts.Mode == RecordModeReplay {
+		return r.replay(req, gql.OperationName, key)
+	}
+	return r.record(req, gql.OperationName, variables, key)
+}
+
+func (r *Recorder) transport() http.RoundTripper {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next == nil {
+		return http.DefaultTransport
+	}
+	return r.next
+}
+
+func (r *Recorder) record(req *http.Request, operationName string, variables map[string]interface{}, key string) (*http.Response, error) {
+	resp, err := r.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	recorded := &recordedResponse{Status: resp.StatusCode}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err == nil {
+		recorded.Body, err = json.Marshal(scrub(decoded))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode response")
+		}
+	} else {
+		raw, _ := json.Marshal(emailPattern.ReplaceAllString(string(data), scrubbedEmail))
+		recorded.Body = raw
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := &fixture{OperationName: operationName, Variables: variables}
+	if r.saved[key] {
+		// Later calls in this session add to the fixture; the first replaces it
+		if existing, err := r.load(key); err == nil {
+			f = existing
+		}
+	}
+	f.Responses = append(f.Responses, recorded)
+	if err := r.save(key, f); err != nil {
+		return nil, err
+	}
+	r.saved[key] = true
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, operationName, key string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := r.load(key)
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, errors.Wrapf(ErrNoRecording, "operation %s (fixture %s)", operationName, r.path(key))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Responses) == 0 {
+		return nil, errors.Wrapf(ErrNoRecording, "operation %s (fixture %s is empty)", operationName, r.path(key))
+	}
+
+	i := r.played[key]
+	if i >= len(f.Responses) {
+		i = len(f.Responses) - 1
+	}
+	r.played[key]++
+	recorded := f.Responses[i]
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// key identifies a request by its operation name and normalized variables
+func (r *Recorder) key(operationName string, variables map[string]interface{}) (string, error) {
+	normalized, err := json.Marshal(r.normalize(variables))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to normalize variables")
+	}
+	sum := sha256.Sum256(append([]byte(operationName+"\n"), normalized...))
+
+	name := operationName
+	if name == "" {
+		name = "anonymous"
+	}
+	return name + "-" + hex.EncodeToString(sum[:])[:12], nil
+}
+
+// normalize drops ignored and null variables; encoding/json sorts map keys,
+// which makes the encoding canonical
+func (r *Recorder) normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			if val == nil || r.ignore[k] {
+				continue
+			}
+			out[k] = r.normalize(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = r.normalize(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (r *Recorder) path(key string) string {
+	return filepath.Join(r.opts.Dir, key+".json")
+}
+
+func (r *Recorder) load(key string) (*fixture, error) {
+	data, err := os.ReadFile(r.path(key))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read fixture")
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse fixture %s", r.path(key))
+	}
+	return &f, nil
+}
+
+func (r *Recorder) save(key string, f *fixture) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode fixture")
+	}
+	if err := os.WriteFile(r.path(key), append(data, '\n'), 0o644); err != nil {
+		return errors.Wrap(err, "failed to write fixture")
+	}
+	return nil
+}
+
+// scrub returns a copy of decoded JSON with credentials, emails and account
+// numbers removed
+func scrub(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			s, isString := val.(string)
+			name := strings.ToLower(k)
+			switch {
+			case isString && s != "" && secretKeys[name]:
+				out[k] = redactedValue
+			case isString && accountNumberKeys[name]:
+				out[k] = zeroDigits(s)
+			default:
+				out[k] = scrub(val)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = scrub(val)
+		}
+		return out
+	case string:
+		return emailPattern.ReplaceAllString(v, scrubbedEmail)
+	default:
+		return v
+	}
+}
+
+// zeroDigits replaces every digit with 0
+func zeroDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return '0'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,179 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecordingServer serves GetAccounts with one more account on each call
+func newRecordingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		var accounts []string
+		for i := int32(1); i <= n; i++ {
+			accounts = append(accounts, fmt.Sprintf(
+				`{"id": "acc-%d", "displayName": "Checking for jane.doe@example.org", "mask": "98%d4"}`, i, i))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data": {"accounts": [%s], "token": "secret-session-token"}}`, strings.Join(accounts, ","))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newRecorderClient(t *testing.T, baseURL string, opts *RecorderOptions) *Client {
+	t.Helper()
+	rec, err := NewRecorder(opts)
+	require.NoError(t, err)
+	client, err := NewClient(&ClientOptions{BaseURL: baseURL, Token: "test-token", Recorder: rec})
+	require.NoError(t, err)
+	return client
+}
+
+func TestRecorder_RecordThenReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	srv := newRecordingServer(t)
+
+	recording := newRecorderClient(t, srv.URL, &RecorderOptions{Dir: dir, Mode: RecordModeRecord})
+	first, err := recording.Accounts.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, "9814", first[0].Mask, "the live response is not scrubbed")
+	_, err = recording.Accounts.List(ctx)
+	require.NoError(t, err)
+
+	srv.Close()
+	replaying := newRecorderClient(t, srv.URL, &RecorderOptions{Dir: dir})
+	var counts []int
+	for i := 0; i < 3; i++ {
+		accounts, err := replaying.Accounts.List(ctx)
+		require.NoError(t, err)
+		counts = append(counts, len(accounts))
+	}
+	assert.Equal(t, []int{1, 2, 2}, counts, "responses replay in order and the last repeats")
+
+	accounts, err := replaying.Accounts.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Checking for user@example.com", accounts[0].DisplayName)
+	assert.Equal(t, "0000", accounts[0].Mask)
+}
+
+func TestRecorder_FixturesAreScrubbed(t *testing.T) {
+	dir := t.TempDir()
+	srv := newRecordingServer(t)
+
+	client := newRecorderClient(t, srv.URL, &RecorderOptions{Dir: dir, Mode: RecordModeRecord})
+	_, err := client.Accounts.List(context.Background())
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "GetAccounts-*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(data), "jane.doe@example.org")
+	assert.NotContains(t, string(data), "secret-session-token")
+	assert.NotContains(t, string(data), "test-token")
+	assert.NotContains(t, string(data), "9814")
+
+	var f fixture
+	require.NoError(t, json.Unmarshal(data, &f))
+	assert.Equal(t, "GetAccounts", f.OperationName)
+	require.Len(t, f.Responses, 1)
+	assert.Equal(t, http.StatusOK, f.Responses[0].Status)
+}
+
+func TestRecorder_RerecordingReplacesFixtures(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	for i := 0; i < 2; i++ {
+		client := newRecorderClient(t, newRecordingServer(t).URL, &RecorderOptions{Dir: dir, Mode: RecordModeRecord})
+		_, err := client.Accounts.List(ctx)
+		require.NoError(t, err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var f fixture
+	require.NoError(t, json.Unmarshal(data, &f))
+	assert.Len(t, f.Responses, 1)
+}
+
+func TestRecorder_ReplayMissingFixture(t *testing.T) {
+	client := newRecorderClient(t, "http://127.0.0.1:1", &RecorderOptions{Dir: t.TempDir()})
+
+	_, err := client.Tags.List(context.Background())
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNoRecording), "got %v", err)
+}
+
+func TestRecorder_MatchesOnVariables(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"data": {"account": {"id": %q, "balanceHistory": []}}}`, req.Variables["accountId"])
+	}))
+	defer srv.Close()
+
+	recording := newRecorderClient(t, srv.URL, &RecorderOptions{Dir: dir, Mode: RecordModeRecord})
+	for _, id := range []string{"acc-1", "acc-2"} {
+		_, err := recording.Accounts.GetHistory(ctx, id)
+		require.NoError(t, err)
+	}
+
+	replaying := newRecorderClient(t, srv.URL, &RecorderOptions{Dir: dir})
+	srv.Close()
+	_, err := replaying.Accounts.GetHistory(ctx, "acc-2")
+	assert.NoError(t, err)
+	_, err = replaying.Accounts.GetHistory(ctx, "acc-3")
+	assert.True(t, errors.Is(err, ErrNoRecording), "got %v", err)
+}
+
+func TestRecorder_IgnoreVariables(t *testing.T) {
+	rec, err := NewRecorder(&RecorderOptions{Dir: t.TempDir(), IgnoreVariables: []string{"startDate", "endDate"}})
+	require.NoError(t, err)
+
+	vars := func(day time.Time) map[string]interface{} {
+		return map[string]interface{}{"input": map[string]interface{}{
+			"accountIds": []interface{}{"acc-1"},
+			"startDate":  day.Format("2006-01-02"),
+			"endDate":    day.Format("2006-01-02"),
+		}}
+	}
+	today, err := rec.key("Web_GetHoldings", vars(time.Now()))
+	require.NoError(t, err)
+	tomorrow, err := rec.key("Web_GetHoldings", vars(time.Now().AddDate(0, 0, 1)))
+	require.NoError(t, err)
+	assert.Equal(t, today, tomorrow)
+}
+
+func TestNewRecorder_RequiresDir(t *testing.T) {
+	_, err := NewRecorder(&RecorderOptions{})
+	assert.Error(t, err)
+}
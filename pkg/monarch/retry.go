@@ -0,0 +1,265 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	internalTypes "github.com/eshaffer321/monarchmoney-go/internal/types"
+)
+
+// RetryPolicy retries failed GraphQL operations. Unlike RetryConfig, which
+// retries at the HTTP level, it classifies errors, waits as long as the
+// server asks in Retry-After and treats mutations with care: a mutation is
+// only retried when the request was rejected before it ran, or when its
+// Dedupe check shows it did not apply.
+type RetryPolicy struct {
+	// MaxAttempts is the most times an operation is tried (default 3)
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for each
+	// further retry (default 500ms)
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay, including one asked for by Retry-After
+	// (default 30s)
+	MaxDelay time.Duration
+
+	// Jitter randomizes each delay by up to this fraction of it (default
+	// 0.2, negative for none)
+	Jitter float64
+
+	// Retryable decides which errors are worth retrying (default IsRetryable)
+	Retryable func(error) bool
+
+	// Dedupe maps mutation operation names to checks that tell whether a
+	// failed mutation was applied anyway
+	Dedupe map[string]DedupeCheck
+
+	// OnAttempt is called after every attempt
+	OnAttempt func(ctx context.Context, attempt RetryAttempt)
+}
+
+// RetryAttempt describes one attempt at an operation
+type RetryAttempt struct {
+	Operation string
+	Mutation  bool
+	Attempt   int
+	Err       error
+	Delay     time.Duration // Wait before the next attempt
+	Retry     bool          // Whether another attempt follows
+}
+
+// DedupeCheck reports whether a mutation sent with variables at or after
+// since was applied, so that it is not retried and applied twice. A check
+// that finds what the mutation created returns it as the mutation's response
+// data, which the caller then receives as if the mutation had succeeded.
+type DedupeCheck func(ctx context.Context, client *Client, variables map[string]interface{}, since time.Time) (applied bool, response interface{}, err error)
+
+// DefaultRetryPolicy returns a policy with the default settings and a
+// dedupe check for creating transactions
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		Dedupe: map[string]DedupeCheck{
+			"Common_CreateTransactionMutation": DedupeCreateTransaction,
+		},
+	}
+}
+
+// DedupeCreateTransaction looks for a transaction matching the input of a
+// failed Common_CreateTransactionMutation that was created since it was sent,
+// and returns it as the mutation's response
+func DedupeCreateTransaction(ctx context.Context, client *Client, variables map[string]interface{}, since time.Time) (bool, interface{}, error) {
+	input, ok := variables["input"].(map[string]interface{})
+	if !ok {
+		return false, nil, errors.New("missing transaction input")
+	}
+	date, err := time.Parse("2006-01-02", fmt.Sprint(input["date"]))
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid transaction date: %w", err)
+	}
+	amount, ok := input["amount"].(float64)
+	if !ok {
+		return false, nil, errors.New("invalid transaction amount")
+	}
+	accountID, _ := input["accountId"].(string)
+	merchant, _ := input["merchantName"].(string)
+	notes, _ := input["notes"].(string)
+
+	query := client.Transactions.Query().Between(date, date).Limit(100)
+	if accountID != "" {
+		query = query.WithAccounts(accountID)
+	}
+	list, err := query.Execute(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Allow for clock skew between us and the server
+	since = since.Add(-5 * time.Minute)
+	for _, txn := range list.Transactions {
+		if math.Abs(txn.Amount-amount) > 0.005 {
+			continue
+		}
+		if merchant != "" && (txn.Merchant == nil || !strings.EqualFold(txn.Merchant.Name, merchant)) {
+			continue
+		}
+		if notes != "" && txn.Notes != notes {
+			continue
+		}
+		// An identical transaction created earlier is not this one
+		if txn.CreatedAt.IsZero() || txn.CreatedAt.Before(since) {
+			continue
+		}
+		return true, map[string]interface{}{
+			"createTransaction": map[string]interface{}{"transaction": txn, "errors": []interface{}{}},
+		}, nil
+	}
+	return false, nil, nil
+}
+
+// withDefaults returns a copy of the policy with defaults filled in
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 500 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 30 * time.Second
+	}
+	switch {
+	case p.Jitter == 0:
+		p.Jitter = 0.2
+	case p.Jitter < 0:
+		p.Jitter = 0
+	case p.Jitter > 1:
+		p.Jitter = 1
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsRetryable
+	}
+	return p
+}
+
+// delay returns how long to wait after the given failed attempt
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var apiErr *internalTypes.Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		// The server said when to come back, so don't add jitter
+		if apiErr.RetryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return apiErr.RetryAfter
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	return time.Duration(delay)
+}
+
+// shouldRetry decides whether to retry a failed attempt. It returns the
+// error to report, which differs from err when a mutation was applied: nil
+// when the dedupe check decoded the applied result into result, and
+// ErrMutationApplied otherwise.
+func (p RetryPolicy) shouldRetry(ctx context.Context, c *Client, operation string, mutation bool, variables map[string]interface{}, since time.Time, err error, result interface{}) (bool, error) {
+	if ctx.Err() != nil || !p.Retryable(err) {
+		return false, err
+	}
+	if !mutation || rejectedBeforeExecution(err) {
+		return true, err
+	}
+
+	check, ok := p.Dedupe[operation]
+	if !ok {
+		return false, err
+	}
+	applied, response, checkErr := check(ctx, c, variables, since)
+	if checkErr != nil {
+		return false, err
+	}
+	if !applied {
+		return true, err
+	}
+	if response != nil && result != nil {
+		if data, marshalErr := json.Marshal(response); marshalErr == nil && json.Unmarshal(data, result) == nil {
+			return false, nil
+		}
+	}
+	return false, &Error{
+		Code:    "MUTATION_APPLIED",
+		Message: fmt.Sprintf("%s failed with %q but was applied", operation, err.Error()),
+		Err:     ErrMutationApplied,
+	}
+}
+
+// rejectedBeforeExecution reports whether the server certainly did not run
+// the request, which makes it safe to send a mutation again
+func rejectedBeforeExecution(err error) bool {
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// executeWithRetry executes a GraphQL operation under the RetryPolicy and
+// returns how many times it was retried
+func (c *Client) executeWithRetry(ctx context.Context, query string, variables map[string]interface{}, result interface{}) (int, error) {
+	if c.options.RetryPolicy == nil {
+		return 0, c.execute(ctx, query, variables, result)
+	}
+	policy := c.options.RetryPolicy.withDefaults()
+	operation := OperationFromContext(ctx)
+	mutation := operationType(query) == "mutation"
+
+	for attempt := 1; ; attempt++ {
+		sent := time.Now()
+		err := c.execute(ctx, query, variables, result)
+
+		retry := false
+		var delay time.Duration
+		if err != nil && attempt < policy.MaxAttempts {
+			retry, err = policy.shouldRetry(ctx, c, operation, mutation, variables, sent, err, result)
+			if retry {
+				delay = policy.delay(attempt, err)
+			}
+		}
+
+		if policy.OnAttempt != nil {
+			policy.OnAttempt(ctx, RetryAttempt{
+				Operation: operation,
+				Mutation:  mutation,
+				Attempt:   attempt,
+				Err:       err,
+				Delay:     delay,
+				Retry:     retry,
+			})
+		}
+		if !retry {
+			return attempt - 1, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt - 1, err
+		}
+
+		if waitErr := c.waitForRateLimit(ctx); waitErr != nil {
+			return attempt - 1, fmt.Errorf("rate limiter: %w", waitErr)
+		}
+	}
+}
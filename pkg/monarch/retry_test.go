@@ -0,0 +1,268 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// retryServer fails the first failures requests for each operation with
+// status, then answers with the operation's canned response
+type retryServer struct {
+	*httptest.Server
+
+	status    int
+	failures  int32
+	responses map[string]string
+	calls     map[string]*int32
+}
+
+func newRetryServer(t *testing.T, status int, failures int32, responses map[string]string) *retryServer {
+	t.Helper()
+	s := &retryServer{status: status, failures: failures, responses: responses, calls: map[string]*int32{}}
+	for op := range responses {
+		s.calls[op] = new(int32)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			OperationName string `json:"operationName"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		calls, ok := s.calls[req.OperationName]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if atomic.AddInt32(calls, 1) <= s.failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(s.status)
+			return
+		}
+		fmt.Fprint(w, s.responses[req.OperationName])
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *retryServer) callCount(op string) int32 {
+	return atomic.LoadInt32(s.calls[op])
+}
+
+func newRetryClient(t *testing.T, url string, policy *RetryPolicy) *Client {
+	t.Helper()
+	client, err := NewClient(&ClientOptions{BaseURL: url, Token: "test-token", RetryPolicy: policy})
+	require.NoError(t, err)
+	return client
+}
+
+const createTransactionResponse = `{"data": {"createTransaction": {"transaction": {"id": "txn-new"}, "errors": []}}}`
+
+func createParams() *CreateTransactionParams {
+	return &CreateTransactionParams{
+		Date:       Date{Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		AccountID:  "acc-1",
+		Amount:     -12.5,
+		Merchant:   &Merchant{Name: "Coffee Shop"},
+		CategoryID: "cat-1",
+	}
+}
+
+func TestRetryPolicy_RetriesQueries(t *testing.T) {
+	srv := newRetryServer(t, http.StatusBadGateway, 2, map[string]string{
+		"GetAccounts": `{"data": {"accounts": [{"id": "acc-1"}]}}`,
+	})
+	var attempts []RetryAttempt
+	client := newRetryClient(t, srv.URL, &RetryPolicy{
+		BaseDelay: time.Millisecond,
+		OnAttempt: func(ctx context.Context, attempt RetryAttempt) {
+			attempts = append(attempts, attempt)
+		},
+	})
+
+	accounts, err := client.Accounts.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.Equal(t, int32(3), srv.callCount("GetAccounts"))
+
+	require.Len(t, attempts, 3)
+	assert.Equal(t, "GetAccounts", attempts[0].Operation)
+	assert.False(t, attempts[0].Mutation)
+	assert.True(t, attempts[0].Retry)
+	assert.True(t, errors.Is(attempts[0].Err, ErrServerError))
+	assert.Equal(t, 3, attempts[2].Attempt)
+	assert.NoError(t, attempts[2].Err)
+	assert.False(t, attempts[2].Retry)
+}
+
+func TestRetryPolicy_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv := newRetryServer(t, http.StatusServiceUnavailable, 10, map[string]string{
+		"GetAccounts": `{"data": {"accounts": []}}`,
+	})
+	client := newRetryClient(t, srv.URL, &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+	_, err := client.Accounts.List(context.Background())
+	assert.True(t, errors.Is(err, ErrServerError), "got %v", err)
+	assert.Equal(t, int32(2), srv.callCount("GetAccounts"))
+}
+
+func TestRetryPolicy_HonorsRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"accounts": []}}`)
+	}))
+	defer srv.Close()
+
+	var delay time.Duration
+	client := newRetryClient(t, srv.URL, &RetryPolicy{
+		BaseDelay: time.Millisecond,
+		OnAttempt: func(ctx context.Context, attempt RetryAttempt) {
+			if attempt.Retry {
+				delay = attempt.Delay
+			}
+		},
+	})
+
+	start := time.Now()
+	_, err := client.Accounts.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, delay)
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+func TestRetryPolicy_DoesNotRetryClientErrors(t *testing.T) {
+	srv := newRetryServer(t, http.StatusBadRequest, 1, map[string]string{
+		"GetAccounts": `{"data": {"accounts": []}}`,
+	})
+	client := newRetryClient(t, srv.URL, &RetryPolicy{BaseDelay: time.Millisecond})
+
+	_, err := client.Accounts.List(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), srv.callCount("GetAccounts"))
+}
+
+func TestRetryPolicy_MutationWithoutDedupeIsNotRetried(t *testing.T) {
+	srv := newRetryServer(t, http.StatusBadGateway, 1, map[string]string{
+		"Common_CreateTransactionMutation": createTransactionResponse,
+	})
+	client := newRetryClient(t, srv.URL, &RetryPolicy{BaseDelay: time.Millisecond})
+
+	_, err := client.Transactions.Create(context.Background(), createParams())
+	assert.True(t, errors.Is(err, ErrServerError), "got %v", err)
+	assert.Equal(t, int32(1), srv.callCount("Common_CreateTransactionMutation"))
+}
+
+func TestRetryPolicy_RateLimitedMutationIsRetried(t *testing.T) {
+	srv := newRetryServer(t, http.StatusTooManyRequests, 1, map[string]string{
+		"Common_CreateTransactionMutation": createTransactionResponse,
+	})
+	client := newRetryClient(t, srv.URL, &RetryPolicy{BaseDelay: time.Millisecond})
+
+	txn, err := client.Transactions.Create(context.Background(), createParams())
+	require.NoError(t, err)
+	assert.Equal(t, "txn-new", txn.ID)
+	assert.Equal(t, int32(2), srv.callCount("Common_CreateTransactionMutation"))
+}
+
+func TestRetryPolicy_DedupeRetriesUnappliedMutation(t *testing.T) {
+	srv := newRetryServer(t, http.StatusBadGateway, 1, map[string]string{
+		"Common_CreateTransactionMutation": createTransactionResponse,
+		"GetTransactionsList":              `{"data": {"allTransactions": {"totalCount": 0, "results": []}}}`,
+	})
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := newRetryClient(t, srv.URL, policy)
+
+	txn, err := client.Transactions.Create(context.Background(), createParams())
+	require.NoError(t, err)
+	assert.Equal(t, "txn-new", txn.ID)
+	assert.Equal(t, int32(2), srv.callCount("Common_CreateTransactionMutation"))
+	assert.Equal(t, int32(2), srv.callCount("GetTransactionsList"), "the lookup is a query and is retried too")
+}
+
+func TestRetryPolicy_DedupeReturnsAppliedTransaction(t *testing.T) {
+	srv := newRetryServer(t, http.StatusBadGateway, 1, map[string]string{
+		"Common_CreateTransactionMutation": createTransactionResponse,
+		"GetTransactionsList": `{"data": {"allTransactions": {"totalCount": 1, "results": [{
+			"id": "txn-applied", "amount": -12.5, "date": "2024-03-01",
+			"merchant": {"name": "Coffee Shop"}, "createdAt": "` + time.Now().UTC().Format(time.RFC3339) + `"
+		}]}}}`,
+	})
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := newRetryClient(t, srv.URL, policy)
+
+	txn, err := client.Transactions.Create(context.Background(), createParams())
+	require.NoError(t, err)
+	assert.Equal(t, "txn-applied", txn.ID)
+	assert.Equal(t, int32(1), srv.callCount("Common_CreateTransactionMutation"))
+}
+
+func TestRetryPolicy_DedupeIgnoresEarlierTransactions(t *testing.T) {
+	srv := newRetryServer(t, http.StatusBadGateway, 1, map[string]string{
+		"Common_CreateTransactionMutation": createTransactionResponse,
+		"GetTransactionsList": `{"data": {"allTransactions": {"totalCount": 2, "results": [
+			{"id": "txn-undated", "amount": -12.5, "date": "2024-03-01", "merchant": {"name": "Coffee Shop"}},
+			{"id": "txn-old", "amount": -12.5, "date": "2024-03-01", "merchant": {"name": "Coffee Shop"},
+			 "createdAt": "` + time.Now().Add(-time.Hour).UTC().Format(time.RFC3339) + `"}
+		]}}}`,
+	})
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := newRetryClient(t, srv.URL, policy)
+
+	txn, err := client.Transactions.Create(context.Background(), createParams())
+	require.NoError(t, err)
+	assert.Equal(t, "txn-new", txn.ID)
+	assert.Equal(t, int32(2), srv.callCount("Common_CreateTransactionMutation"))
+}
+
+func TestDedupeCreateTransaction_WithoutAccount(t *testing.T) {
+	var filters map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				Filters map[string]interface{} `json:"filters"`
+			} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		filters = req.Variables.Filters
+		fmt.Fprint(w, `{"data": {"allTransactions": {"totalCount": 0, "results": []}}}`)
+	}))
+	t.Cleanup(srv.Close)
+	client := newRetryClient(t, srv.URL, nil)
+
+	applied, _, err := DedupeCreateTransaction(context.Background(), client, map[string]interface{}{
+		"input": map[string]interface{}{"date": "2024-03-01", "amount": -12.5},
+	}, time.Now())
+	require.NoError(t, err)
+	assert.False(t, applied)
+	assert.NotContains(t, filters, "accounts")
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond, Jitter: -1}.withDefaults()
+	assert.Equal(t, 100*time.Millisecond, policy.delay(1, ErrServerError))
+	assert.Equal(t, 200*time.Millisecond, policy.delay(2, ErrServerError))
+	assert.Equal(t, 300*time.Millisecond, policy.delay(3, ErrServerError), "capped at MaxDelay")
+
+	jittered := RetryPolicy{BaseDelay: 100 * time.Millisecond}.withDefaults()
+	for i := 0; i < 20; i++ {
+		delay := jittered.delay(1, ErrServerError)
+		assert.GreaterOrEqual(t, delay, 80*time.Millisecond)
+		assert.LessOrEqual(t, delay, 120*time.Millisecond)
+	}
+}
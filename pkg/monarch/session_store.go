@@ -0,0 +1,137 @@
+package monarch
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/auth"
+	internalTypes "github.com/eshaffer321/monarchmoney-go/internal/types"
+	"github.com/pkg/errors"
+)
+
+// SessionStore persists the session between runs. Load returns
+// ErrNotAuthenticated when no session is stored.
+type SessionStore interface {
+	Load() (*Session, error)
+	Save(session *Session) error
+	Delete() error
+}
+
+// NewFileSessionStore stores the session as plaintext JSON in a file readable
+// only by its owner. This is what ClientOptions.SessionFile uses.
+func NewFileSessionStore(path string) SessionStore {
+	return &sessionStore{store: auth.NewFileStore(path)}
+}
+
+// NewEncryptedSessionStore stores the session in a file encrypted with
+// AES-256-GCM using a 32-byte key
+func NewEncryptedSessionStore(path string, key []byte) (SessionStore, error) {
+	store, err := auth.NewEncryptedFileStore(path, key)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionStore{store: store}, nil
+}
+
+// NewPassphraseSessionStore stores the session in a file encrypted with
+// AES-256-GCM using a key derived from passphrase with scrypt
+func NewPassphraseSessionStore(path, passphrase string) (SessionStore, error) {
+	store, err := auth.NewPassphraseFileStore(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionStore{store: store}, nil
+}
+
+// NewMemorySessionStore keeps the session in memory only
+func NewMemorySessionStore() SessionStore {
+	return &sessionStore{store: auth.NewMemoryStore()}
+}
+
+// NewSecretsDirSessionStore stores the session in the file called name in a
+// secrets directory, such as /run/secrets. A secret holding just a token is
+// loaded as a session with that token.
+func NewSecretsDirSessionStore(dir, name string) (SessionStore, error) {
+	store, err := auth.NewSecretsDirStore(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionStore{store: store}, nil
+}
+
+// SessionKeyFromEnv reads a 32-byte encryption key for
+// NewEncryptedSessionStore from an environment variable, hex or base64 encoded
+func SessionKeyFromEnv(name string) ([]byte, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return nil, errors.Errorf("%s is not set", name)
+	}
+	if key, err := hex.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(value); err == nil && len(key) == 32 {
+			return key, nil
+		}
+	}
+	return nil, errors.Errorf("%s must hold a 32-byte key, hex or base64 encoded", name)
+}
+
+// sessionStore exposes an internal store as a SessionStore
+type sessionStore struct {
+	store auth.SessionStore
+}
+
+func (s *sessionStore) Load() (*Session, error) {
+	session, err := s.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	converted := Session(*session)
+	return &converted, nil
+}
+
+func (s *sessionStore) Save(session *Session) error {
+	converted := internalTypes.Session(*session)
+	return s.store.Save(&converted)
+}
+
+func (s *sessionStore) Delete() error {
+	return s.store.Delete()
+}
+
+// externalStore adapts a caller's SessionStore for the internal auth service
+type externalStore struct {
+	store SessionStore
+}
+
+func (s *externalStore) Load() (*internalTypes.Session, error) {
+	session, err := s.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrNotAuthenticated
+	}
+	converted := internalTypes.Session(*session)
+	return &converted, nil
+}
+
+func (s *externalStore) Save(session *internalTypes.Session) error {
+	converted := Session(*session)
+	return s.store.Save(&converted)
+}
+
+func (s *externalStore) Delete() error {
+	return s.store.Delete()
+}
+
+// internalStore returns the internal form of store
+func internalStore(store SessionStore) auth.SessionStore {
+	if s, ok := store.(*sessionStore); ok {
+		return s.store
+	}
+	return &externalStore{store: store}
+}
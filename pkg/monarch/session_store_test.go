@@ -0,0 +1,163 @@
+package monarch
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSession() *Session {
+	return &Session{
+		Token:      "secret-session-token",
+		UserID:     "user-1",
+		Email:      "user@example.com",
+		ExpiresAt:  time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		DeviceUUID: "device-1",
+	}
+}
+
+func TestSessionStores_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	key := make([]byte, 32)
+	key[0] = 7
+
+	encrypted, err := NewEncryptedSessionStore(filepath.Join(dir, "key.enc"), key)
+	require.NoError(t, err)
+	passphrase, err := NewPassphraseSessionStore(filepath.Join(dir, "pass.enc"), "correct horse")
+	require.NoError(t, err)
+	secrets, err := NewSecretsDirSessionStore(filepath.Join(dir, "secrets"), "monarch-session")
+	require.NoError(t, err)
+
+	stores := map[string]SessionStore{
+		"file":       NewFileSessionStore(filepath.Join(dir, "session.json")),
+		"encrypted":  encrypted,
+		"passphrase": passphrase,
+		"memory":     NewMemorySessionStore(),
+		"secrets":    secrets,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Load()
+			assert.True(t, errors.Is(err, ErrNotAuthenticated), "got %v", err)
+
+			want := testSession()
+			require.NoError(t, store.Save(want))
+			got, err := store.Load()
+			require.NoError(t, err)
+			assert.Equal(t, want.Token, got.Token)
+			assert.True(t, want.ExpiresAt.Equal(got.ExpiresAt))
+			assert.Equal(t, want.DeviceUUID, got.DeviceUUID)
+
+			require.NoError(t, store.Delete())
+			_, err = store.Load()
+			assert.True(t, errors.Is(err, ErrNotAuthenticated), "got %v", err)
+			require.NoError(t, store.Delete(), "deleting twice is fine")
+		})
+	}
+}
+
+func TestSessionStores_EncryptedAtRest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.enc")
+
+	store, err := NewPassphraseSessionStore(path, "correct horse")
+	require.NoError(t, err)
+	require.NoError(t, store.Save(testSession()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-session-token")
+	assert.NotContains(t, string(data), "user@example.com")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	wrong, err := NewPassphraseSessionStore(path, "wrong horse")
+	require.NoError(t, err)
+	_, err = wrong.Load()
+	assert.Error(t, err)
+
+	keyed, err := NewEncryptedSessionStore(path, make([]byte, 32))
+	require.NoError(t, err)
+	_, err = keyed.Load()
+	assert.Error(t, err, "a passphrase file cannot be opened with a key")
+
+	_, err = NewEncryptedSessionStore(path, []byte("short"))
+	assert.Error(t, err)
+	_, err = NewPassphraseSessionStore(path, "")
+	assert.Error(t, err)
+}
+
+func TestSecretsDirSessionStore_BareToken(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "monarch"), []byte("mounted-token\n"), 0600))
+
+	store, err := NewSecretsDirSessionStore(dir, "monarch")
+	require.NoError(t, err)
+	session, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "mounted-token", session.Token)
+
+	_, err = NewSecretsDirSessionStore(dir, "../escape")
+	assert.Error(t, err)
+}
+
+func TestSessionKeyFromEnv(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	t.Setenv("MONARCH_TEST_KEY", base64.StdEncoding.EncodeToString(key))
+	got, err := SessionKeyFromEnv("MONARCH_TEST_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	t.Setenv("MONARCH_TEST_KEY", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	got, err = SessionKeyFromEnv("MONARCH_TEST_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	t.Setenv("MONARCH_TEST_KEY", "too-short")
+	_, err = SessionKeyFromEnv("MONARCH_TEST_KEY")
+	assert.Error(t, err)
+
+	_, err = SessionKeyFromEnv("MONARCH_TEST_KEY_UNSET")
+	assert.Error(t, err)
+}
+
+func TestClient_UsesSessionStore(t *testing.T) {
+	srv := newAuthServer(t, false)
+	store := NewMemorySessionStore()
+
+	client, err := NewClient(&ClientOptions{BaseURL: srv.URL, SessionStore: store})
+	require.NoError(t, err)
+	require.NoError(t, client.Auth.Login(context.Background(), "user@example.com", "secret"))
+
+	saved, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", saved.Token)
+
+	// A new client picks the session up from the store
+	next, err := NewClient(&ClientOptions{BaseURL: srv.URL, SessionStore: store})
+	require.NoError(t, err)
+	accounts, err := next.Accounts.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, accounts, 1)
+
+	expired := testSession()
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, store.Save(expired))
+	err = next.Auth.LoadSessionFrom(store)
+	assert.True(t, errors.Is(err, ErrSessionExpired), "got %v", err)
+}
@@ -0,0 +1,186 @@
+package monarch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	internalTypes "github.com/eshaffer321/monarchmoney-go/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the client to OpenTelemetry
+const instrumentationName = "github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+
+// telemetry holds the OpenTelemetry instruments for a client. Either half
+// is optional: a nil tracer records no spans, a nil meter no metrics.
+type telemetry struct {
+	tracer trace.Tracer
+
+	duration      metric.Float64Histogram
+	errors        metric.Int64Counter
+	rateLimitWait metric.Float64Histogram
+}
+
+// newTelemetry creates instruments from the providers in opts, or returns
+// nil when neither is set
+func newTelemetry(opts *ClientOptions) (*telemetry, error) {
+	if opts.TracerProvider == nil && opts.MeterProvider == nil {
+		return nil, nil
+	}
+
+	t := &telemetry{}
+	if opts.TracerProvider != nil {
+		t.tracer = opts.TracerProvider.Tracer(instrumentationName)
+	}
+	if opts.MeterProvider != nil {
+		meter := opts.MeterProvider.Meter(instrumentationName)
+
+		var err error
+		t.duration, err = meter.Float64Histogram("monarch.client.request.duration",
+			metric.WithUnit("s"),
+			metric.WithDescription("Duration of GraphQL operations, including retries"))
+		if err != nil {
+			return nil, err
+		}
+		t.errors, err = meter.Int64Counter("monarch.client.errors",
+			metric.WithUnit("{error}"),
+			metric.WithDescription("Failed GraphQL operations by error code"))
+		if err != nil {
+			return nil, err
+		}
+		t.rateLimitWait, err = meter.Float64Histogram("monarch.client.rate_limit.wait",
+			metric.WithUnit("s"),
+			metric.WithDescription("Time spent waiting for the rate limiter"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// startSpan starts a span for a GraphQL operation
+func (t *telemetry) startSpan(ctx context.Context, operation, query string, variables map[string]interface{}) (context.Context, trace.Span) {
+	if t == nil || t.tracer == nil {
+		return ctx, nil
+	}
+	opType := operationType(query)
+	return t.tracer.Start(ctx, opType+" "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("graphql.operation.name", operation),
+			attribute.String("graphql.operation.type", opType),
+			attribute.Int("monarch.variables.size", variablesSize(variables)),
+		))
+}
+
+// end finishes the span and records the operation's metrics
+func (t *telemetry) end(ctx context.Context, span trace.Span, operation string, retries int, duration time.Duration, err error) {
+	if t == nil {
+		return
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	code := errorCode(err)
+
+	if span != nil {
+		span.SetAttributes(
+			attribute.String("monarch.status", status),
+			attribute.Int("monarch.retry.count", retries),
+		)
+		if err != nil {
+			span.SetAttributes(attribute.String("monarch.error.code", code))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+
+	opAttr := attribute.String("graphql.operation.name", operation)
+	if t.duration != nil {
+		t.duration.Record(ctx, duration.Seconds(),
+			metric.WithAttributes(opAttr, attribute.String("monarch.status", status)))
+	}
+	if err != nil && t.errors != nil {
+		t.errors.Add(ctx, 1, metric.WithAttributes(opAttr, attribute.String("monarch.error.code", code)))
+	}
+}
+
+// recordRateLimitWait records time spent in RateLimiter.Wait
+func (t *telemetry) recordRateLimitWait(ctx context.Context, wait time.Duration) {
+	if t == nil || t.rateLimitWait == nil {
+		return
+	}
+	t.rateLimitWait.Record(ctx, wait.Seconds(),
+		metric.WithAttributes(attribute.String("graphql.operation.name", OperationFromContext(ctx))))
+}
+
+// operationType returns "query", "mutation" or "subscription"
+func operationType(query string) string {
+	query = strings.TrimSpace(query)
+	for _, opType := range []string{"mutation", "subscription"} {
+		if strings.HasPrefix(query, opType) {
+			return opType
+		}
+	}
+	return "query"
+}
+
+// variablesSize returns the size of the encoded variables in bytes
+func variablesSize(variables map[string]interface{}) int {
+	if len(variables) == 0 {
+		return 0
+	}
+	data, err := json.Marshal(variables)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// errorCode classifies an error for metrics, preferring the API's code
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Code != "" {
+		return apiErr.Code
+	}
+	var internalErr *internalTypes.Error
+	if errors.As(err, &internalErr) && internalErr.Code != "" {
+		return internalErr.Code
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "CANCELED"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrTimeout):
+		return "TIMEOUT"
+	case errors.Is(err, ErrRateLimited):
+		return "RATE_LIMITED"
+	case errors.Is(err, ErrSessionExpired):
+		return "SESSION_EXPIRED"
+	case IsAuthError(err):
+		return "UNAUTHENTICATED"
+	case errors.Is(err, ErrNotFound):
+		return "NOT_FOUND"
+	case errors.Is(err, ErrServerError):
+		return "SERVER_ERROR"
+	}
+
+	var gqlErrs *internalTypes.GraphQLErrors
+	if errors.As(err, &gqlErrs) {
+		return "GRAPHQL_ERROR"
+	}
+	return "UNKNOWN"
+}
@@ -0,0 +1,145 @@
+package monarch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTelemetryClient(t *testing.T, handler http.HandlerFunc, opts *ClientOptions) (*Client, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	opts.BaseURL = srv.URL
+	opts.Token = "test-token"
+	opts.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	opts.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client, err := NewClient(opts)
+	require.NoError(t, err)
+	return client, spans, reader
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestTelemetry_RecordsSpanAndMetrics(t *testing.T) {
+	var calls int32
+	client, spans, reader := newTelemetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"accounts": []}}`)
+	}, &ClientOptions{
+		RetryPolicy: &RetryPolicy{BaseDelay: time.Millisecond},
+		RateLimiter: NewTokenBucket(100, 1),
+	})
+
+	_, err := client.Accounts.List(context.Background())
+	require.NoError(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	span := ended[0]
+	assert.Equal(t, "query GetAccounts", span.Name())
+	assert.Equal(t, "GetAccounts", spanAttr(span, "graphql.operation.name").AsString())
+	assert.Equal(t, "query", spanAttr(span, "graphql.operation.type").AsString())
+	assert.Equal(t, "ok", spanAttr(span, "monarch.status").AsString())
+	assert.Equal(t, int64(1), spanAttr(span, "monarch.retry.count").AsInt64())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+
+	metrics := collectMetrics(t, reader)
+	duration, ok := metrics["monarch.client.request.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, duration.DataPoints, 1)
+	assert.Equal(t, uint64(1), duration.DataPoints[0].Count)
+
+	wait, ok := metrics["monarch.client.rate_limit.wait"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, wait.DataPoints, 1)
+	assert.Equal(t, uint64(2), wait.DataPoints[0].Count, "the retry waits on the limiter too")
+
+	_, ok = metrics["monarch.client.errors"]
+	assert.False(t, ok, "no errors are counted for a successful operation")
+}
+
+func TestTelemetry_RecordsErrors(t *testing.T) {
+	client, spans, reader := newTelemetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, &ClientOptions{})
+
+	_, err := client.Accounts.List(context.Background())
+	require.Error(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+	assert.Equal(t, "RATE_LIMITED", spanAttr(ended[0], "monarch.error.code").AsString())
+
+	counter, ok := collectMetrics(t, reader)["monarch.client.errors"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, counter.DataPoints, 1)
+	assert.Equal(t, int64(1), counter.DataPoints[0].Value)
+	code, _ := counter.DataPoints[0].Attributes.Value("monarch.error.code")
+	assert.Equal(t, "RATE_LIMITED", code.AsString())
+}
+
+func TestTelemetry_SpanNestsUnderCaller(t *testing.T) {
+	client, spans, _ := newTelemetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"accounts": []}}`)
+	}, &ClientOptions{})
+
+	tracer := client.options.TracerProvider.Tracer("caller")
+	ctx, parent := tracer.Start(context.Background(), "sync")
+	_, err := client.Accounts.List(ctx)
+	require.NoError(t, err)
+	parent.End()
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(t, parent.SpanContext().SpanID(), ended[0].Parent().SpanID())
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, "", errorCode(nil))
+	assert.Equal(t, "REAUTH_FAILED", errorCode(&Error{Code: "REAUTH_FAILED"}))
+	assert.Equal(t, "NOT_FOUND", errorCode(fmt.Errorf("wrapped: %w", ErrNotFound)))
+	assert.Equal(t, "CANCELED", errorCode(context.Canceled))
+	assert.Equal(t, "UNKNOWN", errorCode(fmt.Errorf("boom")))
+}
@@ -559,11 +559,13 @@ func (b *transactionQueryBuilder) Stream(ctx context.Context) (<-chan *Transacti
 		for {
 			// Create a copy of builder with current offset
 			queryBuilder := &transactionQueryBuilder{
-				client:  b.client,
-				filters: b.filters,
-				limit:   limit,
-				offset:  offset,
-				orderBy: b.orderBy,
+				client:    b.client,
+				filters:   b.filters,
+				limit:     limit,
+				offset:    offset,
+				orderBy:   b.orderBy,
+				minAmount: b.minAmount,
+				maxAmount: b.maxAmount,
 			}
 
 			// Execute query
@@ -199,6 +199,43 @@ func TestTransactionService_Stream(t *testing.T) {
 	mockTransport.AssertExpectations(t)
 }
 
+func TestTransactionService_Stream_KeepsAmountFilters(t *testing.T) {
+	mockTransport := new(MockTransport)
+	client := &Client{
+		transport:   mockTransport,
+		queryLoader: graphql.NewQueryLoader(),
+		options:     &ClientOptions{},
+		baseURL:     "https://api.test.com",
+	}
+	service := newTransactionService(client)
+
+	mockTransport.On("Execute", mock.Anything, mock.AnythingOfType("string"), mock.Anything, mock.Anything).
+		Return(`{
+			"allTransactions": {
+				"totalCount": 3,
+				"results": [
+					{"id": "txn-001", "amount": -10.00},
+					{"id": "txn-002", "amount": -200.00},
+					{"id": "txn-003", "amount": 50.00}
+				]
+			}
+		}`, nil).Once()
+
+	txnChan, errChan := service.Query().
+		WithMinAmount(20).
+		WithMaxAmount(100).
+		Stream(context.Background())
+
+	var ids []string
+	for txn := range txnChan {
+		ids = append(ids, txn.ID)
+	}
+	require.NoError(t, <-errChan)
+
+	assert.Equal(t, []string{"txn-003"}, ids)
+	mockTransport.AssertExpectations(t)
+}
+
 func TestTransactionService_Get(t *testing.T) {
 	// Setup
 	mockTransport := new(MockTransport)
@@ -0,0 +1,649 @@
+package monarchfake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// accountTypes are the account types and subtypes offered for manual accounts
+var accountTypes = []struct {
+	name, display string
+	asset         bool
+	subtypes      []monarch.AccountSubtypeInfo
+}{
+	{"depository", "Cash", true, []monarch.AccountSubtypeInfo{{Name: "checking", Display: "Checking"}, {Name: "savings", Display: "Savings"}}},
+	{"credit", "Credit Cards", false, []monarch.AccountSubtypeInfo{{Name: "credit_card", Display: "Credit Card"}}},
+	{"brokerage", "Investments", true, []monarch.AccountSubtypeInfo{{Name: "brokerage", Display: "Brokerage"}, {Name: "ira", Display: "IRA"}, {Name: "st_401k", Display: "401k"}}},
+	{"loan", "Loans", false, []monarch.AccountSubtypeInfo{{Name: "mortgage", Display: "Mortgage"}, {Name: "auto", Display: "Auto Loan"}}},
+	{"real_estate", "Real Estate", true, []monarch.AccountSubtypeInfo{{Name: "primary_home", Display: "Primary Home"}}},
+	{"other_asset", "Other Assets", true, []monarch.AccountSubtypeInfo{{Name: "other", Display: "Other"}}},
+	{"other_liability", "Other Liabilities", false, []monarch.AccountSubtypeInfo{{Name: "other", Display: "Other"}}},
+}
+
+// AccountService is a fake monarch.AccountService
+type AccountService struct {
+	store *store
+}
+
+// List returns all accounts
+func (s *AccountService) List(ctx context.Context) ([]*monarch.Account, error) {
+	if err := s.store.enter(ctx, "Accounts.List"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	accounts := make([]*monarch.Account, 0, len(s.store.data.Accounts))
+	for _, acc := range s.store.data.Accounts {
+		accounts = append(accounts, s.store.accountCopy(acc))
+	}
+	return accounts, nil
+}
+
+// Get returns an account, or monarch.ErrNotFound
+func (s *AccountService) Get(ctx context.Context, accountID string) (*monarch.Account, error) {
+	if err := s.store.enter(ctx, "Accounts.Get"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	acc := s.store.account(accountID)
+	if acc == nil {
+		return nil, monarch.ErrNotFound
+	}
+	return s.store.accountCopy(acc), nil
+}
+
+// Create adds a manual account. The balance is a display balance, positive
+// for liabilities.
+func (s *AccountService) Create(ctx context.Context, params *monarch.CreateAccountParams) (*monarch.Account, error) {
+	if err := s.store.enter(ctx, "Accounts.Create"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	name := strings.TrimSpace(params.AccountName)
+	if name == "" {
+		return nil, invalid("name is required")
+	}
+	acc, err := s.store.newAccount(name, params.AccountType, params.AccountSubtype)
+	if err != nil {
+		return nil, err
+	}
+	acc.IncludeInNetWorth = params.IncludeInNetWorth
+	sim.SetDisplayBalance(acc, params.CurrentBalance)
+	s.store.data.Accounts = append(s.store.data.Accounts, acc)
+	return s.store.accountCopy(acc), nil
+}
+
+// CreateInvestmentsAccount adds a manual brokerage account with holdings
+func (s *AccountService) CreateInvestmentsAccount(ctx context.Context, params *monarch.CreateInvestmentsAccountParams) (*monarch.Account, error) {
+	if err := s.store.enter(ctx, "Accounts.CreateInvestmentsAccount"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return nil, invalid("name is required")
+	}
+	acc, err := s.store.newAccount(name, "brokerage", params.Subtype)
+	if err != nil {
+		return nil, err
+	}
+	acc.ManualInvestmentsTrackingMethod = params.ManualInvestmentsTrackingMethod
+
+	holdings := make([]*monarch.Holding, 0, len(params.InitialHoldings))
+	for _, initial := range params.InitialHoldings {
+		sec := s.store.security(initial.SecurityID)
+		if sec == nil {
+			return nil, notFound("security", initial.SecurityID)
+		}
+		holdings = append(holdings, &monarch.Holding{
+			ID: s.store.newID("hold"), AccountID: acc.ID, Symbol: sec.Ticker, Name: sec.Name,
+			Quantity: initial.Quantity, UpdatedAt: s.store.now().UTC(),
+		})
+	}
+
+	s.store.data.Accounts = append(s.store.data.Accounts, acc)
+	s.store.data.Holdings = append(s.store.data.Holdings, holdings...)
+	s.store.revalue(acc.ID)
+	return s.store.accountCopy(acc), nil
+}
+
+// Update changes the fields set in params
+func (s *AccountService) Update(ctx context.Context, accountID string, params *monarch.UpdateAccountParams) (*monarch.Account, error) {
+	if err := s.store.enter(ctx, "Accounts.Update"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	acc := s.store.account(accountID)
+	if acc == nil {
+		return nil, notFound("account", accountID)
+	}
+	if params.DisplayName != nil {
+		acc.DisplayName = *params.DisplayName
+	}
+	if params.CurrentBalance != nil {
+		sim.SetDisplayBalance(acc, *params.CurrentBalance)
+	}
+	if params.IncludeInNetWorth != nil {
+		acc.IncludeInNetWorth = *params.IncludeInNetWorth
+	}
+	if params.HideFromList != nil {
+		acc.HideFromList = *params.HideFromList
+	}
+	if params.HideTransactionsFromReports != nil {
+		acc.HideTransactionsFromReports = *params.HideTransactionsFromReports
+	}
+	acc.UpdatedAt = s.store.now().UTC()
+	return s.store.accountCopy(acc), nil
+}
+
+// Delete removes an account together with its transactions and holdings
+func (s *AccountService) Delete(ctx context.Context, accountID string) error {
+	if err := s.store.enter(ctx, "Accounts.Delete"); err != nil {
+		return err
+	}
+	defer s.store.mu.Unlock()
+
+	data := s.store.data
+	if s.store.account(accountID) == nil {
+		return notFound("account", accountID)
+	}
+
+	accounts := data.Accounts[:0]
+	for _, acc := range data.Accounts {
+		if acc.ID != accountID {
+			accounts = append(accounts, acc)
+		}
+	}
+	data.Accounts = accounts
+
+	txns := data.Transactions[:0]
+	for _, t := range data.Transactions {
+		if accountID == sim.AccountOf(t) {
+			delete(data.Splits, t.ID)
+			continue
+		}
+		txns = append(txns, t)
+	}
+	data.Transactions = txns
+
+	holdings := data.Holdings[:0]
+	for _, h := range data.Holdings {
+		if h.AccountID != accountID {
+			holdings = append(holdings, h)
+		}
+	}
+	data.Holdings = holdings
+	return nil
+}
+
+// GetTypes returns the types and subtypes offered for manual accounts
+func (s *AccountService) GetTypes(ctx context.Context) ([]*monarch.AccountType, error) {
+	if err := s.store.enter(ctx, "Accounts.GetTypes"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	var types []*monarch.AccountType
+	for _, t := range accountTypes {
+		possible := make([]*monarch.AccountSubtypeInfo, len(t.subtypes))
+		for i := range t.subtypes {
+			st := t.subtypes[i]
+			possible[i] = &st
+		}
+		for _, st := range possible {
+			types = append(types, &monarch.AccountType{
+				Type:             &monarch.AccountTypeInfo{Name: t.name, Display: t.display},
+				Subtype:          st,
+				PossibleSubtypes: possible,
+			})
+		}
+	}
+	return types, nil
+}
+
+// GetBalances returns each account's daily balance from startDate to today.
+// The start defaults to 31 days ago.
+func (s *AccountService) GetBalances(ctx context.Context, startDate *time.Time) ([]*monarch.AccountBalance, error) {
+	if err := s.store.enter(ctx, "Accounts.GetBalances"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	start := s.store.today().AddDate(0, 0, -31)
+	if startDate != nil {
+		start = *startDate
+	}
+	days := s.store.days(start, s.store.today())
+
+	var balances []*monarch.AccountBalance
+	for _, acc := range s.store.data.Accounts {
+		for _, d := range days {
+			balances = append(balances, &monarch.AccountBalance{
+				AccountID: acc.ID, Date: monarch.Date{Time: d}, Balance: s.store.balanceOn(acc, d),
+			})
+		}
+	}
+	return balances, nil
+}
+
+// GetSnapshots sums the balances of each account type at the end of every
+// month or year since the start date
+func (s *AccountService) GetSnapshots(ctx context.Context, params *monarch.SnapshotParams) ([]*monarch.AccountSnapshot, error) {
+	if params.Timeframe != "year" && params.Timeframe != "month" {
+		return nil, fmt.Errorf("invalid timeframe: %s (must be 'year' or 'month')", params.Timeframe)
+	}
+	if err := s.store.enter(ctx, "Accounts.GetSnapshots"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	start := params.StartDate.UTC()
+	step := func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	period := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if params.Timeframe == "year" {
+		step = func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }
+		period = time.Date(start.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	today := s.store.today()
+	var snapshots []*monarch.AccountSnapshot
+	for ; !period.After(today); period = step(period) {
+		end := step(period).AddDate(0, 0, -1)
+		if end.After(today) {
+			end = today
+		}
+		byType := make(map[[2]string]*monarch.AccountSnapshot)
+		for _, acc := range s.store.data.Accounts {
+			key := [2]string{sim.TypeName(acc), sim.SubtypeName(acc)}
+			snap := byType[key]
+			if snap == nil {
+				snap = &monarch.AccountSnapshot{
+					Month: period.Format("2006-01-02"), Year: period.Year(), Type: key[0], Subtype: key[1],
+				}
+				byType[key] = snap
+				snapshots = append(snapshots, snap)
+			}
+			snap.TotalValue = sim.Round(snap.TotalValue + s.store.balanceOn(acc, end))
+			snap.AccountCount++
+		}
+	}
+	return snapshots, nil
+}
+
+// GetHistory returns an account's daily balances since its first
+// transaction, or for the last 30 days when it has none
+func (s *AccountService) GetHistory(ctx context.Context, accountID string) (*monarch.AccountHistory, error) {
+	if err := s.store.enter(ctx, "Accounts.GetHistory"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	acc := s.store.account(accountID)
+	if acc == nil {
+		return nil, notFound("account", accountID)
+	}
+
+	today := s.store.today()
+	start := today.AddDate(0, 0, -30)
+	for _, t := range s.store.data.Transactions {
+		if sim.AccountOf(t) == acc.ID && t.Date.Before(start) {
+			start = t.Date.Time
+		}
+	}
+
+	history := &monarch.AccountHistory{AccountID: accountID, Balances: make([]*monarch.BalanceEntry, 0)}
+	for _, d := range s.store.days(start, today) {
+		history.Balances = append(history.Balances, &monarch.BalanceEntry{
+			Date: monarch.Date{Time: d}, Balance: s.store.balanceOn(acc, d), Synced: true,
+		})
+	}
+	return history, nil
+}
+
+// GetHoldings returns an account's holdings priced at their securities'
+// current prices
+func (s *AccountService) GetHoldings(ctx context.Context, accountID string) ([]*monarch.Holding, error) {
+	if err := s.store.enter(ctx, "Accounts.GetHoldings"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	var holdings []*monarch.Holding
+	for _, h := range s.store.data.Holdings {
+		if h.AccountID != accountID {
+			continue
+		}
+		c := *h
+		if sec := s.store.securityByTicker(h.Symbol); sec != nil {
+			c.Name = sec.Name
+			c.Price = sec.CurrentPrice
+		}
+		c.Value = sim.Round(c.Quantity * c.Price)
+		holdings = append(holdings, &c)
+	}
+	return holdings, nil
+}
+
+// SearchSecurities matches tickers and names, exact ticker matches first
+func (s *AccountService) SearchSecurities(ctx context.Context, query string, limit int) ([]*monarch.Security, error) {
+	if err := s.store.enter(ctx, "Accounts.SearchSecurities"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	if limit <= 0 {
+		limit = 10
+	}
+	search := strings.ToLower(strings.TrimSpace(query))
+	var exact, partial []*monarch.Security
+	for _, sec := range s.store.data.Securities {
+		c := *sec
+		switch {
+		case search == "":
+		case strings.ToLower(sec.Ticker) == search:
+			exact = append(exact, &c)
+		case strings.Contains(strings.ToLower(sec.Ticker), search),
+			strings.Contains(strings.ToLower(sec.Name), search):
+			partial = append(partial, &c)
+		}
+	}
+
+	results := append(exact, partial...)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// CreateHolding adds a holding to a manual account
+func (s *AccountService) CreateHolding(ctx context.Context, params *monarch.CreateHoldingParams) (*monarch.Holding, error) {
+	if err := s.store.enter(ctx, "Accounts.CreateHolding"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	return s.store.createHolding(params.AccountID, s.store.security(params.SecurityID), params.SecurityID, params.Quantity)
+}
+
+// CreateHoldingByTicker adds a holding of the security with a ticker
+func (s *AccountService) CreateHoldingByTicker(ctx context.Context, accountID, ticker string, quantity float64) (*monarch.Holding, error) {
+	if err := s.store.enter(ctx, "Accounts.CreateHoldingByTicker"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	sec := s.store.securityByTicker(ticker)
+	if sec == nil {
+		return nil, fmt.Errorf("no security found for ticker: %s", ticker)
+	}
+	return s.store.createHolding(accountID, sec, sec.ID, quantity)
+}
+
+// DeleteHolding removes a holding
+func (s *AccountService) DeleteHolding(ctx context.Context, holdingID string) error {
+	if err := s.store.enter(ctx, "Accounts.DeleteHolding"); err != nil {
+		return err
+	}
+	defer s.store.mu.Unlock()
+
+	data := s.store.data
+	for i, h := range data.Holdings {
+		if h.ID == holdingID {
+			data.Holdings = append(data.Holdings[:i], data.Holdings[i+1:]...)
+			s.store.revalue(h.AccountID)
+			return nil
+		}
+	}
+	return notFound("holding", holdingID)
+}
+
+// UpdateHoldingQuantity changes a holding's quantity
+func (s *AccountService) UpdateHoldingQuantity(ctx context.Context, accountID, holdingID string, newQuantity float64) (*monarch.Holding, error) {
+	if err := s.store.enter(ctx, "Accounts.UpdateHoldingQuantity"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	var h *monarch.Holding
+	for _, candidate := range s.store.data.Holdings {
+		if candidate.ID == holdingID {
+			h = candidate
+		}
+	}
+	if h == nil {
+		return nil, notFound("holding", holdingID)
+	}
+	h.Quantity = newQuantity
+	h.UpdatedAt = s.store.now().UTC()
+	s.store.revalue(h.AccountID)
+	return &monarch.Holding{ID: h.ID, AccountID: accountID, Symbol: h.Symbol, Quantity: h.Quantity}, nil
+}
+
+// Refresh starts a refresh of the accounts, or of every account when none
+// are given. See RefreshJob for how refreshes finish.
+func (s *AccountService) Refresh(ctx context.Context, accountIDs ...string) (monarch.RefreshJob, error) {
+	if err := s.store.enter(ctx, "Accounts.Refresh"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	if len(accountIDs) == 0 {
+		for _, acc := range s.store.data.Accounts {
+			accountIDs = append(accountIDs, acc.ID)
+		}
+	}
+	for _, id := range accountIDs {
+		if s.store.account(id) == nil {
+			return nil, notFound("account", id)
+		}
+	}
+	return newRefreshJob(s.store, accountIDs), nil
+}
+
+// RefreshAndWait starts a refresh and waits for it to finish
+func (s *AccountService) RefreshAndWait(ctx context.Context, timeout time.Duration, accountIDs ...string) error {
+	job, err := s.Refresh(ctx, accountIDs...)
+	if err != nil {
+		return err
+	}
+	return job.Wait(ctx, timeout)
+}
+
+// IsRefreshComplete reports whether none of the accounts, or none at all
+// when no IDs are given, are held by Fake.HoldRefresh
+func (s *AccountService) IsRefreshComplete(ctx context.Context, accountIDs ...string) (bool, error) {
+	if err := s.store.enter(ctx, "Accounts.IsRefreshComplete"); err != nil {
+		return false, err
+	}
+	defer s.store.mu.Unlock()
+
+	if len(accountIDs) == 0 {
+		return len(s.store.held) == 0, nil
+	}
+	for _, id := range accountIDs {
+		if s.store.held[id] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// GetAggregateSnapshots returns the daily net worth. Start dates before the
+// first transaction are moved up to it.
+func (s *AccountService) GetAggregateSnapshots(ctx context.Context, params *monarch.AggregateSnapshotsParams) ([]*monarch.AggregateSnapshot, error) {
+	if err := s.store.enter(ctx, "Accounts.GetAggregateSnapshots"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	if params == nil {
+		params = &monarch.AggregateSnapshotsParams{}
+	}
+	today := s.store.today()
+	earliest := today.AddDate(0, 0, -30)
+	for _, t := range s.store.data.Transactions {
+		if t.Date.Before(earliest) {
+			earliest = t.Date.Time
+		}
+	}
+	start := earliest
+	if params.StartDate != nil && params.StartDate.After(start) {
+		start = *params.StartDate
+	}
+	end := today
+	if params.EndDate != nil && params.EndDate.Before(end) {
+		end = *params.EndDate
+	}
+
+	snapshots := make([]*monarch.AggregateSnapshot, 0)
+	for _, d := range s.store.days(start, end) {
+		var total float64
+		for _, acc := range s.store.data.Accounts {
+			if acc.IncludeInNetWorth && (params.AccountType == "" || sim.TypeName(acc) == params.AccountType) {
+				total += s.store.balanceOn(acc, d)
+			}
+		}
+		snapshots = append(snapshots, &monarch.AggregateSnapshot{Date: d.Format("2006-01-02"), Balance: sim.Round(total)})
+	}
+	return snapshots, nil
+}
+
+// UploadBalanceHistory checks the account exists and accepts the upload; the
+// uploaded balances are not stored
+func (s *AccountService) UploadBalanceHistory(ctx context.Context, accountID string, csvContent string) error {
+	if accountID == "" || csvContent == "" {
+		return fmt.Errorf("accountID and csvContent cannot be empty")
+	}
+	if err := s.store.enter(ctx, "Accounts.UploadBalanceHistory"); err != nil {
+		return err
+	}
+	defer s.store.mu.Unlock()
+
+	if s.store.account(accountID) == nil {
+		return notFound("account", accountID)
+	}
+	return nil
+}
+
+// newAccount builds a manual account of a known type
+func (st *store) newAccount(name, typ, subtype string) (*monarch.Account, error) {
+	for _, t := range accountTypes {
+		if t.name != typ {
+			continue
+		}
+		for _, sub := range t.subtypes {
+			if sub.Name == subtype {
+				now := st.now().UTC()
+				return &monarch.Account{
+					ID:                   st.newID("acc"),
+					DisplayName:          name,
+					IsAsset:              t.asset,
+					IsManual:             true,
+					IncludeInNetWorth:    true,
+					CreatedAt:            now,
+					UpdatedAt:            now,
+					DisplayLastUpdatedAt: now,
+					Order:                len(st.data.Accounts),
+					Type:                 &monarch.AccountTypeInfo{Name: t.name, Display: t.display},
+					Subtype:              &monarch.AccountSubtypeInfo{Name: sub.Name, Display: sub.Display},
+				}, nil
+			}
+		}
+		return nil, invalid("unknown subtype %q for account type %q", subtype, typ)
+	}
+	return nil, invalid("unknown account type %q", typ)
+}
+
+// createHolding adds a holding of sec to a manual account
+func (st *store) createHolding(accountID string, sec *monarch.Security, securityID string, quantity float64) (*monarch.Holding, error) {
+	acc := st.account(accountID)
+	if acc == nil {
+		return nil, notFound("account", accountID)
+	}
+	if !acc.IsManual {
+		return nil, invalid("holdings can only be added to manual accounts")
+	}
+	if sec == nil {
+		return nil, notFound("security", securityID)
+	}
+	for _, h := range st.data.Holdings {
+		if h.AccountID == acc.ID && strings.EqualFold(h.Symbol, sec.Ticker) {
+			return nil, &monarch.Error{Code: "DUPLICATE", Message: fmt.Sprintf("account already holds %s", sec.Ticker)}
+		}
+	}
+
+	h := &monarch.Holding{
+		ID: st.newID("hold"), AccountID: acc.ID, Symbol: sec.Ticker, Name: sec.Name,
+		Quantity: quantity, UpdatedAt: st.now().UTC(),
+	}
+	st.data.Holdings = append(st.data.Holdings, h)
+	st.revalue(acc.ID)
+	return &monarch.Holding{ID: h.ID, AccountID: h.AccountID, Symbol: h.Symbol, Quantity: h.Quantity}, nil
+}
+
+// revalue sets the balance of a manual investments account tracked by
+// holdings to the current value of its holdings
+func (st *store) revalue(accountID string) {
+	acc := st.account(accountID)
+	if acc == nil || acc.ManualInvestmentsTrackingMethod != "holdings" {
+		return
+	}
+	var total float64
+	for _, h := range st.data.Holdings {
+		if h.AccountID != accountID {
+			continue
+		}
+		if sec := st.securityByTicker(h.Symbol); sec != nil {
+			total += h.Quantity * sec.CurrentPrice
+		} else {
+			total += h.Quantity * h.Price
+		}
+	}
+	sim.SetDisplayBalance(acc, sim.Round(total))
+}
+
+// balanceOn works an account's balance on a day back from its current balance
+func (st *store) balanceOn(acc *monarch.Account, day time.Time) float64 {
+	balance := acc.CurrentBalance
+	for _, t := range st.data.Transactions {
+		if sim.AccountOf(t) == acc.ID && t.Date.After(day) {
+			balance -= t.Amount
+		}
+	}
+	return sim.Round(balance)
+}
+
+// days lists the days from start to end, capped at ten years
+func (st *store) days(start, end time.Time) []time.Time {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	if limit := end.AddDate(-10, 0, 0); start.Before(limit) {
+		start = limit
+	}
+	var days []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	return days
+}
+
+// adjustBalance moves an account's balance by amount
+func (st *store) adjustBalance(accountID string, amount float64) {
+	acc := st.account(accountID)
+	if acc == nil {
+		return
+	}
+	acc.CurrentBalance = sim.Round(acc.CurrentBalance + amount)
+	acc.DisplayBalance = acc.CurrentBalance
+	if !acc.IsAsset {
+		acc.DisplayBalance = -acc.CurrentBalance
+	}
+}
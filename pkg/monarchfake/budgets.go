@@ -0,0 +1,222 @@
+package monarchfake
+
+import (
+	"context"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// between returns the transactions dated from start to end that keep, when
+// set, accepts
+func (st *store) between(start, end time.Time, keep func(t *monarch.Transaction) bool) []*monarch.Transaction {
+	start, end = dateOf(start), dateOf(end)
+	var txns []*monarch.Transaction
+	for _, t := range st.data.Transactions {
+		if t.Date.Before(start) || t.Date.After(end) || (keep != nil && !keep(t)) {
+			continue
+		}
+		txns = append(txns, t)
+	}
+	return txns
+}
+
+// BudgetService is a fake monarch.BudgetService. Budgets are keyed by
+// category and month, and the amount spent comes from the transactions.
+type BudgetService struct {
+	store *store
+}
+
+// List returns a budget for every income and expense category in each month
+// of the range
+func (s *BudgetService) List(ctx context.Context, startDate, endDate time.Time) ([]*monarch.Budget, error) {
+	if err := s.store.enter(ctx, "Budgets.List"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	return s.store.budgets(startDate, endDate), nil
+}
+
+// ListWithGoals returns the budgets of List; the fake has no goals
+func (s *BudgetService) ListWithGoals(ctx context.Context, startDate, endDate time.Time) ([]*monarch.BudgetWithGoals, error) {
+	if err := s.store.enter(ctx, "Budgets.ListWithGoals"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	budgets := s.store.budgets(startDate, endDate)
+	withGoals := make([]*monarch.BudgetWithGoals, 0, len(budgets))
+	for _, b := range budgets {
+		withGoals = append(withGoals, &monarch.BudgetWithGoals{Budget: b, Goals: []*monarch.Goal{}})
+	}
+	return withGoals, nil
+}
+
+// SetAmount sets the budget of a category for the month of startDate. As
+// with the API, budgetID is the category ID.
+func (s *BudgetService) SetAmount(ctx context.Context, budgetID string, amount float64, rollover bool, startDate time.Time) error {
+	if err := s.store.enter(ctx, "Budgets.SetAmount"); err != nil {
+		return err
+	}
+	defer s.store.mu.Unlock()
+
+	if s.store.category(budgetID) == nil {
+		return notFound("category", budgetID)
+	}
+	month := sim.MonthOf(startDate)
+	budget := s.store.book().Budget(budgetID, month)
+	if budget == nil {
+		budget = &monarch.Budget{CategoryID: budgetID, StartDate: month}
+		s.store.data.Budgets = append(s.store.data.Budgets, budget)
+	}
+	if budget.ID == "" {
+		budget.ID = s.store.newID("budget")
+	}
+	budget.Amount = amount
+	budget.Rollover = rollover
+	return nil
+}
+
+// budgets builds the budget rows the client returns for a date range from
+// the figures the monarchtest server reports
+func (st *store) budgets(startDate, endDate time.Time) []*monarch.Budget {
+	book := st.book()
+	var budgets []*monarch.Budget
+	for _, c := range st.data.Categories {
+		g := st.groupOf(c)
+		if g == nil || (g.Type != "income" && g.Type != "expense") {
+			continue
+		}
+		for m := sim.MonthOf(startDate); !m.After(endDate); m = m.AddDate(0, 1, 0) {
+			amount := book.MonthlyAmount(c.ID, g.Type, m)
+			b := &monarch.Budget{
+				CategoryID:     c.ID,
+				Category:       st.categoryCopy(c.ID),
+				Amount:         amount.PlannedCashFlowAmount,
+				StartDate:      m,
+				EndDate:        m.AddDate(0, 1, -1),
+				Spent:          -amount.ActualAmount,
+				Remaining:      amount.RemainingAmount,
+				Rollover:       amount.RolloverType != "",
+				RolloverType:   amount.RolloverType,
+				RolloverAmount: amount.PreviousMonthRolloverAmount,
+			}
+			if stored := book.Budget(c.ID, m); stored != nil {
+				b.ID = stored.ID
+			}
+			if b.Amount > 0 {
+				b.PercentageComplete = b.Spent / b.Amount * 100
+			}
+			budgets = append(budgets, b)
+		}
+	}
+	return budgets
+}
+
+// CashflowService is a fake monarch.CashflowService. Income is what is booked
+// to income categories; transfers are left out.
+type CashflowService struct {
+	store *store
+}
+
+// Get returns the cashflow summary with totals by category, category group
+// and merchant, largest first. Limit caps each breakdown.
+func (s *CashflowService) Get(ctx context.Context, params *monarch.CashflowParams) (*monarch.Cashflow, error) {
+	if err := s.store.enter(ctx, "Cashflow.Get"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	byCategory := make(map[string]float64)
+	byGroup := make(map[string]float64)
+	byMerchant := make(map[string]float64)
+	merchants := make(map[string]*monarch.Merchant)
+
+	accounts := sim.Set(params.AccountIDs)
+	keep := func(t *monarch.Transaction) bool { return len(accounts) == 0 || accounts[sim.AccountOf(t)] }
+	summary := s.store.cashflow(params.StartDate, params.EndDate, keep, func(a sim.Allocation, _ string) {
+		byCategory[a.CategoryID] += a.Amount
+		if c := s.store.category(a.CategoryID); c != nil {
+			if g := s.store.groupOf(c); g != nil {
+				byGroup[g.ID] += a.Amount
+			}
+		}
+		if m := a.Transaction.Merchant; m != nil {
+			byMerchant[m.ID] += a.Amount
+			merchants[m.ID] = m
+		}
+	})
+
+	cashflow := &monarch.Cashflow{
+		StartDate:       params.StartDate,
+		EndDate:         params.EndDate,
+		Summary:         summary,
+		ByCategory:      make([]*monarch.CashflowCategory, 0, len(byCategory)),
+		ByCategoryGroup: make([]*monarch.CashflowCategoryGroup, 0, len(byGroup)),
+		ByMerchant:      make([]*monarch.CashflowMerchant, 0, len(byMerchant)),
+	}
+	for _, id := range sim.ByAmount(byCategory, params.Limit) {
+		cashflow.ByCategory = append(cashflow.ByCategory, &monarch.CashflowCategory{
+			Category: s.store.categoryCopy(id), Amount: sim.Round(byCategory[id]),
+		})
+	}
+	for _, id := range sim.ByAmount(byGroup, params.Limit) {
+		g := *s.store.group(id)
+		cashflow.ByCategoryGroup = append(cashflow.ByCategoryGroup, &monarch.CashflowCategoryGroup{
+			CategoryGroup: &g, Amount: sim.Round(byGroup[id]),
+		})
+	}
+	for _, id := range sim.ByAmount(byMerchant, params.Limit) {
+		m := *merchants[id]
+		cashflow.ByMerchant = append(cashflow.ByMerchant, &monarch.CashflowMerchant{
+			Merchant: &m, Amount: sim.Round(byMerchant[id]),
+		})
+	}
+	return cashflow, nil
+}
+
+// GetSummary returns income, expense and savings, optionally for one
+// category or some accounts
+func (s *CashflowService) GetSummary(ctx context.Context, params *monarch.CashflowSummaryParams) (*monarch.CashflowSummary, error) {
+	if err := s.store.enter(ctx, "Cashflow.GetSummary"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	accounts := sim.Set(params.AccountsFilter)
+	keep := func(t *monarch.Transaction) bool {
+		return (len(accounts) == 0 || accounts[sim.AccountOf(t)]) &&
+			(params.CategoryID == "" || sim.CategoryOf(t) == params.CategoryID)
+	}
+	return s.store.cashflow(params.StartDate, params.EndDate, keep, nil), nil
+}
+
+// GetSimple returns income, expense and savings for a date range
+func (s *CashflowService) GetSimple(ctx context.Context, startDate, endDate time.Time) (*monarch.CashflowSummary, error) {
+	if err := s.store.enter(ctx, "Cashflow.GetSimple"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	return s.store.cashflow(startDate, endDate, nil, nil), nil
+}
+
+// cashflow tallies income and expense allocations, leaving out transfers.
+// Expense is reported as a positive amount, as by the client.
+func (st *store) cashflow(start, end time.Time, keep func(t *monarch.Transaction) bool, each func(a sim.Allocation, groupType string)) *monarch.CashflowSummary {
+	income, expense := st.book().Cashflow(st.between(start, end, keep), each)
+
+	summary := &monarch.CashflowSummary{
+		StartDate: start,
+		EndDate:   end,
+		Income:    sim.Round(income),
+		Expense:   sim.Round(-expense),
+		Savings:   sim.Round(income + expense),
+	}
+	if summary.Income > 0 {
+		summary.SavingsRate = summary.Savings / summary.Income
+	}
+	return summary
+}
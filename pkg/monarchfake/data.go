@@ -0,0 +1,153 @@
+package monarchfake
+
+import (
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// book returns the data the budget and cashflow services compute from
+func (st *store) book() sim.Book {
+	return sim.Book{
+		Accounts:       st.data.Accounts,
+		Transactions:   st.data.Transactions,
+		Splits:         st.data.Splits,
+		Categories:     st.data.Categories,
+		CategoryGroups: st.data.CategoryGroups,
+		Budgets:        st.data.Budgets,
+	}
+}
+
+// Lookups used by the services; they return the stored objects, not copies
+
+func (st *store) account(id string) *monarch.Account {
+	return st.book().Account(id)
+}
+
+func (st *store) transaction(id string) *monarch.Transaction {
+	for _, t := range st.data.Transactions {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (st *store) category(id string) *monarch.TransactionCategory {
+	return st.book().Category(id)
+}
+
+func (st *store) group(id string) *monarch.CategoryGroup {
+	return st.book().Group(id)
+}
+
+func (st *store) tag(id string) *monarch.Tag {
+	for _, t := range st.data.Tags {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (st *store) security(id string) *monarch.Security {
+	for _, s := range st.data.Securities {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (st *store) securityByTicker(ticker string) *monarch.Security {
+	for _, s := range st.data.Securities {
+		if strings.EqualFold(s.Ticker, ticker) {
+			return s
+		}
+	}
+	return nil
+}
+
+// groupOf returns the group of a category, if it has one
+func (st *store) groupOf(c *monarch.TransactionCategory) *monarch.CategoryGroup {
+	return st.book().GroupOf(c)
+}
+
+// accountCopy returns a copy of an account with its counts filled in
+func (st *store) accountCopy(acc *monarch.Account) *monarch.Account {
+	c := *acc
+	c.TransactionsCount, c.HoldingsCount = 0, 0
+	for _, t := range st.data.Transactions {
+		if sim.AccountOf(t) == acc.ID {
+			c.TransactionsCount++
+		}
+	}
+	for _, h := range st.data.Holdings {
+		if h.AccountID == acc.ID {
+			c.HoldingsCount++
+		}
+	}
+	if acc.Type != nil {
+		t := *acc.Type
+		c.Type = &t
+	}
+	if acc.Subtype != nil {
+		t := *acc.Subtype
+		c.Subtype = &t
+	}
+	if acc.Institution != nil {
+		inst := *acc.Institution
+		c.Institution = &inst
+	}
+	if acc.Credential != nil {
+		cred := *acc.Credential
+		if cred.Institution != nil {
+			inst := *cred.Institution
+			cred.Institution = &inst
+		}
+		c.Credential = &cred
+	}
+	return &c
+}
+
+// categoryCopy returns a copy of a category with its group filled in
+func (st *store) categoryCopy(id string) *monarch.TransactionCategory {
+	cat := st.category(id)
+	if cat == nil {
+		if id == "" {
+			return nil
+		}
+		return &monarch.TransactionCategory{ID: id}
+	}
+	c := *cat
+	if g := st.groupOf(cat); g != nil {
+		group := *g
+		c.Group = &group
+		c.GroupID = g.ID
+	}
+	return &c
+}
+
+// transactionCopy returns a copy of a transaction with the names of its
+// account, category and tags filled in
+func (st *store) transactionCopy(t *monarch.Transaction) *monarch.Transaction {
+	c := *t
+	if t.Merchant != nil {
+		m := *t.Merchant
+		c.Merchant = &m
+	}
+	if acc := st.account(sim.AccountOf(t)); acc != nil {
+		c.Account = &monarch.Account{ID: acc.ID, DisplayName: acc.DisplayName, Mask: acc.Mask}
+	}
+	c.Category = st.categoryCopy(sim.CategoryOf(t))
+	c.Tags = make([]*monarch.Tag, 0, len(t.Tags))
+	for _, ref := range t.Tags {
+		if tag := st.tag(ref.ID); tag != nil {
+			copied := *tag
+			c.Tags = append(c.Tags, &copied)
+		}
+	}
+	c.HasSplits = len(st.data.Splits[t.ID]) > 0
+	return &c
+}
@@ -0,0 +1,210 @@
+// Package monarchfake provides in-memory implementations of the monarch
+// service interfaces for unit tests.
+//
+// The fakes share one store, so they behave consistently across services:
+// creating a transaction moves its account's balance and the spent amount of
+// its category's budget, and a category cannot be deleted while transactions
+// still use it. A Fake is seeded from the same Dataset as the monarchtest
+// server:
+//
+//	fake := monarchfake.New(monarchtest.DefaultDataset())
+//	client := fake.Client()
+//
+//	txn, err := client.Transactions.Create(ctx, &monarch.CreateTransactionParams{...})
+//
+// Code that takes a single service can use the typed fields instead, such as
+// fake.Accounts for a monarch.AccountService.
+package monarchfake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+)
+
+// Compile-time checks that the fakes implement the service interfaces
+var (
+	_ monarch.AccountService             = (*AccountService)(nil)
+	_ monarch.TransactionService         = (*TransactionService)(nil)
+	_ monarch.TransactionCategoryService = (*CategoryService)(nil)
+	_ monarch.TransactionQueryBuilder    = (*queryBuilder)(nil)
+	_ monarch.TagService                 = (*TagService)(nil)
+	_ monarch.BudgetService              = (*BudgetService)(nil)
+	_ monarch.CashflowService            = (*CashflowService)(nil)
+	_ monarch.RecurringService           = (*RecurringService)(nil)
+	_ monarch.InstitutionService         = (*InstitutionService)(nil)
+	_ monarch.RefreshJob                 = (*RefreshJob)(nil)
+)
+
+// Fake holds the fake services and the data they share
+type Fake struct {
+	Accounts     *AccountService
+	Transactions *TransactionService
+	Categories   *CategoryService
+	Tags         *TagService
+	Budgets      *BudgetService
+	Cashflow     *CashflowService
+	Recurring    *RecurringService
+	Institutions *InstitutionService
+
+	store *store
+}
+
+// store is the state shared by the services. Every service method holds mu
+// for its whole run.
+type store struct {
+	mu       sync.Mutex
+	data     *monarchtest.Dataset
+	now      func() time.Time
+	seq      int
+	calls    []string
+	failures map[string]error
+	held     map[string]bool
+}
+
+// New returns fakes seeded with a copy of data. A nil dataset starts empty.
+func New(data *monarchtest.Dataset) *Fake {
+	if data == nil {
+		data = &monarchtest.Dataset{}
+	}
+	st := &store{
+		data:     data.Clone(),
+		now:      time.Now,
+		seq:      1000,
+		failures: make(map[string]error),
+		held:     make(map[string]bool),
+	}
+	categories := &CategoryService{store: st}
+	return &Fake{
+		Accounts:     &AccountService{store: st},
+		Transactions: &TransactionService{store: st, categories: categories},
+		Categories:   categories,
+		Tags:         &TagService{store: st},
+		Budgets:      &BudgetService{store: st},
+		Cashflow:     &CashflowService{store: st},
+		Recurring:    &RecurringService{store: st},
+		Institutions: &InstitutionService{store: st},
+		store:        st,
+	}
+}
+
+// Client returns a monarch client whose services are the fakes. Only the
+// service fields are usable; auth, admin and subscription calls are not faked.
+func (f *Fake) Client() *monarch.Client {
+	return &monarch.Client{
+		Accounts:     f.Accounts,
+		Transactions: f.Transactions,
+		Tags:         f.Tags,
+		Budgets:      f.Budgets,
+		Cashflow:     f.Cashflow,
+		Recurring:    f.Recurring,
+		Institutions: f.Institutions,
+	}
+}
+
+// Data returns a copy of the current state
+func (f *Fake) Data() *monarchtest.Dataset {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	return f.store.data.Clone()
+}
+
+// SetNow replaces the clock used for "today", creation times and refreshes
+func (f *Fake) SetNow(now func() time.Time) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	f.store.now = now
+}
+
+// Fail makes a method return err until ClearFailures is called. Methods are
+// named by service and method, such as "Accounts.List" or
+// "Transactions.Create".
+func (f *Fake) Fail(method string, err error) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	f.store.failures[method] = err
+}
+
+// ClearFailures removes the errors set by Fail
+func (f *Fake) ClearFailures() {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	f.store.failures = make(map[string]error)
+}
+
+// Calls returns the methods called so far, in order
+func (f *Fake) Calls() []string {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	return append([]string(nil), f.store.calls...)
+}
+
+// HoldRefresh keeps accounts syncing in refresh jobs until FinishRefresh
+func (f *Fake) HoldRefresh(accountIDs ...string) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	for _, id := range accountIDs {
+		f.store.held[id] = true
+	}
+}
+
+// FinishRefresh lets held accounts finish syncing
+func (f *Fake) FinishRefresh(accountIDs ...string) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	for _, id := range accountIDs {
+		delete(f.store.held, id)
+	}
+}
+
+// enter starts a service method: it records the call and takes the lock. On
+// error the lock is released; otherwise the caller must unlock.
+func (st *store) enter(ctx context.Context, method string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	st.mu.Lock()
+	st.calls = append(st.calls, method)
+	if err := st.failures[method]; err != nil {
+		st.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// newID returns a fresh ID for a created object
+func (st *store) newID(prefix string) string {
+	st.seq++
+	return fmt.Sprintf("%s-%d", prefix, st.seq)
+}
+
+// clock reads the clock for callers not holding the lock
+func (st *store) clock() time.Time {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.now()
+}
+
+// today returns the current date at midnight UTC
+func (st *store) today() time.Time {
+	now := st.now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// notFound is the error for a missing object. It matches monarch.ErrNotFound.
+func notFound(kind, id string) error {
+	return &monarch.Error{
+		Code:    "NOT_FOUND",
+		Message: fmt.Sprintf("%s %q does not exist", kind, id),
+		Err:     monarch.ErrNotFound,
+	}
+}
+
+// invalid is the error for a rejected mutation
+func invalid(format string, args ...interface{}) error {
+	return &monarch.Error{Code: "INVALID", Message: fmt.Sprintf(format, args...)}
+}
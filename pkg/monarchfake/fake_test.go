@@ -0,0 +1,274 @@
+package monarchfake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thisMonth returns the first and last day of the current month
+func thisMonth() (time.Time, time.Time) {
+	start := sim.MonthOf(time.Now().UTC())
+	return start, start.AddDate(0, 1, -1)
+}
+
+func budgetFor(t *testing.T, budgets []*monarch.Budget, categoryID string) *monarch.Budget {
+	t.Helper()
+	for _, b := range budgets {
+		if b.CategoryID == categoryID {
+			return b
+		}
+	}
+	t.Fatalf("no budget for %s", categoryID)
+	return nil
+}
+
+func TestFake_CreateTransactionUpdatesBalanceAndBudget(t *testing.T) {
+	ctx := context.Background()
+	client := New(monarchtest.DefaultDataset()).Client()
+	start, end := thisMonth()
+
+	created, err := client.Transactions.Create(ctx, &monarch.CreateTransactionParams{
+		Date:       monarch.Date{Time: start.AddDate(0, 0, 6)},
+		AccountID:  "acc-checking",
+		Amount:     -29.499,
+		Merchant:   &monarch.Merchant{Name: "Corner Market"},
+		CategoryID: "cat-groceries",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, -29.5, created.Amount)
+
+	acc, err := client.Accounts.Get(ctx, "acc-checking")
+	require.NoError(t, err)
+	assert.InDelta(t, 4970.5, acc.CurrentBalance, 0.001)
+	assert.Equal(t, 4, acc.TransactionsCount)
+
+	budgets, err := client.Budgets.List(ctx, start, end)
+	require.NoError(t, err)
+	groceries := budgetFor(t, budgets, "cat-groceries")
+	assert.InDelta(t, 150, groceries.Spent, 0.001)
+	assert.InDelta(t, 450, groceries.Remaining, 0.001)
+
+	list, err := client.Transactions.Query().Between(start, end).Search("corner").Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, list.Transactions, 1)
+	assert.Equal(t, "Groceries", list.Transactions[0].Category.Name)
+	assert.Equal(t, "Checking", list.Transactions[0].Account.DisplayName)
+
+	summary, err := client.Cashflow.GetSummary(ctx, &monarch.CashflowSummaryParams{StartDate: start, EndDate: end})
+	require.NoError(t, err)
+	assert.InDelta(t, 4000, summary.Income, 0.001)
+	assert.InDelta(t, 2090.2, summary.Expense, 0.001)
+
+	require.NoError(t, client.Transactions.Delete(ctx, created.ID))
+	acc, err = client.Accounts.Get(ctx, "acc-checking")
+	require.NoError(t, err)
+	assert.InDelta(t, 5000, acc.CurrentBalance, 0.001)
+}
+
+func TestFake_CreateTransactionWithoutBalanceUpdate(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+	update := false
+
+	_, err := fake.Transactions.Create(ctx, &monarch.CreateTransactionParams{
+		Date: monarch.Date{Time: time.Now()}, AccountID: "acc-credit", Amount: -10,
+		CategoryID: "cat-restaurants", ShouldUpdateBalance: &update,
+	})
+	require.NoError(t, err)
+
+	acc, err := fake.Accounts.Get(ctx, "acc-credit")
+	require.NoError(t, err)
+	assert.InDelta(t, 850, acc.DisplayBalance, 0.001)
+}
+
+func TestFake_CreateTransactionValidates(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+
+	_, err := fake.Transactions.Create(ctx, &monarch.CreateTransactionParams{AccountID: "acc-checking", Amount: -1})
+	assert.Error(t, err, "category is required")
+
+	_, err = fake.Transactions.Create(ctx, &monarch.CreateTransactionParams{
+		AccountID: "acc-missing", Amount: -1, CategoryID: "cat-groceries",
+	})
+	assert.True(t, errors.Is(err, monarch.ErrNotFound), "got %v", err)
+	assert.Empty(t, fake.Data().Transactions[5:])
+}
+
+func TestFake_DeleteCategoryInUse(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+	categories := fake.Client().Transactions.Categories()
+
+	err := categories.Delete(ctx, "cat-restaurants")
+	var apiErr *monarch.Error
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	assert.Equal(t, "CATEGORY_IN_USE", apiErr.Code)
+
+	err = categories.DeleteMultiple(ctx, "cat-uncategorized")
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	assert.Equal(t, "FORBIDDEN", apiErr.Code)
+
+	require.NoError(t, fake.Transactions.Delete(ctx, "txn-dinner"))
+	require.NoError(t, categories.Delete(ctx, "cat-restaurants"))
+	for _, b := range fake.Data().Budgets {
+		assert.NotEqual(t, "cat-restaurants", b.CategoryID)
+	}
+}
+
+func TestFake_Splits(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+
+	err := fake.Transactions.UpdateSplits(ctx, "txn-groceries", []*monarch.TransactionSplit{
+		{Amount: -100, CategoryID: "cat-groceries"},
+		{Amount: -10, CategoryID: "cat-restaurants"},
+	})
+	var apiErr *monarch.Error
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	assert.Equal(t, "INVALID", apiErr.Code)
+
+	require.NoError(t, fake.Transactions.UpdateSplits(ctx, "txn-groceries", []*monarch.TransactionSplit{
+		{Amount: -100.5, CategoryID: "cat-groceries"},
+		{Amount: -20, CategoryID: "cat-restaurants"},
+	}))
+
+	details, err := fake.Transactions.Get(ctx, "txn-groceries")
+	require.NoError(t, err)
+	assert.True(t, details.HasSplits)
+	require.Len(t, details.Splits, 2)
+	assert.Equal(t, "Restaurants & Bars", details.Splits[1].Category.Name)
+
+	start, end := thisMonth()
+	budgets, err := fake.Budgets.List(ctx, start, end)
+	require.NoError(t, err)
+	assert.InDelta(t, 65, budgetFor(t, budgets, "cat-restaurants").Spent, 0.001)
+}
+
+func TestFake_QueryPagination(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+
+	page, err := fake.Transactions.Query().Limit(2).Offset(1).Execute(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, page.TotalCount)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, 3, page.NextOffset)
+	require.Len(t, page.Transactions, 2)
+	assert.Equal(t, "txn-groceries", page.Transactions[1].ID, "newest first")
+
+	txns, errs := fake.Transactions.Query().Limit(2).WithMinAmount(100).Stream(ctx)
+	var ids []string
+	for txn := range txns {
+		ids = append(ids, txn.ID)
+	}
+	require.NoError(t, <-errs)
+	assert.ElementsMatch(t, []string{"txn-paycheck", "txn-rent", "txn-groceries"}, ids)
+}
+
+func TestFake_Tags(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+
+	tag, err := fake.Tags.Create(ctx, "Business", "#0000FF")
+	require.NoError(t, err)
+	_, err = fake.Tags.Create(ctx, "business", "#0000FF")
+	assert.Error(t, err)
+
+	require.NoError(t, fake.Tags.SetTransactionTags(ctx, "txn-dinner", tag.ID))
+	list, err := fake.Transactions.Query().WithTags(tag.ID).Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, list.Transactions, 1)
+	assert.Equal(t, "Business", list.Transactions[0].Tags[0].Name)
+
+	tags, err := fake.Tags.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tags[2].TransactionCount)
+}
+
+func TestFake_Holdings(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+
+	_, err := fake.Accounts.CreateHoldingByTicker(ctx, "acc-brokerage", "AAPL", 10)
+	require.NoError(t, err)
+
+	holdings, err := fake.Accounts.GetHoldings(ctx, "acc-brokerage")
+	require.NoError(t, err)
+	require.Len(t, holdings, 3)
+	assert.InDelta(t, 1905, holdings[2].Value, 0.001)
+
+	acc, err := fake.Accounts.Get(ctx, "acc-brokerage")
+	require.NoError(t, err)
+	assert.InDelta(t, 10854.6, acc.CurrentBalance, 0.001)
+
+	_, err = fake.Accounts.CreateHoldingByTicker(ctx, "acc-checking", "VTI", 1)
+	assert.Error(t, err, "holdings need a manual account")
+}
+
+func TestFake_Refresh(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+
+	require.NoError(t, fake.Accounts.RefreshAndWait(ctx, time.Second))
+
+	fake.HoldRefresh("acc-credit")
+	job, err := fake.Accounts.Refresh(ctx, "acc-checking", "acc-credit")
+	require.NoError(t, err)
+	err = job.Wait(ctx, 30*time.Millisecond)
+	assert.True(t, errors.Is(err, monarch.ErrRefreshTimeout), "got %v", err)
+	assert.Equal(t, monarch.RefreshStatusTimeout, job.Status())
+	assert.Equal(t, map[string]bool{"acc-checking": true, "acc-credit": false}, job.GetProgress())
+
+	fake.FinishRefresh("acc-credit")
+	job, err = fake.Accounts.Refresh(ctx, "acc-checking", "acc-credit")
+	require.NoError(t, err)
+	require.NoError(t, job.Wait(ctx, time.Second))
+	metrics := job.GetMetrics()
+	assert.Equal(t, "completed", metrics.Status)
+	assert.Equal(t, 2, metrics.CompletedCount)
+}
+
+func TestFake_FailAndCalls(t *testing.T) {
+	ctx := context.Background()
+	fake := New(monarchtest.DefaultDataset())
+	unavailable := errors.New("service unavailable")
+
+	fake.Fail("Accounts.List", unavailable)
+	_, err := fake.Accounts.List(ctx)
+	assert.Equal(t, unavailable, err)
+
+	fake.ClearFailures()
+	accounts, err := fake.Accounts.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 3)
+
+	accounts[0].DisplayName = "Changed"
+	assert.Equal(t, "Checking", fake.Data().Accounts[0].DisplayName, "results are copies")
+	assert.Equal(t, []string{"Accounts.List", "Accounts.List"}, fake.Calls())
+}
+
+func TestFake_Recurring(t *testing.T) {
+	fake := New(monarchtest.DefaultDataset())
+	start, _ := thisMonth()
+
+	items, err := fake.Recurring.ListWithDateRange(context.Background(), start, start.AddDate(0, 2, -1))
+	require.NoError(t, err)
+
+	var rent int
+	for _, item := range items {
+		if item.ID == "stream-rent" {
+			rent++
+			assert.Equal(t, "Rent", item.Category.Name)
+		}
+	}
+	assert.Equal(t, 2, rent)
+}
@@ -0,0 +1,91 @@
+package monarchfake
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// RecurringService is a fake monarch.RecurringService
+type RecurringService struct {
+	store *store
+}
+
+// List returns the occurrences due in the next month
+func (s *RecurringService) List(ctx context.Context) ([]*monarch.RecurringTransaction, error) {
+	s.store.mu.Lock()
+	start := s.store.today()
+	s.store.mu.Unlock()
+
+	return s.ListWithDateRange(ctx, start, start.AddDate(0, 1, 0))
+}
+
+// ListWithDateRange returns one entry per occurrence of each active stream
+// between the dates, ordered by date. As in the client, NextDate is the date
+// of the occurrence and IsActive is false for past ones.
+func (s *RecurringService) ListWithDateRange(ctx context.Context, startDate, endDate time.Time) ([]*monarch.RecurringTransaction, error) {
+	if err := s.store.enter(ctx, "Recurring.ListWithDateRange"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	start, end := dateOf(startDate), dateOf(endDate)
+	today := s.store.today()
+
+	var items []*monarch.RecurringTransaction
+	for _, r := range s.store.data.Recurring {
+		if !r.IsActive || r.NextDate.IsZero() {
+			continue
+		}
+		for _, d := range sim.Occurrences(r.NextDate.Time, r.Frequency, start, end) {
+			item := &monarch.RecurringTransaction{
+				ID:            r.ID,
+				Amount:        r.Amount,
+				Frequency:     r.Frequency,
+				NextDate:      monarch.Date{Time: d},
+				IsActive:      !d.Before(today),
+				IsApproximate: r.IsApproximate,
+			}
+			if r.Merchant != nil {
+				m := *r.Merchant
+				item.Merchant = &m
+			}
+			if r.Category != nil {
+				if c := s.store.category(r.Category.ID); c != nil {
+					item.Category = &monarch.TransactionCategory{ID: c.ID, Name: c.Name}
+				}
+			}
+			if r.Account != nil {
+				if acc := s.store.account(r.Account.ID); acc != nil {
+					item.Account = &monarch.Account{ID: acc.ID, DisplayName: acc.DisplayName}
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].NextDate.Before(items[j].NextDate.Time) })
+	return items, nil
+}
+
+// InstitutionService is a fake monarch.InstitutionService
+type InstitutionService struct {
+	store *store
+}
+
+// List returns the connected institutions
+func (s *InstitutionService) List(ctx context.Context) ([]*monarch.Institution, error) {
+	if err := s.store.enter(ctx, "Institutions.List"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	institutions := make([]*monarch.Institution, 0, len(s.store.data.Institutions))
+	for _, inst := range s.store.data.Institutions {
+		c := *inst
+		institutions = append(institutions, &c)
+	}
+	return institutions, nil
+}
@@ -0,0 +1,202 @@
+package monarchfake
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// pollInterval is how often Wait checks a refresh
+const pollInterval = 10 * time.Millisecond
+
+// RefreshJob is a fake monarch.RefreshJob.
+//
+// Accounts finish refreshing as soon as the job is checked, except accounts
+// held by Fake.HoldRefresh and accounts whose credential needs an update,
+// which never finish; waiting on those times out.
+type RefreshJob struct {
+	store      *store
+	id         string
+	accountIDs []string
+	startTime  time.Time
+
+	mu         sync.Mutex
+	status     monarch.RefreshStatus
+	endTime    *time.Time
+	lastCheck  time.Time
+	checkCount int
+	progress   map[string]bool
+	cancel     chan struct{}
+}
+
+func newRefreshJob(st *store, accountIDs []string) *RefreshJob {
+	job := &RefreshJob{
+		store:      st,
+		id:         st.newID("refresh"),
+		accountIDs: accountIDs,
+		startTime:  st.now(),
+		status:     monarch.RefreshStatusPending,
+		progress:   make(map[string]bool, len(accountIDs)),
+		cancel:     make(chan struct{}),
+	}
+	for _, id := range accountIDs {
+		job.progress[id] = false
+	}
+	return job
+}
+
+// ID returns the job ID
+func (j *RefreshJob) ID() string {
+	return j.id
+}
+
+// Status returns the current status
+func (j *RefreshJob) Status() monarch.RefreshStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// Wait checks the job until every account has refreshed, the timeout passes
+// (monarch.ErrRefreshTimeout) or the job is cancelled
+func (j *RefreshJob) Wait(ctx context.Context, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	j.mu.Lock()
+	if j.status == monarch.RefreshStatusCancelled {
+		j.mu.Unlock()
+		return errors.New("refresh job was cancelled")
+	}
+	j.status = monarch.RefreshStatusInProgress
+	j.mu.Unlock()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if j.check() {
+			j.finish(monarch.RefreshStatusCompleted)
+			return nil
+		}
+		select {
+		case <-j.cancel:
+			return errors.New("refresh job was cancelled")
+		case <-waitCtx.Done():
+			j.finish(monarch.RefreshStatusTimeout)
+			return monarch.ErrRefreshTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsComplete reports whether the job has completed, checking it when it is
+// in progress
+func (j *RefreshJob) IsComplete(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	switch j.Status() {
+	case monarch.RefreshStatusCompleted:
+		return true, nil
+	case monarch.RefreshStatusInProgress:
+		if j.check() {
+			j.finish(monarch.RefreshStatusCompleted)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Cancel stops the job and any Wait in progress
+func (j *RefreshJob) Cancel(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != monarch.RefreshStatusCancelled {
+		close(j.cancel)
+		j.status = monarch.RefreshStatusCancelled
+		now := j.store.clock()
+		j.endTime = &now
+	}
+	return nil
+}
+
+// GetProgress returns whether each account has refreshed
+func (j *RefreshJob) GetProgress() map[string]bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	progress := make(map[string]bool, len(j.progress))
+	for k, v := range j.progress {
+		progress[k] = v
+	}
+	return progress
+}
+
+// GetMetrics returns job metrics
+func (j *RefreshJob) GetMetrics() monarch.RefreshJobMetrics {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	duration := j.store.clock().Sub(j.startTime)
+	if j.endTime != nil {
+		duration = j.endTime.Sub(j.startTime)
+	}
+	completed := 0
+	for _, done := range j.progress {
+		if done {
+			completed++
+		}
+	}
+	return monarch.RefreshJobMetrics{
+		ID:             j.id,
+		Status:         string(j.status),
+		StartTime:      j.startTime,
+		EndTime:        j.endTime,
+		Duration:       duration,
+		AccountCount:   len(j.accountIDs),
+		CompletedCount: completed,
+		CheckCount:     j.checkCount,
+		LastCheck:      j.lastCheck,
+	}
+}
+
+// check refreshes the accounts that can finish and reports whether all have
+func (j *RefreshJob) check() bool {
+	j.store.mu.Lock()
+	now := j.store.now()
+	done := make(map[string]bool, len(j.accountIDs))
+	for _, id := range j.accountIDs {
+		acc := j.store.account(id)
+		if acc == nil || j.store.held[id] || (acc.Credential != nil && acc.Credential.UpdateRequired) {
+			continue
+		}
+		acc.DisplayLastUpdatedAt = now.UTC()
+		done[id] = true
+	}
+	j.store.mu.Unlock()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.checkCount++
+	j.lastCheck = now
+	all := true
+	for _, id := range j.accountIDs {
+		j.progress[id] = done[id]
+		all = all && done[id]
+	}
+	return all
+}
+
+// finish records the end of the job unless it was cancelled meanwhile
+func (j *RefreshJob) finish(status monarch.RefreshStatus) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status == monarch.RefreshStatusCancelled {
+		return
+	}
+	j.status = status
+	now := j.store.clock()
+	j.endTime = &now
+}
@@ -0,0 +1,81 @@
+package monarchfake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// TagService is a fake monarch.TagService
+type TagService struct {
+	store *store
+}
+
+// List returns all tags with the number of transactions using each
+func (s *TagService) List(ctx context.Context) ([]*monarch.Tag, error) {
+	if err := s.store.enter(ctx, "Tags.List"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, t := range s.store.data.Transactions {
+		for _, tag := range t.Tags {
+			counts[tag.ID]++
+		}
+	}
+	tags := make([]*monarch.Tag, 0, len(s.store.data.Tags))
+	for _, tag := range s.store.data.Tags {
+		c := *tag
+		c.TransactionCount = counts[tag.ID]
+		tags = append(tags, &c)
+	}
+	return tags, nil
+}
+
+// Create adds a tag. Names are unique, ignoring case.
+func (s *TagService) Create(ctx context.Context, name, color string) (*monarch.Tag, error) {
+	if err := s.store.enter(ctx, "Tags.Create"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, invalid("name is required")
+	}
+	for _, tag := range s.store.data.Tags {
+		if strings.EqualFold(tag.Name, name) {
+			return nil, &monarch.Error{Code: "DUPLICATE", Message: fmt.Sprintf("a tag named %q already exists", name)}
+		}
+	}
+
+	tag := &monarch.Tag{ID: s.store.newID("tag"), Name: name, Color: color, Order: len(s.store.data.Tags)}
+	s.store.data.Tags = append(s.store.data.Tags, tag)
+	c := *tag
+	return &c, nil
+}
+
+// SetTransactionTags replaces the tags of a transaction
+func (s *TagService) SetTransactionTags(ctx context.Context, transactionID string, tagIDs ...string) error {
+	if err := s.store.enter(ctx, "Tags.SetTransactionTags"); err != nil {
+		return err
+	}
+	defer s.store.mu.Unlock()
+
+	t := s.store.transaction(transactionID)
+	if t == nil {
+		return notFound("transaction", transactionID)
+	}
+	tags := make([]*monarch.Tag, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if s.store.tag(id) == nil {
+			return notFound("tag", id)
+		}
+		tags = append(tags, &monarch.Tag{ID: id})
+	}
+	t.Tags = tags
+	return nil
+}
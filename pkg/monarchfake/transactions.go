@@ -0,0 +1,629 @@
+package monarchfake
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// TransactionService is a fake monarch.TransactionService.
+//
+// Account balances include every stored transaction, so creating, changing
+// and deleting transactions moves the balances of their accounts. Create
+// skips the update only when ShouldUpdateBalance is explicitly false.
+type TransactionService struct {
+	store      *store
+	categories *CategoryService
+}
+
+// Query returns a query builder with the client's defaults: the newest 100
+// transactions
+func (s *TransactionService) Query() monarch.TransactionQueryBuilder {
+	return &queryBuilder{store: s.store, limit: 100}
+}
+
+// Get returns a transaction with its splits, or monarch.ErrNotFound
+func (s *TransactionService) Get(ctx context.Context, transactionID string) (*monarch.TransactionDetails, error) {
+	if err := s.store.enter(ctx, "Transactions.Get"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	t := s.store.transaction(transactionID)
+	if t == nil {
+		return nil, monarch.ErrNotFound
+	}
+	c := s.store.transactionCopy(t)
+	return &monarch.TransactionDetails{
+		Transaction:        c,
+		OriginalMerchant:   t.PlaidName,
+		OriginalCategoryID: sim.CategoryOf(t),
+		OriginalCategory:   s.store.categoryCopy(sim.CategoryOf(t)),
+		Splits:             s.store.splitsCopy(t.ID),
+	}, nil
+}
+
+// Create adds a transaction. Like the API it requires an existing account and
+// category, and rounds the amount to cents.
+func (s *TransactionService) Create(ctx context.Context, params *monarch.CreateTransactionParams) (*monarch.Transaction, error) {
+	if params.CategoryID == "" {
+		return nil, fmt.Errorf("CategoryID is required by the Monarch API")
+	}
+	if err := s.store.enter(ctx, "Transactions.Create"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	if s.store.account(params.AccountID) == nil {
+		return nil, notFound("account", params.AccountID)
+	}
+	if s.store.category(params.CategoryID) == nil {
+		return nil, notFound("category", params.CategoryID)
+	}
+
+	amount := sim.Round(params.Amount)
+	today := monarch.Date{Time: s.store.today()}
+	t := &monarch.Transaction{
+		ID:        s.store.newID("txn"),
+		Date:      params.Date,
+		Amount:    amount,
+		Notes:     params.Notes,
+		Account:   &monarch.Account{ID: params.AccountID},
+		Category:  &monarch.TransactionCategory{ID: params.CategoryID},
+		CreatedAt: today,
+		UpdatedAt: today,
+	}
+	if params.Merchant != nil && params.Merchant.Name != "" {
+		t.Merchant = s.store.merchant(params.Merchant.Name)
+		t.PlaidName = params.Merchant.Name
+	}
+	s.store.data.Transactions = append(s.store.data.Transactions, t)
+
+	if params.ShouldUpdateBalance == nil || *params.ShouldUpdateBalance {
+		s.store.adjustBalance(params.AccountID, amount)
+	}
+	return &monarch.Transaction{ID: t.ID, Date: t.Date, Amount: t.Amount}, nil
+}
+
+// Update changes the fields set in params, moving account balances when the
+// amount or account changes
+func (s *TransactionService) Update(ctx context.Context, transactionID string, params *monarch.UpdateTransactionParams) (*monarch.Transaction, error) {
+	if err := s.store.enter(ctx, "Transactions.Update"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	t := s.store.transaction(transactionID)
+	if t == nil {
+		return nil, notFound("transaction", transactionID)
+	}
+
+	// Validate everything before changing anything
+	if params.AccountID != nil && s.store.account(*params.AccountID) == nil {
+		return nil, notFound("account", *params.AccountID)
+	}
+	if params.CategoryID != nil && s.store.category(*params.CategoryID) == nil {
+		return nil, notFound("category", *params.CategoryID)
+	}
+	if params.Amount != nil && len(s.store.data.Splits[t.ID]) > 0 && sim.Round(*params.Amount) != t.Amount {
+		return nil, invalid("the amount of a split transaction cannot change")
+	}
+
+	oldAccount, oldAmount := sim.AccountOf(t), t.Amount
+	if params.Date != nil {
+		t.Date = *params.Date
+	}
+	if params.AccountID != nil {
+		t.Account = &monarch.Account{ID: *params.AccountID}
+	}
+	if params.Amount != nil {
+		t.Amount = sim.Round(*params.Amount)
+	}
+	if params.Merchant != nil {
+		t.Merchant = s.store.merchant(*params.Merchant)
+	}
+	if params.CategoryID != nil {
+		t.Category = &monarch.TransactionCategory{ID: *params.CategoryID}
+	}
+	if params.Notes != nil {
+		t.Notes = *params.Notes
+	}
+	if params.HideFromReports != nil {
+		t.HideFromReports = *params.HideFromReports
+	}
+	if params.NeedsReview != nil {
+		t.NeedsReview = *params.NeedsReview
+	}
+	t.UpdatedAt = monarch.Date{Time: s.store.today()}
+
+	if oldAccount != sim.AccountOf(t) || oldAmount != t.Amount {
+		s.store.adjustBalance(oldAccount, -oldAmount)
+		s.store.adjustBalance(sim.AccountOf(t), t.Amount)
+	}
+	return s.store.transactionCopy(t), nil
+}
+
+// Delete removes a transaction and takes its amount out of the account
+// balance
+func (s *TransactionService) Delete(ctx context.Context, transactionID string) error {
+	if err := s.store.enter(ctx, "Transactions.Delete"); err != nil {
+		return err
+	}
+	defer s.store.mu.Unlock()
+
+	data := s.store.data
+	for i, t := range data.Transactions {
+		if t.ID == transactionID {
+			data.Transactions = append(data.Transactions[:i], data.Transactions[i+1:]...)
+			delete(data.Splits, transactionID)
+			s.store.adjustBalance(sim.AccountOf(t), -t.Amount)
+			return nil
+		}
+	}
+	return notFound("transaction", transactionID)
+}
+
+// GetSummary aggregates all transactions
+func (s *TransactionService) GetSummary(ctx context.Context) (*monarch.TransactionSummary, error) {
+	if err := s.store.enter(ctx, "Transactions.GetSummary"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	txns := s.store.data.Transactions
+	summary := &monarch.TransactionSummary{Count: len(txns)}
+	var first, last time.Time
+	for i, t := range txns {
+		summary.Sum += t.Amount
+		if t.Amount > 0 {
+			summary.SumIncome += t.Amount
+		} else {
+			summary.SumExpense += t.Amount
+		}
+		if i == 0 || t.Amount > summary.Max {
+			summary.Max = t.Amount
+		}
+		if t.Amount < summary.MaxExpense {
+			summary.MaxExpense = t.Amount
+		}
+		if first.IsZero() || t.Date.Before(first) {
+			first = t.Date.Time
+		}
+		if t.Date.After(last) {
+			last = t.Date.Time
+		}
+	}
+	if len(txns) > 0 {
+		summary.Avg = sim.Round(summary.Sum / float64(len(txns)))
+		summary.First = first.Format("2006-01-02")
+		summary.Last = last.Format("2006-01-02")
+	}
+	summary.Sum = sim.Round(summary.Sum)
+	summary.SumIncome = sim.Round(summary.SumIncome)
+	summary.SumExpense = sim.Round(summary.SumExpense)
+	return summary, nil
+}
+
+// GetSplits returns a transaction's splits
+func (s *TransactionService) GetSplits(ctx context.Context, transactionID string) ([]*monarch.TransactionSplit, error) {
+	if err := s.store.enter(ctx, "Transactions.GetSplits"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	if s.store.transaction(transactionID) == nil {
+		return nil, notFound("transaction", transactionID)
+	}
+	return s.store.splitsCopy(transactionID), nil
+}
+
+// UpdateSplits replaces a transaction's splits. The split amounts must add up
+// to the transaction amount; an empty list removes the splits.
+func (s *TransactionService) UpdateSplits(ctx context.Context, transactionID string, splits []*monarch.TransactionSplit) error {
+	if err := s.store.enter(ctx, "Transactions.UpdateSplits"); err != nil {
+		return err
+	}
+	defer s.store.mu.Unlock()
+
+	t := s.store.transaction(transactionID)
+	if t == nil {
+		return notFound("transaction", transactionID)
+	}
+
+	stored := make([]*monarch.TransactionSplit, 0, len(splits))
+	var total float64
+	for _, split := range splits {
+		if s.store.category(split.CategoryID) == nil {
+			return notFound("category", split.CategoryID)
+		}
+		merchant := t.Merchant
+		if split.Merchant != nil && split.Merchant.Name != "" {
+			merchant = s.store.merchant(split.Merchant.Name)
+		}
+		stored = append(stored, &monarch.TransactionSplit{
+			ID:         s.store.newID("split"),
+			Amount:     split.Amount,
+			Notes:      split.Notes,
+			CategoryID: split.CategoryID,
+			Category:   &monarch.TransactionCategory{ID: split.CategoryID},
+			Merchant:   merchant,
+		})
+		total += split.Amount
+	}
+	if len(stored) > 0 && math.Abs(total-t.Amount) > 0.005 {
+		return invalid("split amounts add up to %.2f, expected %.2f", total, t.Amount)
+	}
+
+	if len(stored) == 0 {
+		delete(s.store.data.Splits, transactionID)
+	} else {
+		s.store.data.Splits[transactionID] = stored
+	}
+	t.HasSplits = len(stored) > 0
+	return nil
+}
+
+// Categories returns the fake category service
+func (s *TransactionService) Categories() monarch.TransactionCategoryService {
+	return s.categories
+}
+
+// queryBuilder is a fake monarch.TransactionQueryBuilder
+type queryBuilder struct {
+	store      *store
+	start, end *time.Time
+	accounts   []string
+	categories []string
+	tags       []string
+	minAmount  float64
+	maxAmount  float64
+	search     string
+	limit      int
+	offset     int
+}
+
+// Between keeps transactions dated from start to end, inclusive
+func (b *queryBuilder) Between(start, end time.Time) monarch.TransactionQueryBuilder {
+	s, e := dateOf(start), dateOf(end)
+	b.start, b.end = &s, &e
+	return b
+}
+
+// WithAccounts keeps transactions in any of the accounts
+func (b *queryBuilder) WithAccounts(accountIDs ...string) monarch.TransactionQueryBuilder {
+	b.accounts = accountIDs
+	return b
+}
+
+// WithCategories keeps transactions in any of the categories
+func (b *queryBuilder) WithCategories(categoryIDs ...string) monarch.TransactionQueryBuilder {
+	b.categories = categoryIDs
+	return b
+}
+
+// WithTags keeps transactions with any of the tags
+func (b *queryBuilder) WithTags(tagIDs ...string) monarch.TransactionQueryBuilder {
+	b.tags = tagIDs
+	return b
+}
+
+// WithMinAmount keeps transactions whose absolute amount is at least amount.
+// Like the client, it filters the page after pagination.
+func (b *queryBuilder) WithMinAmount(amount float64) monarch.TransactionQueryBuilder {
+	b.minAmount = amount
+	return b
+}
+
+// WithMaxAmount keeps transactions whose absolute amount is at most amount.
+// Like the client, it filters the page after pagination.
+func (b *queryBuilder) WithMaxAmount(amount float64) monarch.TransactionQueryBuilder {
+	b.maxAmount = amount
+	return b
+}
+
+// Search keeps transactions whose merchant, original name or notes contain
+// query, ignoring case
+func (b *queryBuilder) Search(query string) monarch.TransactionQueryBuilder {
+	b.search = strings.ToLower(query)
+	return b
+}
+
+// Limit sets the page size
+func (b *queryBuilder) Limit(limit int) monarch.TransactionQueryBuilder {
+	b.limit = limit
+	return b
+}
+
+// Offset sets the page start
+func (b *queryBuilder) Offset(offset int) monarch.TransactionQueryBuilder {
+	b.offset = offset
+	return b
+}
+
+// Execute returns a page of matching transactions, newest first
+func (b *queryBuilder) Execute(ctx context.Context) (*monarch.TransactionList, error) {
+	if err := b.store.enter(ctx, "Transactions.Query"); err != nil {
+		return nil, err
+	}
+	defer b.store.mu.Unlock()
+
+	txns := b.matching()
+	total := len(txns)
+	offset := b.offset
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if b.limit >= 0 && offset+b.limit < total {
+		end = offset + b.limit
+	}
+
+	page := make([]*monarch.Transaction, 0, end-offset)
+	for _, t := range txns[offset:end] {
+		abs := math.Abs(t.Amount)
+		if (b.minAmount > 0 && abs < b.minAmount) || (b.maxAmount > 0 && abs > b.maxAmount) {
+			continue
+		}
+		page = append(page, b.store.transactionCopy(t))
+	}
+	return &monarch.TransactionList{
+		Transactions: page,
+		TotalCount:   total,
+		HasMore:      b.offset+b.limit < total,
+		NextOffset:   b.offset + b.limit,
+	}, nil
+}
+
+// Stream sends every matching transaction from the offset on, fetching pages
+// of at most 100
+func (b *queryBuilder) Stream(ctx context.Context) (<-chan *monarch.Transaction, <-chan error) {
+	txnChan := make(chan *monarch.Transaction)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(txnChan)
+		defer close(errChan)
+
+		page := *b
+		if page.limit <= 0 || page.limit > 100 {
+			page.limit = 100
+		}
+		for {
+			result, err := page.Execute(ctx)
+			if err != nil {
+				errChan <- err
+				return
+			}
+			for _, t := range result.Transactions {
+				select {
+				case <-ctx.Done():
+					errChan <- ctx.Err()
+					return
+				case txnChan <- t:
+				}
+			}
+			if !result.HasMore {
+				return
+			}
+			page.offset = result.NextOffset
+		}
+	}()
+
+	return txnChan, errChan
+}
+
+// matching returns the transactions passing the filters, newest first
+func (b *queryBuilder) matching() []*monarch.Transaction {
+	accounts, categories, tags := sim.Set(b.accounts), sim.Set(b.categories), sim.Set(b.tags)
+
+	var out []*monarch.Transaction
+	for _, t := range b.store.data.Transactions {
+		switch {
+		case b.start != nil && t.Date.Before(*b.start),
+			b.end != nil && t.Date.After(*b.end),
+			len(accounts) > 0 && !accounts[sim.AccountOf(t)],
+			len(categories) > 0 && !categories[sim.CategoryOf(t)]:
+			continue
+		}
+		if len(tags) > 0 {
+			tagged := false
+			for _, tag := range t.Tags {
+				tagged = tagged || tags[tag.ID]
+			}
+			if !tagged {
+				continue
+			}
+		}
+		if b.search != "" {
+			text := strings.ToLower(t.PlaidName + " " + t.Notes)
+			if t.Merchant != nil {
+				text += " " + strings.ToLower(t.Merchant.Name)
+			}
+			if !strings.Contains(text, b.search) {
+				continue
+			}
+		}
+		out = append(out, t)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Date.After(out[j].Date.Time) })
+	return out
+}
+
+// CategoryService is a fake monarch.TransactionCategoryService
+type CategoryService struct {
+	store *store
+}
+
+// List returns all categories with their groups
+func (s *CategoryService) List(ctx context.Context) ([]*monarch.TransactionCategory, error) {
+	if err := s.store.enter(ctx, "Categories.List"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	categories := make([]*monarch.TransactionCategory, 0, len(s.store.data.Categories))
+	for _, c := range s.store.data.Categories {
+		categories = append(categories, s.store.categoryCopy(c.ID))
+	}
+	return categories, nil
+}
+
+// Create adds a category to a group. The icon defaults to "❓" as in the
+// client.
+func (s *CategoryService) Create(ctx context.Context, params *monarch.CreateCategoryParams) (*monarch.TransactionCategory, error) {
+	if err := s.store.enter(ctx, "Categories.Create"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return nil, invalid("name is required")
+	}
+	g := s.store.group(params.GroupID)
+	if g == nil {
+		return nil, notFound("category group", params.GroupID)
+	}
+
+	order := 0
+	for _, c := range s.store.data.Categories {
+		if s.store.groupOf(c) == g {
+			order++
+		}
+	}
+	icon := params.Icon
+	if icon == "" {
+		icon = "❓"
+	}
+	c := &monarch.TransactionCategory{
+		ID:      s.store.newID("cat"),
+		Name:    name,
+		Icon:    icon,
+		Order:   order,
+		GroupID: g.ID,
+		Group:   &monarch.CategoryGroup{ID: g.ID},
+	}
+	s.store.data.Categories = append(s.store.data.Categories, c)
+	return s.store.categoryCopy(c.ID), nil
+}
+
+// Delete removes a category and its budgets. System categories and categories
+// used by transactions or splits cannot be deleted.
+func (s *CategoryService) Delete(ctx context.Context, categoryID string) error {
+	if err := s.store.enter(ctx, "Categories.Delete"); err != nil {
+		return err
+	}
+	defer s.store.mu.Unlock()
+
+	return s.store.deleteCategory(categoryID)
+}
+
+// DeleteMultiple deletes categories in order, stopping at the first failure
+func (s *CategoryService) DeleteMultiple(ctx context.Context, categoryIDs ...string) error {
+	for _, id := range categoryIDs {
+		if err := s.Delete(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete category %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// GetGroups returns the category groups
+func (s *CategoryService) GetGroups(ctx context.Context) ([]*monarch.CategoryGroup, error) {
+	if err := s.store.enter(ctx, "Categories.GetGroups"); err != nil {
+		return nil, err
+	}
+	defer s.store.mu.Unlock()
+
+	groups := make([]*monarch.CategoryGroup, 0, len(s.store.data.CategoryGroups))
+	for _, g := range s.store.data.CategoryGroups {
+		c := *g
+		groups = append(groups, &c)
+	}
+	return groups, nil
+}
+
+func (st *store) deleteCategory(id string) error {
+	c := st.category(id)
+	if c == nil {
+		return notFound("category", id)
+	}
+	if c.IsSystemCategory {
+		return &monarch.Error{Code: "FORBIDDEN", Message: fmt.Sprintf("system category %q cannot be deleted", c.Name)}
+	}
+
+	uses := 0
+	for _, t := range st.data.Transactions {
+		if sim.CategoryOf(t) == id {
+			uses++
+		}
+	}
+	for _, splits := range st.data.Splits {
+		for _, split := range splits {
+			if split.CategoryID == id {
+				uses++
+			}
+		}
+	}
+	if uses > 0 {
+		return &monarch.Error{
+			Code:    "CATEGORY_IN_USE",
+			Message: fmt.Sprintf("category %q is used by %d transactions", c.Name, uses),
+		}
+	}
+
+	for i, other := range st.data.Categories {
+		if other.ID == id {
+			st.data.Categories = append(st.data.Categories[:i], st.data.Categories[i+1:]...)
+			break
+		}
+	}
+	budgets := st.data.Budgets[:0]
+	for _, b := range st.data.Budgets {
+		if b.CategoryID != id {
+			budgets = append(budgets, b)
+		}
+	}
+	st.data.Budgets = budgets
+	return nil
+}
+
+// splitsCopy returns copies of a transaction's splits with their categories
+// filled in
+func (st *store) splitsCopy(transactionID string) []*monarch.TransactionSplit {
+	splits := make([]*monarch.TransactionSplit, 0, len(st.data.Splits[transactionID]))
+	for _, split := range st.data.Splits[transactionID] {
+		c := *split
+		if split.Merchant != nil {
+			m := *split.Merchant
+			c.Merchant = &m
+		}
+		c.Category = st.categoryCopy(split.CategoryID)
+		splits = append(splits, &c)
+	}
+	return splits
+}
+
+// merchant returns the merchant with a name, reusing the ID of an existing one
+func (st *store) merchant(name string) *monarch.Merchant {
+	for _, t := range st.data.Transactions {
+		if t.Merchant != nil && strings.EqualFold(t.Merchant.Name, name) {
+			return &monarch.Merchant{ID: t.Merchant.ID, Name: name}
+		}
+	}
+	return &monarch.Merchant{ID: st.newID("merch"), Name: name}
+}
+
+// dateOf drops the time of day
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,21 @@
+// Package monarchsync mirrors Monarch Money data into a local SQLite database
+// for offline SQL analysis and dashboards.
+//
+//	db, err := monarchsync.Open("monarch.db")
+//	...
+//	syncer, err := monarchsync.New(client, db, nil)
+//	...
+//	result, err := syncer.Sync(ctx)
+//
+// The first sync streams every transaction. Later syncs query only the
+// transactions dated from Lookback before the previous sync, and rewrite the
+// ones whose UpdatedAt changed. A full sync every FullSyncInterval catches
+// edits to older transactions. Accounts, categories, tags, recurring items
+// and holdings are small and are fetched in full every time.
+//
+// Objects that disappear from Monarch keep their rows, with deleted_at set
+// to when the sync noticed. Query live data with "WHERE deleted_at IS NULL".
+//
+// The SQLite driver, github.com/mattn/go-sqlite3, is written in C, so the
+// package is only built with cgo enabled (CGO_ENABLED=1 and a C compiler).
+package monarchsync
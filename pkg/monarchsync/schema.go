@@ -0,0 +1,161 @@
+//go:build cgo
+
+package monarchsync
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	// Registers the "sqlite3" driver used by Open
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Every mirrored object table has the object's JSON in data, the time the
+// mirror last wrote the row in synced_at and, once the object disappears
+// from Monarch, the time that was noticed in deleted_at. Dates are stored as
+// YYYY-MM-DD and times as RFC 3339 text, so they sort and compare as
+// strings.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS accounts (
+		id TEXT PRIMARY KEY,
+		display_name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		subtype TEXT NOT NULL,
+		institution TEXT NOT NULL,
+		is_asset INTEGER NOT NULL,
+		is_manual INTEGER NOT NULL,
+		is_hidden INTEGER NOT NULL,
+		include_in_net_worth INTEGER NOT NULL,
+		current_balance REAL NOT NULL,
+		display_balance REAL NOT NULL,
+		updated_at TEXT NOT NULL,
+		data TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		deleted_at TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS category_groups (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		data TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		deleted_at TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS categories (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		group_id TEXT NOT NULL,
+		is_disabled INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		deleted_at TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS tags (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		color TEXT NOT NULL,
+		data TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		deleted_at TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS transactions (
+		id TEXT PRIMARY KEY,
+		date TEXT NOT NULL,
+		amount REAL NOT NULL,
+		account_id TEXT NOT NULL,
+		category_id TEXT NOT NULL,
+		merchant TEXT NOT NULL,
+		notes TEXT NOT NULL,
+		pending INTEGER NOT NULL,
+		hide_from_reports INTEGER NOT NULL,
+		needs_review INTEGER NOT NULL,
+		updated_at TEXT NOT NULL,
+		data TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		deleted_at TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS transactions_date ON transactions (date)`,
+	`CREATE INDEX IF NOT EXISTS transactions_account ON transactions (account_id, date)`,
+	`CREATE INDEX IF NOT EXISTS transactions_category ON transactions (category_id, date)`,
+	`CREATE TABLE IF NOT EXISTS transaction_tags (
+		transaction_id TEXT NOT NULL,
+		tag_id TEXT NOT NULL,
+		PRIMARY KEY (transaction_id, tag_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS recurring (
+		id TEXT PRIMARY KEY,
+		merchant TEXT NOT NULL,
+		amount REAL NOT NULL,
+		frequency TEXT NOT NULL,
+		next_date TEXT NOT NULL,
+		account_id TEXT NOT NULL,
+		category_id TEXT NOT NULL,
+		is_active INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		deleted_at TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS holdings (
+		id TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		name TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		price REAL NOT NULL,
+		value REAL NOT NULL,
+		cost_basis REAL NOT NULL,
+		data TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		deleted_at TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS budgets (
+		category_id TEXT NOT NULL,
+		month TEXT NOT NULL,
+		amount REAL NOT NULL,
+		spent REAL NOT NULL,
+		remaining REAL NOT NULL,
+		rollover INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		PRIMARY KEY (category_id, month)
+	)`,
+	`CREATE TABLE IF NOT EXISTS balance_history (
+		account_id TEXT NOT NULL,
+		date TEXT NOT NULL,
+		balance REAL NOT NULL,
+		PRIMARY KEY (account_id, date)
+	)`,
+	`CREATE TABLE IF NOT EXISTS sync_state (
+		resource TEXT PRIMARY KEY,
+		synced_at TEXT NOT NULL,
+		full_synced_at TEXT
+	)`,
+}
+
+// Open opens the SQLite database at path, creating it if needed
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open database")
+	}
+	// SQLite allows one writer; a single connection avoids busy errors
+	// between the syncer's own transactions
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to open database")
+	}
+	return db, nil
+}
+
+// migrate creates the mirror's tables
+func migrate(ctx context.Context, db *sql.DB) error {
+	for _, stmt := range schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return errors.Wrap(err, "failed to create schema")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+//go:build cgo
+
+package monarchsync
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/pkg/errors"
+)
+
+// Options configures a Syncer
+type Options struct {
+	// Lookback is how long before the previous sync an incremental sync
+	// looks for new, changed and deleted transactions and balances
+	// (default 30 days). Pending transactions often post days later.
+	Lookback time.Duration
+
+	// FullSyncInterval is how often transactions and balance history are
+	// fetched in full (default 7 days). Negative disables periodic full
+	// syncs; FullSync still runs one.
+	FullSyncInterval time.Duration
+
+	// BudgetMonths is how many months of budgets, up to and including the
+	// current one, are mirrored (default 12)
+	BudgetMonths int
+}
+
+// withDefaults returns a copy of the options with zero values defaulted
+func (o *Options) withDefaults() Options {
+	var out Options
+	if o != nil {
+		out = *o
+	}
+	if out.Lookback <= 0 {
+		out.Lookback = 30 * 24 * time.Hour
+	}
+	if out.FullSyncInterval == 0 {
+		out.FullSyncInterval = 7 * 24 * time.Hour
+	}
+	if out.BudgetMonths <= 0 {
+		out.BudgetMonths = 12
+	}
+	return out
+}
+
+// Stats counts what a sync did to one table
+type Stats struct {
+	// Upserted counts rows inserted or rewritten
+	Upserted int
+
+	// Unchanged counts objects that matched their rows
+	Unchanged int
+
+	// Deleted counts rows newly marked deleted
+	Deleted int
+}
+
+// Result reports what a sync did
+type Result struct {
+	// Full is true when transactions and balance history were fetched in full
+	Full bool
+
+	// Tables maps table names to what the sync did to them
+	Tables map[string]*Stats
+}
+
+// Syncer mirrors a client's data into a database
+type Syncer struct {
+	client *monarch.Client
+	db     *sql.DB
+	opts   Options
+	now    func() time.Time
+}
+
+// New creates a syncer, creating the mirror's tables in db if needed. A nil
+// opts uses the defaults.
+func New(client *monarch.Client, db *sql.DB, opts *Options) (*Syncer, error) {
+	if client == nil {
+		return nil, errors.New("sync requires a client")
+	}
+	if db == nil {
+		return nil, errors.New("sync requires a database")
+	}
+	if err := migrate(context.Background(), db); err != nil {
+		return nil, err
+	}
+	return &Syncer{client: client, db: db, opts: opts.withDefaults(), now: time.Now}, nil
+}
+
+// Sync brings the mirror up to date, fetching transactions and balance
+// history in full only on the first sync and every FullSyncInterval
+func (s *Syncer) Sync(ctx context.Context) (*Result, error) {
+	return s.sync(ctx, false)
+}
+
+// FullSync brings the mirror up to date, fetching every transaction and
+// all balance history
+func (s *Syncer) FullSync(ctx context.Context) (*Result, error) {
+	return s.sync(ctx, true)
+}
+
+// sync runs each resource in its own database transaction, together with
+// its sync_state row, so a failure leaves earlier resources synced and the
+// failed one as it was
+func (s *Syncer) sync(ctx context.Context, full bool) (*Result, error) {
+	now := s.now().UTC()
+	result := &Result{Tables: make(map[string]*Stats)}
+
+	if err := s.syncCategoryGroups(ctx, now, result); err != nil {
+		return result, err
+	}
+	if err := s.syncCategories(ctx, now, result); err != nil {
+		return result, err
+	}
+	if err := s.syncTags(ctx, now, result); err != nil {
+		return result, err
+	}
+	accounts, err := s.syncAccounts(ctx, now, result)
+	if err != nil {
+		return result, err
+	}
+	if err := s.syncHoldings(ctx, now, accounts, result); err != nil {
+		return result, err
+	}
+	if err := s.syncRecurring(ctx, now, result); err != nil {
+		return result, err
+	}
+	if err := s.syncBudgets(ctx, now, result); err != nil {
+		return result, err
+	}
+
+	txState, err := s.state(ctx, "transactions")
+	if err != nil {
+		return result, err
+	}
+	result.Full = full || s.needsFull(txState, now)
+	if err := s.syncTransactions(ctx, now, txState, result); err != nil {
+		return result, err
+	}
+
+	balanceState, err := s.state(ctx, "balance_history")
+	if err != nil {
+		return result, err
+	}
+	if err := s.syncBalanceHistory(ctx, now, balanceState, result.Full || s.needsFull(balanceState, now), accounts, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// syncState is a resource's row in sync_state
+type syncState struct {
+	syncedAt     time.Time
+	fullSyncedAt time.Time
+}
+
+// state reads a resource's sync state; it is zero before the first sync
+func (s *Syncer) state(ctx context.Context, resource string) (syncState, error) {
+	var synced string
+	var full sql.NullString
+	err := s.db.QueryRowContext(ctx, "SELECT synced_at, full_synced_at FROM sync_state WHERE resource = ?", resource).Scan(&synced, &full)
+	if err == sql.ErrNoRows {
+		return syncState{}, nil
+	}
+	if err != nil {
+		return syncState{}, errors.Wrap(err, "failed to read sync state")
+	}
+
+	var st syncState
+	st.syncedAt, _ = time.Parse(time.RFC3339Nano, synced)
+	if full.Valid {
+		st.fullSyncedAt, _ = time.Parse(time.RFC3339Nano, full.String)
+	}
+	return st, nil
+}
+
+// needsFull reports whether a resource is due a full sync
+func (s *Syncer) needsFull(st syncState, now time.Time) bool {
+	if st.syncedAt.IsZero() || st.fullSyncedAt.IsZero() {
+		return true
+	}
+	return s.opts.FullSyncInterval > 0 && now.Sub(st.fullSyncedAt) >= s.opts.FullSyncInterval
+}
+
+// windowStart is the first date an incremental sync looks at
+func (s *Syncer) windowStart(st syncState) time.Time {
+	return dateOf(st.syncedAt.Add(-s.opts.Lookback))
+}
+
+// setState records a sync of resource inside tx
+func setState(ctx context.Context, tx *sql.Tx, resource string, now time.Time, full bool) error {
+	var fullAt interface{}
+	if full {
+		fullAt = formatTime(now)
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO sync_state (resource, synced_at, full_synced_at) VALUES (?, ?, ?)
+		ON CONFLICT (resource) DO UPDATE SET
+			synced_at = excluded.synced_at,
+			full_synced_at = COALESCE(excluded.full_synced_at, sync_state.full_synced_at)`,
+		resource, formatTime(now), fullAt)
+	return errors.Wrap(err, "failed to record sync state")
+}
+
+// inTx runs fn in a database transaction, committing when it succeeds
+func (s *Syncer) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return errors.Wrap(tx.Commit(), "failed to commit transaction")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
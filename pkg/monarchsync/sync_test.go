@@ -0,0 +1,200 @@
+//go:build cgo
+
+package monarchsync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchfake"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+func testDataset() *monarchtest.Dataset {
+	food := &monarch.CategoryGroup{ID: "grp-food", Name: "Food", Type: "expense"}
+	day := func(daysAgo int) monarch.Date {
+		return monarch.Date{Time: time.Date(2026, 10, 15-daysAgo, 0, 0, 0, 0, time.UTC)}
+	}
+	txn := func(id string, daysAgo int, amount float64) *monarch.Transaction {
+		return &monarch.Transaction{
+			ID: id, Date: day(daysAgo), Amount: amount, UpdatedAt: day(daysAgo),
+			Account:  &monarch.Account{ID: "acc-checking"},
+			Category: &monarch.TransactionCategory{ID: "cat-groceries"},
+			Merchant: &monarch.Merchant{ID: "m-1", Name: "Market"},
+		}
+	}
+	return &monarchtest.Dataset{
+		Accounts: []*monarch.Account{
+			{
+				ID: "acc-checking", DisplayName: "Checking", IsAsset: true, CurrentBalance: 1000, DisplayBalance: 1000,
+				Type: &monarch.AccountTypeInfo{Name: "depository"}, Subtype: &monarch.AccountSubtypeInfo{Name: "checking"},
+			},
+			{
+				ID: "acc-brokerage", DisplayName: "Brokerage", IsAsset: true, IsManual: true,
+				Type: &monarch.AccountTypeInfo{Name: "brokerage"}, Subtype: &monarch.AccountSubtypeInfo{Name: "brokerage"},
+			},
+		},
+		CategoryGroups: []*monarch.CategoryGroup{food},
+		Categories: []*monarch.TransactionCategory{
+			{ID: "cat-groceries", Name: "Groceries", Group: food, GroupID: food.ID},
+			{ID: "cat-dining", Name: "Dining", Group: food, GroupID: food.ID},
+		},
+		Tags: []*monarch.Tag{{ID: "tag-trip", Name: "Trip", Color: "#ff0000"}},
+		Transactions: []*monarch.Transaction{
+			txn("txn-old", 200, -40),
+			txn("txn-1", 10, -25),
+			txn("txn-2", 3, -60),
+		},
+		Budgets: []*monarch.Budget{
+			{ID: "bud-1", CategoryID: "cat-groceries", Amount: 500, StartDate: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		Recurring: []*monarch.RecurringTransaction{
+			{ID: "rec-1", Merchant: &monarch.Merchant{Name: "Gym"}, Amount: -30, Frequency: "monthly", NextDate: day(-5), IsActive: true},
+		},
+		Securities: []*monarch.Security{{ID: "sec-aapl", Ticker: "AAPL", Name: "Apple Inc.", CurrentPrice: 150}},
+		Holdings:   []*monarch.Holding{{ID: "hold-1", AccountID: "acc-brokerage", Symbol: "AAPL", Quantity: 10}},
+	}
+}
+
+func newTestSyncer(t *testing.T, opts *Options) (*Syncer, *monarchfake.Fake, *sql.DB, *time.Time) {
+	t.Helper()
+	now := testNow
+	fake := monarchfake.New(testDataset())
+	fake.SetNow(func() time.Time { return now })
+
+	db, err := Open(filepath.Join(t.TempDir(), "monarch.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	s, err := New(fake.Client(), db, opts)
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+	return s, fake, db, &now
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	require.NoError(t, db.QueryRow(query, args...).Scan(&n))
+	return n
+}
+
+func TestSync_First(t *testing.T) {
+	s, _, db, _ := newTestSyncer(t, nil)
+
+	result, err := s.Sync(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Full)
+	assert.Equal(t, 3, result.Tables["transactions"].Upserted)
+
+	assert.Equal(t, 2, count(t, db, "SELECT COUNT(*) FROM accounts"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM category_groups"))
+	assert.Equal(t, 2, count(t, db, "SELECT COUNT(*) FROM categories"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM tags"))
+	assert.Equal(t, 3, count(t, db, "SELECT COUNT(*) FROM transactions"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM recurring"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM holdings WHERE symbol = 'AAPL' AND value = 1500"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM budgets WHERE category_id = 'cat-groceries' AND month = '2026-10-01' AND amount = 500"))
+	assert.Positive(t, count(t, db, "SELECT COUNT(*) FROM balance_history WHERE account_id = 'acc-checking'"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM transactions WHERE id = 'txn-1' AND date = '2026-10-05' AND amount = -25 AND merchant = 'Market'"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM transactions WHERE json_extract(data, '$.account.id') = 'acc-checking' AND id = 'txn-2'"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM sync_state WHERE resource = 'transactions' AND full_synced_at IS NOT NULL"))
+}
+
+func TestSync_Incremental(t *testing.T) {
+	s, fake, db, now := newTestSyncer(t, nil)
+	ctx := context.Background()
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+
+	*now = now.AddDate(0, 0, 1)
+	notes := "split with Sam"
+	_, err = fake.Transactions.Update(ctx, "txn-1", &monarch.UpdateTransactionParams{Notes: &notes})
+	require.NoError(t, err)
+	require.NoError(t, fake.Tags.SetTransactionTags(ctx, "txn-1", "tag-trip"))
+	require.NoError(t, fake.Transactions.Delete(ctx, "txn-2"))
+	_, err = fake.Transactions.Create(ctx, &monarch.CreateTransactionParams{
+		Date: monarch.Date{Time: now.AddDate(0, 0, -1)}, AccountID: "acc-checking", Amount: -12, CategoryID: "cat-dining",
+	})
+	require.NoError(t, err)
+
+	queries := len(fake.Calls())
+	result, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.Full)
+	assert.Equal(t, Stats{Upserted: 2, Deleted: 1}, *result.Tables["transactions"])
+	assert.Contains(t, fake.Calls()[queries:], "Accounts.GetBalances")
+	assert.NotContains(t, fake.Calls()[queries:], "Accounts.GetHistory")
+
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM transactions WHERE id = 'txn-1' AND notes = ?", notes))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM transaction_tags WHERE transaction_id = 'txn-1' AND tag_id = 'tag-trip'"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM transactions WHERE id = 'txn-2' AND deleted_at IS NOT NULL"))
+	assert.Equal(t, 3, count(t, db, "SELECT COUNT(*) FROM transactions WHERE deleted_at IS NULL"))
+
+	result, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Unchanged: 2}, *result.Tables["transactions"], "transactions outside the window are not fetched")
+}
+
+func TestSync_OldDeletionsWaitForFullSync(t *testing.T) {
+	s, fake, db, now := newTestSyncer(t, nil)
+	ctx := context.Background()
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, fake.Transactions.Delete(ctx, "txn-old"))
+	*now = now.AddDate(0, 0, 1)
+	_, err = s.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count(t, db, "SELECT COUNT(*) FROM transactions WHERE deleted_at IS NOT NULL"))
+
+	*now = now.AddDate(0, 0, 7)
+	result, err := s.Sync(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Full, "full syncs run every FullSyncInterval")
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM transactions WHERE id = 'txn-old' AND deleted_at IS NOT NULL"))
+}
+
+func TestSync_DeletedObjects(t *testing.T) {
+	s, fake, db, _ := newTestSyncer(t, nil)
+	ctx := context.Background()
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, fake.Transactions.Categories().Delete(ctx, "cat-dining"))
+	require.NoError(t, fake.Accounts.DeleteHolding(ctx, "hold-1"))
+	result, err := s.Sync(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, Stats{Unchanged: 1, Deleted: 1}, *result.Tables["categories"])
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM categories WHERE id = 'cat-dining' AND deleted_at IS NOT NULL"))
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM holdings WHERE id = 'hold-1' AND deleted_at IS NOT NULL"))
+}
+
+func TestSync_FailureKeepsState(t *testing.T) {
+	s, fake, db, now := newTestSyncer(t, nil)
+	ctx := context.Background()
+	_, err := s.Sync(ctx)
+	require.NoError(t, err)
+	before := count(t, db, "SELECT COUNT(*) FROM sync_state WHERE resource = 'transactions' AND synced_at = ?", formatTime(testNow))
+
+	fake.Fail("Transactions.Query", errors.New("boom"))
+	*now = now.AddDate(0, 0, 1)
+	_, err = s.Sync(ctx)
+	require.Error(t, err)
+
+	assert.Equal(t, 1, before)
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM sync_state WHERE resource = 'transactions' AND synced_at = ?", formatTime(testNow)),
+		"a failed sync does not move the transaction window")
+	assert.Equal(t, 1, count(t, db, "SELECT COUNT(*) FROM sync_state WHERE resource = 'accounts' AND synced_at = ?", formatTime(*now)),
+		"resources synced before the failure keep their progress")
+}
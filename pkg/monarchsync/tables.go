@@ -0,0 +1,444 @@
+//go:build cgo
+
+package monarchsync
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/pkg/errors"
+)
+
+// objectTable is a mirrored table keyed by object ID
+type objectTable struct {
+	name    string
+	columns []string // besides id, data, synced_at and deleted_at
+
+	// version names a column that changes whenever the object does, so
+	// rows are compared by it instead of by their data
+	version string
+}
+
+var (
+	accountsTable = objectTable{name: "accounts", columns: []string{
+		"display_name", "type", "subtype", "institution", "is_asset", "is_manual", "is_hidden",
+		"include_in_net_worth", "current_balance", "display_balance", "updated_at",
+	}}
+	categoryGroupsTable = objectTable{name: "category_groups", columns: []string{"name", "type"}}
+	categoriesTable     = objectTable{name: "categories", columns: []string{"name", "group_id", "is_disabled"}}
+	tagsTable           = objectTable{name: "tags", columns: []string{"name", "color"}}
+	transactionsTable   = objectTable{name: "transactions", version: "updated_at", columns: []string{
+		"date", "amount", "account_id", "category_id", "merchant", "notes", "pending",
+		"hide_from_reports", "needs_review", "updated_at",
+	}}
+	recurringTable = objectTable{name: "recurring", columns: []string{
+		"merchant", "amount", "frequency", "next_date", "account_id", "category_id", "is_active",
+	}}
+	holdingsTable = objectTable{name: "holdings", columns: []string{
+		"account_id", "symbol", "name", "quantity", "price", "value", "cost_basis",
+	}}
+)
+
+// row is one object's column values, in the order of objectTable.columns
+type row struct {
+	id      string
+	values  []interface{}
+	version string
+	object  interface{}
+}
+
+// tableWriter upserts the objects of one sync into a table and marks the
+// rows it did not see as deleted
+type tableWriter struct {
+	tx    *sql.Tx
+	table objectTable
+	now   string
+	stats *Stats
+
+	// live holds the IDs of rows in scope that were not deleted before the
+	// sync and have not been seen yet
+	live map[string]bool
+}
+
+// newTableWriter starts writing table. Rows matching scope (all when empty)
+// that the sync does not put are marked deleted by finish.
+func newTableWriter(ctx context.Context, tx *sql.Tx, table objectTable, now time.Time, stats *Stats, scope string, args ...interface{}) (*tableWriter, error) {
+	query := "SELECT id FROM " + table.name + " WHERE deleted_at IS NULL"
+	if scope != "" {
+		query += " AND " + scope
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", table.name)
+	}
+	defer rows.Close()
+
+	live := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", table.name)
+		}
+		live[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", table.name)
+	}
+	return &tableWriter{tx: tx, table: table, now: formatTime(now), stats: stats, live: live}, nil
+}
+
+// put writes r unless its row already matches, reporting whether it wrote
+func (w *tableWriter) put(ctx context.Context, r row) (bool, error) {
+	delete(w.live, r.id)
+
+	data, err := json.Marshal(r.object)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to encode %s %s", w.table.name, r.id)
+	}
+
+	version := "data"
+	if w.table.version != "" && r.version != "" {
+		version = w.table.version
+	}
+	var stored string
+	var deleted bool
+	err = w.tx.QueryRowContext(ctx, "SELECT "+version+", deleted_at IS NOT NULL FROM "+w.table.name+" WHERE id = ?", r.id).Scan(&stored, &deleted)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return false, errors.Wrapf(err, "failed to read %s %s", w.table.name, r.id)
+	case !deleted && (version == "data" && stored == string(data) || version != "data" && stored == r.version):
+		w.stats.Unchanged++
+		return false, nil
+	}
+
+	args := append([]interface{}{r.id}, r.values...)
+	args = append(args, string(data), w.now)
+	if _, err := w.tx.ExecContext(ctx, w.table.upsertSQL(), args...); err != nil {
+		return false, errors.Wrapf(err, "failed to write %s %s", w.table.name, r.id)
+	}
+	w.stats.Upserted++
+	return true, nil
+}
+
+// finish marks the live rows that were not put as deleted
+func (w *tableWriter) finish(ctx context.Context) error {
+	for id := range w.live {
+		_, err := w.tx.ExecContext(ctx, "UPDATE "+w.table.name+" SET deleted_at = ?, synced_at = ? WHERE id = ?", w.now, w.now, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete %s %s", w.table.name, id)
+		}
+		w.stats.Deleted++
+	}
+	return nil
+}
+
+// upsertSQL inserts a row or overwrites it, clearing deleted_at
+func (t objectTable) upsertSQL() string {
+	columns := append([]string{"id"}, t.columns...)
+	columns = append(columns, "data", "synced_at")
+	updates := make([]string, 0, len(columns))
+	for _, c := range columns[1:] {
+		updates = append(updates, c+" = excluded."+c)
+	}
+	updates = append(updates, "deleted_at = NULL")
+	return fmt.Sprintf("INSERT INTO %s (%s, deleted_at) VALUES (%sNULL) ON CONFLICT (id) DO UPDATE SET %s",
+		t.name, strings.Join(columns, ", "), strings.Repeat("?, ", len(columns)), strings.Join(updates, ", "))
+}
+
+// syncObjects mirrors a complete list of objects into table
+func (s *Syncer) syncObjects(ctx context.Context, now time.Time, table objectTable, rows []row, result *Result) error {
+	stats := &Stats{}
+	result.Tables[table.name] = stats
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		w, err := newTableWriter(ctx, tx, table, now, stats, "")
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if _, err := w.put(ctx, r); err != nil {
+				return err
+			}
+		}
+		if err := w.finish(ctx); err != nil {
+			return err
+		}
+		return setState(ctx, tx, table.name, now, true)
+	})
+}
+
+func (s *Syncer) syncCategoryGroups(ctx context.Context, now time.Time, result *Result) error {
+	groups, err := s.client.Transactions.Categories().GetGroups(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list category groups")
+	}
+	rows := make([]row, 0, len(groups))
+	for _, g := range groups {
+		rows = append(rows, row{id: g.ID, object: g, values: []interface{}{g.Name, g.Type}})
+	}
+	return s.syncObjects(ctx, now, categoryGroupsTable, rows, result)
+}
+
+func (s *Syncer) syncCategories(ctx context.Context, now time.Time, result *Result) error {
+	categories, err := s.client.Transactions.Categories().List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list categories")
+	}
+	rows := make([]row, 0, len(categories))
+	for _, c := range categories {
+		groupID := c.GroupID
+		if groupID == "" && c.Group != nil {
+			groupID = c.Group.ID
+		}
+		rows = append(rows, row{id: c.ID, object: c, values: []interface{}{c.Name, groupID, c.IsDisabled}})
+	}
+	return s.syncObjects(ctx, now, categoriesTable, rows, result)
+}
+
+func (s *Syncer) syncTags(ctx context.Context, now time.Time, result *Result) error {
+	tags, err := s.client.Tags.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list tags")
+	}
+	rows := make([]row, 0, len(tags))
+	for _, t := range tags {
+		rows = append(rows, row{id: t.ID, object: t, values: []interface{}{t.Name, t.Color}})
+	}
+	return s.syncObjects(ctx, now, tagsTable, rows, result)
+}
+
+func (s *Syncer) syncAccounts(ctx context.Context, now time.Time, result *Result) ([]*monarch.Account, error) {
+	accounts, err := s.client.Accounts.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list accounts")
+	}
+	rows := make([]row, 0, len(accounts))
+	for _, a := range accounts {
+		var typ, subtype, institution string
+		if a.Type != nil {
+			typ = a.Type.Name
+		}
+		if a.Subtype != nil {
+			subtype = a.Subtype.Name
+		}
+		if a.Institution != nil {
+			institution = a.Institution.Name
+		}
+		rows = append(rows, row{id: a.ID, object: a, values: []interface{}{
+			a.DisplayName, typ, subtype, institution, a.IsAsset, a.IsManual, a.IsHidden,
+			a.IncludeInNetWorth, a.CurrentBalance, a.DisplayBalance, formatTime(a.UpdatedAt),
+		}})
+	}
+	return accounts, s.syncObjects(ctx, now, accountsTable, rows, result)
+}
+
+// syncHoldings mirrors the holdings of accounts that may hold investments
+func (s *Syncer) syncHoldings(ctx context.Context, now time.Time, accounts []*monarch.Account, result *Result) error {
+	var rows []row
+	for _, a := range accounts {
+		if a.HoldingsCount == 0 && (a.Type == nil || a.Type.Name != "brokerage") {
+			continue
+		}
+		holdings, err := s.client.Accounts.GetHoldings(ctx, a.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list holdings of account %s", a.ID)
+		}
+		for _, h := range holdings {
+			accountID := h.AccountID
+			if accountID == "" {
+				accountID = a.ID
+			}
+			rows = append(rows, row{id: h.ID, object: h, values: []interface{}{
+				accountID, h.Symbol, h.Name, h.Quantity, h.Price, h.Value, h.CostBasis,
+			}})
+		}
+	}
+	return s.syncObjects(ctx, now, holdingsTable, rows, result)
+}
+
+func (s *Syncer) syncRecurring(ctx context.Context, now time.Time, result *Result) error {
+	items, err := s.client.Recurring.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list recurring transactions")
+	}
+	rows := make([]row, 0, len(items))
+	for _, r := range items {
+		var merchant, accountID, categoryID string
+		if r.Merchant != nil {
+			merchant = r.Merchant.Name
+		}
+		if r.Account != nil {
+			accountID = r.Account.ID
+		}
+		if r.Category != nil {
+			categoryID = r.Category.ID
+		}
+		rows = append(rows, row{id: r.ID, object: r, values: []interface{}{
+			merchant, r.Amount, r.Frequency, formatDate(r.NextDate.Time), accountID, categoryID, r.IsActive,
+		}})
+	}
+	return s.syncObjects(ctx, now, recurringTable, rows, result)
+}
+
+// syncBudgets replaces the budgets of the last BudgetMonths months
+func (s *Syncer) syncBudgets(ctx context.Context, now time.Time, result *Result) error {
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := current.AddDate(0, 1-s.opts.BudgetMonths, 0)
+	end := current.AddDate(0, 1, -1)
+
+	budgets, err := s.client.Budgets.List(ctx, start, end)
+	if err != nil {
+		return errors.Wrap(err, "failed to list budgets")
+	}
+
+	stats := &Stats{}
+	result.Tables["budgets"] = stats
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM budgets WHERE month >= ? AND month <= ?", formatDate(start), formatDate(end)); err != nil {
+			return errors.Wrap(err, "failed to clear budgets")
+		}
+		for _, b := range budgets {
+			categoryID := b.CategoryID
+			if categoryID == "" && b.Category != nil {
+				categoryID = b.Category.ID
+			}
+			data, err := json.Marshal(b)
+			if err != nil {
+				return errors.Wrap(err, "failed to encode budget")
+			}
+			month := time.Date(b.StartDate.Year(), b.StartDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+			_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO budgets
+				(category_id, month, amount, spent, remaining, rollover, data, synced_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				categoryID, formatDate(month), b.Amount, b.Spent, b.Remaining, b.Rollover, string(data), formatTime(now))
+			if err != nil {
+				return errors.Wrap(err, "failed to write budget")
+			}
+			stats.Upserted++
+		}
+		return setState(ctx, tx, "budgets", now, true)
+	})
+}
+
+// syncTransactions streams every transaction on a full sync, or the ones
+// dated within the incremental window otherwise. Only rows in the fetched
+// range can be marked deleted.
+func (s *Syncer) syncTransactions(ctx context.Context, now time.Time, st syncState, result *Result) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	query := s.client.Transactions.Query()
+	scope, args := "", []interface{}(nil)
+	if !result.Full {
+		// The window reaches a year ahead for scheduled transactions
+		start, end := s.windowStart(st), dateOf(now).AddDate(1, 0, 0)
+		query = query.Between(start, end)
+		scope, args = "date >= ? AND date <= ?", []interface{}{formatDate(start), formatDate(end)}
+	}
+
+	stats := &Stats{}
+	result.Tables["transactions"] = stats
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		w, err := newTableWriter(ctx, tx, transactionsTable, now, stats, scope, args...)
+		if err != nil {
+			return err
+		}
+
+		txns, errs := query.Stream(ctx)
+		for t := range txns {
+			if err := s.putTransaction(ctx, w, t); err != nil {
+				return err
+			}
+		}
+		if err := <-errs; err != nil {
+			return errors.Wrap(err, "failed to list transactions")
+		}
+
+		if err := w.finish(ctx); err != nil {
+			return err
+		}
+		return setState(ctx, tx, "transactions", now, result.Full)
+	})
+}
+
+// putTransaction writes a transaction and, when it changed, its tags
+func (s *Syncer) putTransaction(ctx context.Context, w *tableWriter, t *monarch.Transaction) error {
+	var merchant, accountID, categoryID, version string
+	if t.Merchant != nil {
+		merchant = t.Merchant.Name
+	}
+	if t.Account != nil {
+		accountID = t.Account.ID
+	}
+	if t.Category != nil {
+		categoryID = t.Category.ID
+	}
+	if !t.UpdatedAt.IsZero() {
+		version = formatTime(t.UpdatedAt.Time)
+	}
+
+	changed, err := w.put(ctx, row{id: t.ID, object: t, version: version, values: []interface{}{
+		formatDate(t.Date.Time), t.Amount, accountID, categoryID, merchant, t.Notes, t.Pending,
+		t.HideFromReports, t.NeedsReview, version,
+	}})
+	if err != nil || !changed {
+		return err
+	}
+
+	if _, err := w.tx.ExecContext(ctx, "DELETE FROM transaction_tags WHERE transaction_id = ?", t.ID); err != nil {
+		return errors.Wrap(err, "failed to write transaction tags")
+	}
+	for _, tag := range t.Tags {
+		if _, err := w.tx.ExecContext(ctx, "INSERT OR IGNORE INTO transaction_tags (transaction_id, tag_id) VALUES (?, ?)", t.ID, tag.ID); err != nil {
+			return errors.Wrap(err, "failed to write transaction tags")
+		}
+	}
+	return nil
+}
+
+// syncBalanceHistory fetches each account's full history on a full sync,
+// and the recent balances of all accounts from the window start otherwise
+func (s *Syncer) syncBalanceHistory(ctx context.Context, now time.Time, st syncState, full bool, accounts []*monarch.Account, result *Result) error {
+	var balances []*monarch.AccountBalance
+	if full {
+		for _, a := range accounts {
+			history, err := s.client.Accounts.GetHistory(ctx, a.ID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get balance history of account %s", a.ID)
+			}
+			for _, b := range history.Balances {
+				balances = append(balances, &monarch.AccountBalance{AccountID: a.ID, Date: b.Date, Balance: b.Balance})
+			}
+		}
+	} else {
+		start := s.windowStart(st)
+		var err error
+		balances, err = s.client.Accounts.GetBalances(ctx, &start)
+		if err != nil {
+			return errors.Wrap(err, "failed to get account balances")
+		}
+	}
+
+	stats := &Stats{}
+	result.Tables["balance_history"] = stats
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, b := range balances {
+			if b.Date.IsZero() {
+				continue
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO balance_history (account_id, date, balance) VALUES (?, ?, ?)
+				ON CONFLICT (account_id, date) DO UPDATE SET balance = excluded.balance`,
+				b.AccountID, formatDate(b.Date.Time), b.Balance)
+			if err != nil {
+				return errors.Wrap(err, "failed to write balance history")
+			}
+			stats.Upserted++
+		}
+		return setState(ctx, tx, "balance_history", now, full)
+	})
+}
@@ -0,0 +1,657 @@
+package monarchtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+func init() {
+	register(map[string]handler{
+		"GetAccounts":                           (*Server).listAccounts,
+		"GetAccountTypeOptions":                 (*Server).accountTypeOptions,
+		"Web_CreateManualAccount":               (*Server).createManualAccount,
+		"Common_CreateManualInvestmentsAccount": (*Server).createInvestmentsAccount,
+		"Common_UpdateAccount":                  (*Server).updateAccount,
+		"Common_DeleteAccount":                  (*Server).deleteAccount,
+		"GetAccountRecentBalances":              (*Server).recentBalances,
+		"GetAccountHistory":                     (*Server).accountHistory,
+		"GetSnapshotsByAccountType":             (*Server).snapshotsByAccountType,
+		"GetAggregateSnapshots":                 (*Server).aggregateSnapshots,
+		"Web_GetHoldings":                       (*Server).listHoldings,
+		"SecuritySearch":                        (*Server).searchSecurities,
+		"Common_CreateManualHolding":            (*Server).createHolding,
+		"Common_UpdateHoldingMutation":          (*Server).updateHolding,
+		"Common_DeleteHolding":                  (*Server).deleteHolding,
+		"Common_ForceRefreshAccountsMutation":   (*Server).forceRefresh,
+		"CheckRefreshStatus":                    (*Server).refreshStatus,
+		"ForceRefreshAccountsQuery":             (*Server).syncInProgress,
+		"GetInstitutions":                       (*Server).listInstitutions,
+		"GetSubscriptionDetails":                (*Server).subscription,
+	})
+}
+
+// accountTypes are the account types and subtypes offered for manual accounts
+var accountTypes = []struct {
+	name, display string
+	asset         bool
+	subtypes      []monarch.AccountSubtypeInfo
+}{
+	{"depository", "Cash", true, []monarch.AccountSubtypeInfo{{Name: "checking", Display: "Checking"}, {Name: "savings", Display: "Savings"}}},
+	{"credit", "Credit Cards", false, []monarch.AccountSubtypeInfo{{Name: "credit_card", Display: "Credit Card"}}},
+	{"brokerage", "Investments", true, []monarch.AccountSubtypeInfo{{Name: "brokerage", Display: "Brokerage"}, {Name: "ira", Display: "IRA"}, {Name: "st_401k", Display: "401k"}}},
+	{"loan", "Loans", false, []monarch.AccountSubtypeInfo{{Name: "mortgage", Display: "Mortgage"}, {Name: "auto", Display: "Auto Loan"}}},
+	{"real_estate", "Real Estate", true, []monarch.AccountSubtypeInfo{{Name: "primary_home", Display: "Primary Home"}}},
+	{"other_asset", "Other Assets", true, []monarch.AccountSubtypeInfo{{Name: "other", Display: "Other"}}},
+	{"other_liability", "Other Liabilities", false, []monarch.AccountSubtypeInfo{{Name: "other", Display: "Other"}}},
+}
+
+func (s *Server) listAccounts(map[string]interface{}) (interface{}, error) {
+	accounts := make([]*monarch.Account, 0, len(s.data.Accounts))
+	for _, acc := range s.data.Accounts {
+		a := *acc
+		a.TransactionsCount, a.HoldingsCount = 0, 0
+		for _, t := range s.data.Transactions {
+			if sim.AccountOf(t) == acc.ID {
+				a.TransactionsCount++
+			}
+		}
+		for _, h := range s.data.Holdings {
+			if h.AccountID == acc.ID {
+				a.HoldingsCount++
+			}
+		}
+		accounts = append(accounts, &a)
+	}
+	return map[string]interface{}{
+		"accounts":             accounts,
+		"householdPreferences": map[string]interface{}{"id": "household-preferences", "accountGroupOrder": []string{}},
+	}, nil
+}
+
+func (s *Server) accountTypeOptions(map[string]interface{}) (interface{}, error) {
+	var options []map[string]interface{}
+	for _, t := range accountTypes {
+		for i := range t.subtypes {
+			options = append(options, map[string]interface{}{
+				"type":             map[string]interface{}{"name": t.name, "display": t.display, "possibleSubtypes": t.subtypes},
+				"subtype":          t.subtypes[i],
+				"possibleSubtypes": t.subtypes,
+			})
+		}
+	}
+	return map[string]interface{}{"accountTypeOptions": options}, nil
+}
+
+func (s *Server) createManualAccount(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"createManualAccount": map[string]interface{}{"account": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	name := strings.TrimSpace(str(input, "name"))
+	if name == "" {
+		return fail("INVALID", "name is required")
+	}
+	acc, err := s.newAccount(name, str(input, "type"), str(input, "subtype"))
+	if err != nil {
+		return fail("INVALID", "%v", err)
+	}
+	if include, ok := boolean(input, "includeInNetWorth"); ok {
+		acc.IncludeInNetWorth = include
+	}
+	balance, _ := number(input, "displayBalance")
+	sim.SetDisplayBalance(acc, balance)
+	s.data.Accounts = append(s.data.Accounts, acc)
+
+	return map[string]interface{}{
+		"createManualAccount": map[string]interface{}{"account": map[string]string{"id": acc.ID}, "errors": noErrors},
+	}, nil
+}
+
+func (s *Server) createInvestmentsAccount(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"createManualInvestmentsAccount": map[string]interface{}{"account": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	name := strings.TrimSpace(str(input, "name"))
+	if name == "" {
+		return fail("INVALID", "name is required")
+	}
+	acc, err := s.newAccount(name, "brokerage", str(input, "subtype"))
+	if err != nil {
+		return fail("INVALID", "%v", err)
+	}
+	acc.ManualInvestmentsTrackingMethod = str(input, "manualInvestmentsTrackingMethod")
+
+	initial, _ := input["initialHoldings"].([]interface{})
+	holdings := make([]*monarch.Holding, 0, len(initial))
+	for _, item := range initial {
+		entry, _ := item.(map[string]interface{})
+		sec := s.data.security(str(entry, "securityId"))
+		if sec == nil {
+			return fail("NOT_FOUND", "security %q does not exist", str(entry, "securityId"))
+		}
+		quantity, _ := number(entry, "quantity")
+		holdings = append(holdings, &monarch.Holding{
+			ID: s.newID("hold"), AccountID: acc.ID, Symbol: sec.Ticker, Name: sec.Name, Quantity: quantity,
+		})
+	}
+
+	s.data.Accounts = append(s.data.Accounts, acc)
+	s.data.Holdings = append(s.data.Holdings, holdings...)
+	s.revalue(acc.ID)
+
+	return map[string]interface{}{
+		"createManualInvestmentsAccount": map[string]interface{}{"account": map[string]string{"id": acc.ID}, "errors": noErrors},
+	}, nil
+}
+
+// newAccount builds a manual account of a known type
+func (s *Server) newAccount(name, typ, subtype string) (*monarch.Account, error) {
+	for _, t := range accountTypes {
+		if t.name != typ {
+			continue
+		}
+		for _, st := range t.subtypes {
+			if st.Name == subtype {
+				now := s.now().UTC()
+				return &monarch.Account{
+					ID:                   s.newID("acc"),
+					DisplayName:          name,
+					IsAsset:              t.asset,
+					IsManual:             true,
+					IncludeInNetWorth:    true,
+					CreatedAt:            now,
+					UpdatedAt:            now,
+					DisplayLastUpdatedAt: now,
+					Order:                len(s.data.Accounts),
+					Type:                 &monarch.AccountTypeInfo{Name: t.name, Display: t.display},
+					Subtype:              &monarch.AccountSubtypeInfo{Name: st.Name, Display: st.Display},
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown subtype %q for account type %q", subtype, typ)
+	}
+	return nil, fmt.Errorf("unknown account type %q", typ)
+}
+
+func (s *Server) updateAccount(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	acc := s.data.account(str(input, "id"))
+	if acc == nil {
+		return map[string]interface{}{
+			"updateAccount": map[string]interface{}{
+				"account": nil,
+				"errors":  payloadError("NOT_FOUND", "account %q does not exist", str(input, "id")),
+			},
+		}, nil
+	}
+
+	if name, ok := input["name"].(string); ok {
+		acc.DisplayName = name
+	}
+	if balance, ok := number(input, "displayBalance"); ok {
+		sim.SetDisplayBalance(acc, balance)
+	}
+	if include, ok := boolean(input, "includeInNetWorth"); ok {
+		acc.IncludeInNetWorth = include
+	}
+	if hide, ok := boolean(input, "hideFromSummaryList"); ok {
+		acc.HideFromList = hide
+	}
+	if hide, ok := boolean(input, "hideTransactionsFromReports"); ok {
+		acc.HideTransactionsFromReports = hide
+	}
+	acc.UpdatedAt = s.now().UTC()
+
+	return map[string]interface{}{
+		"updateAccount": map[string]interface{}{"account": acc, "errors": noErrors},
+	}, nil
+}
+
+// deleteAccount removes an account together with its transactions and holdings
+func (s *Server) deleteAccount(vars map[string]interface{}) (interface{}, error) {
+	id := str(vars, "id")
+	if s.data.account(id) == nil {
+		return map[string]interface{}{
+			"deleteAccount": map[string]interface{}{"deleted": false, "errors": payloadError("NOT_FOUND", "account %q does not exist", id)},
+		}, nil
+	}
+
+	accounts := s.data.Accounts[:0]
+	for _, acc := range s.data.Accounts {
+		if acc.ID != id {
+			accounts = append(accounts, acc)
+		}
+	}
+	s.data.Accounts = accounts
+
+	txns := s.data.Transactions[:0]
+	for _, t := range s.data.Transactions {
+		if sim.AccountOf(t) == id {
+			delete(s.data.Splits, t.ID)
+			continue
+		}
+		txns = append(txns, t)
+	}
+	s.data.Transactions = txns
+
+	holdings := s.data.Holdings[:0]
+	for _, h := range s.data.Holdings {
+		if h.AccountID != id {
+			holdings = append(holdings, h)
+		}
+	}
+	s.data.Holdings = holdings
+	delete(s.refreshing, id)
+
+	return map[string]interface{}{
+		"deleteAccount": map[string]interface{}{"deleted": true, "errors": noErrors},
+	}, nil
+}
+
+// recentBalances returns one balance per day from startDate to today
+func (s *Server) recentBalances(vars map[string]interface{}) (interface{}, error) {
+	start, ok := date(vars, "startDate")
+	if !ok {
+		return nil, fmt.Errorf("startDate must be YYYY-MM-DD")
+	}
+	days := s.days(start, s.today())
+
+	accounts := make([]map[string]interface{}, 0, len(s.data.Accounts))
+	for _, acc := range s.data.Accounts {
+		balances := make([]float64, len(days))
+		for i, d := range days {
+			balances[i] = s.balanceOn(acc, d)
+		}
+		accounts = append(accounts, map[string]interface{}{"id": acc.ID, "recentBalances": balances})
+	}
+	return map[string]interface{}{"accounts": accounts}, nil
+}
+
+// accountHistory returns the daily balances of an account since its first
+// transaction, or the last 30 days when it has none
+func (s *Server) accountHistory(vars map[string]interface{}) (interface{}, error) {
+	acc := s.data.account(str(vars, "accountId"))
+	if acc == nil {
+		return nil, fmt.Errorf("account %q not found", str(vars, "accountId"))
+	}
+
+	today := s.today()
+	start := today.AddDate(0, 0, -30)
+	for _, t := range s.data.Transactions {
+		if sim.AccountOf(t) == acc.ID && t.Date.Before(start) {
+			start = t.Date.Time
+		}
+	}
+
+	var history []map[string]interface{}
+	for _, d := range s.days(start, today) {
+		history = append(history, map[string]interface{}{"date": d.Format("2006-01-02"), "balance": s.balanceOn(acc, d)})
+	}
+	return map[string]interface{}{
+		"account": map[string]interface{}{"id": acc.ID, "balanceHistory": history},
+	}, nil
+}
+
+// snapshotsByAccountType sums the balances of each account type at the end of
+// every month or year since startDate
+func (s *Server) snapshotsByAccountType(vars map[string]interface{}) (interface{}, error) {
+	start, ok := date(vars, "startDate")
+	if !ok {
+		return nil, fmt.Errorf("startDate must be YYYY-MM-DD")
+	}
+	step := func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	period := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if str(vars, "timeframe") == "year" {
+		step = func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }
+		period = time.Date(start.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	today := s.today()
+	var snapshots []map[string]interface{}
+	for ; !period.After(today); period = step(period) {
+		end := step(period).AddDate(0, 0, -1)
+		if end.After(today) {
+			end = today
+		}
+		sums := make(map[[2]string]float64)
+		var keys [][2]string
+		for _, acc := range s.data.Accounts {
+			key := [2]string{sim.TypeName(acc), sim.SubtypeName(acc)}
+			if _, ok := sums[key]; !ok {
+				keys = append(keys, key)
+			}
+			sums[key] += s.balanceOn(acc, end)
+		}
+		for _, key := range keys {
+			snapshots = append(snapshots, map[string]interface{}{
+				"month":          period.Format("2006-01-02"),
+				"accountType":    key[0],
+				"accountSubtype": key[1],
+				"sum":            sim.Round(sums[key]),
+			})
+		}
+	}
+	return map[string]interface{}{"snapshotsByAccountType": snapshots}, nil
+}
+
+// aggregateSnapshots returns the daily net worth. Start dates before the first
+// transaction are moved up to it, since the client asks for 150 years.
+func (s *Server) aggregateSnapshots(vars map[string]interface{}) (interface{}, error) {
+	filters := object(vars, "filters")
+	today := s.today()
+	start, ok := date(filters, "startDate")
+	if !ok {
+		start = today.AddDate(0, 0, -30)
+	}
+	end, ok := date(filters, "endDate")
+	if !ok || end.After(today) {
+		end = today
+	}
+	earliest := today.AddDate(0, 0, -30)
+	for _, t := range s.data.Transactions {
+		if t.Date.Before(earliest) {
+			earliest = t.Date.Time
+		}
+	}
+	if start.Before(earliest) {
+		start = earliest
+	}
+
+	accountType := str(filters, "accountType")
+	snapshots := make([]map[string]interface{}, 0)
+	for _, d := range s.days(start, end) {
+		var total float64
+		for _, acc := range s.data.Accounts {
+			if acc.IncludeInNetWorth && (accountType == "" || sim.TypeName(acc) == accountType) {
+				total += s.balanceOn(acc, d)
+			}
+		}
+		snapshots = append(snapshots, map[string]interface{}{"date": d.Format("2006-01-02"), "balance": sim.Round(total)})
+	}
+	return map[string]interface{}{"aggregateSnapshots": snapshots}, nil
+}
+
+func (s *Server) listHoldings(vars map[string]interface{}) (interface{}, error) {
+	accounts := sim.Set(stringList(object(vars, "input"), "accountIds"))
+
+	edges := make([]map[string]interface{}, 0)
+	for _, h := range s.data.Holdings {
+		if len(accounts) > 0 && !accounts[h.AccountID] {
+			continue
+		}
+		sec := s.data.securityByTicker(h.Symbol)
+		if sec == nil {
+			sec = &monarch.Security{ID: "sec-" + strings.ToLower(h.Symbol), Name: h.Name, Ticker: h.Symbol, CurrentPrice: h.Price}
+		}
+		edges = append(edges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"id":         "agg-" + h.ID,
+				"quantity":   h.Quantity,
+				"basis":      h.CostBasis,
+				"totalValue": sim.Round(h.Quantity * sec.CurrentPrice),
+				"holdings": []map[string]interface{}{
+					{"id": h.ID, "name": sec.Name, "ticker": sec.Ticker, "closingPrice": sec.CurrentPrice},
+				},
+				"security": sec,
+			},
+		})
+	}
+	return map[string]interface{}{
+		"portfolio": map[string]interface{}{"aggregateHoldings": map[string]interface{}{"edges": edges}},
+	}, nil
+}
+
+// searchSecurities matches tickers and names, exact ticker matches first
+func (s *Server) searchSecurities(vars map[string]interface{}) (interface{}, error) {
+	search := strings.ToLower(strings.TrimSpace(str(vars, "search")))
+	var exact, partial []*monarch.Security
+	for _, sec := range s.data.Securities {
+		switch {
+		case search == "":
+		case strings.ToLower(sec.Ticker) == search:
+			exact = append(exact, sec)
+		case strings.Contains(strings.ToLower(sec.Ticker), search),
+			strings.Contains(strings.ToLower(sec.Name), search):
+			partial = append(partial, sec)
+		}
+	}
+
+	results := append(exact, partial...)
+	if limit, ok := number(vars, "limit"); ok && limit > 0 && int(limit) < len(results) {
+		results = results[:int(limit)]
+	}
+	if results == nil {
+		results = []*monarch.Security{}
+	}
+	return map[string]interface{}{"securities": results}, nil
+}
+
+func (s *Server) createHolding(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"createManualHolding": map[string]interface{}{"holding": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	acc := s.data.account(str(input, "accountId"))
+	if acc == nil {
+		return fail("NOT_FOUND", "account %q does not exist", str(input, "accountId"))
+	}
+	if !acc.IsManual {
+		return fail("INVALID", "holdings can only be added to manual accounts")
+	}
+	sec := s.data.security(str(input, "securityId"))
+	if sec == nil {
+		return fail("NOT_FOUND", "security %q does not exist", str(input, "securityId"))
+	}
+	for _, h := range s.data.Holdings {
+		if h.AccountID == acc.ID && strings.EqualFold(h.Symbol, sec.Ticker) {
+			return fail("DUPLICATE", "account already holds %s", sec.Ticker)
+		}
+	}
+	quantity, _ := number(input, "quantity")
+
+	h := &monarch.Holding{
+		ID: s.newID("hold"), AccountID: acc.ID, Symbol: sec.Ticker, Name: sec.Name,
+		Quantity: quantity, UpdatedAt: s.now().UTC(),
+	}
+	s.data.Holdings = append(s.data.Holdings, h)
+	s.revalue(acc.ID)
+
+	return map[string]interface{}{
+		"createManualHolding": map[string]interface{}{
+			"holding": map[string]interface{}{"id": h.ID, "ticker": h.Symbol},
+			"errors":  noErrors,
+		},
+	}, nil
+}
+
+func (s *Server) updateHolding(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	h := s.data.holding(str(input, "id"))
+	if h == nil {
+		return map[string]interface{}{
+			"updateHolding": map[string]interface{}{
+				"holding": nil,
+				"errors":  payloadError("NOT_FOUND", "holding %q does not exist", str(input, "id")),
+			},
+		}, nil
+	}
+
+	if quantity, ok := number(input, "quantity"); ok {
+		h.Quantity = quantity
+	}
+	h.UpdatedAt = s.now().UTC()
+	s.revalue(h.AccountID)
+
+	return map[string]interface{}{
+		"updateHolding": map[string]interface{}{
+			"holding": map[string]interface{}{"id": h.ID, "quantity": h.Quantity},
+			"errors":  noErrors,
+		},
+	}, nil
+}
+
+func (s *Server) deleteHolding(vars map[string]interface{}) (interface{}, error) {
+	id := str(vars, "id")
+	for i, h := range s.data.Holdings {
+		if h.ID == id {
+			s.data.Holdings = append(s.data.Holdings[:i], s.data.Holdings[i+1:]...)
+			s.revalue(h.AccountID)
+			return map[string]interface{}{
+				"deleteHolding": map[string]interface{}{"deleted": true, "errors": noErrors},
+			}, nil
+		}
+	}
+	return map[string]interface{}{
+		"deleteHolding": map[string]interface{}{"deleted": false, "errors": payloadError("NOT_FOUND", "holding %q does not exist", id)},
+	}, nil
+}
+
+// forceRefresh starts a refresh. Refreshes finish immediately: the next status
+// check reports the accounts as synced, unless their credential needs an update.
+func (s *Server) forceRefresh(vars map[string]interface{}) (interface{}, error) {
+	ids := stringList(object(vars, "input"), "accountIds")
+	for _, id := range ids {
+		if s.data.account(id) == nil {
+			return map[string]interface{}{
+				"forceRefreshAccounts": map[string]interface{}{"success": false, "errors": payloadError("NOT_FOUND", "account %q does not exist", id)},
+			}, nil
+		}
+	}
+	for _, id := range ids {
+		s.refreshing[id] = true
+	}
+	return map[string]interface{}{
+		"forceRefreshAccounts": map[string]interface{}{"success": true, "errors": noErrors},
+	}, nil
+}
+
+func (s *Server) refreshStatus(vars map[string]interface{}) (interface{}, error) {
+	now := s.now().UTC()
+	accounts := make([]map[string]interface{}, 0)
+	for _, id := range stringList(vars, "accountIds") {
+		acc := s.data.account(id)
+		if acc == nil {
+			continue
+		}
+
+		status := map[string]interface{}{"id": acc.ID, "syncing": false, "lastSyncedAt": nil, "credential": nil}
+		if acc.Credential != nil {
+			status["credential"] = map[string]interface{}{
+				"updateRequired":                 acc.Credential.UpdateRequired,
+				"disconnectedFromDataProviderAt": acc.Credential.DisconnectedFromDataProviderAt,
+			}
+		}
+		if s.refreshing[id] && (acc.Credential == nil || !acc.Credential.UpdateRequired) {
+			acc.DisplayLastUpdatedAt = now
+			status["lastSyncedAt"] = now
+		} else if !acc.DisplayLastUpdatedAt.IsZero() {
+			status["lastSyncedAt"] = acc.DisplayLastUpdatedAt
+		}
+		accounts = append(accounts, status)
+	}
+	return map[string]interface{}{"accounts": accounts}, nil
+}
+
+func (s *Server) syncInProgress(map[string]interface{}) (interface{}, error) {
+	accounts := make([]map[string]interface{}, 0, len(s.data.Accounts))
+	for _, acc := range s.data.Accounts {
+		accounts = append(accounts, map[string]interface{}{"id": acc.ID, "hasSyncInProgress": false})
+	}
+	return map[string]interface{}{"accounts": accounts}, nil
+}
+
+func (s *Server) listInstitutions(map[string]interface{}) (interface{}, error) {
+	credentials := make([]map[string]interface{}, 0, len(s.data.Institutions))
+	for _, inst := range s.data.Institutions {
+		credentials = append(credentials, map[string]interface{}{
+			"id":             inst.CredentialID,
+			"updateRequired": inst.UpdateRequired,
+			"dataProvider":   inst.DataProvider,
+			"institution":    map[string]interface{}{"id": inst.ID, "name": inst.Name, "url": inst.URL},
+		})
+	}
+	return map[string]interface{}{"credentials": credentials}, nil
+}
+
+// subscription serves both subscription queries, which share an operation name
+func (s *Server) subscription(map[string]interface{}) (interface{}, error) {
+	sub := s.data.Subscription
+	if sub == nil {
+		return map[string]interface{}{"subscription": nil}, nil
+	}
+	return map[string]interface{}{
+		"subscription": map[string]interface{}{
+			"id":                    sub.ID,
+			"planType":              sub.PlanType,
+			"status":                sub.Status,
+			"startDate":             sub.StartDate,
+			"endDate":               sub.EndDate,
+			"trialEndsAt":           sub.TrialEndsAt,
+			"canceledAt":            sub.CanceledAt,
+			"features":              sub.Features,
+			"paymentSource":         "STRIPE",
+			"referralCode":          "",
+			"isOnFreeTrial":         sub.TrialEndsAt != nil && sub.TrialEndsAt.After(s.now()),
+			"hasPremiumEntitlement": sub.Status == "active" || sub.Status == "trialing",
+		},
+	}, nil
+}
+
+// revalue sets the balance of a manual investments account tracked by
+// holdings to the current value of its holdings
+func (s *Server) revalue(accountID string) {
+	acc := s.data.account(accountID)
+	if acc == nil || acc.ManualInvestmentsTrackingMethod != "holdings" {
+		return
+	}
+	var total float64
+	for _, h := range s.data.Holdings {
+		if h.AccountID != accountID {
+			continue
+		}
+		if sec := s.data.securityByTicker(h.Symbol); sec != nil {
+			total += h.Quantity * sec.CurrentPrice
+		} else {
+			total += h.Quantity * h.Price
+		}
+	}
+	sim.SetDisplayBalance(acc, sim.Round(total))
+}
+
+// balanceOn works an account's balance on a day back from its current balance
+func (s *Server) balanceOn(acc *monarch.Account, day time.Time) float64 {
+	balance := acc.CurrentBalance
+	for _, t := range s.data.Transactions {
+		if sim.AccountOf(t) == acc.ID && t.Date.After(day) {
+			balance -= t.Amount
+		}
+	}
+	return sim.Round(balance)
+}
+
+// days lists the days from start to end, capped at ten years
+func (s *Server) days(start, end time.Time) []time.Time {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	if limit := end.AddDate(-10, 0, 0); start.Before(limit) {
+		start = limit
+	}
+	var days []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	return days
+}
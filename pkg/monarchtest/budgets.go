@@ -0,0 +1,287 @@
+package monarchtest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+func init() {
+	register(map[string]handler{
+		"Common_GetJointPlanningData":              (*Server).budgetData,
+		"GetBudgetsWithGoals":                      (*Server).budgetData,
+		"SetBudgetAmount":                          (*Server).setBudgetAmount,
+		"Web_GetCashFlowPage":                      (*Server).cashflowPage,
+		"Web_GetCashFlowSummary":                   (*Server).cashflowSummary,
+		"GetCashflowTest":                          (*Server).cashflowAggregates,
+		"GetCashflowSimple":                        (*Server).cashflowAggregates,
+		"GetTransactionsPage":                      (*Server).transactionsSummary,
+		"Web_GetUpcomingRecurringTransactionItems": (*Server).recurringItems,
+	})
+}
+
+// budgetData reports planned and actual amounts for every income and expense
+// category in each month of the range. Actual amounts come from transactions.
+func (s *Server) budgetData(vars map[string]interface{}) (interface{}, error) {
+	start, ok := date(vars, "startDate")
+	if !ok {
+		return nil, fmt.Errorf("startDate must be YYYY-MM-DD")
+	}
+	end, ok := date(vars, "endDate")
+	if !ok {
+		return nil, fmt.Errorf("endDate must be YYYY-MM-DD")
+	}
+
+	var months []time.Time
+	for m := sim.MonthOf(start); !m.After(end); m = m.AddDate(0, 1, 0) {
+		months = append(months, m)
+	}
+
+	rows := make([]map[string]interface{}, 0, len(s.data.Categories))
+	for _, c := range s.data.Categories {
+		g := s.data.categoryGroupOf(c)
+		if g == nil || (g.Type != "income" && g.Type != "expense") {
+			continue
+		}
+		amounts := make([]*monarch.BudgetMonthlyAmount, 0, len(months))
+		for _, m := range months {
+			amounts = append(amounts, s.data.book().MonthlyAmount(c.ID, g.Type, m))
+		}
+		rows = append(rows, map[string]interface{}{"category": s.categoryJSON(c), "monthlyAmounts": amounts})
+	}
+
+	return map[string]interface{}{
+		"budgetData": map[string]interface{}{"monthlyAmountsByCategory": rows},
+		"goalsV2":    map[string]interface{}{"goals": []interface{}{}},
+	}, nil
+}
+
+// setBudgetAmount sets the budget of the category named by budgetId for the
+// month of startDate
+func (s *Server) setBudgetAmount(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"setBudgetAmount": map[string]interface{}{"budget": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	id := str(input, "budgetId")
+	if s.data.category(id) == nil {
+		return fail("NOT_FOUND", "category %q does not exist", id)
+	}
+	start, ok := date(input, "startDate")
+	if !ok {
+		return fail("INVALID", "startDate must be YYYY-MM-DD")
+	}
+	amount, _ := number(input, "amount")
+	rollover, _ := boolean(input, "rollover")
+
+	month := sim.MonthOf(start)
+	budget := s.data.book().Budget(id, month)
+	if budget == nil {
+		budget = &monarch.Budget{ID: s.newID("budget"), CategoryID: id, StartDate: month}
+		s.data.Budgets = append(s.data.Budgets, budget)
+	}
+	if budget.ID == "" {
+		budget.ID = s.newID("budget")
+	}
+	budget.Amount = amount
+	budget.Rollover = rollover
+
+	return map[string]interface{}{
+		"setBudgetAmount": map[string]interface{}{
+			"budget": map[string]interface{}{"id": budget.ID, "amount": budget.Amount, "rollover": budget.Rollover},
+			"errors": noErrors,
+		},
+	}, nil
+}
+
+// cashflowTotals accumulates the figures of a cashflow report
+type cashflowTotals struct {
+	income, expense float64
+}
+
+func (c cashflowTotals) summary() map[string]interface{} {
+	savings := sim.Round(c.income + c.expense)
+	rate := 0.0
+	if c.income > 0 {
+		rate = savings / sim.Round(c.income)
+	}
+	return map[string]interface{}{
+		"sum":         savings,
+		"sumIncome":   sim.Round(c.income),
+		"sumExpense":  sim.Round(c.expense),
+		"savings":     savings,
+		"savingsRate": rate,
+	}
+}
+
+// cashflow tallies income and expense allocations, leaving out transfers
+func (s *Server) cashflow(txns []*monarch.Transaction, each func(a sim.Allocation, groupType string)) cashflowTotals {
+	income, expense := s.data.book().Cashflow(txns, each)
+	return cashflowTotals{income: income, expense: expense}
+}
+
+func (s *Server) cashflowPage(vars map[string]interface{}) (interface{}, error) {
+	byCategory := make(map[string]float64)
+	byGroup := make(map[string]float64)
+	byMerchant := make(map[string]float64)
+	merchants := make(map[string]*monarch.Merchant)
+
+	totals := s.cashflow(s.filterTransactions(object(vars, "filters")), func(a sim.Allocation, _ string) {
+		byCategory[a.CategoryID] += a.Amount
+		if c := s.data.category(a.CategoryID); c != nil {
+			if g := s.data.categoryGroupOf(c); g != nil {
+				byGroup[g.ID] += a.Amount
+			}
+		}
+		if m := a.Transaction.Merchant; m != nil {
+			byMerchant[m.ID] += a.Amount
+			merchants[m.ID] = m
+		}
+	})
+
+	categories := make([]map[string]interface{}, 0, len(byCategory))
+	for _, id := range sim.ByAmount(byCategory, 0) {
+		var category interface{}
+		if c := s.data.category(id); c != nil {
+			category = s.categoryJSON(c)
+		}
+		categories = append(categories, grouped("category", category, byCategory[id]))
+	}
+	groups := make([]map[string]interface{}, 0, len(byGroup))
+	for _, id := range sim.ByAmount(byGroup, 0) {
+		groups = append(groups, grouped("categoryGroup", s.data.group(id), byGroup[id]))
+	}
+	merchantRows := make([]map[string]interface{}, 0, len(byMerchant))
+	for _, id := range sim.ByAmount(byMerchant, 0) {
+		merchantRows = append(merchantRows, grouped("merchant", merchants[id], byMerchant[id]))
+	}
+
+	return map[string]interface{}{
+		"byCategory":      categories,
+		"byCategoryGroup": groups,
+		"byMerchant":      merchantRows,
+		"summary":         []map[string]interface{}{{"summary": totals.summary()}},
+	}, nil
+}
+
+func (s *Server) cashflowSummary(vars map[string]interface{}) (interface{}, error) {
+	totals := s.cashflow(s.filterTransactions(object(vars, "filters")), nil)
+	return map[string]interface{}{
+		"summary": []map[string]interface{}{{"summary": totals.summary()}},
+	}, nil
+}
+
+func (s *Server) cashflowAggregates(vars map[string]interface{}) (interface{}, error) {
+	filters := map[string]interface{}{"startDate": vars["startDate"], "endDate": vars["endDate"]}
+	totals := s.cashflow(s.filterTransactions(filters), nil)
+	return map[string]interface{}{
+		"aggregates": []map[string]interface{}{{"summary": totals.summary()}},
+	}, nil
+}
+
+// transactionsSummary aggregates the transactions matching the filters
+func (s *Server) transactionsSummary(vars map[string]interface{}) (interface{}, error) {
+	txns := s.filterTransactions(object(vars, "filters"))
+	summary := &monarch.TransactionSummary{Count: len(txns)}
+	var first, last time.Time
+	for i, t := range txns {
+		summary.Sum += t.Amount
+		if t.Amount > 0 {
+			summary.SumIncome += t.Amount
+		} else {
+			summary.SumExpense += t.Amount
+		}
+		if i == 0 || t.Amount > summary.Max {
+			summary.Max = t.Amount
+		}
+		if t.Amount < summary.MaxExpense {
+			summary.MaxExpense = t.Amount
+		}
+		if first.IsZero() || t.Date.Before(first) {
+			first = t.Date.Time
+		}
+		if t.Date.After(last) {
+			last = t.Date.Time
+		}
+	}
+	if len(txns) > 0 {
+		summary.Avg = sim.Round(summary.Sum / float64(len(txns)))
+		summary.First = first.Format("2006-01-02")
+		summary.Last = last.Format("2006-01-02")
+	}
+	summary.Sum = sim.Round(summary.Sum)
+	summary.SumIncome = sim.Round(summary.SumIncome)
+	summary.SumExpense = sim.Round(summary.SumExpense)
+
+	return map[string]interface{}{
+		"aggregates": []map[string]interface{}{{"summary": summary}},
+	}, nil
+}
+
+// recurringItems lists the occurrences of active recurring streams between
+// startDate and endDate, stepping from each stream's next date
+func (s *Server) recurringItems(vars map[string]interface{}) (interface{}, error) {
+	start, ok := date(vars, "startDate")
+	if !ok {
+		return nil, fmt.Errorf("startDate must be YYYY-MM-DD")
+	}
+	end, ok := date(vars, "endDate")
+	if !ok {
+		return nil, fmt.Errorf("endDate must be YYYY-MM-DD")
+	}
+	today := s.today()
+
+	items := make([]map[string]interface{}, 0)
+	for _, r := range s.data.Recurring {
+		if !r.IsActive || r.NextDate.IsZero() {
+			continue
+		}
+		for _, d := range sim.Occurrences(r.NextDate.Time, r.Frequency, start, end) {
+			item := map[string]interface{}{
+				"stream": map[string]interface{}{
+					"id":            r.ID,
+					"frequency":     r.Frequency,
+					"amount":        r.Amount,
+					"isApproximate": r.IsApproximate,
+					"merchant":      r.Merchant,
+				},
+				"date":          monarch.Date{Time: d},
+				"isPast":        d.Before(today),
+				"transactionId": nil,
+				"amount":        r.Amount,
+				"amountDiff":    nil,
+				"category":      nil,
+				"account":       nil,
+			}
+			if r.Category != nil {
+				if c := s.data.category(r.Category.ID); c != nil {
+					item["category"] = map[string]interface{}{"id": c.ID, "name": c.Name}
+				}
+			}
+			if r.Account != nil {
+				if acc := s.data.account(r.Account.ID); acc != nil {
+					item["account"] = map[string]interface{}{"id": acc.ID, "displayName": acc.DisplayName}
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i]["date"].(monarch.Date).Before(items[j]["date"].(monarch.Date).Time)
+	})
+	return map[string]interface{}{"recurringTransactionItems": items}, nil
+}
+
+// grouped is a row of a grouped cashflow aggregate
+func grouped(key string, value interface{}, sum float64) map[string]interface{} {
+	return map[string]interface{}{
+		"groupBy": map[string]interface{}{key: value},
+		"summary": map[string]interface{}{"sum": sim.Round(sum)},
+	}
+}
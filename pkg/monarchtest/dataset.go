@@ -0,0 +1,242 @@
+package monarchtest
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// Dataset is the state served by a Server.
+//
+// Objects refer to each other by ID: a transaction's Account, Category and Tags
+// only need their ID set, and the server fills in names from the dataset when
+// it responds. Budgets are keyed by CategoryID and the month of StartDate.
+// Holdings are priced from the Securities with a matching ticker.
+type Dataset struct {
+	Accounts       []*monarch.Account                     `json:"accounts"`
+	Transactions   []*monarch.Transaction                 `json:"transactions"`
+	Splits         map[string][]*monarch.TransactionSplit `json:"splits"`
+	CategoryGroups []*monarch.CategoryGroup               `json:"categoryGroups"`
+	Categories     []*monarch.TransactionCategory         `json:"categories"`
+	Tags           []*monarch.Tag                         `json:"tags"`
+	Budgets        []*monarch.Budget                      `json:"budgets"`
+	Recurring      []*monarch.RecurringTransaction        `json:"recurring"`
+	Securities     []*monarch.Security                    `json:"securities"`
+	Holdings       []*monarch.Holding                     `json:"holdings"`
+	Institutions   []*monarch.Institution                 `json:"institutions"`
+	Subscription   *monarch.Subscription                  `json:"subscription"`
+}
+
+// Clone returns a deep copy of the dataset
+func (d *Dataset) Clone() *Dataset {
+	data, err := json.Marshal(d)
+	if err != nil {
+		panic("monarchtest: copy dataset: " + err.Error())
+	}
+	var c Dataset
+	if err := json.Unmarshal(data, &c); err != nil {
+		panic("monarchtest: copy dataset: " + err.Error())
+	}
+	if c.Splits == nil {
+		c.Splits = make(map[string][]*monarch.TransactionSplit)
+	}
+	return &c
+}
+
+// DefaultDataset returns a small household: a checking account, a credit card
+// and a manual brokerage account, with this month's transactions and budgets
+func DefaultDataset() *Dataset {
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) monarch.Date { return monarch.Date{Time: month.AddDate(0, 0, n-1)} }
+
+	chase := &monarch.Institution{ID: "inst-chase", Name: "Chase", URL: "https://www.chase.com", CredentialID: "cred-chase", DataProvider: "PLAID"}
+	amex := &monarch.Institution{ID: "inst-amex", Name: "American Express", URL: "https://www.americanexpress.com", CredentialID: "cred-amex", DataProvider: "PLAID"}
+
+	groups := []*monarch.CategoryGroup{
+		{ID: "grp-income", Name: "Income", Type: "income", Order: 0},
+		{ID: "grp-food", Name: "Food & Dining", Type: "expense", Order: 1},
+		{ID: "grp-bills", Name: "Bills & Utilities", Type: "expense", Order: 2},
+		{ID: "grp-other", Name: "Other", Type: "expense", Order: 3},
+		{ID: "grp-transfers", Name: "Transfers", Type: "transfer", Order: 4},
+	}
+	category := func(id, name, group string, order int) *monarch.TransactionCategory {
+		return &monarch.TransactionCategory{ID: id, Name: name, Order: order, GroupID: group, Group: &monarch.CategoryGroup{ID: group}}
+	}
+	uncategorized := category("cat-uncategorized", "Uncategorized", "grp-other", 0)
+	uncategorized.SystemCategory = "uncategorized"
+	uncategorized.IsSystemCategory = true
+
+	ref := func(id string) *monarch.TransactionCategory { return &monarch.TransactionCategory{ID: id} }
+	checking := &monarch.Account{ID: "acc-checking"}
+	card := &monarch.Account{ID: "acc-credit"}
+	txn := func(id string, d monarch.Date, amount float64, merchant string, acc *monarch.Account, cat string) *monarch.Transaction {
+		return &monarch.Transaction{
+			ID: id, Date: d, Amount: amount, PlaidName: strings.ToUpper(merchant),
+			Merchant: &monarch.Merchant{ID: "merch-" + strings.ToLower(strings.Fields(merchant)[0]), Name: merchant},
+			Account:  acc, Category: ref(cat), CreatedAt: d, UpdatedAt: d,
+		}
+	}
+	budget := func(cat string, amount float64) *monarch.Budget {
+		return &monarch.Budget{CategoryID: cat, Amount: amount, StartDate: month}
+	}
+
+	return &Dataset{
+		Accounts: []*monarch.Account{
+			{
+				ID: "acc-checking", DisplayName: "Checking", Mask: "1234", IsAsset: true,
+				CurrentBalance: 5000, DisplayBalance: 5000, IncludeInNetWorth: true, DataProvider: "PLAID",
+				Type:        &monarch.AccountTypeInfo{Name: "depository", Display: "Cash"},
+				Subtype:     &monarch.AccountSubtypeInfo{Name: "checking", Display: "Checking"},
+				Credential:  &monarch.Credential{ID: "cred-chase", DataProvider: "PLAID", Institution: chase},
+				Institution: chase,
+			},
+			{
+				ID: "acc-credit", DisplayName: "Credit Card", Mask: "9876",
+				CurrentBalance: -850, DisplayBalance: 850, IncludeInNetWorth: true, DataProvider: "PLAID",
+				Type:        &monarch.AccountTypeInfo{Name: "credit", Display: "Credit Cards"},
+				Subtype:     &monarch.AccountSubtypeInfo{Name: "credit_card", Display: "Credit Card"},
+				Credential:  &monarch.Credential{ID: "cred-amex", DataProvider: "PLAID", Institution: amex},
+				Institution: amex,
+			},
+			{
+				ID: "acc-brokerage", DisplayName: "Brokerage", IsAsset: true, IsManual: true,
+				CurrentBalance: 8949.6, DisplayBalance: 8949.6, IncludeInNetWorth: true,
+				ManualInvestmentsTrackingMethod: "holdings",
+				Type:                            &monarch.AccountTypeInfo{Name: "brokerage", Display: "Investments"},
+				Subtype:                         &monarch.AccountSubtypeInfo{Name: "brokerage", Display: "Brokerage"},
+			},
+		},
+		Transactions: []*monarch.Transaction{
+			txn("txn-paycheck", day(1), 4000, "Acme Payroll", checking, "cat-paychecks"),
+			txn("txn-rent", day(1), -1800, "Property Management", checking, "cat-rent"),
+			txn("txn-groceries", day(2), -120.5, "Whole Foods", card, "cat-groceries"),
+			txn("txn-dinner", day(3), -45, "Pizza Place", card, "cat-restaurants"),
+			txn("txn-electric", day(5), -95.2, "City Electric", checking, "cat-utilities"),
+		},
+		Splits:         map[string][]*monarch.TransactionSplit{},
+		CategoryGroups: groups,
+		Categories: []*monarch.TransactionCategory{
+			category("cat-paychecks", "Paychecks", "grp-income", 0),
+			category("cat-groceries", "Groceries", "grp-food", 0),
+			category("cat-restaurants", "Restaurants & Bars", "grp-food", 1),
+			category("cat-rent", "Rent", "grp-bills", 0),
+			category("cat-utilities", "Gas & Electric", "grp-bills", 1),
+			uncategorized,
+			category("cat-transfer", "Transfer", "grp-transfers", 0),
+		},
+		Tags: []*monarch.Tag{
+			{ID: "tag-reimbursable", Name: "Reimbursable", Color: "#19D2A5", Order: 0},
+			{ID: "tag-vacation", Name: "Vacation", Color: "#FF7369", Order: 1},
+		},
+		Budgets: []*monarch.Budget{
+			budget("cat-paychecks", 4000),
+			budget("cat-groceries", 600),
+			budget("cat-restaurants", 200),
+			budget("cat-rent", 1800),
+			budget("cat-utilities", 150),
+		},
+		Recurring: []*monarch.RecurringTransaction{
+			{
+				ID: "stream-rent", Merchant: &monarch.Merchant{ID: "merch-property", Name: "Property Management"},
+				Amount: -1800, Frequency: "monthly", NextDate: monarch.Date{Time: month.AddDate(0, 1, 0)},
+				Category: ref("cat-rent"), Account: &monarch.Account{ID: "acc-checking"}, IsActive: true,
+			},
+			{
+				ID: "stream-paycheck", Merchant: &monarch.Merchant{ID: "merch-acme", Name: "Acme Payroll"},
+				Amount: 2000, Frequency: "biweekly", NextDate: day(15), IsApproximate: true,
+				Category: ref("cat-paychecks"), Account: &monarch.Account{ID: "acc-checking"}, IsActive: true,
+			},
+		},
+		Securities: []*monarch.Security{
+			{ID: "sec-vti", Name: "Vanguard Total Stock Market ETF", Ticker: "VTI", CurrentPrice: 250.12},
+			{ID: "sec-bnd", Name: "Vanguard Total Bond Market ETF", Ticker: "BND", CurrentPrice: 72.3},
+			{ID: "sec-aapl", Name: "Apple Inc.", Ticker: "AAPL", CurrentPrice: 190.5},
+		},
+		Holdings: []*monarch.Holding{
+			{ID: "hold-vti", AccountID: "acc-brokerage", Symbol: "VTI", Quantity: 30, CostBasis: 6000},
+			{ID: "hold-bnd", AccountID: "acc-brokerage", Symbol: "BND", Quantity: 20, CostBasis: 1500},
+		},
+		Institutions: []*monarch.Institution{chase, amex},
+		Subscription: &monarch.Subscription{ID: "sub-1", PlanType: "premium", Status: "active", StartDate: month.AddDate(-1, 0, 0)},
+	}
+}
+
+// book returns the data the budget and cashflow handlers compute from
+func (d *Dataset) book() sim.Book {
+	return sim.Book{
+		Accounts:       d.Accounts,
+		Transactions:   d.Transactions,
+		Splits:         d.Splits,
+		Categories:     d.Categories,
+		CategoryGroups: d.CategoryGroups,
+		Budgets:        d.Budgets,
+	}
+}
+
+// Lookups used by the handlers; they return the live objects, not copies
+
+func (d *Dataset) account(id string) *monarch.Account {
+	return d.book().Account(id)
+}
+
+func (d *Dataset) transaction(id string) *monarch.Transaction {
+	for _, t := range d.Transactions {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (d *Dataset) category(id string) *monarch.TransactionCategory {
+	return d.book().Category(id)
+}
+
+func (d *Dataset) group(id string) *monarch.CategoryGroup {
+	return d.book().Group(id)
+}
+
+func (d *Dataset) tag(id string) *monarch.Tag {
+	for _, t := range d.Tags {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (d *Dataset) security(id string) *monarch.Security {
+	for _, s := range d.Securities {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (d *Dataset) securityByTicker(ticker string) *monarch.Security {
+	for _, s := range d.Securities {
+		if strings.EqualFold(s.Ticker, ticker) {
+			return s
+		}
+	}
+	return nil
+}
+
+func (d *Dataset) holding(id string) *monarch.Holding {
+	for _, h := range d.Holdings {
+		if h.ID == id {
+			return h
+		}
+	}
+	return nil
+}
+
+// categoryGroupOf returns the group of a category, if it has one
+func (d *Dataset) categoryGroupOf(c *monarch.TransactionCategory) *monarch.CategoryGroup {
+	return d.book().GroupOf(c)
+}
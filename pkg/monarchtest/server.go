@@ -0,0 +1,305 @@
+// Package monarchtest provides an offline fake of the Monarch Money API for
+// end-to-end tests.
+//
+// The fake speaks the GraphQL operations used by package monarch, backed by an
+// in-memory Dataset. Queries read from the dataset and mutations change it, so
+// a test can create a transaction and then see it in lists, budgets and
+// cashflow:
+//
+//	srv := monarchtest.NewServer(monarchtest.DefaultDataset())
+//	defer srv.Close()
+//
+//	client, err := monarch.NewClient(&monarch.ClientOptions{
+//		BaseURL: srv.URL,
+//		Token:   srv.Token,
+//	})
+package monarchtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+// DefaultToken is the API token accepted by a new Server
+const DefaultToken = "monarchtest-token"
+
+// Call is a GraphQL operation received by the server
+type Call struct {
+	OperationName string
+	Variables     map[string]interface{}
+}
+
+// Server is a fake Monarch API server
+type Server struct {
+	*httptest.Server
+
+	// Token is the token clients must send; requests without it get a 401
+	Token string
+
+	mu         sync.Mutex
+	data       *Dataset
+	calls      []Call
+	failures   map[string]string
+	refreshing map[string]bool
+	seq        int
+	now        func() time.Time
+}
+
+// handler serves one operation. Returned errors become GraphQL errors; mutation
+// validation failures are reported in the payload instead, like the real API.
+type handler func(s *Server, vars map[string]interface{}) (interface{}, error)
+
+// handlers maps operation names to their implementation
+var handlers = map[string]handler{}
+
+// register adds operation handlers; it is called from the init functions of
+// the files implementing each area of the API
+func register(ops map[string]handler) {
+	for name, h := range ops {
+		handlers[name] = h
+	}
+}
+
+// NewServer starts a server seeded with a copy of data. A nil dataset starts
+// empty. Callers must Close the server.
+func NewServer(data *Dataset) *Server {
+	if data == nil {
+		data = &Dataset{}
+	}
+	s := &Server{
+		Token:      DefaultToken,
+		data:       data.Clone(),
+		failures:   make(map[string]string),
+		refreshing: make(map[string]bool),
+		seq:        1000,
+		now:        time.Now,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns a monarch client authenticated against the server
+func (s *Server) Client() (*monarch.Client, error) {
+	return monarch.NewClient(&monarch.ClientOptions{
+		BaseURL: s.URL,
+		Token:   s.Token,
+	})
+}
+
+// Data returns a copy of the current dataset
+func (s *Server) Data() *Dataset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Clone()
+}
+
+// Seed replaces the dataset with a copy of data
+func (s *Server) Seed(data *Dataset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data.Clone()
+	s.refreshing = make(map[string]bool)
+}
+
+// Calls returns every operation received so far
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the variables of each call to an operation
+func (s *Server) CallsTo(operationName string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var vars []map[string]interface{}
+	for _, c := range s.calls {
+		if c.OperationName == operationName {
+			vars = append(vars, c.Variables)
+		}
+	}
+	return vars
+}
+
+// FailOperation makes every call to an operation return a GraphQL error with
+// message until ClearFailures is called
+func (s *Server) FailOperation(operationName, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[operationName] = message
+}
+
+// ClearFailures removes the errors set by FailOperation
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = make(map[string]string)
+}
+
+// Operations lists the GraphQL operation names the server implements
+func Operations() []string {
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// graphQLRequest is the body of a GraphQL request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQLError is an entry of the errors list of a GraphQL response
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/graphql" && r.URL.Path != "/graphql/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("Authorization") != "Token "+s.Token {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"detail": "Authentication credentials were not provided."})
+		return
+	}
+
+	var req graphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid request body: " + err.Error()})
+		return
+	}
+	if req.OperationName == "" {
+		req.OperationName = operationName(req.Query)
+	}
+	if req.Variables == nil {
+		req.Variables = map[string]interface{}{}
+	}
+
+	data, err := s.execute(req.OperationName, req.Variables)
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data":   nil,
+			"errors": []graphQLError{{Message: err.Error()}},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// execute records and runs an operation
+func (s *Server) execute(op string, vars map[string]interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, Call{OperationName: op, Variables: vars})
+	if msg, ok := s.failures[op]; ok {
+		return nil, fmt.Errorf("%s", msg)
+	}
+	h, ok := handlers[op]
+	if !ok {
+		return nil, fmt.Errorf("monarchtest: unknown operation %q", op)
+	}
+	return h(s, vars)
+}
+
+// operationName extracts the operation name from a query document
+func operationName(query string) string {
+	for _, prefix := range []string{"mutation ", "query "} {
+		if idx := strings.Index(query, prefix); idx >= 0 {
+			rest := query[idx+len(prefix):]
+			if end := strings.IndexAny(rest, "( {"); end > 0 {
+				return rest[:end]
+			}
+		}
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newID returns a fresh ID for a created object
+func (s *Server) newID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%d", prefix, s.seq)
+}
+
+// today returns the current date at midnight UTC
+func (s *Server) today() time.Time {
+	now := s.now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// payloadError is the error list entry of a mutation payload
+func payloadError(code, format string, args ...interface{}) []map[string]interface{} {
+	return []map[string]interface{}{{
+		"code":        code,
+		"message":     fmt.Sprintf(format, args...),
+		"fieldErrors": []interface{}{},
+	}}
+}
+
+// noErrors is the empty error list of a successful mutation payload
+var noErrors = []map[string]interface{}{}
+
+// Variable accessors. GraphQL variables arrive as decoded JSON, so numbers are
+// float64 and lists are []interface{}.
+
+func object(vars map[string]interface{}, key string) map[string]interface{} {
+	m, _ := vars[key].(map[string]interface{})
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m
+}
+
+func str(vars map[string]interface{}, key string) string {
+	v, _ := vars[key].(string)
+	return v
+}
+
+func number(vars map[string]interface{}, key string) (float64, bool) {
+	v, ok := vars[key].(float64)
+	return v, ok
+}
+
+func boolean(vars map[string]interface{}, key string) (bool, bool) {
+	v, ok := vars[key].(bool)
+	return v, ok
+}
+
+func stringList(vars map[string]interface{}, key string) []string {
+	list, _ := vars[key].([]interface{})
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func date(vars map[string]interface{}, key string) (time.Time, bool) {
+	t, err := time.Parse("2006-01-02", str(vars, key))
+	return t, err == nil
+}
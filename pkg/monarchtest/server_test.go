@@ -0,0 +1,281 @@
+package monarchtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/graphql"
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient starts a server with the default dataset and a client for it
+func newTestClient(t *testing.T) (*Server, *monarch.Client) {
+	t.Helper()
+	srv := NewServer(DefaultDataset())
+	t.Cleanup(srv.Close)
+	client, err := srv.Client()
+	require.NoError(t, err)
+	return srv, client
+}
+
+// thisMonth returns the first and last day of the current month
+func thisMonth() (time.Time, time.Time) {
+	start := sim.MonthOf(time.Now().UTC())
+	return start, start.AddDate(0, 1, -1)
+}
+
+func budgetFor(t *testing.T, budgets []*monarch.Budget, categoryID string) *monarch.Budget {
+	t.Helper()
+	for _, b := range budgets {
+		if b.CategoryID == categoryID {
+			return b
+		}
+	}
+	t.Fatalf("no budget for %s", categoryID)
+	return nil
+}
+
+func TestOperations_CoverEveryQuery(t *testing.T) {
+	loader := graphql.NewQueryLoader()
+	implemented := sim.Set(Operations())
+
+	paths, err := loader.List()
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	for _, path := range paths {
+		query, err := loader.Load(path)
+		require.NoError(t, err, path)
+		name := operationName(query)
+		require.NotEmpty(t, name, path)
+		assert.True(t, implemented[name], "%s: operation %s is not implemented", path, name)
+	}
+}
+
+func TestServer_RejectsWrongToken(t *testing.T) {
+	srv := NewServer(DefaultDataset())
+	defer srv.Close()
+
+	client, err := monarch.NewClient(&monarch.ClientOptions{BaseURL: srv.URL, Token: "wrong"})
+	require.NoError(t, err)
+
+	_, err = client.Accounts.List(context.Background())
+	assert.True(t, errors.Is(err, monarch.ErrNotAuthenticated), "got %v", err)
+}
+
+func TestServer_CreateTransactionUpdatesReports(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+	start, end := thisMonth()
+
+	update := true
+	created, err := client.Transactions.Create(ctx, &monarch.CreateTransactionParams{
+		Date:                monarch.Date{Time: start.AddDate(0, 0, 6)},
+		AccountID:           "acc-checking",
+		Amount:              -29.5,
+		Merchant:            &monarch.Merchant{Name: "Corner Market"},
+		CategoryID:          "cat-groceries",
+		ShouldUpdateBalance: &update,
+	})
+	require.NoError(t, err)
+
+	list, err := client.Transactions.Query().Between(start, end).Search("corner").Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, list.Transactions, 1)
+	assert.Equal(t, created.ID, list.Transactions[0].ID)
+	assert.Equal(t, "Groceries", list.Transactions[0].Category.Name)
+	assert.Equal(t, "Checking", list.Transactions[0].Account.DisplayName)
+
+	acc, err := client.Accounts.Get(ctx, "acc-checking")
+	require.NoError(t, err)
+	assert.InDelta(t, 4970.5, acc.CurrentBalance, 0.001)
+	assert.Equal(t, 4, acc.TransactionsCount)
+
+	budgets, err := client.Budgets.List(ctx, start, end)
+	require.NoError(t, err)
+	groceries := budgetFor(t, budgets, "cat-groceries")
+	assert.InDelta(t, 150, groceries.Spent, 0.001)
+	assert.InDelta(t, 450, groceries.Remaining, 0.001)
+
+	summary, err := client.Cashflow.GetSummary(ctx, &monarch.CashflowSummaryParams{StartDate: start, EndDate: end})
+	require.NoError(t, err)
+	assert.InDelta(t, 4000, summary.Income, 0.001)
+	assert.InDelta(t, 2090.2, summary.Expense, 0.001)
+
+	assert.Len(t, srv.CallsTo("Common_CreateTransactionMutation"), 1)
+}
+
+func TestServer_CreateTransactionValidatesReferences(t *testing.T) {
+	_, client := newTestClient(t)
+
+	_, err := client.Transactions.Create(context.Background(), &monarch.CreateTransactionParams{
+		Date:       monarch.Date{Time: time.Now()},
+		AccountID:  "acc-missing",
+		Amount:     -10,
+		CategoryID: "cat-groceries",
+	})
+
+	var apiErr *monarch.Error
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	assert.Equal(t, "NOT_FOUND", apiErr.Code)
+}
+
+func TestServer_Splits(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	err := client.Transactions.UpdateSplits(ctx, "txn-groceries", []*monarch.TransactionSplit{
+		{Amount: -100, CategoryID: "cat-groceries"},
+		{Amount: -10, CategoryID: "cat-restaurants"},
+	})
+	var apiErr *monarch.Error
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	assert.Equal(t, "INVALID", apiErr.Code)
+
+	require.NoError(t, client.Transactions.UpdateSplits(ctx, "txn-groceries", []*monarch.TransactionSplit{
+		{Amount: -100.5, CategoryID: "cat-groceries"},
+		{Amount: -20, CategoryID: "cat-restaurants"},
+	}))
+
+	splits, err := client.Transactions.GetSplits(ctx, "txn-groceries")
+	require.NoError(t, err)
+	require.Len(t, splits, 2)
+	assert.Equal(t, "Restaurants & Bars", splits[1].Category.Name)
+
+	start, end := thisMonth()
+	budgets, err := client.Budgets.List(ctx, start, end)
+	require.NoError(t, err)
+	assert.InDelta(t, 65, budgetFor(t, budgets, "cat-restaurants").Spent, 0.001)
+}
+
+func TestServer_SetBudgetAmount(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+	start, end := thisMonth()
+	next := start.AddDate(0, 1, 0)
+
+	require.NoError(t, client.Budgets.SetAmount(ctx, "cat-groceries", 700, false, start))
+	require.NoError(t, client.Budgets.SetAmount(ctx, "cat-groceries", 650, true, next))
+
+	budgets, err := client.Budgets.List(ctx, start, next.AddDate(0, 1, -1))
+	require.NoError(t, err)
+	var amounts []float64
+	for _, b := range budgets {
+		if b.CategoryID == "cat-groceries" {
+			amounts = append(amounts, b.Amount)
+		}
+	}
+	assert.Equal(t, []float64{700, 650}, amounts)
+
+	err = client.Budgets.SetAmount(ctx, "cat-missing", 1, false, end)
+	assert.Error(t, err)
+}
+
+func TestServer_DeleteCategory(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+	categories := client.Transactions.Categories()
+
+	err := categories.Delete(ctx, "cat-restaurants")
+	var apiErr *monarch.Error
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	assert.Equal(t, "CATEGORY_IN_USE", apiErr.Code)
+
+	created, err := categories.Create(ctx, &monarch.CreateCategoryParams{Name: "Coffee", GroupID: "grp-food"})
+	require.NoError(t, err)
+	require.NoError(t, categories.Delete(ctx, created.ID))
+
+	require.NotNil(t, srv.Data().category("cat-restaurants"))
+	assert.Nil(t, srv.Data().category(created.ID))
+}
+
+func TestServer_Tags(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	tag, err := client.Tags.Create(ctx, "Business", "#0000FF")
+	require.NoError(t, err)
+	_, err = client.Tags.Create(ctx, "business", "#0000FF")
+	assert.Error(t, err)
+
+	require.NoError(t, client.Tags.SetTransactionTags(ctx, "txn-dinner", tag.ID))
+
+	list, err := client.Transactions.Query().WithTags(tag.ID).Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, list.Transactions, 1)
+	assert.Equal(t, "txn-dinner", list.Transactions[0].ID)
+	assert.Equal(t, "Business", list.Transactions[0].Tags[0].Name)
+
+	tags, err := client.Tags.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, tags, 3)
+	assert.Equal(t, 1, tags[2].TransactionCount)
+}
+
+func TestServer_Holdings(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	_, err := client.Accounts.CreateHoldingByTicker(ctx, "acc-brokerage", "AAPL", 10)
+	require.NoError(t, err)
+
+	holdings, err := client.Accounts.GetHoldings(ctx, "acc-brokerage")
+	require.NoError(t, err)
+	require.Len(t, holdings, 3)
+	assert.Equal(t, "AAPL", holdings[2].Symbol)
+	assert.InDelta(t, 1905, holdings[2].Value, 0.001)
+
+	acc, err := client.Accounts.Get(ctx, "acc-brokerage")
+	require.NoError(t, err)
+	assert.InDelta(t, 10854.6, acc.CurrentBalance, 0.001)
+
+	_, err = client.Accounts.CreateHoldingByTicker(ctx, "acc-checking", "VTI", 1)
+	assert.Error(t, err, "holdings need a manual account")
+}
+
+func TestServer_RefreshAndWait(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+
+	require.NoError(t, client.Accounts.RefreshAndWait(ctx, 5*time.Second, "acc-checking", "acc-credit"))
+	assert.Len(t, srv.CallsTo("CheckRefreshStatus"), 1)
+}
+
+func TestServer_FailOperation(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+
+	srv.FailOperation("GetAccounts", "service unavailable")
+	_, err := client.Accounts.List(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service unavailable")
+
+	srv.ClearFailures()
+	accounts, err := client.Accounts.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 3)
+}
+
+func TestServer_Recurring(t *testing.T) {
+	_, client := newTestClient(t)
+	start, _ := thisMonth()
+
+	items, err := client.Recurring.ListWithDateRange(context.Background(), start, start.AddDate(0, 2, -1))
+	require.NoError(t, err)
+
+	var rent, paychecks int
+	for _, item := range items {
+		switch item.ID {
+		case "stream-rent":
+			rent++
+		case "stream-paycheck":
+			paychecks++
+		}
+	}
+	assert.Equal(t, 2, rent, "rent is due on the first of each month")
+	assert.GreaterOrEqual(t, paychecks, 4)
+}
@@ -0,0 +1,590 @@
+package monarchtest
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/internal/sim"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+)
+
+func init() {
+	register(map[string]handler{
+		"GetTransactionsList":              (*Server).listTransactions,
+		"GetTransactionDetails":            (*Server).getTransaction,
+		"TransactionSplitQuery":            (*Server).getTransaction,
+		"GetTransactionSplits":             (*Server).getTransaction,
+		"Common_CreateTransactionMutation": (*Server).createTransaction,
+		"UpdateTransaction":                (*Server).updateTransaction,
+		"Common_DeleteTransactionMutation": (*Server).deleteTransaction,
+		"Common_SplitTransactionMutation":  (*Server).updateTransactionSplit,
+		"GetHouseholdTransactionTags":      (*Server).listTags,
+		"Common_CreateTransactionTag":      (*Server).createTag,
+		"Web_SetTransactionTags":           (*Server).setTransactionTags,
+		"SetTransactionTags":               (*Server).setTransactionTags,
+		"GetCategories":                    (*Server).listCategories,
+		"GetTransactionCategories":         (*Server).listCategories,
+		"ManageGetCategoryGroups":          (*Server).listCategoryGroups,
+		"GetCategoryGroups":                (*Server).listCategoryGroups,
+		"Web_CreateCategory":               (*Server).createCategory,
+		"CreateTransactionCategory":        (*Server).createCategory,
+		"Web_DeleteCategory":               (*Server).deleteCategory,
+		"DeleteTransactionCategory":        (*Server).deleteCategory,
+	})
+}
+
+func (s *Server) listTransactions(vars map[string]interface{}) (interface{}, error) {
+	txns := s.filterTransactions(object(vars, "filters"))
+	sort.SliceStable(txns, func(i, j int) bool { return txns[i].Date.After(txns[j].Date.Time) })
+
+	total := len(txns)
+	offset := 0
+	if v, ok := number(vars, "offset"); ok && v > 0 {
+		offset = int(v)
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if v, ok := number(vars, "limit"); ok && int(v) >= 0 && offset+int(v) < total {
+		end = offset + int(v)
+	}
+
+	results := make([]map[string]interface{}, 0, end-offset)
+	for _, t := range txns[offset:end] {
+		results = append(results, s.transactionJSON(t))
+	}
+	return map[string]interface{}{
+		"allTransactions": map[string]interface{}{"totalCount": total, "results": results},
+	}, nil
+}
+
+// filterTransactions applies a TransactionFilterInput
+func (s *Server) filterTransactions(filters map[string]interface{}) []*monarch.Transaction {
+	start, hasStart := date(filters, "startDate")
+	end, hasEnd := date(filters, "endDate")
+	accounts := sim.Set(stringList(filters, "accounts"))
+	categories := sim.Set(stringList(filters, "categories"))
+	tags := sim.Set(stringList(filters, "tags"))
+	search := strings.ToLower(str(filters, "search"))
+
+	var out []*monarch.Transaction
+	for _, t := range s.data.Transactions {
+		switch {
+		case hasStart && t.Date.Before(start),
+			hasEnd && t.Date.After(end),
+			len(accounts) > 0 && !accounts[sim.AccountOf(t)],
+			len(categories) > 0 && !categories[sim.CategoryOf(t)]:
+			continue
+		}
+		if len(tags) > 0 {
+			tagged := false
+			for _, tag := range t.Tags {
+				tagged = tagged || tags[tag.ID]
+			}
+			if !tagged {
+				continue
+			}
+		}
+		if search != "" {
+			text := strings.ToLower(t.PlaidName + " " + t.Notes)
+			if t.Merchant != nil {
+				text += " " + strings.ToLower(t.Merchant.Name)
+			}
+			if !strings.Contains(text, search) {
+				continue
+			}
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func (s *Server) getTransaction(vars map[string]interface{}) (interface{}, error) {
+	id := str(vars, "id")
+	if id == "" {
+		id = str(vars, "transactionId")
+	}
+	t := s.data.transaction(id)
+	if t == nil {
+		return map[string]interface{}{"getTransaction": nil}, nil
+	}
+
+	details := s.transactionJSON(t)
+	splits := make([]map[string]interface{}, 0, len(s.data.Splits[id]))
+	for _, split := range s.data.Splits[id] {
+		splits = append(splits, s.splitJSON(split))
+	}
+	details["splitTransactions"] = splits
+	details["splits"] = splits
+	details["originalMerchant"] = t.PlaidName
+	details["originalCategory"] = details["category"]
+	return map[string]interface{}{"getTransaction": details}, nil
+}
+
+func (s *Server) createTransaction(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"createTransaction": map[string]interface{}{"transaction": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	d, ok := date(input, "date")
+	if !ok {
+		return fail("INVALID", "date must be YYYY-MM-DD")
+	}
+	acc := s.data.account(str(input, "accountId"))
+	if acc == nil {
+		return fail("NOT_FOUND", "account %q does not exist", str(input, "accountId"))
+	}
+	cat := s.data.category(str(input, "categoryId"))
+	if cat == nil {
+		return fail("NOT_FOUND", "category %q does not exist", str(input, "categoryId"))
+	}
+	amount, _ := number(input, "amount")
+
+	t := &monarch.Transaction{
+		ID:        s.newID("txn"),
+		Date:      monarch.Date{Time: d},
+		Amount:    amount,
+		Notes:     str(input, "notes"),
+		Account:   &monarch.Account{ID: acc.ID},
+		Category:  &monarch.TransactionCategory{ID: cat.ID},
+		CreatedAt: monarch.Date{Time: s.today()},
+		UpdatedAt: monarch.Date{Time: s.today()},
+	}
+	if name := str(input, "merchantName"); name != "" {
+		t.Merchant = s.merchant(name)
+	}
+	s.data.Transactions = append(s.data.Transactions, t)
+
+	if update, _ := boolean(input, "shouldUpdateBalance"); update {
+		adjustBalance(acc, amount)
+	}
+
+	return map[string]interface{}{
+		"createTransaction": map[string]interface{}{"transaction": s.transactionJSON(t), "errors": noErrors},
+	}, nil
+}
+
+func (s *Server) updateTransaction(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"updateTransaction": map[string]interface{}{"transaction": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	t := s.data.transaction(str(input, "id"))
+	if t == nil {
+		return fail("NOT_FOUND", "transaction %q does not exist", str(input, "id"))
+	}
+
+	// Validate everything before changing anything
+	if _, ok := input["date"]; ok {
+		if _, ok := date(input, "date"); !ok {
+			return fail("INVALID", "date must be YYYY-MM-DD")
+		}
+	}
+	if id, ok := input["accountId"].(string); ok && s.data.account(id) == nil {
+		return fail("NOT_FOUND", "account %q does not exist", id)
+	}
+	if id, ok := input["category"].(string); ok && s.data.category(id) == nil {
+		return fail("NOT_FOUND", "category %q does not exist", id)
+	}
+
+	if d, ok := date(input, "date"); ok {
+		t.Date = monarch.Date{Time: d}
+	}
+	if id, ok := input["accountId"].(string); ok {
+		t.Account = &monarch.Account{ID: id}
+	}
+	if amount, ok := number(input, "amount"); ok {
+		t.Amount = amount
+	}
+	if name, ok := input["name"].(string); ok {
+		t.Merchant = s.merchant(name)
+	}
+	if id, ok := input["category"].(string); ok {
+		t.Category = &monarch.TransactionCategory{ID: id}
+	}
+	if notes, ok := input["notes"].(string); ok {
+		t.Notes = notes
+	}
+	if hide, ok := boolean(input, "hideFromReports"); ok {
+		t.HideFromReports = hide
+	}
+	if review, ok := boolean(input, "needsReview"); ok {
+		t.NeedsReview = review
+	}
+	t.UpdatedAt = monarch.Date{Time: s.today()}
+
+	return map[string]interface{}{
+		"updateTransaction": map[string]interface{}{"transaction": s.transactionJSON(t), "errors": noErrors},
+	}, nil
+}
+
+func (s *Server) deleteTransaction(vars map[string]interface{}) (interface{}, error) {
+	id := str(object(vars, "input"), "transactionId")
+	for i, t := range s.data.Transactions {
+		if t.ID == id {
+			s.data.Transactions = append(s.data.Transactions[:i], s.data.Transactions[i+1:]...)
+			delete(s.data.Splits, id)
+			return map[string]interface{}{
+				"deleteTransaction": map[string]interface{}{"deleted": true, "errors": noErrors},
+			}, nil
+		}
+	}
+	return map[string]interface{}{
+		"deleteTransaction": map[string]interface{}{"deleted": false, "errors": payloadError("NOT_FOUND", "transaction %q does not exist", id)},
+	}, nil
+}
+
+// updateTransactionSplit replaces a transaction's splits. The split amounts
+// must add up to the transaction amount; an empty list removes the splits.
+func (s *Server) updateTransactionSplit(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"updateTransactionSplit": map[string]interface{}{"transaction": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	id := str(input, "transactionId")
+	t := s.data.transaction(id)
+	if t == nil {
+		return fail("NOT_FOUND", "transaction %q does not exist", id)
+	}
+
+	data, _ := input["splitData"].([]interface{})
+	splits := make([]*monarch.TransactionSplit, 0, len(data))
+	var total float64
+	for _, item := range data {
+		entry, _ := item.(map[string]interface{})
+		amount, _ := number(entry, "amount")
+		catID := str(entry, "categoryId")
+		if s.data.category(catID) == nil {
+			return fail("NOT_FOUND", "category %q does not exist", catID)
+		}
+		split := &monarch.TransactionSplit{
+			ID:         s.newID("split"),
+			Amount:     amount,
+			Notes:      str(entry, "notes"),
+			CategoryID: catID,
+			Category:   &monarch.TransactionCategory{ID: catID},
+			Merchant:   t.Merchant,
+		}
+		if name := str(entry, "merchantName"); name != "" {
+			split.Merchant = s.merchant(name)
+		}
+		splits = append(splits, split)
+		total += amount
+	}
+	if len(splits) > 0 && math.Abs(total-t.Amount) > 0.005 {
+		return fail("INVALID", "split amounts add up to %.2f, expected %.2f", total, t.Amount)
+	}
+
+	if len(splits) == 0 {
+		delete(s.data.Splits, id)
+	} else {
+		s.data.Splits[id] = splits
+	}
+	t.HasSplits = len(splits) > 0
+
+	out := make([]map[string]interface{}, 0, len(splits))
+	for _, split := range splits {
+		out = append(out, s.splitJSON(split))
+	}
+	return map[string]interface{}{
+		"updateTransactionSplit": map[string]interface{}{
+			"transaction": map[string]interface{}{
+				"id":                   t.ID,
+				"amount":               t.Amount,
+				"hasSplitTransactions": t.HasSplits,
+				"splitTransactions":    out,
+			},
+			"errors": noErrors,
+		},
+	}, nil
+}
+
+func (s *Server) listTags(map[string]interface{}) (interface{}, error) {
+	counts := make(map[string]int)
+	for _, t := range s.data.Transactions {
+		for _, tag := range t.Tags {
+			counts[tag.ID]++
+		}
+	}
+	tags := make([]map[string]interface{}, 0, len(s.data.Tags))
+	for _, tag := range s.data.Tags {
+		tags = append(tags, map[string]interface{}{
+			"id": tag.ID, "name": tag.Name, "color": tag.Color, "order": tag.Order, "transactionCount": counts[tag.ID],
+		})
+	}
+	return map[string]interface{}{"householdTransactionTags": tags}, nil
+}
+
+func (s *Server) createTag(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	name := strings.TrimSpace(str(input, "name"))
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"createTransactionTag": map[string]interface{}{"tag": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+	if name == "" {
+		return fail("INVALID", "name is required")
+	}
+	for _, tag := range s.data.Tags {
+		if strings.EqualFold(tag.Name, name) {
+			return fail("DUPLICATE", "a tag named %q already exists", name)
+		}
+	}
+
+	tag := &monarch.Tag{ID: s.newID("tag"), Name: name, Color: str(input, "color"), Order: len(s.data.Tags)}
+	s.data.Tags = append(s.data.Tags, tag)
+	return map[string]interface{}{
+		"createTransactionTag": map[string]interface{}{"tag": tag, "errors": noErrors},
+	}, nil
+}
+
+func (s *Server) setTransactionTags(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"setTransactionTags": map[string]interface{}{"transaction": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	t := s.data.transaction(str(input, "transactionId"))
+	if t == nil {
+		return fail("NOT_FOUND", "transaction %q does not exist", str(input, "transactionId"))
+	}
+	tags := make([]*monarch.Tag, 0)
+	for _, id := range stringList(input, "tagIds") {
+		if s.data.tag(id) == nil {
+			return fail("NOT_FOUND", "tag %q does not exist", id)
+		}
+		tags = append(tags, &monarch.Tag{ID: id})
+	}
+	t.Tags = tags
+
+	return map[string]interface{}{
+		"setTransactionTags": map[string]interface{}{
+			"transaction": map[string]interface{}{"id": t.ID, "tags": s.tagsJSON(t)},
+			"errors":      noErrors,
+		},
+	}, nil
+}
+
+func (s *Server) listCategories(map[string]interface{}) (interface{}, error) {
+	categories := make([]map[string]interface{}, 0, len(s.data.Categories))
+	for _, c := range s.data.Categories {
+		categories = append(categories, s.categoryJSON(c))
+	}
+	return map[string]interface{}{"categories": categories}, nil
+}
+
+func (s *Server) listCategoryGroups(map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{"categoryGroups": s.data.CategoryGroups}, nil
+}
+
+func (s *Server) createCategory(vars map[string]interface{}) (interface{}, error) {
+	input := object(vars, "input")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"createCategory": map[string]interface{}{"category": nil, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	name := strings.TrimSpace(str(input, "name"))
+	if name == "" {
+		return fail("INVALID", "name is required")
+	}
+	g := s.data.group(str(input, "group"))
+	if g == nil {
+		return fail("NOT_FOUND", "category group %q does not exist", str(input, "group"))
+	}
+
+	order := 0
+	for _, c := range s.data.Categories {
+		if s.data.categoryGroupOf(c) == g {
+			order++
+		}
+	}
+	c := &monarch.TransactionCategory{
+		ID:      s.newID("cat"),
+		Name:    name,
+		Icon:    str(input, "icon"),
+		Order:   order,
+		GroupID: g.ID,
+		Group:   &monarch.CategoryGroup{ID: g.ID},
+	}
+	s.data.Categories = append(s.data.Categories, c)
+
+	return map[string]interface{}{
+		"createCategory": map[string]interface{}{"category": s.categoryJSON(c), "errors": noErrors},
+	}, nil
+}
+
+// deleteCategory removes a category. Transactions and splits using it move to
+// moveToCategoryId when given; otherwise a category still in use is an error.
+func (s *Server) deleteCategory(vars map[string]interface{}) (interface{}, error) {
+	id := str(vars, "id")
+	moveTo := str(vars, "moveToCategoryId")
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"deleteCategory": map[string]interface{}{"deleted": false, "errors": payloadError(code, format, args...)},
+		}, nil
+	}
+
+	c := s.data.category(id)
+	if c == nil {
+		return fail("NOT_FOUND", "category %q does not exist", id)
+	}
+	if c.IsSystemCategory {
+		return fail("FORBIDDEN", "system category %q cannot be deleted", c.Name)
+	}
+	if moveTo != "" && (moveTo == id || s.data.category(moveTo) == nil) {
+		return fail("INVALID", "cannot move transactions to category %q", moveTo)
+	}
+
+	var using []*monarch.Transaction
+	for _, t := range s.data.Transactions {
+		if sim.CategoryOf(t) == id {
+			using = append(using, t)
+		}
+	}
+	var splitsUsing []*monarch.TransactionSplit
+	for _, splits := range s.data.Splits {
+		for _, split := range splits {
+			if split.CategoryID == id {
+				splitsUsing = append(splitsUsing, split)
+			}
+		}
+	}
+	if moveTo == "" && len(using)+len(splitsUsing) > 0 {
+		return fail("CATEGORY_IN_USE", "category %q is used by %d transactions", c.Name, len(using)+len(splitsUsing))
+	}
+	for _, t := range using {
+		t.Category = &monarch.TransactionCategory{ID: moveTo}
+	}
+	for _, split := range splitsUsing {
+		split.CategoryID = moveTo
+		split.Category = &monarch.TransactionCategory{ID: moveTo}
+	}
+
+	for i, other := range s.data.Categories {
+		if other.ID == id {
+			s.data.Categories = append(s.data.Categories[:i], s.data.Categories[i+1:]...)
+			break
+		}
+	}
+	budgets := s.data.Budgets[:0]
+	for _, b := range s.data.Budgets {
+		if b.CategoryID != id {
+			budgets = append(budgets, b)
+		}
+	}
+	s.data.Budgets = budgets
+
+	return map[string]interface{}{
+		"deleteCategory": map[string]interface{}{"deleted": true, "errors": noErrors},
+	}, nil
+}
+
+// merchant returns the merchant with a name, reusing the ID of an existing one
+func (s *Server) merchant(name string) *monarch.Merchant {
+	for _, t := range s.data.Transactions {
+		if t.Merchant != nil && strings.EqualFold(t.Merchant.Name, name) {
+			return &monarch.Merchant{ID: t.Merchant.ID, Name: name}
+		}
+	}
+	return &monarch.Merchant{ID: s.newID("merch"), Name: name}
+}
+
+// adjustBalance adds amount to an account's balance. Display balances of
+// liabilities are positive.
+func adjustBalance(acc *monarch.Account, amount float64) {
+	acc.CurrentBalance = math.Round((acc.CurrentBalance+amount)*100) / 100
+	acc.DisplayBalance = acc.CurrentBalance
+	if !acc.IsAsset {
+		acc.DisplayBalance = -acc.CurrentBalance
+	}
+}
+
+// transactionJSON renders a transaction with its references resolved
+func (s *Server) transactionJSON(t *monarch.Transaction) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":                   t.ID,
+		"amount":               t.Amount,
+		"pending":              t.Pending,
+		"date":                 t.Date,
+		"hideFromReports":      t.HideFromReports,
+		"plaidName":            t.PlaidName,
+		"notes":                t.Notes,
+		"isRecurring":          t.IsRecurring,
+		"needsReview":          t.NeedsReview,
+		"isSplitTransaction":   t.IsSplitTransaction,
+		"hasSplitTransactions": len(s.data.Splits[t.ID]) > 0,
+		"createdAt":            t.CreatedAt,
+		"updatedAt":            t.UpdatedAt,
+		"merchant":             t.Merchant,
+		"category":             nil,
+		"account":              nil,
+		"tags":                 s.tagsJSON(t),
+	}
+	if c := s.data.category(sim.CategoryOf(t)); c != nil {
+		out["category"] = s.categoryJSON(c)
+	}
+	if acc := s.data.account(sim.AccountOf(t)); acc != nil {
+		out["account"] = map[string]interface{}{"id": acc.ID, "displayName": acc.DisplayName}
+	}
+	return out
+}
+
+// tagsJSON renders the tags of a transaction
+func (s *Server) tagsJSON(t *monarch.Transaction) []*monarch.Tag {
+	tags := make([]*monarch.Tag, 0, len(t.Tags))
+	for _, ref := range t.Tags {
+		if tag := s.data.tag(ref.ID); tag != nil {
+			tags = append(tags, &monarch.Tag{ID: tag.ID, Name: tag.Name, Color: tag.Color, Order: tag.Order})
+		}
+	}
+	return tags
+}
+
+// splitJSON renders a transaction split
+func (s *Server) splitJSON(split *monarch.TransactionSplit) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":       split.ID,
+		"amount":   split.Amount,
+		"notes":    split.Notes,
+		"merchant": split.Merchant,
+		"category": nil,
+	}
+	if c := s.data.category(split.CategoryID); c != nil {
+		out["category"] = map[string]interface{}{"id": c.ID, "name": c.Name}
+	}
+	return out
+}
+
+// categoryJSON renders a category with its group
+func (s *Server) categoryJSON(c *monarch.TransactionCategory) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":               c.ID,
+		"name":             c.Name,
+		"icon":             c.Icon,
+		"order":            c.Order,
+		"systemCategory":   c.SystemCategory,
+		"isSystemCategory": c.IsSystemCategory,
+		"isDisabled":       c.IsDisabled,
+		"group":            nil,
+	}
+	if g := s.data.categoryGroupOf(c); g != nil {
+		out["group"] = g
+	}
+	return out
+}
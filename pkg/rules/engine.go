@@ -0,0 +1,431 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/pkg/errors"
+)
+
+// Engine runs a rule set, with the accounts, categories and tags its rules
+// name resolved to IDs
+type Engine struct {
+	client     *monarch.Client
+	rules      []*resolvedRule
+	categories map[string]string // ID to name
+	tags       map[string]string // ID to name
+}
+
+// resolvedRule is a rule with its names resolved to IDs
+type resolvedRule struct {
+	*Rule
+	accounts   map[string]bool
+	categories map[string]bool
+	tags       map[string]bool
+	category   string
+	addTags    []string
+	split      []SplitShare // categories are IDs
+}
+
+// NewEngine resolves the names in a rule set. Every account, category and
+// tag a rule names must exist, and a name shared by several must be given
+// as an ID instead.
+func NewEngine(ctx context.Context, client *monarch.Client, set *RuleSet) (*Engine, error) {
+	categories, err := client.Transactions.Categories().List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list categories")
+	}
+	tags, err := client.Tags.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tags")
+	}
+	var accounts []*monarch.Account
+	for _, r := range set.Rules {
+		if len(r.Match.Accounts) > 0 {
+			if accounts, err = client.Accounts.List(ctx); err != nil {
+				return nil, errors.Wrap(err, "failed to list accounts")
+			}
+			break
+		}
+	}
+
+	e := &Engine{client: client, categories: make(map[string]string), tags: make(map[string]string)}
+	var categoryItems, tagItems, accountItems []namedItem
+	for _, c := range categories {
+		e.categories[c.ID] = c.Name
+		categoryItems = append(categoryItems, namedItem{c.ID, c.Name})
+	}
+	for _, t := range tags {
+		e.tags[t.ID] = t.Name
+		tagItems = append(tagItems, namedItem{t.ID, t.Name})
+	}
+	for _, a := range accounts {
+		accountItems = append(accountItems, namedItem{a.ID, a.DisplayName})
+	}
+
+	for i, r := range set.Rules {
+		// Rules built in code rather than parsed are checked here
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := r.compile(); err != nil {
+			return nil, errors.Wrap(err, r.Name)
+		}
+		rr := &resolvedRule{Rule: r}
+		if rr.accounts, err = resolveSet("account", r.Match.Accounts, accountItems); err != nil {
+			return nil, errors.Wrap(err, r.Name)
+		}
+		if rr.categories, err = resolveSet("category", r.Match.Categories, categoryItems); err != nil {
+			return nil, errors.Wrap(err, r.Name)
+		}
+		if rr.tags, err = resolveSet("tag", r.Match.Tags, tagItems); err != nil {
+			return nil, errors.Wrap(err, r.Name)
+		}
+		if r.Actions.Category != "" {
+			if rr.category, err = resolve("category", r.Actions.Category, categoryItems); err != nil {
+				return nil, errors.Wrap(err, r.Name)
+			}
+		}
+		for _, name := range r.Actions.AddTags {
+			id, err := resolve("tag", name, tagItems)
+			if err != nil {
+				return nil, errors.Wrap(err, r.Name)
+			}
+			rr.addTags = append(rr.addTags, id)
+		}
+		for _, s := range r.Actions.Split {
+			id, err := resolve("category", s.Category, categoryItems)
+			if err != nil {
+				return nil, errors.Wrap(err, r.Name)
+			}
+			rr.split = append(rr.split, SplitShare{Category: id, Percent: s.Percent, Notes: s.Notes})
+		}
+		e.rules = append(e.rules, rr)
+	}
+	return e, nil
+}
+
+// Change is what the rules do to one transaction
+type Change struct {
+	Transaction *monarch.Transaction
+
+	// Rules that matched, in order
+	Rules []string
+
+	// Fields describes each change for previews
+	Fields []FieldChange
+
+	update monarch.UpdateTransactionParams
+	tagIDs []string // nil when the tags are unchanged
+	splits []*monarch.TransactionSplit
+}
+
+// FieldChange is one field's old and new value, by name
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// state is a transaction as the rules have changed it so far
+type state struct {
+	merchant   string
+	categoryID string
+	tagIDs     []string
+	notes      string
+	hidden     bool
+	splits     []*monarch.TransactionSplit
+}
+
+// Plan streams the transactions of a query and returns the changes the
+// rules make, without applying them
+func (e *Engine) Plan(ctx context.Context, query monarch.TransactionQueryBuilder) ([]*Change, error) {
+	txns, errs := query.Stream(ctx)
+	var changes []*Change
+	for t := range txns {
+		if c := e.Evaluate(t); c != nil {
+			changes = append(changes, c)
+		}
+	}
+	if err := <-errs; err != nil {
+		return nil, errors.Wrap(err, "failed to stream transactions")
+	}
+	return changes, nil
+}
+
+// Evaluate runs the rules over one transaction. It returns nil when no rule
+// changes anything.
+func (e *Engine) Evaluate(t *monarch.Transaction) *Change {
+	before := e.initialState(t)
+	after := before
+	after.tagIDs = append([]string(nil), before.tagIDs...)
+
+	var matched []string
+	for _, r := range e.rules {
+		if !r.matches(t, &after) {
+			continue
+		}
+		matched = append(matched, r.Name)
+		r.apply(t, &after)
+		if r.Stop {
+			break
+		}
+	}
+
+	c := e.diff(t, &before, &after)
+	if c == nil {
+		return nil
+	}
+	c.Rules = matched
+	return c
+}
+
+// Apply makes the planned changes: fields through Transactions.Update, then
+// tags and splits. It stops at the first failure; planning again afterwards
+// finds only the changes left.
+func (e *Engine) Apply(ctx context.Context, changes []*Change) error {
+	for _, c := range changes {
+		id := c.Transaction.ID
+		if c.update != (monarch.UpdateTransactionParams{}) {
+			update := c.update
+			if _, err := e.client.Transactions.Update(ctx, id, &update); err != nil {
+				return errors.Wrapf(err, "failed to update transaction %s", id)
+			}
+		}
+		if c.tagIDs != nil {
+			if err := e.client.Tags.SetTransactionTags(ctx, id, c.tagIDs...); err != nil {
+				return errors.Wrapf(err, "failed to set tags of transaction %s", id)
+			}
+		}
+		if c.splits != nil {
+			if err := e.client.Transactions.UpdateSplits(ctx, id, c.splits); err != nil {
+				return errors.Wrapf(err, "failed to split transaction %s", id)
+			}
+		}
+	}
+	return nil
+}
+
+// WritePreview writes planned changes as text, one transaction per block
+func WritePreview(w io.Writer, changes []*Change) error {
+	var b strings.Builder
+	for _, c := range changes {
+		t := c.Transaction
+		fmt.Fprintf(&b, "%s  %s  %.2f  (%s)\n", t.Date.Format("2006-01-02"), describe(t), t.Amount, strings.Join(c.Rules, ", "))
+		for _, f := range c.Fields {
+			fmt.Fprintf(&b, "    %s: %q -> %q\n", f.Field, f.From, f.To)
+		}
+	}
+	fmt.Fprintf(&b, "%d transactions to change\n", len(changes))
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err, "failed to write preview")
+}
+
+func (e *Engine) initialState(t *monarch.Transaction) state {
+	s := state{notes: t.Notes, hidden: t.HideFromReports}
+	if t.Merchant != nil {
+		s.merchant = t.Merchant.Name
+	}
+	if t.Category != nil {
+		s.categoryID = t.Category.ID
+	}
+	for _, tag := range t.Tags {
+		s.tagIDs = append(s.tagIDs, tag.ID)
+	}
+	return s
+}
+
+// matches reports whether a transaction, as changed so far, meets every
+// condition of the rule
+func (r *resolvedRule) matches(t *monarch.Transaction, s *state) bool {
+	if r.merchant != nil && !r.merchant.MatchString(s.merchant) {
+		return false
+	}
+	if r.plaidName != nil && !r.plaidName.MatchString(t.PlaidName) {
+		return false
+	}
+	if a := r.Match.Amount; a != nil {
+		if a.Min != nil && t.Amount < *a.Min || a.Max != nil && t.Amount > *a.Max {
+			return false
+		}
+	}
+	if r.accounts != nil && (t.Account == nil || !r.accounts[t.Account.ID]) {
+		return false
+	}
+	if r.categories != nil && !r.categories[s.categoryID] {
+		return false
+	}
+	if r.tags != nil {
+		tagged := false
+		for _, id := range s.tagIDs {
+			tagged = tagged || r.tags[id]
+		}
+		if !tagged {
+			return false
+		}
+	}
+	return true
+}
+
+// apply makes the rule's changes to the state
+func (r *resolvedRule) apply(t *monarch.Transaction, s *state) {
+	a := r.Actions
+	if r.category != "" {
+		s.categoryID = r.category
+	}
+	if a.Merchant != "" {
+		s.merchant = a.Merchant
+	}
+	for _, id := range r.addTags {
+		if !contains(s.tagIDs, id) {
+			s.tagIDs = append(s.tagIDs, id)
+		}
+	}
+	if a.Notes != nil {
+		s.notes = *a.Notes
+	}
+	if a.HideFromReports != nil {
+		s.hidden = *a.HideFromReports
+	}
+	if len(r.split) > 0 && !t.HasSplits && !t.IsSplitTransaction && s.splits == nil {
+		s.splits = splitAmounts(t.Amount, r.split)
+	}
+}
+
+// splitAmounts divides amount by percent to the cent; the last share takes
+// the rounding difference so the splits add up to the amount
+func splitAmounts(amount float64, shares []SplitShare) []*monarch.TransactionSplit {
+	splits := make([]*monarch.TransactionSplit, len(shares))
+	remaining := amount
+	for i, s := range shares {
+		part := math.Round(amount*s.Percent) / 100
+		if i == len(shares)-1 {
+			part = math.Round(remaining*100) / 100
+		}
+		remaining -= part
+		splits[i] = &monarch.TransactionSplit{Amount: part, CategoryID: s.Category, Notes: s.Notes}
+	}
+	return splits
+}
+
+// diff turns the changed state into a Change, or nil if nothing changed
+func (e *Engine) diff(t *monarch.Transaction, before, after *state) *Change {
+	c := &Change{Transaction: t}
+	if after.categoryID != before.categoryID {
+		c.update.CategoryID = &after.categoryID
+		c.Fields = append(c.Fields, FieldChange{"category", e.categoryName(before.categoryID), e.categoryName(after.categoryID)})
+	}
+	if after.merchant != before.merchant {
+		c.update.Merchant = &after.merchant
+		c.Fields = append(c.Fields, FieldChange{"merchant", before.merchant, after.merchant})
+	}
+	if after.notes != before.notes {
+		c.update.Notes = &after.notes
+		c.Fields = append(c.Fields, FieldChange{"notes", before.notes, after.notes})
+	}
+	if after.hidden != before.hidden {
+		c.update.HideFromReports = &after.hidden
+		c.Fields = append(c.Fields, FieldChange{"hideFromReports", strconv.FormatBool(before.hidden), strconv.FormatBool(after.hidden)})
+	}
+	if len(after.tagIDs) != len(before.tagIDs) {
+		// Tags are only ever added
+		c.tagIDs = after.tagIDs
+		c.Fields = append(c.Fields, FieldChange{"tags", e.tagList(before.tagIDs), e.tagList(after.tagIDs)})
+	}
+	if after.splits != nil {
+		c.splits = after.splits
+		parts := make([]string, len(after.splits))
+		for i, s := range after.splits {
+			parts[i] = fmt.Sprintf("%s %.2f", e.categoryName(s.CategoryID), s.Amount)
+		}
+		c.Fields = append(c.Fields, FieldChange{"splits", "", strings.Join(parts, "; ")})
+	}
+
+	if len(c.Fields) == 0 {
+		return nil
+	}
+	return c
+}
+
+func (e *Engine) categoryName(id string) string {
+	if name, ok := e.categories[id]; ok {
+		return name
+	}
+	return id
+}
+
+func (e *Engine) tagList(ids []string) string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = id
+		if name, ok := e.tags[id]; ok {
+			names[i] = name
+		}
+	}
+	return strings.Join(names, ";")
+}
+
+func describe(t *monarch.Transaction) string {
+	if t.Merchant != nil && t.Merchant.Name != "" {
+		return t.Merchant.Name
+	}
+	return t.PlaidName
+}
+
+// namedItem is an ID and name a rule can refer to
+type namedItem struct {
+	id   string
+	name string
+}
+
+// resolve maps a value to an item ID. Values match an ID exactly or a name
+// case-insensitively; a name shared by several items is an error.
+func resolve(kind, value string, items []namedItem) (string, error) {
+	var matches []string
+	for _, item := range items {
+		if item.id == value {
+			return item.id, nil
+		}
+		if strings.EqualFold(item.name, value) {
+			matches = append(matches, item.id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", errors.Errorf("no %s named %q", kind, value)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", errors.Errorf("%s name %q is ambiguous, use one of the IDs: %s", kind, value, strings.Join(matches, ", "))
+	}
+}
+
+// resolveSet resolves values to a set of IDs, or nil for no values
+func resolveSet(kind string, values []string, items []namedItem) (map[string]bool, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		id, err := resolve(kind, v, items)
+		if err != nil {
+			return nil, err
+		}
+		set[id] = true
+	}
+	return set, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarch"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchfake"
+	"github.com/eshaffer321/monarchmoney-go/pkg/monarchtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFake() *monarchfake.Fake {
+	day := func(s string) monarch.Date {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			panic(err)
+		}
+		return monarch.Date{Time: d}
+	}
+	txn := func(id, date string, amount float64, merchant, plaidName string) *monarch.Transaction {
+		return &monarch.Transaction{
+			ID: id, Date: day(date), Amount: amount, PlaidName: plaidName,
+			Merchant: &monarch.Merchant{Name: merchant},
+			Account:  &monarch.Account{ID: "acc-checking"},
+			Category: &monarch.TransactionCategory{ID: "cat-uncategorized"},
+		}
+	}
+	group := &monarch.CategoryGroup{ID: "grp", Name: "Spending", Type: "expense"}
+	category := func(id, name string) *monarch.TransactionCategory {
+		return &monarch.TransactionCategory{ID: id, Name: name, Group: group, GroupID: group.ID}
+	}
+
+	treat := txn("txn-coffee", "2026-10-02", -5.5, "Starbucks", "STARBUCKS #123")
+	treat.Tags = []*monarch.Tag{{ID: "tag-work", Name: "Work"}}
+	big := txn("txn-big-coffee", "2026-10-03", -80, "Starbucks", "STARBUCKS #123")
+	done := txn("txn-done", "2026-10-05", -3, "Blue Bottle", "BLUE BOTTLE")
+	done.Category = &monarch.TransactionCategory{ID: "cat-coffee"}
+	done.Tags = []*monarch.Tag{{ID: "tag-treats", Name: "Treats"}}
+
+	fake := monarchfake.New(&monarchtest.Dataset{
+		Accounts:       []*monarch.Account{{ID: "acc-checking", DisplayName: "Checking", IsAsset: true}},
+		CategoryGroups: []*monarch.CategoryGroup{group},
+		Categories: []*monarch.TransactionCategory{
+			category("cat-uncategorized", "Uncategorized"),
+			category("cat-coffee", "Coffee Shops"),
+			category("cat-groceries", "Groceries"),
+			category("cat-household", "Household"),
+		},
+		Tags: []*monarch.Tag{{ID: "tag-work", Name: "Work"}, {ID: "tag-treats", Name: "Treats"}},
+		Transactions: []*monarch.Transaction{
+			treat, big, done,
+			txn("txn-costco", "2026-10-04", -100.01, "COSTCO WHOLESALE #42", "COSTCO WHOLESALE #42"),
+		},
+	})
+	fake.SetNow(func() time.Time { return time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC) })
+	return fake
+}
+
+func newEngine(t *testing.T, fake *monarchfake.Fake, rules string) *Engine {
+	t.Helper()
+	set, err := Parse([]byte(rules))
+	require.NoError(t, err)
+	engine, err := NewEngine(context.Background(), fake.Client(), set)
+	require.NoError(t, err)
+	return engine
+}
+
+func TestEngine_PlanAndApply(t *testing.T) {
+	fake := newFake()
+	ctx := context.Background()
+	client := fake.Client()
+	engine := newEngine(t, fake, testRules+`
+  - name: Never reached for Costco
+    match:
+      merchant: Costco
+    actions:
+      notes: not reached
+`)
+
+	changes, err := engine.Plan(ctx, client.Transactions.Query())
+	require.NoError(t, err)
+	require.Len(t, changes, 2, "the already categorized coffee and the large one are unchanged")
+
+	costco, coffee := changes[0], changes[1]
+	assert.Equal(t, "txn-costco", costco.Transaction.ID)
+	assert.Equal(t, []string{"rule 2"}, costco.Rules, "stop skips the rules after")
+	assert.Equal(t, []FieldChange{
+		{"merchant", "COSTCO WHOLESALE #42", "Costco"},
+		{"splits", "", "Groceries -70.01; Household -30.00"},
+	}, costco.Fields)
+
+	assert.Equal(t, "txn-coffee", coffee.Transaction.ID)
+	assert.Equal(t, []FieldChange{
+		{"category", "Uncategorized", "Coffee Shops"},
+		{"tags", "Work", "Work;Treats"},
+	}, coffee.Fields)
+
+	var preview bytes.Buffer
+	require.NoError(t, WritePreview(&preview, changes))
+	assert.Contains(t, preview.String(), "2026-10-02  Starbucks  -5.50  (Coffee)\n    category: \"Uncategorized\" -> \"Coffee Shops\"\n")
+	assert.Contains(t, preview.String(), "2 transactions to change\n")
+	assert.NotContains(t, fake.Calls(), "Transactions.Update", "planning changes nothing")
+
+	require.NoError(t, engine.Apply(ctx, changes))
+
+	details, err := client.Transactions.Get(ctx, "txn-coffee")
+	require.NoError(t, err)
+	assert.Equal(t, "cat-coffee", details.Transaction.Category.ID)
+	require.Len(t, details.Transaction.Tags, 2)
+
+	splits, err := client.Transactions.GetSplits(ctx, "txn-costco")
+	require.NoError(t, err)
+	require.Len(t, splits, 2)
+	assert.Equal(t, -70.01, splits[0].Amount)
+	assert.Equal(t, "paper goods", splits[1].Notes)
+
+	changes, err = engine.Plan(ctx, client.Transactions.Query())
+	require.NoError(t, err)
+	assert.Empty(t, changes, "applied rules have nothing left to do")
+}
+
+func TestEngine_RulesSeeEarlierChanges(t *testing.T) {
+	fake := newFake()
+	engine := newEngine(t, fake, `
+rules:
+  - name: Clean up
+    match: {plaidName: "^STARBUCKS"}
+    actions: {merchant: Coffee}
+  - name: Categorize
+    match: {merchant: "^Coffee$", categories: [Uncategorized], accounts: [Checking]}
+    actions: {category: Coffee Shops}
+  - name: Tag work coffee
+    match: {categories: [Coffee Shops], tags: [Work]}
+    actions: {hideFromReports: true}
+`)
+
+	txns, err := fake.Client().Transactions.Query().Execute(context.Background())
+	require.NoError(t, err)
+	var coffee *monarch.Transaction
+	for _, t := range txns.Transactions {
+		if t.ID == "txn-coffee" {
+			coffee = t
+		}
+	}
+	require.NotNil(t, coffee)
+
+	change := engine.Evaluate(coffee)
+	require.NotNil(t, change)
+	assert.Equal(t, []string{"Clean up", "Categorize", "Tag work coffee"}, change.Rules)
+	assert.Equal(t, []FieldChange{
+		{"category", "Uncategorized", "Coffee Shops"},
+		{"merchant", "Starbucks", "Coffee"},
+		{"hideFromReports", "false", "true"},
+	}, change.Fields)
+}
+
+func TestNewEngine_UnknownNames(t *testing.T) {
+	fake := newFake()
+	for _, rules := range []string{
+		`rules: [{match: {merchant: x}, actions: {category: Nope}}]`,
+		`rules: [{match: {tags: [Nope]}, actions: {category: Groceries}}]`,
+		`rules: [{match: {accounts: [Savings]}, actions: {category: Groceries}}]`,
+		`rules: [{match: {merchant: x}, actions: {split: [{category: Nope, percent: 100}]}}]`,
+	} {
+		set, err := Parse([]byte(rules))
+		require.NoError(t, err)
+		_, err = NewEngine(context.Background(), fake.Client(), set)
+		assert.ErrorContains(t, err, "no ", rules)
+	}
+}
+
+func TestSplitAmounts(t *testing.T) {
+	splits := splitAmounts(-10, []SplitShare{{Category: "a", Percent: 33.33}, {Category: "b", Percent: 33.33}, {Category: "c", Percent: 33.34}})
+	var total float64
+	for _, s := range splits {
+		total += s.Amount
+	}
+	assert.InDelta(t, -10, total, 1e-9)
+	assert.Equal(t, -3.33, splits[0].Amount)
+	assert.Equal(t, -3.34, splits[2].Amount)
+}
@@ -0,0 +1,184 @@
+// Package rules categorizes transactions with rules kept in a YAML or JSON
+// file, so they can live in version control.
+//
+//	rules:
+//	  - name: Coffee
+//	    match:
+//	      merchant: "(?i)starbucks|blue bottle"
+//	      amount: {min: -25, max: 0}
+//	    actions:
+//	      category: Coffee Shops
+//	      addTags: [Treats]
+//	  - name: Costco run
+//	    match:
+//	      plaidName: "^COSTCO"
+//	    actions:
+//	      split:
+//	        - {category: Groceries, percent: 70}
+//	        - {category: Household, percent: 30}
+//
+// Rules are evaluated in order and each sees the changes of the rules before
+// it, so one rule can clean up a merchant name and a later one categorize by
+// it. Running the rules is split into planning and applying:
+//
+//	set, err := rules.Load("rules.yaml")
+//	...
+//	engine, err := rules.NewEngine(ctx, client, set)
+//	...
+//	changes, err := engine.Plan(ctx, client.Transactions.Query().Between(start, end))
+//	...
+//	rules.WritePreview(os.Stdout, changes) // dry run
+//	err = engine.Apply(ctx, changes)
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// RuleSet is the contents of a rules file
+type RuleSet struct {
+	Rules []*Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule applies its actions to transactions that meet every condition of its
+// match
+type Rule struct {
+	// Name identifies the rule in previews and errors (default "rule N")
+	Name string `yaml:"name" json:"name"`
+
+	Match   Match   `yaml:"match" json:"match"`
+	Actions Actions `yaml:"actions" json:"actions"`
+
+	// Stop skips the remaining rules for transactions this rule matches
+	Stop bool `yaml:"stop" json:"stop"`
+
+	merchant  *regexp.Regexp
+	plaidName *regexp.Regexp
+}
+
+// Match holds the conditions of a rule. Empty conditions match anything,
+// but a rule needs at least one. Accounts, categories and tags are names or
+// IDs; a transaction matches if it has any of those listed.
+type Match struct {
+	// Merchant is a regular expression for the merchant name
+	Merchant string `yaml:"merchant" json:"merchant"`
+
+	// PlaidName is a regular expression for the name the institution sent
+	PlaidName string `yaml:"plaidName" json:"plaidName"`
+
+	// Amount bounds the signed amount; spending is negative
+	Amount *AmountRange `yaml:"amount" json:"amount"`
+
+	Accounts   []string `yaml:"accounts" json:"accounts"`
+	Categories []string `yaml:"categories" json:"categories"`
+	Tags       []string `yaml:"tags" json:"tags"`
+}
+
+// AmountRange is an inclusive amount range; either end may be left open
+type AmountRange struct {
+	Min *float64 `yaml:"min" json:"min"`
+	Max *float64 `yaml:"max" json:"max"`
+}
+
+// Actions are the changes a rule makes. Categories and tags are names or
+// IDs, and must already exist.
+type Actions struct {
+	Category        string   `yaml:"category" json:"category"`
+	Merchant        string   `yaml:"merchant" json:"merchant"`
+	AddTags         []string `yaml:"addTags" json:"addTags"`
+	Notes           *string  `yaml:"notes" json:"notes"`
+	HideFromReports *bool    `yaml:"hideFromReports" json:"hideFromReports"`
+
+	// Split divides the transaction by percentages adding up to 100.
+	// Transactions that are already split are left alone.
+	Split []SplitShare `yaml:"split" json:"split"`
+}
+
+// SplitShare is one part of a split
+type SplitShare struct {
+	Category string  `yaml:"category" json:"category"`
+	Percent  float64 `yaml:"percent" json:"percent"`
+	Notes    string  `yaml:"notes" json:"notes"`
+}
+
+// Load reads a rules file in YAML or JSON
+func Load(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read rules")
+	}
+	set, err := Parse(data)
+	return set, errors.Wrap(err, path)
+}
+
+// Parse reads rules in YAML or JSON, which YAML also reads, and checks them.
+// Unknown fields are rejected so typos do not silently match everything.
+func Parse(data []byte) (*RuleSet, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	set := &RuleSet{}
+	if err := dec.Decode(set); err != nil {
+		return nil, errors.Wrap(err, "failed to parse rules")
+	}
+	for i, r := range set.Rules {
+		if r == nil {
+			return nil, errors.Errorf("rule %d is empty", i+1)
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := r.compile(); err != nil {
+			return nil, errors.Wrap(err, r.Name)
+		}
+	}
+	return set, nil
+}
+
+// compile checks a rule and compiles its regular expressions
+func (r *Rule) compile() error {
+	m, a := r.Match, r.Actions
+	if m.Merchant == "" && m.PlaidName == "" && m.Amount == nil &&
+		len(m.Accounts) == 0 && len(m.Categories) == 0 && len(m.Tags) == 0 {
+		return errors.New("match has no conditions")
+	}
+	if a.Category == "" && a.Merchant == "" && len(a.AddTags) == 0 &&
+		a.Notes == nil && a.HideFromReports == nil && len(a.Split) == 0 {
+		return errors.New("no actions")
+	}
+
+	var err error
+	if m.Merchant != "" {
+		if r.merchant, err = regexp.Compile(m.Merchant); err != nil {
+			return errors.Wrap(err, "invalid merchant pattern")
+		}
+	}
+	if m.PlaidName != "" {
+		if r.plaidName, err = regexp.Compile(m.PlaidName); err != nil {
+			return errors.Wrap(err, "invalid plaidName pattern")
+		}
+	}
+	if m.Amount != nil && m.Amount.Min != nil && m.Amount.Max != nil && *m.Amount.Min > *m.Amount.Max {
+		return errors.New("amount min is above max")
+	}
+
+	if len(a.Split) > 0 {
+		var total float64
+		for _, s := range a.Split {
+			if s.Category == "" || s.Percent <= 0 {
+				return errors.New("each split needs a category and a positive percent")
+			}
+			total += s.Percent
+		}
+		if math.Abs(total-100) > 1e-9 {
+			return errors.Errorf("split percents add up to %g, not 100", total)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRules = `
+rules:
+  - name: Coffee
+    match:
+      merchant: "(?i)starbucks|blue bottle"
+      amount: {min: -25, max: 0}
+    actions:
+      category: Coffee Shops
+      addTags: [Treats]
+  - match:
+      plaidName: "^COSTCO"
+    actions:
+      merchant: Costco
+      split:
+        - {category: Groceries, percent: 70}
+        - {category: Household, percent: 30, notes: paper goods}
+    stop: true
+`
+
+func TestParse_YAML(t *testing.T) {
+	set, err := Parse([]byte(testRules))
+	require.NoError(t, err)
+	require.Len(t, set.Rules, 2)
+
+	coffee := set.Rules[0]
+	assert.Equal(t, "Coffee", coffee.Name)
+	assert.Equal(t, -25.0, *coffee.Match.Amount.Min)
+	assert.Equal(t, []string{"Treats"}, coffee.Actions.AddTags)
+	assert.True(t, coffee.merchant.MatchString("STARBUCKS #123"))
+
+	costco := set.Rules[1]
+	assert.Equal(t, "rule 2", costco.Name)
+	assert.True(t, costco.Stop)
+	assert.Equal(t, SplitShare{Category: "Household", Percent: 30, Notes: "paper goods"}, costco.Actions.Split[1])
+}
+
+func TestParse_JSON(t *testing.T) {
+	set, err := Parse([]byte(`{"rules": [{"name": "Rent", "match": {"accounts": ["Checking"], "amount": {"max": -1000}},
+		"actions": {"category": "Rent", "hideFromReports": false, "notes": ""}}]}`))
+	require.NoError(t, err)
+	require.Len(t, set.Rules, 1)
+	rent := set.Rules[0]
+	assert.Nil(t, rent.Match.Amount.Min)
+	assert.Equal(t, -1000.0, *rent.Match.Amount.Max)
+	require.NotNil(t, rent.Actions.Notes)
+	assert.Empty(t, *rent.Actions.Notes)
+	require.NotNil(t, rent.Actions.HideFromReports)
+}
+
+func TestParse_Errors(t *testing.T) {
+	for name, tc := range map[string]struct{ rules, err string }{
+		"unknown field": {`rules: [{match: {merchent: x}, actions: {category: A}}]`, "merchent"},
+		"no conditions": {`rules: [{actions: {category: A}}]`, "no conditions"},
+		"no actions":    {`rules: [{match: {merchant: x}}]`, "no actions"},
+		"bad regexp":    {`rules: [{match: {merchant: "("}, actions: {category: A}}]`, "invalid merchant pattern"},
+		"amount range":  {`rules: [{match: {amount: {min: 5, max: 1}}, actions: {category: A}}]`, "min is above max"},
+		"split total":   {`rules: [{match: {merchant: x}, actions: {split: [{category: A, percent: 60}, {category: B, percent: 30}]}}]`, "add up to 90"},
+		"split percent": {`rules: [{match: {merchant: x}, actions: {split: [{category: A, percent: 100}, {category: B}]}}]`, "positive percent"},
+	} {
+		_, err := Parse([]byte(tc.rules))
+		assert.ErrorContains(t, err, tc.err, name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testRules), 0o600))
+	set, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, set.Rules, 2)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}